@@ -7,3 +7,18 @@ type Querier interface {
 	// SELECT * FROM @@table WHERE id=@id
 	GetByID(id int) (gen.T, error) // returns struct and error
 }
+
+// UserOrderSummary is a row of the order totals of a user.
+type UserOrderSummary struct {
+	UserID     int64
+	Name       string
+	OrderCount int64
+	Total      float64
+}
+
+// Reporter is the interface of the reporting queries joining several tables.
+type Reporter interface {
+	// SELECT u.id AS user_id, u.name, COUNT(o.id) AS order_count, COALESCE(SUM(o.total), 0) AS total
+	// FROM @@table u LEFT JOIN orders o ON o.user_id = u.id WHERE u.id=@id GROUP BY u.id, u.name
+	GetUserOrderSummary(id int) ([]UserOrderSummary, error)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"command/cmd"
+	"command/cmd/encrypt"
+	"command/cmd/orm"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// catalogFlag is a flag of a command with the name of its top-level
+// command, root for the flags of the root command.
+type catalogFlag struct {
+	flag *pflag.Flag
+	top  string
+}
+
+// catalogFlags appends the flags of c and its subcommands under top.
+func catalogFlags(flags []catalogFlag, c *cobra.Command, top string) []catalogFlag {
+	c.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		// cobra describes the help flags
+		if f.Name != "help" {
+			flags = append(flags, catalogFlag{flag: f, top: top})
+		}
+	})
+	for _, sub := range c.Commands() {
+		flags = catalogFlags(flags, sub, top)
+	}
+	return flags
+}
+
+// catalogKey returns the key of the catalog describing a flag, a key
+// <top>.<subcommands>.flag.<name> whose message is its description. The
+// flags shared by subcommands share the key of their parent, the deprecated
+// aliases the key flag.deprecated.
+func catalogKey(cf catalogFlag) (string, bool) {
+	if replacement, ok := cmd.Replacement(cf.flag); ok {
+		return "flag.deprecated", cf.flag.Usage == cmd.T("flag.deprecated", replacement)
+	}
+	for key := range cmd.Messages("en") {
+		if strings.HasPrefix(key, cf.top+".") && strings.HasSuffix(key, ".flag."+cf.flag.Name) && cmd.T(key) == cf.flag.Usage {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func TestCatalogFlags(t *testing.T) {
+	cmds := []cmd.ICommand{
+		orm.NewOrmCommand(),
+		encrypt.NewRSA(),
+		encrypt.NewBundle(),
+		encrypt.NewInspect(),
+		encrypt.NewAudit(),
+		encrypt.NewEncrypt(),
+		cmd.NewVersion(),
+	}
+	cmds = append(cmds, cmd.NewDoctor(cmds))
+
+	var flags []catalogFlag
+	cmd.RootFlags().VisitAll(func(f *pflag.Flag) {
+		flags = append(flags, catalogFlag{flag: f, top: "root"})
+	})
+	for _, c := range cmds {
+		flags = catalogFlags(flags, c.Command(), c.Command().Name())
+	}
+
+	for _, cf := range flags {
+		key, ok := catalogKey(cf)
+		if !ok {
+			t.Errorf("--%s of %s is described as %q, not by a %s.*flag.%s catalog key", cf.flag.Name, cf.top, cf.flag.Usage, cf.top, cf.flag.Name)
+			continue
+		}
+		for _, loc := range cmd.Locales() {
+			if cmd.Messages(loc)[key] == "" {
+				t.Errorf("catalog %s has no description of %s", loc, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type (
+	// Option configures ExecuteWith.
+	Option func(*options)
+	// options are the settings of ExecuteWith.
+	options struct {
+		defaults map[string]map[string]string
+	}
+)
+
+// WithDefaults overrides flag defaults by command and flag name, such as
+// {"orm": {"style": "dao"}}. Subcommands are named by their path below the
+// root command, like "orm drift". Only defaults change, so --help shows them
+// and explicit flags still win. Unknown commands or flags fail at startup.
+func WithDefaults(defaults map[string]map[string]string) Option {
+	return func(o *options) {
+		if o.defaults == nil {
+			o.defaults = make(map[string]map[string]string)
+		}
+		for name, flags := range defaults {
+			if o.defaults[name] == nil {
+				o.defaults[name] = make(map[string]string)
+			}
+			for flag, value := range flags {
+				o.defaults[name][flag] = value
+			}
+		}
+	}
+}
+
+// applyDefaults sets the flag defaults of the commands below root.
+func applyDefaults(root *cobra.Command, defaults map[string]map[string]string) error {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c, err := findCommand(root, name)
+		if err != nil {
+			return err
+		}
+		for flag, value := range defaults[name] {
+			source := fmt.Sprintf("default --%s of command %q", flag, name)
+			f, err := ResolveFlag(c.Flags(), flag, source)
+			if err == nil && f == nil {
+				f, err = ResolveFlag(c.PersistentFlags(), flag, source)
+			}
+			if err != nil {
+				return err
+			}
+			if f == nil {
+				return fmt.Errorf("default for unknown flag --%s of command %q", flag, name)
+			}
+			if err := setDefault(f, value); err != nil {
+				return fmt.Errorf("default for flag --%s of command %q: %w", flag, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// findCommand returns the command at a space separated path below root.
+func findCommand(root *cobra.Command, path string) (*cobra.Command, error) {
+	c := root
+	for _, name := range strings.Fields(path) {
+		var next *cobra.Command
+		for _, sub := range c.Commands() {
+			if sub.Name() == name {
+				next = sub
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("default for unknown command %q", path)
+		}
+		c = next
+	}
+	if c == root {
+		return nil, fmt.Errorf("default for unknown command %q", path)
+	}
+	return c, nil
+}
+
+// setDefault sets the value and the default of a flag without marking it
+// changed. Slice values are replaced, so explicit flags do not append to
+// the default.
+func setDefault(f *pflag.Flag, value string) error {
+	if s, ok := f.Value.(pflag.SliceValue); ok {
+		var items []string
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		if err := s.Replace(items); err != nil {
+			return err
+		}
+	} else if err := f.Value.Set(value); err != nil {
+		return err
+	}
+	f.DefValue = f.Value.String()
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newDefaultsRoot returns a root command with an orm command recording the
+// flags it ran with.
+func newDefaultsRoot(style *string, tables *[]string) *cobra.Command {
+	root := &cobra.Command{Use: "command"}
+	orm := &cobra.Command{Use: "orm", RunE: func(*cobra.Command, []string) error { return nil }}
+	orm.Flags().StringVar(style, "style", "model", "generation style")
+	orm.Flags().StringSliceVarP(tables, "tables", "t", nil, "tables")
+	orm.AddCommand(&cobra.Command{Use: "drift", RunE: func(*cobra.Command, []string) error { return nil }})
+	root.AddCommand(orm)
+	return root
+}
+
+func TestApplyDefaults(t *testing.T) {
+	var style string
+	var tables []string
+	root := newDefaultsRoot(&style, &tables)
+	if err := applyDefaults(root, map[string]map[string]string{"orm": {"style": "dao", "tables": "users,orders"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var help bytes.Buffer
+	root.SetOut(&help)
+	root.SetArgs([]string{"orm", "--help"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(help.String(), `generation style (default "dao")`) {
+		t.Errorf("help does not show the default:\n%s", help.String())
+	}
+
+	tests := []struct {
+		args   []string
+		style  string
+		tables []string
+	}{
+		{args: []string{"orm"}, style: "dao", tables: []string{"users", "orders"}},
+		{args: []string{"orm", "--style", "model", "-t", "items"}, style: "model", tables: []string{"items"}},
+	}
+	for _, tt := range tests {
+		root := newDefaultsRoot(&style, &tables)
+		if err := applyDefaults(root, map[string]map[string]string{"orm": {"style": "dao", "tables": "users,orders"}}); err != nil {
+			t.Fatal(err)
+		}
+		root.SetArgs(tt.args)
+		if err := root.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		if style != tt.style || !slices.Equal(tables, tt.tables) {
+			t.Errorf("%v: style %q, tables %v", tt.args, style, tables)
+		}
+	}
+}
+
+func TestApplyDefaultsUnknown(t *testing.T) {
+	for _, defaults := range []map[string]map[string]string{
+		{"gen": {"style": "dao"}},
+		{"orm": {"out": "./gen"}},
+		{"orm drift": {"style": "dao"}},
+		{"": {"verbose": "true"}},
+		{"orm": {"style": ""}, "orm missing": {"style": "dao"}},
+	} {
+		var style string
+		var tables []string
+		if err := applyDefaults(newDefaultsRoot(&style, &tables), defaults); err == nil {
+			t.Errorf("%v accepted", defaults)
+		}
+	}
+}
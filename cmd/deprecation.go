@@ -0,0 +1,148 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/spf13/pflag"
+)
+
+// deprecatedAnnotation annotates the deprecated flags with their replacement
+// and the version removing them.
+const deprecatedAnnotation = "czx_deprecated"
+
+var (
+	// strictFlags turns the use of deprecated flags into errors.
+	strictFlags bool
+	// noticed are the deprecated names whose notice was printed.
+	noticed   = make(map[string]bool)
+	noticedMu sync.Mutex
+)
+
+// deprecatedValue is the value of a deprecated flag, setting its replacement.
+type deprecatedValue struct {
+	name        string
+	replacement *pflag.Flag
+	removal     string
+}
+
+func (v *deprecatedValue) String() string {
+	return v.replacement.Value.String()
+}
+
+func (v *deprecatedValue) Type() string {
+	return v.replacement.Value.Type()
+}
+
+// Set sets the replacement from the command line.
+func (v *deprecatedValue) Set(value string) error {
+	if err := deprecationNotice("--"+v.name, v.replacement.Name, v.removal); err != nil {
+		return err
+	}
+	if err := v.replacement.Value.Set(value); err != nil {
+		return err
+	}
+	v.replacement.Changed = true
+	return nil
+}
+
+// DeprecateFlag adds name to fs as a hidden alias of the flag replacement,
+// which must be defined already. The alias still parses, from the command
+// line, the environment and the config file through ResolveFlag, and sets
+// the replacement with a notice naming the version removing it, or fails
+// with --strict-flags.
+func DeprecateFlag(fs *pflag.FlagSet, name, replacement, removal string) {
+	r := fs.Lookup(replacement)
+	if r == nil {
+		panic("deprecated flag --" + name + " replaced by the unknown flag --" + replacement)
+	}
+	f := fs.VarPF(&deprecatedValue{name: name, replacement: r, removal: removal}, name, "", T("flag.deprecated", replacement))
+	f.NoOptDefVal = r.NoOptDefVal
+	f.Hidden = true
+	f.Annotations = map[string][]string{deprecatedAnnotation: {replacement, removal}}
+}
+
+// Replacement returns the replacement of a deprecated flag.
+func Replacement(f *pflag.Flag) (string, bool) {
+	if a, ok := f.Annotations[deprecatedAnnotation]; ok {
+		return a[0], true
+	}
+	return "", false
+}
+
+// ResolveFlag returns the flag of fs named name, or the replacement of a
+// deprecated flag after its notice. source names where the deprecated name
+// was given, such as an environment variable. It returns nil for unknown
+// flags, and an error for deprecated ones with --strict-flags.
+func ResolveFlag(fs *pflag.FlagSet, name, source string) (*pflag.Flag, error) {
+	f := fs.Lookup(name)
+	if f == nil {
+		return nil, nil
+	}
+	a, ok := f.Annotations[deprecatedAnnotation]
+	if !ok {
+		return f, nil
+	}
+	if err := deprecationNotice(source, a[0], a[1]); err != nil {
+		return nil, err
+	}
+	return fs.Lookup(a[0]), nil
+}
+
+// deprecationNotice prints once the notice of a deprecated name, or returns
+// the error of --strict-flags.
+func deprecationNotice(source, replacement, removal string) error {
+	if strictFlags {
+		return Exit(ExitUsage, fmt.Errorf("%s is deprecated, use --%s instead (--strict-flags)", source, replacement))
+	}
+	noticedMu.Lock()
+	defer noticedMu.Unlock()
+	if !noticed[source] {
+		noticed[source] = true
+		color.New(color.FgYellow).Fprintf(os.Stderr, "%s is deprecated and will be removed in %s, use --%s instead\n", source, removal, replacement)
+	}
+	return nil
+}
+
+// argStrictFlags reports whether the arguments enable --strict-flags, so the
+// deprecated flags given before it fail too.
+func argStrictFlags(args []string) bool {
+	for _, arg := range args[:indexOrLen(args, "--")] {
+		if v, ok := strings.CutPrefix(arg, "--strict-flags="); ok {
+			strict, _ := strconv.ParseBool(v)
+			return strict
+		}
+		if arg == "--strict-flags" {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOrLen returns the index of s in args, or the length of args.
+func indexOrLen(args []string, s string) int {
+	if i := slices.Index(args, s); i >= 0 {
+		return i
+	}
+	return len(args)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"command/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+// legacyCommand is a command whose --old flag is deprecated in favor of
+// --new, recording the value of --new it ran with.
+type legacyCommand struct {
+	name string
+	got  *string
+	ran  *bool
+}
+
+func (l legacyCommand) Command() *cobra.Command {
+	c := &cobra.Command{Use: l.name, Short: "Legacy", RunE: func(*cobra.Command, []string) error {
+		*l.ran = true
+		return nil
+	}}
+	c.Flags().StringVar(l.got, "new", "default", "The new flag")
+	cmd.DeprecateFlag(c.Flags(), "old", "new", "2.0")
+	return c
+}
+
+func TestDeprecatedFlag(t *testing.T) {
+	args, stderr := os.Args, os.Stderr
+	defer func() { os.Args, os.Stderr = args, stderr }()
+
+	tests := []struct {
+		name     string
+		args     []string
+		defaults map[string]string
+		want     string
+		notice   string
+		strict   bool
+	}{
+		{
+			name:   "command line",
+			args:   []string{"--old", "x"},
+			want:   "x",
+			notice: "--old is deprecated and will be removed in 2.0, use --new instead",
+		},
+		{
+			name:   "command line with --strict-flags after it",
+			args:   []string{"--old", "x", "--strict-flags"},
+			strict: true,
+		},
+		{
+			name:   "command line with --strict-flags=true",
+			args:   []string{"--strict-flags=true", "--old", "x"},
+			strict: true,
+		},
+		{
+			name: "command line with --strict-flags=false",
+			args: []string{"--strict-flags=false", "--old", "x"},
+			want: "x",
+		},
+		{
+			name:     "default",
+			defaults: map[string]string{"old": "x"},
+			want:     "x",
+			notice:   `default --old of command "legacy-4" is deprecated and will be removed in 2.0, use --new instead`,
+		},
+		{
+			name:     "default overridden by the replacement",
+			args:     []string{"--new", "y"},
+			defaults: map[string]string{"old": "x"},
+			want:     "y",
+		},
+		{
+			name:     "default with --strict-flags",
+			args:     []string{"--strict-flags"},
+			defaults: map[string]string{"old": "x"},
+			strict:   true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			os.Stderr = w
+
+			// The root command is global, each case adds its own command
+			name := fmt.Sprintf("legacy-%d", i)
+			got, ran := "", false
+			os.Args = append([]string{"command", name}, tt.args...)
+			var opts []cmd.Option
+			if tt.defaults != nil {
+				opts = append(opts, cmd.WithDefaults(map[string]map[string]string{name: tt.defaults}))
+			}
+			err = cmd.ExecuteWith([]cmd.ICommand{legacyCommand{name: name, got: &got, ran: &ran}}, opts...)
+			w.Close()
+			os.Stderr = stderr
+			notice, _ := io.ReadAll(r)
+
+			if tt.strict {
+				if code := cmd.ExitCode(err, true); code != cmd.ExitUsage || !strings.Contains(err.Error(), "--strict-flags") {
+					t.Fatalf("exit code %d, want %d: %v", code, cmd.ExitUsage, err)
+				}
+				if ran {
+					t.Errorf("the command ran with --new %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("--new = %q, want %q", got, tt.want)
+			}
+			if tt.notice != "" && !strings.Contains(string(notice), tt.notice) {
+				t.Errorf("notice %q, want %q", notice, tt.notice)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Statuses of doctor checks.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+// staleBuildAge is the age of the build commit the version check warns about.
+const staleBuildAge = 180 * 24 * time.Hour
+
+type (
+	// Checker is implemented by the commands contributing checks to doctor.
+	Checker interface {
+		Checks() []Check
+	}
+	// Check is a named doctor check.
+	Check struct {
+		Name string
+		// Run performs the check, it should return once ctx is done
+		Run func(ctx context.Context) CheckResult
+	}
+	// CheckResult is the outcome of a check, with a remediation hint for
+	// warnings and failures.
+	CheckResult struct {
+		Status  string
+		Message string
+		Hint    string
+	}
+	Doctor struct {
+		cmds    []ICommand
+		skip    []string
+		timeout time.Duration
+	}
+)
+
+// NewDoctor returns the doctor command running the checks of the commands
+// implementing Checker, after its own version and asset checks.
+func NewDoctor(cmds []ICommand) *Doctor {
+	return &Doctor{cmds: cmds}
+}
+
+// Command implements ICommand.
+func (d *Doctor) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the database, config, generated code and keys of the project",
+		Long: `Run the checks of every subsystem and print a pass/warn/fail table with a
+hint for each problem: database connectivity and privileges, validity of the
+orm config and rules, freshness of the generated code against its lockfile
+(orm verify), unencrypted private keys in the working directory, the age of the build and the integrity of its
+embedded assets.
+
+The orm checks read the settings of the environment and of the config file
+named by CZX_ORM_CONFIG. Every check is bounded by --timeout. The command
+only fails when a check fails, warnings are reported.
+
+` + ExitCodesHelp,
+		Example: `# Check the whole setup
+command doctor
+
+# Skip the freshness check and give each check 30 seconds
+command doctor --skip orm.freshness --timeout 30s`,
+		Args: cobra.NoArgs,
+		RunE: d.run,
+	}
+	cmd.Flags().StringSliceVar(&d.skip, "skip", nil, T("doctor.flag.skip"))
+	cmd.Flags().DurationVar(&d.timeout, "timeout", 10*time.Second, T("doctor.flag.timeout"))
+	return cmd
+}
+
+// checks returns the checks of doctor and of the commands.
+func (d *Doctor) checks() []Check {
+	checks := []Check{{Name: "version", Run: versionCheck}, {Name: "assets", Run: assetsCheck}}
+	for _, c := range d.cmds {
+		if checker, ok := c.(Checker); ok {
+			checks = append(checks, checker.Checks()...)
+		}
+	}
+	return checks
+}
+
+// run executes the doctor command logic.
+func (d *Doctor) run(c *cobra.Command, _ []string) error {
+	if d.timeout <= 0 {
+		return Exit(ExitUsage, errors.New("--timeout must be positive"))
+	}
+	checks := d.checks()
+	names := make([]string, len(checks))
+	for i, check := range checks {
+		names[i] = check.Name
+	}
+	for _, name := range d.skip {
+		if !slices.Contains(names, name) {
+			return Exit(ExitUsage, fmt.Errorf("unknown check %q, checks: %s", name, strings.Join(names, ", ")))
+		}
+	}
+
+	results := make([]CheckResult, len(checks))
+	for i, check := range checks {
+		if slices.Contains(d.skip, check.Name) {
+			results[i] = CheckResult{Status: CheckSkip, Message: "skipped with --skip"}
+			continue
+		}
+		results[i] = d.runCheck(c.Context(), check)
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+	for i, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", checks[i].Name, r.Status, r.Message)
+		if r.Status == CheckFail {
+			failed++
+		}
+	}
+	w.Flush()
+	for i, r := range results {
+		if r.Hint != "" && (r.Status == CheckWarn || r.Status == CheckFail) {
+			fmt.Printf("\n%s: %s", checks[i].Name, r.Hint)
+		}
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		return Exit(ExitFailure, fmt.Errorf("%d of %d checks failed", failed, len(checks)))
+	}
+	color.Green("\nAll checks passed.\n\n")
+	return nil
+}
+
+// runCheck runs a check within the timeout, turning a panic or an overrun
+// into a failure. A check still running after the timeout is abandoned.
+func (d *Doctor) runCheck(parent context.Context, check Check) CheckResult {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, d.timeout)
+	defer cancel()
+
+	done := make(chan CheckResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- CheckResult{Status: CheckFail, Message: fmt.Sprintf("check panicked: %v", r)}
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+	select {
+	case r := <-done:
+		return r
+	case <-ctx.Done():
+		return CheckResult{
+			Status:  CheckFail,
+			Message: fmt.Sprintf("timed out after %s", d.timeout),
+			Hint:    fmt.Sprintf("raise --timeout or skip the check with --skip %s", check.Name),
+		}
+	}
+}
+
+// versionCheck warns when the binary was built from an old commit.
+func versionCheck(context.Context) CheckResult {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return CheckResult{Status: CheckPass, Message: "build information unavailable"}
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		if s.Key != "vcs.time" {
+			continue
+		}
+		built, err := time.Parse(time.RFC3339, s.Value)
+		if err != nil {
+			break
+		}
+		if age := time.Since(built); age > staleBuildAge {
+			return CheckResult{
+				Status:  CheckWarn,
+				Message: fmt.Sprintf("version %s built from a commit of %s, %d days ago", version, built.Format(time.DateOnly), int(age.Hours()/24)),
+				Hint:    "rebuild the command from the latest sources",
+			}
+		}
+		return CheckResult{Status: CheckPass, Message: fmt.Sprintf("version %s built from a commit of %s", version, built.Format(time.DateOnly))}
+	}
+	return CheckResult{Status: CheckPass, Message: fmt.Sprintf("version %s, commit date unknown", version)}
+}
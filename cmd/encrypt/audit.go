@@ -0,0 +1,381 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"bytes"
+	"command/cmd"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxAuditBytes bounds the bytes read from every file, so huge binaries do
+// not stall the scan. Key files are far smaller.
+const maxAuditBytes = 64 << 10
+
+// Severities of audit findings, in increasing order.
+var severities = []string{"info", "low", "medium", "high"}
+
+type (
+	Audit struct {
+		output    string
+		failLevel string
+	}
+	// auditKey is a key file found by the audit.
+	auditKey struct {
+		Path        string `json:"path"`
+		Kind        string `json:"kind"`
+		Algorithm   string `json:"algorithm,omitempty"`
+		Fingerprint string `json:"fingerprint,omitempty"`
+		Encrypted   bool   `json:"encrypted,omitempty"`
+		Insecure    bool   `json:"insecure,omitempty"`
+		bits        int
+		mode        fs.FileMode
+	}
+	// auditFinding is a key hygiene issue.
+	auditFinding struct {
+		Path     string `json:"path"`
+		Severity string `json:"severity"`
+		Check    string `json:"check"`
+		Message  string `json:"message"`
+	}
+	// auditReport is the result of an audit, as written by --output json.
+	auditReport struct {
+		Keys     []*auditKey    `json:"keys"`
+		Findings []auditFinding `json:"findings"`
+	}
+)
+
+func NewAudit() *Audit {
+	return &Audit{}
+}
+
+// Command implements cmd.ICommand.
+func (a *Audit) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "audit <dir>",
+		GroupID: "encrypt",
+		Short:   "Scan a directory tree for key hygiene issues",
+		Long: `Walk a directory tree, identify key files (PEM, DER and ssh formats) and
+report:
+
+  high    unencrypted private keys, RSA keys under 2048 bits,
+          private keys readable by everyone
+  medium  keys past the expiry hint of their key.meta.json,
+          insecure test keys
+  info    private and public keys of the same pair in one directory
+
+At most 64 KiB are read from every file. The command fails when a finding
+is at or above --fail-level.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Audit a repository
+command audit .
+
+# Report as JSON and only fail on high findings
+command audit /etc --output json --fail-level high`,
+		Args: cobra.ExactArgs(1),
+		RunE: a.run,
+	}
+
+	// Setup flags
+	a.flags(cmd)
+	return cmd
+}
+
+// flags setup flags for the audit command.
+func (a *Audit) flags(c *cobra.Command) {
+	c.Flags().StringVar(&a.output, "output", "text", cmd.T("audit.flag.output"))
+	c.Flags().StringVar(&a.failLevel, "fail-level", "high", cmd.T("audit.flag.fail-level"))
+}
+
+// run executes the audit command logic.
+func (a *Audit) run(_ *cobra.Command, args []string) error {
+	if a.output != "text" && a.output != "json" {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("invalid output: %s, must be text or json", a.output))
+	}
+	if a.failLevel != "none" && !slices.Contains(severities, a.failLevel) {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("invalid fail level: %s, must be one of %s or none", a.failLevel, strings.Join(severities, ", ")))
+	}
+
+	report, err := audit(args[0], time.Now())
+	if err != nil {
+		return err
+	}
+	if a.output == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		report.print()
+	}
+
+	if a.failLevel == "none" {
+		return nil
+	}
+	level := slices.Index(severities, a.failLevel)
+	for _, f := range report.Findings {
+		if slices.Index(severities, f.Severity) >= level {
+			return cmd.Exit(cmd.ExitFailure, fmt.Errorf("audit found %s findings", a.failLevel+" or higher"))
+		}
+	}
+	return nil
+}
+
+// audit scans the tree at root.
+func audit(root string, now time.Time) (*auditReport, error) {
+	report := &auditReport{Keys: []*auditKey{}, Findings: []auditFinding{}}
+	metas := make(map[string]*keyMeta)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			meta, err := readMeta(path)
+			if err != nil {
+				report.add(filepath.Join(path, metaFile), "low", "meta", err.Error())
+			} else if meta != nil {
+				metas[path] = meta
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		key, err := sniffKey(path)
+		if err != nil || key == nil {
+			return err
+		}
+		report.Keys = append(report.Keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range report.Keys {
+		report.check(key, metas[filepath.Dir(key.Path)], now)
+	}
+	report.pairs()
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Path < report.Findings[j].Path
+	})
+	return report, nil
+}
+
+// sniffKey reads the head of a file and identifies the key it contains,
+// nil when it is no key file.
+func sniffKey(path string) (*auditKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxAuditBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	key := &auditKey{Path: path, mode: stat.Mode().Perm(), Insecure: bytes.Contains(data, []byte("INSECURE TEST KEY"))}
+	if block, _ := pem.Decode(data); block != nil {
+		switch {
+		case block.Type == "OPENSSH PRIVATE KEY":
+			key.Kind = "private"
+			raw, err := ssh.ParseRawPrivateKey(data)
+			var missing *ssh.PassphraseMissingError
+			switch {
+			case errors.As(err, &missing):
+				key.Encrypted = true
+				return key.describe(missing.PublicKey)
+			case err != nil:
+				return key, nil
+			}
+			if priv, ok := raw.(privateKey); ok {
+				return key.describe(priv.Public())
+			}
+			return key, nil
+		case block.Type == "ENCRYPTED PRIVATE KEY", block.Headers["Proc-Type"] == "4,ENCRYPTED":
+			key.Kind, key.Encrypted = "private", true
+			return key, nil
+		case strings.HasSuffix(block.Type, "KEY"), block.Type == "CERTIFICATE":
+			info, err := parseKeyData(data)
+			if err != nil {
+				// A key block we cannot parse is still reported
+				key.Kind = strings.ToLower(block.Type)
+				return key, nil
+			}
+			key.Kind = info.Kind
+			return key.describe(info.Public)
+		}
+		return nil, nil
+	}
+
+	// ssh public keys, as in authorized_keys or id_*.pub
+	if pub, _, _, _, err := ssh.ParseAuthorizedKey(data); err == nil {
+		key.Kind = "public"
+		return key.describe(pub)
+	}
+
+	// DER is an ASN.1 sequence, only whole files are tried
+	if len(data) > 0 && data[0] == 0x30 && stat.Size() <= maxAuditBytes {
+		if info, err := parseKeyData(data); err == nil {
+			key.Kind = info.Kind
+			return key.describe(info.Public)
+		}
+	}
+	return nil, nil
+}
+
+// describe completes key with its public key, a crypto or an ssh one.
+func (key *auditKey) describe(pub any) (*auditKey, error) {
+	if sshPub, ok := pub.(ssh.CryptoPublicKey); ok {
+		pub = sshPub.CryptoPublicKey()
+	}
+	if pub == nil {
+		return key, nil
+	}
+	key.Algorithm = algorithm(pub)
+	if rsaPub, ok := pub.(*rsa.PublicKey); ok {
+		key.bits = rsaPub.N.BitLen()
+	}
+	fp, err := fingerprint(pub.(crypto.PublicKey))
+	if err == nil {
+		key.Fingerprint = fp
+	}
+	return key, nil
+}
+
+// check adds the findings of a single key.
+func (r *auditReport) check(key *auditKey, meta *keyMeta, now time.Time) {
+	if key.Kind == "private" && !key.Encrypted {
+		r.add(key.Path, "high", "unencrypted", "private key is not encrypted")
+	}
+	if key.bits > 0 && key.bits < 2048 {
+		r.add(key.Path, "high", "weak", fmt.Sprintf("RSA key of %d bits, use at least 2048", key.bits))
+	}
+	if key.Kind == "private" && key.mode&0o004 != 0 {
+		r.add(key.Path, "high", "permissions", fmt.Sprintf("private key is readable by everyone (%v)", key.mode))
+	}
+
+	if meta == nil || !slices.Contains(meta.Files, filepath.Base(key.Path)) {
+		if key.Insecure {
+			r.add(key.Path, "medium", "insecure", "insecure test key")
+		}
+		return
+	}
+	if meta.Insecure || key.Insecure {
+		key.Insecure = true
+		r.add(key.Path, "medium", "insecure", "insecure test key")
+	}
+	if meta.Expired(now) {
+		r.add(key.Path, "medium", "expired", fmt.Sprintf("key expired on %s (%s)", meta.Expires, meta.Purpose))
+	}
+}
+
+// pairs reports the private and public keys of one pair in one directory.
+func (r *auditReport) pairs() {
+	for _, priv := range r.Keys {
+		if priv.Kind != "private" || priv.Fingerprint == "" {
+			continue
+		}
+		for _, pub := range r.Keys {
+			if pub.Kind == "public" && pub.Fingerprint == priv.Fingerprint && filepath.Dir(pub.Path) == filepath.Dir(priv.Path) {
+				r.add(priv.Path, "info", "pair", "public key "+filepath.Base(pub.Path)+" is in the same directory")
+			}
+		}
+	}
+}
+
+func (r *auditReport) add(path, severity, check, message string) {
+	r.Findings = append(r.Findings, auditFinding{Path: path, Severity: severity, Check: check, Message: message})
+}
+
+// print writes the text report.
+func (r *auditReport) print() {
+	fmt.Printf("Scanned %d key file(s), %d finding(s)\n\n", len(r.Keys), len(r.Findings))
+	for _, f := range r.Findings {
+		line := fmt.Sprintf("%-6s %-12s %s: %s", f.Severity, f.Check, f.Path, f.Message)
+		switch f.Severity {
+		case "high":
+			color.Red(line)
+		case "medium":
+			color.Yellow(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+var _ cmd.ICommand = (*Audit)(nil)
+
+// Checks implements cmd.Checker.
+func (a *Audit) Checks() []cmd.Check {
+	return []cmd.Check{{Name: "keys.unencrypted", Run: unencryptedCheck}}
+}
+
+// unencryptedCheck looks for unencrypted private keys in the working
+// directory, except the insecure test keys.
+func unencryptedCheck(context.Context) cmd.CheckResult {
+	report, err := audit(".", time.Now())
+	if err != nil {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "make the directory tree readable"}
+	}
+	insecure := make(map[string]bool)
+	for _, key := range report.Keys {
+		insecure[key.Path] = key.Insecure
+	}
+	var paths []string
+	for _, f := range report.Findings {
+		if f.Check == "unencrypted" && !insecure[f.Path] {
+			paths = append(paths, f.Path)
+		}
+	}
+	if len(paths) > 0 {
+		return cmd.CheckResult{
+			Status:  cmd.CheckFail,
+			Message: fmt.Sprintf("%d unencrypted private keys: %s", len(paths), strings.Join(paths, ", ")),
+			Hint:    "encrypt them, e.g. with openssl pkcs8 -topk8 or ssh-keygen -p, or move them out of the project",
+		}
+	}
+	return cmd.CheckResult{Status: cmd.CheckPass, Message: fmt.Sprintf("no unencrypted private key among %d key files", len(report.Keys))}
+}
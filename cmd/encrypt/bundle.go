@@ -0,0 +1,300 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"bufio"
+	"command/cmd"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// privateKey is implemented by the private keys of the standard library.
+type privateKey interface {
+	Public() crypto.PublicKey
+	Equal(crypto.PrivateKey) bool
+}
+
+type Bundle struct {
+	key           string
+	cert          string
+	chain         []string
+	out           string
+	passwordEnv   string
+	passwordStdin bool
+	insecure      bool
+}
+
+func NewBundle() *Bundle {
+	return &Bundle{}
+}
+
+// Command implements cmd.ICommand.
+func (b *Bundle) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bundle",
+		GroupID: "encrypt",
+		Short:   "PKCS#12 bundle tools",
+		Long: `Assemble a password-protected PKCS#12 (.p12) archive from existing
+private key and certificate files, as used by Java key stores.
+
+The password is read from the environment variable of --password-env, from
+the first line of the standard input with --password-stdin, or prompted for
+on the terminal. It is never taken from the command line, where other users
+and the shell history would see it. The archive is parsed back with the same
+password before it is reported as written.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Bundle a key and its certificate, prompting for the password
+command bundle --key ./out/private.pem --cert ./out/cert.pem -o ./out/bundle.p12
+
+# Include the intermediate certificates of the chain
+command bundle --key key.pem --cert cert.pem --ca intermediate.pem --ca root.pem
+
+# Read the password from the environment in a pipeline
+P12_PASSWORD=$(vault read -field=password secret/p12) command bundle --key key.pem --cert cert.pem --password-env P12_PASSWORD
+
+# Bundle without a password
+command bundle --key key.pem --cert cert.pem --insecure-empty-password`,
+		Args: cobra.MaximumNArgs(0),
+		RunE: b.run,
+	}
+
+	// Setup flags
+	b.flags(cmd)
+	return cmd
+}
+
+// flags setup flags for the bundle command.
+func (b *Bundle) flags(c *cobra.Command) {
+	c.Flags().StringVar(&b.key, "key", "", cmd.T("bundle.flag.key"))
+	c.Flags().StringVar(&b.cert, "cert", "", cmd.T("bundle.flag.cert"))
+	c.Flags().StringArrayVar(&b.chain, "ca", nil, cmd.T("bundle.flag.ca"))
+	c.Flags().StringVarP(&b.out, "out", "o", "./out/bundle.p12", cmd.T("bundle.flag.out"))
+	c.Flags().StringVar(&b.passwordEnv, "password-env", "", cmd.T("bundle.flag.password-env"))
+	c.Flags().BoolVar(&b.passwordStdin, "password-stdin", false, cmd.T("bundle.flag.password-stdin"))
+	c.Flags().BoolVar(&b.insecure, "insecure-empty-password", false, cmd.T("bundle.flag.insecure-empty-password"))
+
+	_ = c.MarkFlagRequired("key")
+	_ = c.MarkFlagRequired("cert")
+	c.MarkFlagsMutuallyExclusive("password-env", "password-stdin")
+}
+
+// run executes the bundle command logic.
+func (b *Bundle) run(_ *cobra.Command, _ []string) error {
+	if err := b.exec(); err != nil {
+		return err
+	}
+
+	color.Green("PKCS#12 bundle written to %s\n\n", b.out)
+	return nil
+}
+
+// exec loads the key and certificates and writes the archive. Missing or
+// invalid inputs are usage errors.
+func (b *Bundle) exec() error {
+	keyData, err := os.ReadFile(b.key)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("read key: %w", err))
+	}
+	key, err := parseKey(keyData)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("%s: %w", b.key, err))
+	}
+
+	certs, err := readCerts(b.cert)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+	chain := certs[1:]
+	for _, path := range b.chain {
+		ca, err := readCerts(path)
+		if err != nil {
+			return cmd.Exit(cmd.ExitUsage, err)
+		}
+		chain = append(chain, ca...)
+	}
+	priv, err := keyPair(key, certs[0])
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+
+	password, err := b.readPassword()
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+	return cmd.Exit(cmd.ExitFailure, writeP12(b.out, priv, certs[0], chain, password))
+}
+
+// keyPair checks that key is the private key of cert.
+func keyPair(key crypto.PrivateKey, cert *x509.Certificate) (privateKey, error) {
+	priv, ok := key.(privateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if pub, ok := priv.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(cert.PublicKey) {
+		return nil, errors.New("the private key does not match the certificate")
+	}
+	return priv, nil
+}
+
+// readPassword returns the archive password of --password-env or
+// --password-stdin, an empty one with --insecure-empty-password alone, or
+// prompts for it.
+func (b *Bundle) readPassword() (string, error) {
+	switch {
+	case b.passwordEnv != "":
+		password, ok := os.LookupEnv(b.passwordEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s of --password-env is not set", b.passwordEnv)
+		}
+		return checkPassword(password, b.insecure)
+	case b.passwordStdin:
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("read password from the standard input: %w", err)
+		}
+		return checkPassword(strings.TrimRight(line, "\r\n"), b.insecure)
+	case b.insecure:
+		return "", nil
+	}
+	return p12Password()
+}
+
+// checkPassword refuses an empty password unless insecure.
+func checkPassword(password string, insecure bool) (string, error) {
+	if password == "" && !insecure {
+		return "", errors.New("empty password, use --insecure-empty-password to allow it")
+	}
+	return password, nil
+}
+
+// writeP12 encodes key, cert and chain as a PKCS#12 archive, checks that the
+// archive decodes back to the same content and writes it to path.
+func writeP12(path string, priv privateKey, cert *x509.Certificate, chain []*x509.Certificate, password string) error {
+	data, err := pkcs12.Modern.Encode(priv, cert, chain, password)
+	if err != nil {
+		return fmt.Errorf("failed to encode PKCS#12: %w", err)
+	}
+
+	// Verify the archive before declaring success
+	decKey, decCert, decChain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return fmt.Errorf("failed to verify PKCS#12: %w", err)
+	}
+	if !decCert.Equal(cert) || len(decChain) != len(chain) || !priv.Equal(decKey) {
+		return errors.New("failed to verify PKCS#12: decoded content differs")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	return nil
+}
+
+// p12Password prompts for the archive password on a terminal.
+func p12Password() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("no password set, use --password-env, --password-stdin or --insecure-empty-password")
+	}
+
+	fmt.Fprint(os.Stderr, "PKCS#12 password: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	fmt.Fprint(os.Stderr, "Confirm password: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+
+	if string(first) != string(second) {
+		return "", errors.New("passwords do not match")
+	}
+	return checkPassword(string(first), false)
+}
+
+// parseKey parses a PKCS#1, PKCS#8 or EC private key, PEM or DER encoded.
+func parseKey(data []byte) (crypto.PrivateKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(data); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(data); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key, must be PKCS1, PKCS8 or EC")
+}
+
+// readCerts reads the certificates of a PEM or DER encoded file.
+func readCerts(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read certificate: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return []*x509.Certificate{cert}, nil
+}
+
+var _ cmd.ICommand = (*Bundle)(nil)
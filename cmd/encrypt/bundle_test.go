@@ -0,0 +1,162 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"command/cmd"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// writeKeyPair writes a PEM private key and its self-signed certificate into
+// dir, returning their paths.
+func writeKeyPair(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath, certPath := filepath.Join(dir, name+".key"), filepath.Join(dir, name+".crt")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath, certPath
+}
+
+func TestBundleInputs(t *testing.T) {
+	dir := t.TempDir()
+	key, cert := writeKeyPair(t, dir, "svc")
+	otherKey, _ := writeKeyPair(t, dir, "other")
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_P12_PASSWORD", "s3cret")
+
+	tests := []struct {
+		name string
+		b    Bundle
+		code int
+	}{
+		{name: "missing key", b: Bundle{key: filepath.Join(dir, "missing.key"), cert: cert, passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitUsage},
+		{name: "invalid key", b: Bundle{key: garbage, cert: cert, passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitUsage},
+		{name: "missing cert", b: Bundle{key: key, cert: filepath.Join(dir, "missing.crt"), passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitUsage},
+		{name: "invalid cert", b: Bundle{key: key, cert: garbage, passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitUsage},
+		{name: "missing ca", b: Bundle{key: key, cert: cert, chain: []string{filepath.Join(dir, "missing.crt")}, passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitUsage},
+		{name: "key of another cert", b: Bundle{key: otherKey, cert: cert, passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitUsage},
+		{name: "unset password variable", b: Bundle{key: key, cert: cert, passwordEnv: "TEST_P12_UNSET"}, code: cmd.ExitUsage},
+		{name: "password variable", b: Bundle{key: key, cert: cert, passwordEnv: "TEST_P12_PASSWORD"}, code: cmd.ExitOK},
+		{name: "insecure empty password", b: Bundle{key: key, cert: cert, insecure: true}, code: cmd.ExitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.b.out = filepath.Join(t.TempDir(), "bundle.p12")
+			err := tt.b.exec()
+			if got := cmd.ExitCode(err, true); got != tt.code {
+				t.Fatalf("exit code %d (%v), want %d", got, err, tt.code)
+			}
+			if tt.code != cmd.ExitOK {
+				if _, err := os.Stat(tt.b.out); err == nil {
+					t.Error("bundle written on error")
+				}
+				return
+			}
+			data, err := os.ReadFile(tt.b.out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			password := os.Getenv(tt.b.passwordEnv)
+			if _, _, err := pkcs12.Decode(data, password); err != nil {
+				t.Errorf("decode bundle: %v", err)
+			}
+		})
+	}
+}
+
+func TestBundlePasswordStdin(t *testing.T) {
+	dir := t.TempDir()
+	key, cert := writeKeyPair(t, dir, "svc")
+
+	for _, tt := range []struct {
+		input string
+		code  int
+	}{
+		{input: "s3cret\n", code: cmd.ExitOK},
+		{input: "s3cret\r\nignored\n", code: cmd.ExitOK},
+		{input: "\n", code: cmd.ExitUsage},
+		{input: "", code: cmd.ExitUsage},
+	} {
+		stdin, err := os.CreateTemp(dir, "stdin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stdin.WriteString(tt.input); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stdin.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		orig := os.Stdin
+		os.Stdin = stdin
+		b := Bundle{key: key, cert: cert, passwordStdin: true, out: filepath.Join(dir, "bundle.p12")}
+		err = b.exec()
+		os.Stdin = orig
+		stdin.Close()
+
+		if got := cmd.ExitCode(err, true); got != tt.code {
+			t.Errorf("input %q: exit code %d (%v), want %d", tt.input, got, err, tt.code)
+			continue
+		}
+		if tt.code == cmd.ExitOK {
+			data, err := os.ReadFile(b.out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := pkcs12.Decode(data, "s3cret"); err != nil {
+				t.Errorf("input %q: decode bundle: %v", tt.input, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"command/cmd"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type Inspect struct{}
+
+// keyInfo describes a parsed key or certificate file.
+type keyInfo struct {
+	// Kind is private, public or certificate
+	Kind string
+	// Format is PKCS1, PKCS8, PKIX, EC or X509
+	Format string
+	// Encoding is PEM or DER
+	Encoding string
+	Public   crypto.PublicKey
+}
+
+func NewInspect() *Inspect {
+	return &Inspect{}
+}
+
+// Command implements cmd.ICommand.
+func (i *Inspect) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "inspect <file>",
+		GroupID: "encrypt",
+		Short:   "Show the details of a key or certificate file",
+		Long: `Show the kind, format, algorithm and fingerprint of a PEM or DER encoded
+key or certificate file, with the key.meta.json metadata of its directory
+when present.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Inspect a generated public key
+command inspect ./out/public.pem`,
+		Args: cobra.ExactArgs(1),
+		RunE: i.run,
+	}
+	return cmd
+}
+
+// run executes the inspect command logic.
+func (i *Inspect) run(_ *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+	info, err := parseKeyData(data)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("%s: %w", args[0], err))
+	}
+	fp, err := fingerprint(info.Public)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("File:        %s\n", args[0])
+	fmt.Printf("Kind:        %s (%s, %s)\n", info.Kind, info.Format, info.Encoding)
+	fmt.Printf("Algorithm:   %s\n", algorithm(info.Public))
+	fmt.Printf("Fingerprint: %s\n", fp)
+
+	meta, err := readMeta(filepath.Dir(args[0]))
+	if err != nil || meta == nil {
+		return err
+	}
+	fmt.Printf("\nMetadata (%s):\n", metaFile)
+	if meta.Fingerprint != fp {
+		color.Yellow("  describes another key (%s)\n", meta.Fingerprint)
+		return nil
+	}
+	fmt.Printf("  Purpose:   %s\n", meta.Purpose)
+	fmt.Printf("  Created:   %s by %s\n", meta.CreatedAt.Format(time.RFC3339), meta.Creator)
+	if meta.Expires != "" {
+		if meta.Expired(time.Now()) {
+			color.Red("  Expires:   %s (expired)\n", meta.Expires)
+		} else {
+			fmt.Printf("  Expires:   %s\n", meta.Expires)
+		}
+	}
+	if meta.Insecure {
+		color.Yellow("  INSECURE test key, never use it outside tests\n")
+	}
+	return nil
+}
+
+// parseKeyData parses a PEM or DER encoded private key, public key or
+// certificate. Only the first PEM block is read.
+func parseKeyData(data []byte) (*keyInfo, error) {
+	info := &keyInfo{Encoding: "DER"}
+	if block, _ := pem.Decode(data); block != nil {
+		info.Encoding, data = "PEM", block.Bytes
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(data); err == nil {
+		return info.private("PKCS8", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return info.private("PKCS1", key)
+	}
+	if key, err := x509.ParseECPrivateKey(data); err == nil {
+		return info.private("EC", key)
+	}
+	if pub, err := x509.ParsePKIXPublicKey(data); err == nil {
+		info.Kind, info.Format, info.Public = "public", "PKIX", pub
+		return info, nil
+	}
+	if pub, err := x509.ParsePKCS1PublicKey(data); err == nil {
+		info.Kind, info.Format, info.Public = "public", "PKCS1", pub
+		return info, nil
+	}
+	if cert, err := x509.ParseCertificate(data); err == nil {
+		info.Kind, info.Format, info.Public = "certificate", "X509", cert.PublicKey
+		return info, nil
+	}
+	return nil, errors.New("not a supported key or certificate")
+}
+
+// private completes info with a parsed private key.
+func (info *keyInfo) private(format string, key any) (*keyInfo, error) {
+	priv, ok := key.(privateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	info.Kind, info.Format, info.Public = "private", format, priv.Public()
+	return info, nil
+}
+
+var _ cmd.ICommand = (*Inspect)(nil)
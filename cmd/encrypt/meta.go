@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// metaFile is written next to generated keys with --meta.
+const metaFile = "key.meta.json"
+
+// expiresLayout is the date layout of the --expires hint.
+const expiresLayout = "2006-01-02"
+
+// keyMeta records what a generated key pair is for.
+type keyMeta struct {
+	Purpose     string    `json:"purpose,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Algorithm   string    `json:"algorithm"`
+	Fingerprint string    `json:"fingerprint"`
+	Expires     string    `json:"expires,omitempty"`
+	Creator     string    `json:"creator,omitempty"`
+	Insecure    bool      `json:"insecure,omitempty"`
+	Files       []string  `json:"files"`
+}
+
+// Expired reports whether the expiry hint of the key is in the past.
+func (m *keyMeta) Expired(now time.Time) bool {
+	if m.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(expiresLayout, m.Expires)
+	return err == nil && !now.Before(expires)
+}
+
+// writeMeta writes the metadata file into dir.
+func writeMeta(dir string, meta *keyMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, metaFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write meta: %w", err)
+	}
+	return nil
+}
+
+// readMeta reads the metadata file of dir, nil when there is none.
+func readMeta(dir string) (*keyMeta, error) {
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta keyMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, metaFile), err)
+	}
+	return &meta, nil
+}
+
+// fingerprint returns the SHA256 fingerprint of a public key, in the format
+// of ssh-keygen -l.
+func fingerprint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// algorithm describes the algorithm and size of a public key.
+func algorithm(pub crypto.PublicKey) string {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + pub.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", pub)
+	}
+}
+
+// creator returns user@host of the current process.
+func creator() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
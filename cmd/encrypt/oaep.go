@@ -0,0 +1,399 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"command/cmd"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// oaepPEMType is the PEM block type of the containers of the rsa encrypt and
+// wrap commands. The headers record how to decrypt the bytes:
+//
+//	Mode:        encrypt or wrap
+//	OAEP-Hash:   sha1, sha256 or sha512
+//	OAEP-Label:  the base64 label, absent when empty
+//	Wrapped-Key: the base64 RSA-OAEP ciphertext of the AES-256 key (encrypt)
+//	Nonce:       the base64 AES-GCM nonce (encrypt)
+//
+// The bytes are the AES-256-GCM ciphertext of the input for encrypt, and the
+// RSA-OAEP ciphertext of the input for wrap.
+const oaepPEMType = "CZX RSA OAEP MESSAGE"
+
+// Modes of the OAEP commands.
+const (
+	oaepEncrypt = "encrypt"
+	oaepDecrypt = "decrypt"
+	oaepWrap    = "wrap"
+	oaepUnwrap  = "unwrap"
+)
+
+// defaultOAEPHash is the OAEP hash of --oaep-hash when not set.
+const defaultOAEPHash = "sha256"
+
+// oaepHashes are the hashes of --oaep-hash by name.
+var oaepHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+type (
+	// OAEP is an rsa subcommand encrypting or wrapping with RSA-OAEP, or
+	// decrypting or unwrapping the result.
+	OAEP struct {
+		mode  string
+		key   string
+		in    string
+		out   string
+		hash  string
+		label string
+		raw   bool
+	}
+	// oaepParams are the OAEP parameters of a container.
+	oaepParams struct {
+		Hash  string
+		Label []byte
+	}
+)
+
+// newOAEP returns the rsa subcommand of mode.
+func newOAEP(mode string) *OAEP {
+	return &OAEP{mode: mode}
+}
+
+// Command implements cmd.ICommand.
+func (o *OAEP) Command() *cobra.Command {
+	c := &cobra.Command{
+		Use:  o.mode,
+		Args: cobra.NoArgs,
+		RunE: o.run,
+	}
+	switch o.mode {
+	case oaepEncrypt:
+		c.Short = "Encrypt a file for the holder of an RSA private key"
+		c.Long = `Encrypt a file of any size with a random AES-256-GCM key, itself
+encrypted with RSA-OAEP for the public key of --key, a private key or a
+certificate. The OAEP hash and label are recorded in the header of the
+output, so decrypt finds them without flags.`
+		c.Example = `# Encrypt a file for the owner of a key pair
+command rsa encrypt -k public.pem -i report.csv -o report.csv.enc
+
+# Match a peer using SHA-1 and a label
+command rsa encrypt -k peer.pem --oaep-hash sha1 --oaep-label orders -i msg -o msg.enc`
+	case oaepDecrypt:
+		c.Short = "Decrypt a file encrypted with rsa encrypt"
+		c.Long = `Decrypt a file written by rsa encrypt with the private key of --key.
+The OAEP hash and label come from the header of the file, --oaep-hash and
+--oaep-label replace them, and the decryption fails unless they match the
+ones the file was encrypted with.`
+		c.Example = `# Decrypt a file
+command rsa decrypt -k private.pem -i report.csv.enc -o report.csv`
+	case oaepWrap:
+		c.Short = "Wrap a key with RSA-OAEP"
+		c.Long = `Encrypt a small secret, such as a symmetric key, with RSA-OAEP for the
+public key of --key. The output records the OAEP hash and label in its
+header, with --raw it is the bare RSA-OAEP ciphertext expected by other
+implementations, such as a Java Cipher "RSA/ECB/OAEPWithSHA-1AndMGF1Padding".`
+		c.Example = `# Wrap a data key for a Java service using SHA-1 and a label
+command rsa wrap -k peer.pem --oaep-hash sha1 --oaep-label orders --raw -i data.key -o data.key.wrapped`
+	case oaepUnwrap:
+		c.Short = "Unwrap a key wrapped with RSA-OAEP"
+		c.Long = `Decrypt a secret wrapped with rsa wrap, or with --raw a bare RSA-OAEP
+ciphertext of another implementation, with the private key of --key. The
+OAEP hash and label come from the header, or from --oaep-hash and
+--oaep-label, which replace them and are required to match.`
+		c.Example = `# Unwrap a data key sent by a Java service
+command rsa unwrap -k private.pem --oaep-hash sha1 --oaep-label orders --raw -i data.key.wrapped -o data.key`
+	}
+	c.Long += "\n\n" + cmd.ExitCodesHelp
+
+	c.Flags().StringVarP(&o.key, "key", "k", "", cmd.T("rsa.oaep.flag.key"))
+	c.Flags().StringVarP(&o.in, "in", "i", "-", cmd.T("rsa.oaep.flag.in"))
+	c.Flags().StringVarP(&o.out, "out", "o", "-", cmd.T("rsa.oaep.flag.out"))
+	c.Flags().StringVar(&o.hash, "oaep-hash", defaultOAEPHash, cmd.T("rsa.oaep.flag.oaep-hash"))
+	c.Flags().StringVar(&o.label, "oaep-label", "", cmd.T("rsa.oaep.flag.oaep-label"))
+	if o.mode == oaepWrap || o.mode == oaepUnwrap {
+		c.Flags().BoolVar(&o.raw, "raw", false, cmd.T("rsa.oaep.flag.raw"))
+	}
+	_ = c.MarkFlagRequired("key")
+	return c
+}
+
+// run executes the OAEP subcommand logic.
+func (o *OAEP) run(c *cobra.Command, _ []string) error {
+	if _, ok := oaepHashes[o.hash]; !ok {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("invalid OAEP hash: %s, must be sha1, sha256 or sha512", o.hash))
+	}
+	keyData, err := os.ReadFile(o.key)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+	in, err := readInput(o.in)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+
+	params := oaepParams{Hash: o.hash, Label: []byte(o.label)}
+	var out []byte
+	switch o.mode {
+	case oaepEncrypt, oaepWrap:
+		info, err := parseKeyData(keyData)
+		if err != nil {
+			return cmd.Exit(cmd.ExitUsage, err)
+		}
+		pub, ok := info.Public.(*rsa.PublicKey)
+		if !ok {
+			return cmd.Exit(cmd.ExitUsage, fmt.Errorf("%s is not an RSA key", o.key))
+		}
+		o.warnSHA1(params)
+		if o.mode == oaepEncrypt {
+			out, err = oaepSeal(pub, params, in)
+		} else {
+			out, err = oaepWrapKey(pub, params, in, o.raw)
+		}
+		if err != nil {
+			return cmd.Exit(cmd.ExitFailure, err)
+		}
+	default:
+		key, err := parseKey(keyData)
+		if err != nil {
+			return cmd.Exit(cmd.ExitUsage, err)
+		}
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return cmd.Exit(cmd.ExitUsage, fmt.Errorf("%s is not an RSA private key", o.key))
+		}
+		if o.raw {
+			o.warnSHA1(params)
+			out, err = oaepDecryptKey(priv, params, in)
+		} else {
+			override := oaepOverride{}
+			if c.Flags().Changed("oaep-hash") {
+				override.Hash = &params.Hash
+			}
+			if c.Flags().Changed("oaep-label") {
+				override.Label = &params.Label
+			}
+			out, err = oaepOpen(priv, in, override, o.warnSHA1)
+		}
+		if err != nil {
+			return cmd.Exit(cmd.ExitFailure, err)
+		}
+	}
+
+	if err := writeOutput(o.out, out); err != nil {
+		return cmd.Exit(cmd.ExitFailure, err)
+	}
+	return nil
+}
+
+// warnSHA1 warns on the standard error that SHA-1 is for legacy peers.
+func (o *OAEP) warnSHA1(params oaepParams) {
+	if params.Hash == "sha1" {
+		fmt.Fprintln(os.Stderr, color.YellowString("Warning: OAEP with SHA-1 is only meant for legacy peers, prefer sha256"))
+	}
+}
+
+// oaepOverride are the OAEP parameters given on the command line, replacing
+// the ones of the header when set.
+type oaepOverride struct {
+	Hash  *string
+	Label *[]byte
+}
+
+// oaepSeal encrypts data with a random AES-256-GCM key wrapped for pub.
+func oaepSeal(pub *rsa.PublicKey, params oaepParams, data []byte) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	wrapped, err := rsa.EncryptOAEP(oaepHashes[params.Hash](), rand.Reader, pub, key, params.Label)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	block := params.block(oaepEncrypt)
+	block.Headers["Wrapped-Key"] = base64.StdEncoding.EncodeToString(wrapped)
+	block.Headers["Nonce"] = base64.StdEncoding.EncodeToString(nonce)
+	block.Bytes = gcm.Seal(nil, nonce, data, nil)
+	return pem.EncodeToMemory(block), nil
+}
+
+// oaepWrapKey encrypts a secret for pub, in a container unless raw.
+func oaepWrapKey(pub *rsa.PublicKey, params oaepParams, secret []byte, raw bool) ([]byte, error) {
+	h := oaepHashes[params.Hash]()
+	if max := pub.Size() - 2*h.Size() - 2; len(secret) > max {
+		return nil, fmt.Errorf("input of %d bytes exceeds the %d bytes RSA-OAEP can wrap with this key and hash, use rsa encrypt", len(secret), max)
+	}
+	wrapped, err := rsa.EncryptOAEP(h, rand.Reader, pub, secret, params.Label)
+	if err != nil {
+		return nil, err
+	}
+	if raw {
+		return wrapped, nil
+	}
+	block := params.block(oaepWrap)
+	block.Bytes = wrapped
+	return pem.EncodeToMemory(block), nil
+}
+
+// oaepOpen decrypts a container of rsa encrypt or rsa wrap with priv. warn
+// is called with the parameters used.
+func oaepOpen(priv *rsa.PrivateKey, data []byte, override oaepOverride, warn func(oaepParams)) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != oaepPEMType {
+		return nil, errors.New("not an rsa encrypt or wrap container, use --raw for a bare RSA-OAEP ciphertext")
+	}
+	params, err := parseOAEPParams(block.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if override.Hash != nil {
+		params.Hash = *override.Hash
+	}
+	if override.Label != nil {
+		params.Label = *override.Label
+	}
+	warn(params)
+
+	switch block.Headers["Mode"] {
+	case oaepWrap:
+		return oaepDecryptKey(priv, params, block.Bytes)
+	case oaepEncrypt:
+		wrapped, err := base64.StdEncoding.DecodeString(block.Headers["Wrapped-Key"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Wrapped-Key header: %w", err)
+		}
+		nonce, err := base64.StdEncoding.DecodeString(block.Headers["Nonce"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Nonce header: %w", err)
+		}
+		key, err := oaepDecryptKey(priv, params, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(nonce) != gcm.NonceSize() {
+			return nil, errors.New("invalid Nonce header")
+		}
+		plain, err := gcm.Open(nil, nonce, block.Bytes, nil)
+		if err != nil {
+			return nil, errors.New("decryption failed, the file is corrupted")
+		}
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("unknown container mode %q", block.Headers["Mode"])
+	}
+}
+
+// oaepDecryptKey decrypts a bare RSA-OAEP ciphertext with priv.
+func oaepDecryptKey(priv *rsa.PrivateKey, params oaepParams, ciphertext []byte) ([]byte, error) {
+	newHash, ok := oaepHashes[params.Hash]
+	if !ok {
+		return nil, fmt.Errorf("unsupported OAEP hash %q", params.Hash)
+	}
+	plain, err := rsa.DecryptOAEP(newHash(), nil, priv, ciphertext, params.Label)
+	if err != nil {
+		return nil, fmt.Errorf("RSA-OAEP decryption failed, wrong key or OAEP hash %s and label do not match: %w", params.Hash, err)
+	}
+	return plain, nil
+}
+
+// block returns a container block of mode recording the parameters.
+func (p oaepParams) block(mode string) *pem.Block {
+	headers := map[string]string{"Mode": mode, "OAEP-Hash": p.Hash}
+	if len(p.Label) > 0 {
+		headers["OAEP-Label"] = base64.StdEncoding.EncodeToString(p.Label)
+	}
+	return &pem.Block{Type: oaepPEMType, Headers: headers}
+}
+
+// parseOAEPParams reads the parameters recorded in the container headers.
+func parseOAEPParams(headers map[string]string) (oaepParams, error) {
+	params := oaepParams{Hash: headers["OAEP-Hash"]}
+	if _, ok := oaepHashes[params.Hash]; !ok {
+		names := make([]string, 0, len(oaepHashes))
+		for name := range oaepHashes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return oaepParams{}, fmt.Errorf("unsupported OAEP-Hash header %q, must be one of %v", params.Hash, names)
+	}
+	if label, ok := headers["OAEP-Label"]; ok {
+		var err error
+		if params.Label, err = base64.StdEncoding.DecodeString(label); err != nil {
+			return oaepParams{}, fmt.Errorf("invalid OAEP-Label header: %w", err)
+		}
+	}
+	return params, nil
+}
+
+// newGCM returns AES-GCM with key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readInput reads a file, or the standard input for "-".
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeOutput writes a file, or the standard output for "-".
+func writeOutput(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+var _ cmd.ICommand = (*OAEP)(nil)
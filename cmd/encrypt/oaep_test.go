@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"testing"
+)
+
+func TestOAEPCrossParameters(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []oaepParams
+	for _, h := range []string{"sha1", "sha256", "sha512"} {
+		for _, label := range []string{"", "orders"} {
+			vectors = append(vectors, oaepParams{Hash: h, Label: []byte(label)})
+		}
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	noWarn := func(oaepParams) {}
+
+	for _, enc := range vectors {
+		name := fmt.Sprintf("%s/%q", enc.Hash, enc.Label)
+		sealed, err := oaepSeal(&priv.PublicKey, enc, secret)
+		if err != nil {
+			t.Fatalf("%s: seal: %v", name, err)
+		}
+		wrapped, err := oaepWrapKey(&priv.PublicKey, enc, secret, false)
+		if err != nil {
+			t.Fatalf("%s: wrap: %v", name, err)
+		}
+		raw, err := oaepWrapKey(&priv.PublicKey, enc, secret, true)
+		if err != nil {
+			t.Fatalf("%s: raw wrap: %v", name, err)
+		}
+
+		// The header alone is enough to decrypt.
+		for _, container := range [][]byte{sealed, wrapped} {
+			got, err := oaepOpen(priv, container, oaepOverride{}, noWarn)
+			if err != nil || !bytes.Equal(got, secret) {
+				t.Errorf("%s: auto-detect = %q, %v", name, got, err)
+			}
+		}
+
+		// Explicit parameters only decrypt when they match.
+		for _, dec := range vectors {
+			match := dec.Hash == enc.Hash && bytes.Equal(dec.Label, enc.Label)
+			override := oaepOverride{Hash: &dec.Hash, Label: &dec.Label}
+			for kind, container := range map[string][]byte{"encrypt": sealed, "wrap": wrapped} {
+				got, err := oaepOpen(priv, container, override, noWarn)
+				if match != (err == nil) || match && !bytes.Equal(got, secret) {
+					t.Errorf("%s %s decrypted as %s/%q: %q, %v", kind, name, dec.Hash, dec.Label, got, err)
+				}
+			}
+			got, err := oaepDecryptKey(priv, dec, raw)
+			if match != (err == nil) || match && !bytes.Equal(got, secret) {
+				t.Errorf("raw %s unwrapped as %s/%q: %q, %v", name, dec.Hash, dec.Label, got, err)
+			}
+		}
+	}
+}
+
+func TestOAEPHeader(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := oaepWrapKey(&priv.PublicKey, oaepParams{Hash: defaultOAEPHash}, []byte("k"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(out)
+	if block == nil || block.Type != oaepPEMType {
+		t.Fatalf("unexpected container %q", out)
+	}
+	if block.Headers["OAEP-Hash"] != "sha256" || block.Headers["Mode"] != oaepWrap {
+		t.Errorf("headers = %v", block.Headers)
+	}
+	if _, ok := block.Headers["OAEP-Label"]; ok {
+		t.Errorf("empty label recorded: %v", block.Headers)
+	}
+
+	var warned []string
+	if _, err := oaepOpen(priv, out, oaepOverride{}, func(p oaepParams) { warned = append(warned, p.Hash) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(warned) != 1 || warned[0] != "sha256" {
+		t.Errorf("warn called with %v", warned)
+	}
+
+	block.Headers["OAEP-Hash"] = "md5"
+	if _, err := oaepOpen(priv, pem.EncodeToMemory(block), oaepOverride{}, func(oaepParams) {}); err == nil {
+		t.Error("unsupported OAEP-Hash header accepted")
+	}
+}
+
+func TestOAEPWrapTooLarge(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 128 - 2*64 - 2 leaves no room with SHA-512 on a 1024 bit key.
+	if _, err := oaepWrapKey(&priv.PublicKey, oaepParams{Hash: "sha512"}, []byte("k"), true); err == nil {
+		t.Error("oversized input wrapped")
+	}
+}
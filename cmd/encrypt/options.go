@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+type (
+	// IKeyOption configures a key generation command in code.
+	IKeyOption interface {
+		apply(*KeyOption)
+	}
+	KeyOptionFunc func(*KeyOption)
+	// KeyOption holds the settings shared by the key generation commands.
+	// They become the flag defaults, so a flag set on the command line wins.
+	KeyOption struct {
+		// bits is the key size
+		bits int
+		// format is the key format, PKCS1 or PKCS8
+		format string
+		// encoding is the file encoding, PEM or DER
+		encoding string
+		// outDir is the directory the key files are written to
+		outDir string
+	}
+)
+
+func (f KeyOptionFunc) apply(o *KeyOption) {
+	f(o)
+}
+
+// newKeyOption returns the defaults overridden by opts.
+func newKeyOption(defaults KeyOption, opts ...IKeyOption) KeyOption {
+	for _, o := range opts {
+		o.apply(&defaults)
+	}
+	return defaults
+}
+
+// WithBits sets the key size.
+func WithBits(bits int) IKeyOption {
+	return KeyOptionFunc(func(o *KeyOption) {
+		o.bits = bits
+	})
+}
+
+// WithFormat sets the key format, PKCS1 or PKCS8.
+func WithFormat(format string) IKeyOption {
+	return KeyOptionFunc(func(o *KeyOption) {
+		o.format = format
+	})
+}
+
+// WithEncoding sets the key file encoding, PEM or DER.
+func WithEncoding(encoding string) IKeyOption {
+	return KeyOptionFunc(func(o *KeyOption) {
+		o.encoding = encoding
+	})
+}
+
+// WithOutDir sets the directory the key files are written to.
+func WithOutDir(dir string) IKeyOption {
+	return KeyOptionFunc(func(o *KeyOption) {
+		o.outDir = dir
+	})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readPrivateKey returns the private key written to dir with its format and
+// encoding.
+func readPrivateKey(t *testing.T, dir string) (key *rsa.PrivateKey, format, encoding string) {
+	t.Helper()
+	meta, err := readMeta(dir)
+	if err != nil || meta == nil {
+		t.Fatalf("no key.meta.json in %s: %v", dir, err)
+	}
+	for _, name := range meta.Files {
+		if !strings.HasPrefix(name, "private") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoding = "DER"
+		if block, _ := pem.Decode(data); block != nil {
+			data, encoding = block.Bytes, "PEM"
+		}
+		if key, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+			return key, "PKCS1", encoding
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return parsed.(*rsa.PrivateKey), "PKCS8", encoding
+	}
+	t.Fatalf("no private key in %v", meta.Files)
+	return nil, "", ""
+}
+
+func TestKeyOptionPrecedence(t *testing.T) {
+	optDir, flagDir := t.TempDir(), t.TempDir()
+	opts := []IKeyOption{WithBits(1024), WithFormat("PKCS1"), WithEncoding("DER"), WithOutDir(optDir)}
+	tests := []struct {
+		name     string
+		args     []string
+		bits     int
+		format   string
+		encoding string
+		dir      string
+	}{
+		{name: "options", bits: 1024, format: "PKCS1", encoding: "DER", dir: optDir},
+		{name: "one flag", args: []string{"-e", "PEM"}, bits: 1024, format: "PKCS1", encoding: "PEM", dir: optDir},
+		{
+			name: "every flag",
+			args: []string{"-b", "2048", "--format", "PKCS8", "-e", "PEM", "-o", flagDir},
+			bits: 2048, format: "PKCS8", encoding: "PEM", dir: flagDir,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRSA(opts...).Command()
+			c.SetArgs(append([]string{"--test-key", "--seed", "options"}, tt.args...))
+			c.SilenceUsage, c.SilenceErrors = true, true
+			if err := c.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			key, format, encoding := readPrivateKey(t, tt.dir)
+			if key.N.BitLen() != tt.bits || format != tt.format || encoding != tt.encoding {
+				t.Errorf("%d-bit %s %s key, want %d-bit %s %s", key.N.BitLen(), format, encoding, tt.bits, tt.format, tt.encoding)
+			}
+		})
+	}
+
+	// The options are the defaults shown by the help
+	flags := NewRSA(opts...).Command().Flags()
+	for name, want := range map[string]string{"bits": "1024", "format": "PKCS1", "encoding": "DER", "out": optDir} {
+		if got := flags.Lookup(name).DefValue; got != want {
+			t.Errorf("--%s defaults to %q, want %q", name, got, want)
+		}
+	}
+	flags = NewRSA().Command().Flags()
+	for name, want := range map[string]string{"bits": "2048", "format": "PKCS8", "encoding": "PEM", "out": "./out"} {
+		if got := flags.Lookup(name).DefValue; got != want {
+			t.Errorf("--%s defaults to %q without options, want %q", name, got, want)
+		}
+	}
+}
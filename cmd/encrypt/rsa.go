@@ -6,9 +6,11 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/fatih/color"
 
@@ -16,14 +18,27 @@ import (
 )
 
 type RSA struct {
+	opt      KeyOption
 	format   string
 	encoding string
 	bits     int
 	outDir   string
+	testKey  bool
+	seed     string
+	meta     bool
+	purpose  string
+	expires  string
 }
 
-func NewRSA() *RSA {
-	return &RSA{}
+// NewRSA returns the rsa command. The options set the defaults of the
+// --bits, --format, --encoding and --out flags.
+func NewRSA(opts ...IKeyOption) *RSA {
+	return &RSA{opt: newKeyOption(KeyOption{
+		bits:     2048,
+		format:   "PKCS8",
+		encoding: "PEM",
+		outDir:   "./out",
+	}, opts...)}
 }
 
 // Command implements cmd.ICommand.
@@ -32,7 +47,10 @@ func (r *RSA) Command() *cobra.Command {
 		Use:     "rsa",
 		GroupID: "encrypt",
 		Short:   "RSA public key and private key tools",
-		Long: `Generate RSA public and private key files.
+		Long: `Generate RSA public and private key files. The encrypt, decrypt, wrap
+and unwrap subcommands use the keys with RSA-OAEP.
+
+` + cmd.ExitCodesHelp + `
 
 site: https://gorm.io/gen`,
 		Example: `# Generate RSA public and private key files with default settings
@@ -42,36 +60,53 @@ command rsa
 command rsa --format PKCS1 -e DER -b 4096 -o ./keys
 
 # Generate RSA keys with PEM encoding and 2048 bits
-command rsa -e PEM -b 2048`,
+command rsa -e PEM -b 2048
+
+# Record the purpose and intended lifetime of the keys in key.meta.json
+command rsa --meta --purpose "jwt signing for svc-x" --expires 2026-01-01
+
+# Generate a reproducible, insecure key pair for test fixtures
+command rsa --test-key --seed fixtures-v1 -o ./testdata`,
 		Args: cobra.MaximumNArgs(0),
-		Run:  r.run,
+		RunE: r.run,
 	}
 
 	// Setup flags
 	r.flags(cmd)
+	for _, mode := range []string{oaepEncrypt, oaepDecrypt, oaepWrap, oaepUnwrap} {
+		cmd.AddCommand(newOAEP(mode).Command())
+	}
 	return cmd
 }
 
 // flags setup flags for the RSA command.
 func (r *RSA) flags(c *cobra.Command) {
-	c.Flags().StringVar(&r.format, "format", "PKCS8", "Specify the key format: PKCS1 or PKCS8")
-	c.Flags().StringVarP(&r.encoding, "encoding", "e", "PEM", "Specify the key encoding: PEM or DER")
-	c.Flags().IntVarP(&r.bits, "bits", "b", 2048, "Specify the key length in bits")
-	c.Flags().StringVarP(&r.outDir, "out", "o", "./out", "Specify the output directory for the generated key files")
+	c.Flags().StringVar(&r.format, "format", r.opt.format, cmd.T("rsa.flag.format"))
+	c.Flags().StringVarP(&r.encoding, "encoding", "e", r.opt.encoding, cmd.T("rsa.flag.encoding"))
+	c.Flags().IntVarP(&r.bits, "bits", "b", r.opt.bits, cmd.T("rsa.flag.bits"))
+	c.Flags().StringVarP(&r.outDir, "out", "o", r.opt.outDir, cmd.T("rsa.flag.out"))
+	c.Flags().BoolVar(&r.testKey, "test-key", false, cmd.T("rsa.flag.test-key"))
+	c.Flags().StringVar(&r.seed, "seed", "", cmd.T("rsa.flag.seed"))
+	c.Flags().BoolVar(&r.meta, "meta", false, cmd.T("rsa.flag.meta"))
+	c.Flags().StringVar(&r.purpose, "purpose", "", cmd.T("rsa.flag.purpose"))
+	c.Flags().StringVar(&r.expires, "expires", "", cmd.T("rsa.flag.expires"))
 }
 
 // run executes the RSA command logic.
-func (r *RSA) run(_ *cobra.Command, _ []string) {
+func (r *RSA) run(_ *cobra.Command, _ []string) error {
 	if err := r.validate(); err != nil {
-		color.Red("Error: %v \n\n", err)
-		return
+		return cmd.Exit(cmd.ExitUsage, err)
 	}
 	if err := r.exec(); err != nil {
-		color.Red("Error: %v \n\n", err)
-		return
+		return cmd.Exit(cmd.ExitFailure, err)
 	}
 
-	color.Green("RSA keys generated successfully!\n\n")
+	if r.testKey {
+		color.Yellow("%s\n\n", cmd.T("rsa.insecure_generated"))
+		return nil
+	}
+	color.Green("%s\n\n", cmd.T("rsa.generated"))
+	return nil
 }
 
 // exec executes the RSA key generation logic.
@@ -91,7 +126,29 @@ func (r *RSA) exec() error {
 	if err := r.public(pubKey); err != nil {
 		return err
 	}
-	return nil
+
+	// Describe the keys, test keys always so that they are flagged as
+	// insecure whatever their encoding
+	if !r.meta && !r.testKey {
+		return nil
+	}
+	fp, err := fingerprint(pubKey)
+	if err != nil {
+		return err
+	}
+	return writeMeta(r.outDir, &keyMeta{
+		Purpose:     r.purpose,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		Algorithm:   algorithm(pubKey),
+		Fingerprint: fp,
+		Expires:     r.expires,
+		Creator:     creator(),
+		Insecure:    r.testKey,
+		Files: []string{
+			"private" + r.suffix() + "." + ext(r.encoding),
+			"public" + r.suffix() + "." + ext(r.encoding),
+		},
+	})
 }
 
 // public generates an RSA public key and writes it to a file.
@@ -116,13 +173,13 @@ func (r *RSA) public(pubKey *rsa.PublicKey) (err error) {
 
 	// Encode public key based on encoding
 	if r.encoding == "PEM" {
-		pubOut = pem.EncodeToMemory(&pem.Block{Type: pubBlockType, Bytes: pubBytes})
+		pubOut = r.header(pem.EncodeToMemory(&pem.Block{Type: pubBlockType, Bytes: pubBytes}))
 	} else {
 		pubOut = pubBytes
 	}
 
 	// Write keys to files
-	pubPath := filepath.Join(r.outDir, "public."+ext(r.encoding))
+	pubPath := filepath.Join(r.outDir, "public"+r.suffix()+"."+ext(r.encoding))
 
 	if err := os.WriteFile(pubPath, pubOut, 0644); err != nil {
 		return fmt.Errorf("write public: %w", err)
@@ -132,7 +189,7 @@ func (r *RSA) public(pubKey *rsa.PublicKey) (err error) {
 
 // private generates an RSA private key and writes it to a file.
 func (r *RSA) private() (*rsa.PublicKey, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, r.bits)
+	privateKey, err := r.generate()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate RSA private key: %w", err)
 	}
@@ -160,13 +217,13 @@ func (r *RSA) private() (*rsa.PublicKey, error) {
 	case "DER":
 		privOut = privBytes
 	case "PEM":
-		privOut = pem.EncodeToMemory(&pem.Block{Type: privBlockType, Bytes: privBytes})
+		privOut = r.header(pem.EncodeToMemory(&pem.Block{Type: privBlockType, Bytes: privBytes}))
 	default:
 		return nil, fmt.Errorf("unsupported encoding: %s", r.encoding)
 	}
 
 	// Write private key to file
-	privPath := filepath.Join(r.outDir, "private."+ext(r.encoding))
+	privPath := filepath.Join(r.outDir, "private"+r.suffix()+"."+ext(r.encoding))
 	if err := os.WriteFile(privPath, privOut, 0600); err != nil {
 		return nil, fmt.Errorf("write private: %w", err)
 	}
@@ -174,6 +231,34 @@ func (r *RSA) private() (*rsa.PublicKey, error) {
 	return &privateKey.PublicKey, nil
 }
 
+// generate returns a new RSA private key, derived from the seed for test keys.
+func (r *RSA) generate() (*rsa.PrivateKey, error) {
+	if !r.testKey {
+		return rsa.GenerateKey(rand.Reader, r.bits)
+	}
+	random, err := seededReader(r.seed)
+	if err != nil {
+		return nil, err
+	}
+	return deterministicRSA(random, r.bits)
+}
+
+// header prefixes PEM output of test keys with the insecure marker.
+func (r *RSA) header(out []byte) []byte {
+	if !r.testKey {
+		return out
+	}
+	return append([]byte(insecureHeader), out...)
+}
+
+// suffix returns the file name suffix marking test keys.
+func (r *RSA) suffix() string {
+	if r.testKey {
+		return ".insecure"
+	}
+	return ""
+}
+
 // ext returns the file extension based on the encoding type.
 func ext(encoding string) string {
 	if encoding == "PEM" {
@@ -187,19 +272,35 @@ func (r *RSA) validate() error {
 	switch r.encoding {
 	case "PEM", "DER":
 	default:
-		return fmt.Errorf("invalid encoding: %s, must be PEM or DER", r.encoding)
+		return errors.New(cmd.T("rsa.invalid_encoding", r.encoding))
 	}
 
 	switch r.bits {
 	case 1024, 2048, 3072, 4096:
 	default:
-		return fmt.Errorf("invalid bits: %d, must be one of 1024, 2048, 3072, 4096", r.bits)
+		return errors.New(cmd.T("rsa.invalid_bits", r.bits))
 	}
 
 	switch r.format {
 	case "PKCS1", "PKCS8":
 	default:
-		return fmt.Errorf("invalid format: %s, must be PKCS1 or PKCS8", r.format)
+		return errors.New(cmd.T("rsa.invalid_format", r.format))
+	}
+
+	if r.testKey && r.seed == "" {
+		return errors.New(cmd.T("rsa.test_key_seed"))
+	}
+	if !r.testKey && r.seed != "" {
+		return errors.New(cmd.T("rsa.seed_test_key"))
+	}
+
+	if !r.meta && (r.purpose != "" || r.expires != "") {
+		return errors.New(cmd.T("rsa.meta_only"))
+	}
+	if r.expires != "" {
+		if _, err := time.Parse(expiresLayout, r.expires); err != nil {
+			return errors.New(cmd.T("rsa.invalid_expires", r.expires))
+		}
 	}
 
 	return nil
@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// share is one Shamir share of a secret: the evaluations at X of the random
+// polynomials hiding each secret byte.
+type share struct {
+	X byte
+	Y []byte
+}
+
+// gfMul multiplies in GF(2^8) with the AES polynomial x^8+x^4+x^3+x+1,
+// without data dependent branches.
+func gfMul(a, b byte) byte {
+	var p byte
+	for range 8 {
+		p ^= a & -(b & 1)
+		hi := a >> 7
+		a = a<<1 ^ 0x1b&-hi
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns the multiplicative inverse of a non-zero a, a^254.
+func gfInv(a byte) byte {
+	r := byte(1)
+	for range 254 {
+		r = gfMul(r, a)
+	}
+	return r
+}
+
+// splitSecret splits secret into n shares, any threshold of which recover it.
+func splitSecret(secret []byte, n, threshold int) ([]share, error) {
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, fmt.Errorf("invalid shares %d and threshold %d, need 2 <= threshold <= shares <= 255", n, threshold)
+	}
+
+	shares := make([]share, n)
+	for i := range shares {
+		shares[i] = share{X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+	coeffs := make([]byte, threshold)
+	for j, s := range secret {
+		// Random polynomial of degree threshold-1 with the secret byte at 0
+		coeffs[0] = s
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			// Horner's rule
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = gfMul(y, shares[i].X) ^ coeffs[k]
+			}
+			shares[i].Y[j] = y
+		}
+	}
+	clear(coeffs)
+	return shares, nil
+}
+
+// combineShares recovers the secret of the shares by Lagrange interpolation at
+// zero. Too few shares produce a wrong secret, which the caller detects.
+func combineShares(shares []share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares")
+	}
+	seen := make(map[byte]bool, len(shares))
+	for _, s := range shares {
+		if s.X == 0 || seen[s.X] {
+			return nil, fmt.Errorf("duplicate or invalid share number %d", s.X)
+		}
+		if len(s.Y) != len(shares[0].Y) {
+			return nil, errors.New("shares of different secrets")
+		}
+		seen[s.X] = true
+	}
+
+	secret := make([]byte, len(shares[0].Y))
+	for i, si := range shares {
+		// Lagrange basis polynomial of share i at zero
+		basis := byte(1)
+		for j, sj := range shares {
+			if i != j {
+				basis = gfMul(basis, gfMul(sj.X, gfInv(sj.X^si.X)))
+			}
+		}
+		for k, y := range si.Y {
+			secret[k] ^= gfMul(y, basis)
+		}
+	}
+	return secret, nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"command/cmd"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// sharePEMType is the PEM block type of a key share file.
+const sharePEMType = "CZX KEY SHARE"
+
+type (
+	Split struct {
+		in        string
+		out       string
+		shares    int
+		threshold int
+	}
+	Combine struct {
+		out string
+	}
+	// Encrypt groups the key escrow commands, encrypt split and encrypt
+	// combine.
+	Encrypt struct {
+		split   *Split
+		combine *Combine
+	}
+)
+
+func NewSplit() *Split {
+	return &Split{}
+}
+
+func NewCombine() *Combine {
+	return &Combine{}
+}
+
+func NewEncrypt() *Encrypt {
+	return &Encrypt{split: NewSplit(), combine: NewCombine()}
+}
+
+// Command implements cmd.ICommand.
+func (e *Encrypt) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "encrypt",
+		GroupID: "encrypt",
+		Short:   "Split key files into Shamir shares and recover them",
+		Args:    cobra.NoArgs,
+	}
+	cmd.AddCommand(e.split.Command(), e.combine.Command())
+	return cmd
+}
+
+// Command implements cmd.ICommand.
+func (s *Split) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Split a key file into Shamir shares",
+		Long: `Split a key file into Shamir secret shares for escrow, so that any
+--threshold of the --shares share files recover it with encrypt combine
+and fewer reveal nothing about it.
+
+Every share file is PEM encoded with its number, the threshold and the
+SHA-256 checksums of the share and of the key file.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Split a root key among five officers, three of them recover it
+command encrypt split --in private.pem --shares 5 --threshold 3 -o ./shares`,
+		Args: cobra.NoArgs,
+		RunE: s.run,
+	}
+
+	// Setup flags
+	s.flags(cmd)
+	return cmd
+}
+
+// flags setup flags for the split command.
+func (s *Split) flags(c *cobra.Command) {
+	c.Flags().StringVar(&s.in, "in", "", cmd.T("encrypt.split.flag.in"))
+	c.Flags().StringVarP(&s.out, "out", "o", "./shares", cmd.T("encrypt.split.flag.out"))
+	c.Flags().IntVar(&s.shares, "shares", 5, cmd.T("encrypt.split.flag.shares"))
+	c.Flags().IntVar(&s.threshold, "threshold", 3, cmd.T("encrypt.split.flag.threshold"))
+
+	_ = c.MarkFlagRequired("in")
+}
+
+// run executes the split command logic.
+func (s *Split) run(_ *cobra.Command, _ []string) error {
+	if s.threshold < 2 || s.threshold > s.shares || s.shares > 255 {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("invalid shares %d and threshold %d, need 2 <= threshold <= shares <= 255", s.shares, s.threshold))
+	}
+	secret, err := os.ReadFile(s.in)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+	if len(secret) == 0 {
+		return cmd.Exit(cmd.ExitUsage, fmt.Errorf("%s is empty", s.in))
+	}
+
+	shares, err := splitSecret(secret, s.shares, s.threshold)
+	if err != nil {
+		return cmd.Exit(cmd.ExitFailure, err)
+	}
+	if err := os.MkdirAll(s.out, 0700); err != nil {
+		return cmd.Exit(cmd.ExitFailure, fmt.Errorf("mkdir: %w", err))
+	}
+
+	sum := sha256.Sum256(secret)
+	created := time.Now().UTC().Format(time.RFC3339)
+	for _, sh := range shares {
+		block := &pem.Block{
+			Type: sharePEMType,
+			Headers: map[string]string{
+				"Share":         fmt.Sprintf("%d/%d", sh.X, s.shares),
+				"Threshold":     strconv.Itoa(s.threshold),
+				"Source":        filepath.Base(s.in),
+				"Created":       created,
+				"Secret-SHA256": hex.EncodeToString(sum[:]),
+				"Share-SHA256":  shareChecksum(sh),
+			},
+			Bytes: append([]byte{sh.X}, sh.Y...),
+		}
+		path := filepath.Join(s.out, fmt.Sprintf("share-%d-of-%d.pem", sh.X, s.shares))
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+			return cmd.Exit(cmd.ExitFailure, fmt.Errorf("write share: %w", err))
+		}
+	}
+
+	color.Green("%d shares written to %s, any %d of them recover %s\n\n", s.shares, s.out, s.threshold, s.in)
+	return nil
+}
+
+// Command implements cmd.ICommand.
+func (c *Combine) Command() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "combine <share>...",
+		Short: "Recover a key file from its Shamir shares",
+		Long: `Recover a key file split with encrypt split from at least threshold
+of its share files. Every share is checked against its checksum and the
+recovered key against the checksum of the original file before it is written.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Recover a key from three shares
+command encrypt combine --out private.pem share-1-of-5.pem share-3-of-5.pem share-4-of-5.pem`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: c.run,
+	}
+	command.Flags().StringVar(&c.out, "out", "", cmd.T("encrypt.combine.flag.out"))
+	_ = command.MarkFlagRequired("out")
+	return command
+}
+
+// run executes the combine command logic.
+func (c *Combine) run(_ *cobra.Command, args []string) error {
+	secret, err := combineFiles(args)
+	if err != nil {
+		return cmd.Exit(cmd.ExitUsage, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.out), 0700); err != nil {
+		return cmd.Exit(cmd.ExitFailure, fmt.Errorf("mkdir: %w", err))
+	}
+	if err := os.WriteFile(c.out, secret, 0600); err != nil {
+		return cmd.Exit(cmd.ExitFailure, fmt.Errorf("write key: %w", err))
+	}
+
+	color.Green("Key recovered to %s\n\n", c.out)
+	return nil
+}
+
+// combineFiles reads and checks the share files and recovers their secret.
+func combineFiles(paths []string) ([]byte, error) {
+	var (
+		shares    []share
+		threshold int
+		checksum  string
+	)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != sharePEMType || len(block.Bytes) < 2 {
+			return nil, fmt.Errorf("%s: not a key share file", path)
+		}
+		sh := share{X: block.Bytes[0], Y: block.Bytes[1:]}
+		if block.Headers["Share-SHA256"] != shareChecksum(sh) {
+			return nil, fmt.Errorf("%s: share is corrupted, its checksum does not match", path)
+		}
+
+		t, err := strconv.Atoi(block.Headers["Threshold"])
+		if err != nil || t < 2 {
+			return nil, fmt.Errorf("%s: invalid threshold %q", path, block.Headers["Threshold"])
+		}
+		if checksum == "" {
+			threshold, checksum = t, block.Headers["Secret-SHA256"]
+		} else if t != threshold || block.Headers["Secret-SHA256"] != checksum {
+			return nil, fmt.Errorf("%s: share of another key", path)
+		}
+		for _, other := range shares {
+			if other.X == sh.X {
+				return nil, fmt.Errorf("%s: share %d is given twice", path, sh.X)
+			}
+		}
+		shares = append(shares, sh)
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("insufficient shares: got %d, need %d", len(shares), threshold)
+	}
+
+	secret, err := combineShares(shares[:threshold])
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(secret)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return nil, errors.New("recovered key does not match its checksum, the shares are wrong")
+	}
+	return secret, nil
+}
+
+// shareChecksum returns the hex SHA-256 of a share's number and value.
+func shareChecksum(sh share) string {
+	sum := sha256.Sum256(append([]byte{sh.X}, sh.Y...))
+	return hex.EncodeToString(sum[:])
+}
+
+var (
+	_ cmd.ICommand = (*Split)(nil)
+	_ cmd.ICommand = (*Combine)(nil)
+	_ cmd.ICommand = (*Encrypt)(nil)
+)
@@ -0,0 +1,123 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// insecureHeader is written above the PEM blocks of test keys.
+const insecureHeader = "# INSECURE TEST KEY: derived from a public seed, never use it outside tests\n"
+
+// testKeyInfo binds the derived DRBG key to this tool and purpose.
+const testKeyInfo = "czx-command test-key rsa v1"
+
+// zeroReader is an endless stream of zero bytes, encrypted into a keystream.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// seededReader returns a deterministic random stream for seed: an AES-256-CTR
+// keystream whose key is derived from the seed with HKDF-SHA256.
+func seededReader(seed string) (io.Reader, error) {
+	key, err := hkdf.Key(sha256.New, []byte(seed), nil, testKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	return cipher.StreamReader{S: cipher.NewCTR(block, iv), R: zeroReader{}}, nil
+}
+
+// deterministicRSA generates an RSA key from random only. rsa.GenerateKey
+// cannot be used as it ignores custom random sources.
+func deterministicRSA(random io.Reader, bits int) (*rsa.PrivateKey, error) {
+	e := big.NewInt(65537)
+	one := big.NewInt(1)
+	for {
+		p, err := seededPrime(random, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := seededPrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+		pMinus, qMinus := new(big.Int).Sub(p, one), new(big.Int).Sub(q, one)
+		phi := new(big.Int).Mul(pMinus, qMinus)
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		key.Precompute()
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid test key: %w", err)
+		}
+		return key, nil
+	}
+}
+
+// seededPrime reads candidates of the given size from random until one is prime.
+func seededPrime(random io.Reader, bits int) (*big.Int, error) {
+	if bits < 2 {
+		return nil, errors.New("prime size too small")
+	}
+	buf := make([]byte, (bits+7)/8)
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+		// Clear the excess bits, then set the two top bits so the product
+		// has the full length, and the low bit so the candidate is odd
+		excess := uint(len(buf)*8 - bits)
+		buf[0] &= byte(0xff >> excess)
+		p := new(big.Int).SetBytes(buf)
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, bits-2, 1)
+		p.SetBit(p, 0, 1)
+		if p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
+}
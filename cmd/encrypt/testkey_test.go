@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package encrypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateTestKey runs the rsa command with --test-key into a new directory.
+func generateTestKey(t *testing.T, args ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	c := NewRSA().Command()
+	c.SetArgs(append([]string{"--test-key", "--seed", "fixtures-v1", "-b", "1024", "-o", dir}, args...))
+	c.SilenceUsage, c.SilenceErrors = true, true
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestTestKeyMarkedInsecure(t *testing.T) {
+	for _, encoding := range []string{"PEM", "DER"} {
+		t.Run(encoding, func(t *testing.T) {
+			dir := generateTestKey(t, "-e", encoding)
+
+			// The metadata flags the keys without --meta
+			meta, err := readMeta(dir)
+			if err != nil || meta == nil {
+				t.Fatalf("no key.meta.json: %v", err)
+			}
+			if !meta.Insecure {
+				t.Error("key.meta.json does not flag the keys as insecure")
+			}
+			for _, name := range meta.Files {
+				if !strings.Contains(name, ".insecure.") {
+					t.Errorf("file %s has no .insecure suffix", name)
+				}
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := bytes.HasPrefix(data, []byte(insecureHeader)); got != (encoding == "PEM") {
+					t.Errorf("%s starts with the insecure header: %v", name, got)
+				}
+			}
+
+			// The JSON report of the audit flags them
+			report, err := audit(dir, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Keys) != 2 {
+				t.Fatalf("audit found %d keys, want 2", len(report.Keys))
+			}
+			for _, key := range report.Keys {
+				if !key.Insecure {
+					t.Errorf("audit does not flag %s as insecure", key.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestTestKeyDeterministic(t *testing.T) {
+	read := func(dir string) []byte {
+		data, err := os.ReadFile(filepath.Join(dir, "private.insecure.pem"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if !bytes.Equal(read(generateTestKey(t)), read(generateTestKey(t))) {
+		t.Error("the same seed derived different keys")
+	}
+}
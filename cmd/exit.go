@@ -0,0 +1,111 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of all commands.
+const (
+	// ExitOK reports success
+	ExitOK = 0
+	// ExitFailure reports a generic or internal failure
+	ExitFailure = 1
+	// ExitUsage reports invalid arguments, flags, rules or configuration
+	ExitUsage = 2
+	// ExitExternal reports an unreachable dependency such as a database
+	ExitExternal = 3
+	// ExitRefused reports an action refused by a safety check
+	ExitRefused = 4
+	// ExitStale reports a dry run finding generated files out of date
+	ExitStale = 5
+)
+
+// ExitCodesHelp documents the exit codes in the long help of the commands.
+const ExitCodesHelp = `Exit codes:
+  0  success
+  1  generic or internal failure
+  2  usage or validation error
+  3  external dependency unreachable, such as the database or network
+  4  refused by a safety check, such as overwrite protection or a policy
+  5  a dry run found generated files that would be created or changed`
+
+// ExitCoder is implemented by errors carrying their exit code.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ExitError attaches an exit code to an error.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode implements ExitCoder.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Exit returns err with the given exit code, nil when err is nil.
+func Exit(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode returns the exit code of an error returned by a command.
+// Errors raised before the command runs come from cobra's argument and flag
+// validation and are usage errors, other unclassified errors are failures.
+func ExitCode(err error, ran bool) int {
+	var coder ExitCoder
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	case !ran:
+		return ExitUsage
+	default:
+		return ExitFailure
+	}
+}
+
+// trackRun wraps the RunE of c and its subcommands to record that a command
+// started running, and silences the usage output from then on.
+func trackRun(c *cobra.Command, ran *bool) {
+	if run := c.RunE; run != nil {
+		c.RunE = func(c *cobra.Command, args []string) error {
+			*ran = true
+			c.SilenceUsage = true
+			return run(c, args)
+		}
+	}
+	for _, sub := range c.Commands() {
+		trackRun(sub, ran)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"command/cmd"
+	"command/cmd/orm"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		ran  bool
+		want int
+	}{
+		{"success", nil, true, cmd.ExitOK},
+		{"flag parsing", errors.New("unknown flag: --nope"), false, cmd.ExitUsage},
+		{"usage", cmd.Exit(cmd.ExitUsage, errors.New("bad rule")), true, cmd.ExitUsage},
+		{"invalid config", fmt.Errorf("load config: %w", &orm.ConfigError{Field: "out_path"}), true, cmd.ExitUsage},
+		{"connection", cmd.Exit(cmd.ExitExternal, fmt.Errorf("connect: %w", orm.ErrConnect)), true, cmd.ExitExternal},
+		{"refused", fmt.Errorf("apply: %w", &orm.PolicyError{}), true, cmd.ExitRefused},
+		{"corrupted asset", &cmd.CorruptedError{Asset: "locales/en.json"}, true, cmd.ExitFailure},
+		{"internal", errors.New("write model: disk full"), true, cmd.ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cmd.ExitCode(tt.err, tt.ran); got != tt.want {
+				t.Errorf("ExitCode(%v, %v) = %d, want %d", tt.err, tt.ran, got, tt.want)
+			}
+		})
+	}
+}
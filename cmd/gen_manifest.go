@@ -0,0 +1,98 @@
+//go:build ignore
+
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// gen_manifest writes the SHA-256 hashes of the files under the directories
+// of a package as a Go map, the manifest its embedded assets are checked
+// against. It is run by go generate:
+//
+//	go run gen_manifest.go -pkg cmd -var localeManifest -o locale_manifest.gen.go locales
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// licenseHeader is the license of the repository, on top of every file.
+const licenseHeader = `/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+`
+
+func main() {
+	pkg := flag.String("pkg", "", "Package of the generated file")
+	name := flag.String("var", "", "Variable holding the manifest")
+	out := flag.String("o", "", "Generated file")
+	flag.Parse()
+	if *pkg == "" || *name == "" || *out == "" || flag.NArg() == 0 {
+		log.Fatal("usage: gen_manifest -pkg name -var name -o file dir...")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(licenseHeader)
+	fmt.Fprintf(&buf, "\n// Code generated by gen_manifest.go; DO NOT EDIT.\n\npackage %s\n\n", *pkg)
+	fmt.Fprintf(&buf, "// %s are the SHA-256 hashes of the embedded assets by path.\n", *name)
+	fmt.Fprintf(&buf, "var %s = map[string]string{\n", *name)
+	for _, dir := range flag.Args() {
+		// WalkDir visits the files in lexical order
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(data)
+			fmt.Fprintf(&buf, "\t%q: %q,\n", filepath.ToSlash(path), hex.EncodeToString(sum[:]))
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
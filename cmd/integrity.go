@@ -0,0 +1,140 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"sync"
+)
+
+type (
+	// CorruptedError reports an embedded asset not matching the manifest of
+	// the build, the binary being truncated or altered.
+	CorruptedError struct {
+		Asset string
+	}
+	// assetSet is the embedded files of a package with the SHA-256 hashes of
+	// its build manifest by path.
+	assetSet struct {
+		fsys     fs.FS
+		manifest map[string]string
+	}
+)
+
+func (e *CorruptedError) Error() string {
+	return fmt.Sprintf("binary corrupted, embedded asset %s does not match its build manifest, reinstall the command", e.Asset)
+}
+
+// ExitCode implements ExitCoder.
+func (e *CorruptedError) ExitCode() int {
+	return ExitFailure
+}
+
+var (
+	// assetSets are the registered embedded files by name.
+	assetSets   = make(map[string]assetSet)
+	assetSetsMu sync.Mutex
+	// verified are the results of the asset checks by set and path.
+	verified sync.Map
+)
+
+// RegisterAssets registers the embedded files of a package with the manifest
+// generated for them, checked by VerifyAsset and VerifyAssets.
+func RegisterAssets(name string, fsys fs.FS, manifest map[string]string) {
+	assetSetsMu.Lock()
+	defer assetSetsMu.Unlock()
+	assetSets[name] = assetSet{fsys, manifest}
+}
+
+// VerifyAsset checks an embedded file of a registered set against its
+// manifest, once, before its first use.
+func VerifyAsset(set, name string) error {
+	key := set + ":" + name
+	if err, ok := verified.Load(key); ok {
+		return errOrNil(err)
+	}
+	assetSetsMu.Lock()
+	s, ok := assetSets[set]
+	assetSetsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown asset set %q", set)
+	}
+	err := s.verify(name)
+	verified.Store(key, err)
+	return err
+}
+
+// VerifyAssets checks every embedded file of every registered set, and that
+// the manifests list no missing or extra file.
+func VerifyAssets() (int, error) {
+	assetSetsMu.Lock()
+	sets := maps.Clone(assetSets)
+	assetSetsMu.Unlock()
+
+	n := 0
+	for _, name := range slices.Sorted(maps.Keys(sets)) {
+		s := sets[name]
+		var files []string
+		err := fs.WalkDir(s.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, p)
+			}
+			return err
+		})
+		if err != nil {
+			return n, err
+		}
+		for _, file := range slices.Sorted(maps.Keys(s.manifest)) {
+			if !slices.Contains(files, file) {
+				return n, &CorruptedError{Asset: file}
+			}
+		}
+		for _, file := range files {
+			if err := s.verify(file); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// verify checks the hash of an embedded file against the manifest.
+func (s assetSet) verify(name string) error {
+	want, ok := s.manifest[name]
+	if !ok {
+		return &CorruptedError{Asset: name}
+	}
+	data, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return &CorruptedError{Asset: name}
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != want {
+		return &CorruptedError{Asset: name}
+	}
+	return nil
+}
+
+// errOrNil returns the error stored in a sync.Map, nil for a nil error.
+func errOrNil(v any) error {
+	err, _ := v.(error)
+	return err
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultLocale is the locale of the complete catalog, the fallback of
+// every other one.
+const defaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+//go:generate go run gen_manifest.go -pkg cmd -var localeManifest -o locale_manifest.gen.go locales
+
+var (
+	// locale selects the message catalog, set by --locale or the environment.
+	locale = envLocale()
+	// catalogs are the loaded message catalogs by locale.
+	catalogs   = make(map[string]map[string]string)
+	catalogsMu sync.Mutex
+)
+
+// T returns the message of key in the current locale, formatted with args.
+// Keys missing from the locale fall back to English.
+func T(key string, args ...any) string {
+	msg, ok := lookup(locale, key)
+	if !ok {
+		msg, ok = lookup(defaultLocale, key)
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Locales returns the locales with a message catalog.
+func Locales() []string {
+	entries, _ := localeFS.ReadDir("locales")
+	var locales []string
+	for _, e := range entries {
+		locales = append(locales, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return locales
+}
+
+// Messages returns the message keys of a locale catalog.
+func Messages(loc string) map[string]string {
+	return catalog(loc)
+}
+
+// lookup finds key in the catalog of loc, or of its language like zh for zh-TW.
+func lookup(loc, key string) (string, bool) {
+	if msg, ok := catalog(loc)[key]; ok {
+		return msg, true
+	}
+	if lang, _, ok := strings.Cut(loc, "-"); ok {
+		msg, ok := catalog(lang)[key]
+		return msg, ok
+	}
+	return "", false
+}
+
+// catalog loads the embedded catalog of loc, empty when there is none.
+func catalog(loc string) map[string]string {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	if c, ok := catalogs[loc]; ok {
+		return c
+	}
+	c := make(map[string]string)
+	if data, err := localeFS.ReadFile("locales/" + loc + ".json"); err == nil {
+		// A corrupted catalog would garble every message, the messages of
+		// the failure included
+		if err := VerifyAsset("locales", "locales/"+loc+".json"); err != nil {
+			fmt.Fprintf(os.Stderr, "\n%s\n\n", err)
+			os.Exit(ExitFailure)
+		}
+		_ = json.Unmarshal(data, &c)
+	}
+	catalogs[loc] = c
+	return c
+}
+
+// normalizeLocale turns POSIX locale names like zh_CN.UTF-8 into zh-CN.
+func normalizeLocale(s string) string {
+	s, _, _ = strings.Cut(s, ".")
+	s, _, _ = strings.Cut(s, "@")
+	lang, region, ok := strings.Cut(strings.ReplaceAll(s, "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+// envLocale returns the locale of the LC_ALL, LC_MESSAGES or LANG variable.
+func envLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" && v != "C" && v != "POSIX" {
+			return normalizeLocale(v)
+		}
+	}
+	return defaultLocale
+}
+
+func init() {
+	RegisterAssets("locales", localeFS, localeManifest)
+}
+
+// argLocale returns the value of a --locale argument, so the flag descriptions
+// are localized before the flags are parsed.
+func argLocale(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--locale="); ok {
+			return normalizeLocale(v), true
+		}
+		if arg == "--locale" && i+1 < len(args) {
+			return normalizeLocale(args[i+1]), true
+		}
+	}
+	return "", false
+}
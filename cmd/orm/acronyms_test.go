@@ -0,0 +1,82 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAcronyms(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		args   []string
+		opts   []IOrmOption
+		fields map[string]string
+	}{
+		{
+			name:   "defaults",
+			golden: "acronyms_default.golden",
+			fields: map[string]string{"user_id": "UserID", "api_url": "APIURL", "ip_addr": "IPAddr", "client_uuid": "ClientUUID", "sku_code": "SkuCode"},
+		},
+		{
+			name:   "flag",
+			golden: "acronyms_flag.golden",
+			args:   []string{"--acronyms", "sku"},
+			fields: map[string]string{"sku_code": "SKUCode", "callback_url": "CallbackURL"},
+		},
+		{
+			name:   "rename wins",
+			golden: "acronyms_rename.golden",
+			opts: []IOrmOption{
+				WithAcronyms([]string{"SKU"}),
+				WithFieldRename(map[string]string{"api_clients->api_url": "ApiUrl", "*->sku_code": "Sku"}),
+			},
+			fields: map[string]string{"api_url": "ApiUrl", "sku_code": "Sku", "user_id": "UserID"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-t", "api_clients"}, tt.args...)
+			files := generate(t, openFixture(t, "acronyms"), args, tt.opts...)
+			src := files["model/api_clients.gen.go"]
+			golden(t, tt.golden, src)
+
+			if !strings.Contains(src, "type APIClient struct") {
+				t.Errorf("model not named APIClient:\n%s", src)
+			}
+			for column, name := range tt.fields {
+				// The tags keep referencing the column
+				line := fieldLine(src, name)
+				tagged := strings.Contains(line, "column:"+column+";") || strings.Contains(line, "column:"+column+`"`)
+				if !tagged || !strings.Contains(line, `json:"`+column) {
+					t.Errorf("%s: field %s = %q", column, name, line)
+				}
+			}
+		})
+	}
+}
+
+func TestAcronymsRenameDao(t *testing.T) {
+	files := generateBuilt(t, openFixture(t, "acronyms"), []string{"-t", "api_clients", "--style", "dao"},
+		WithDaoTables([]string{"api_clients"}),
+		WithFieldRename(map[string]string{"api_clients->api_url": "ApiUrl"}),
+	)
+	if src := files["dao/api_clients.gen.go"]; !strings.Contains(src, "ApiUrl ") || strings.Contains(src, "APIURL") {
+		t.Errorf("dao does not use the renamed field:\n%s", src)
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to path through a temporary file in the same
+// directory, synced and renamed into place, so a crash never leaves a
+// truncated file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// staging redirects the output of gen to a staging directory next to the
+// query directory, so an interrupted run leaves the target untouched.
+// Every step writing generated files, such as the post-processing passes,
+// runs on the staged tree before commit moves it into place.
+type staging struct {
+	dir string
+	// target and staged query and model directories
+	out, model             string
+	stagedOut, stagedModel string
+	// gen output settings restored after the run
+	outPath, outFile, modelPkgPath string
+}
+
+// stage points the generator and the Orm options at a new staging directory.
+func (o *Orm) stage() (*staging, error) {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return nil, err
+	}
+	// Staged inside the module, so gen resolves the model import path
+	dir, err := os.MkdirTemp(filepath.Dir(out), ".ormstage-")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &staging{
+		dir:          dir,
+		out:          out,
+		model:        model,
+		stagedOut:    filepath.Join(dir, "query", filepath.Base(out)),
+		stagedModel:  filepath.Join(dir, "model", filepath.Base(model)),
+		outPath:      o.opt.gconf.OutPath,
+		outFile:      o.generator.OutFile,
+		modelPkgPath: o.opt.gconf.ModelPkgPath,
+	}
+	o.generator.OutPath = s.stagedOut
+	o.generator.OutFile = filepath.Join(s.stagedOut, filepath.Base(s.outFile))
+	o.generator.ModelPkgPath = s.stagedModel
+	o.opt.gconf.OutPath, o.opt.gconf.ModelPkgPath = s.stagedOut, s.stagedModel
+	return s, nil
+}
+
+// restore points the generator and the Orm options back at the target.
+func (s *staging) restore(o *Orm) {
+	o.generator.OutPath, o.generator.OutFile, o.generator.ModelPkgPath = s.out, s.outFile, s.modelPkgPath
+	o.opt.gconf.OutPath, o.opt.gconf.ModelPkgPath = s.outPath, s.modelPkgPath
+}
+
+// abort removes the staging directory, leaving the target untouched.
+func (s *staging) abort(o *Orm) {
+	s.restore(o)
+	os.RemoveAll(s.dir)
+}
+
+// commit fixes the staged model and query import paths in the query files
+// and moves the staged files into place, one rename per file. With move set
+// only the files it accepts by target path are moved.
+func (s *staging) commit(o *Orm, move func(target string) bool) error {
+	defer s.abort(o)
+
+	if err := rewriteImports(s.stagedOut, o.importReplacements(s.dirs())); err != nil {
+		return err
+	}
+
+	for from, to := range s.dirs() {
+		if err := moveFiles(from, to, move, o.place); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// place moves a staged file to its target path, or writes it to the WriteFS
+// of the options when set. A target with the same content is left untouched,
+// keeping its mtime, unless --force-write is set.
+func (o *Orm) place(staged, target string) error {
+	if o.opt.fs != nil {
+		data, err := os.ReadFile(staged)
+		if err != nil {
+			return err
+		}
+		if !o.forceWrite && o.fsHolds(target, data) {
+			o.unchanged++
+			o.markWritten(target)
+			return nil
+		}
+		o.rewritten++
+		return o.writeFile(target, data, 0644)
+	}
+	if !o.forceWrite && sameContent(staged, target) {
+		o.unchanged++
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	o.rewritten++
+	return os.Rename(staged, target)
+}
+
+// sameContent reports whether the files at a and b exist with the same
+// content, provenance comments aside.
+func sameContent(a, b string) bool {
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false
+	}
+	dataB, err := os.ReadFile(b)
+	return err == nil && sameGenerated(dataA, dataB)
+}
+
+// dirs returns the target directory by staged directory.
+func (s *staging) dirs() map[string]string {
+	return map[string]string{s.stagedModel: s.model, s.stagedOut: s.out}
+}
+
+// moveFiles places the files of the from tree at the same paths below to,
+// the ones accepted by move when it is set.
+func moveFiles(from, to string, move func(target string) bool, place func(staged, target string) error) error {
+	err := filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(to, rel)
+		if move != nil && !move(dst) {
+			return nil
+		}
+		return place(path, dst)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// generate runs gen and the post-processing passes in a staging directory
+// whose changed files are moved into place, so unchanged files keep their
+// mtime. With atomic set they are moved only when the whole run succeeded and
+// was not interrupted. With --force-write and without atomic or a WriteFS gen
+// writes the target directly.
+func (o *Orm) generate(ctx context.Context, atomic bool) error {
+	if o.forceWrite && !atomic && o.opt.fs == nil {
+		o.generator.Execute()
+		if err := o.postProcess(); err != nil {
+			return err
+		}
+		if err := o.rlsFacades(); err != nil {
+			return err
+		}
+		if err := o.writeEnums(); err != nil {
+			return err
+		}
+		if err := o.writeFactories(); err != nil {
+			return err
+		}
+		if o.withMocks {
+			if err := o.mocks(); err != nil {
+				return err
+			}
+		}
+		out, err := filepath.Abs(o.opt.gconf.OutPath)
+		if err != nil {
+			return err
+		}
+		model, err := o.modelOutPath()
+		if err != nil {
+			return err
+		}
+		return rewriteImports(out, o.importReplacements(map[string]string{out: out, model: model}))
+	}
+
+	s, err := o.generateStaged()
+	if err != nil {
+		return err
+	}
+	if atomic && ctx != nil && ctx.Err() != nil {
+		s.abort(o)
+		return fmt.Errorf("generation interrupted, target left untouched: %w", ctx.Err())
+	}
+	return s.commit(o, nil)
+}
+
+// generateStaged runs gen and the post-processing passes in a new staging
+// directory, which the caller commits or aborts.
+func (o *Orm) generateStaged() (s *staging, err error) {
+	s, err = o.stage()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// gen panics on errors
+		if r := recover(); r != nil {
+			s.abort(o)
+			s, err = nil, fmt.Errorf("generation failed, target left untouched: %v", r)
+		}
+	}()
+
+	o.generator.Execute()
+	if err := o.postProcess(); err != nil {
+		s.abort(o)
+		return nil, err
+	}
+	if err := o.rlsFacades(); err != nil {
+		s.abort(o)
+		return nil, err
+	}
+	if err := o.writeEnums(); err != nil {
+		s.abort(o)
+		return nil, err
+	}
+	if err := o.writeFactories(); err != nil {
+		s.abort(o)
+		return nil, err
+	}
+	if o.withMocks {
+		if err := o.mocks(); err != nil {
+			s.abort(o)
+			return nil, err
+		}
+	}
+	return s, nil
+}
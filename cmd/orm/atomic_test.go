@@ -0,0 +1,105 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+func TestAtomicFailedRun(t *testing.T) {
+	db := openFixture(t, "users")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tests := []struct {
+		name string
+		// typ maps the varchar columns, failing the run in its own way
+		typ DataTypeFn
+		err string
+	}{
+		{
+			// Ctrl-C while the models are generated
+			name: "interrupted",
+			typ:  func(gorm.ColumnType) string { cancel(); return "[]byte" },
+			err:  "generation interrupted",
+		},
+		{
+			// gen panics on a model that does not format
+			name: "gen error",
+			typ:  func(gorm.ColumnType) string { return "map[" },
+			err:  "generation failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := generate(t, db, []string{"-t", "users"})
+
+			c := NewOrmCommand(
+				WithDB(db),
+				WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}),
+				WithDataType(map[string]DataTypeFn{"*->varchar": tt.typ}),
+			).Command()
+			c.SetArgs([]string{"-t", "users", "--atomic", "--config", ""})
+			c.SilenceUsage, c.SilenceErrors = true, true
+			if err := c.ExecuteContext(ctx); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("err = %v, want %q", err, tt.err)
+			}
+
+			if after := readTree(t, "."); !maps.Equal(after, before) {
+				t.Errorf("target changed by the failed run: %v, was %v", keys(after), keys(before))
+			}
+			filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+				if err == nil && (strings.HasPrefix(d.Name(), ".ormstage-") || strings.Contains(d.Name(), ".tmp-")) {
+					t.Errorf("left behind %s", path)
+				}
+				return err
+			})
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.gen.go")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed write leaves the old content and no temporary file
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "users.gen.go"), []byte("new"), 0644); err == nil {
+		t.Error("write to a missing directory succeeded")
+	}
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	info, _ := os.Stat(path)
+	if len(entries) != 1 || string(data) != "new" || info.Mode().Perm() != 0600 {
+		t.Errorf("wrote %q with mode %v, entries %v", data, info.Mode(), entries)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// auditColumns are the columns added to the shadow audit tables. Tables with
+// a column of the same name are refused.
+var auditColumns = []string{"audit_id", "action", "changed_at", "changed_by"}
+
+// auditSQLCommand returns the orm audit-sql subcommand.
+func (o *Orm) auditSQLCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "audit-sql",
+		Short: "Generate shadow audit tables and triggers for the selected tables",
+		Long: `Generate the MySQL statements mirroring every UPDATE and DELETE of the listed
+tables, or of all tables, into shadow audit tables: a CREATE TABLE IF NOT
+EXISTS statement per table, with the columns of the table plus action,
+changed_at and changed_by, and AFTER UPDATE and AFTER DELETE triggers storing
+the previous row, each guarded by DROP TRIGGER IF EXISTS.
+
+Columns excluded by the ignore rules are left out of the shadow tables. The
+statements are only written, never executed.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Print the audit SQL of the orders and payments tables
+command orm audit-sql -t orders -t payments
+
+# Write the audit SQL of every table to audit.sql
+command orm audit-sql --out audit.sql`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).auditSQL),
+	}
+	c.Flags().StringArrayP("tables", "t", nil, cmd.T("orm.audit-sql.flag.tables"))
+	c.Flags().String("suffix", "_audit", cmd.T("orm.audit-sql.flag.suffix"))
+	c.Flags().String("out", "", cmd.T("orm.audit-sql.flag.out"))
+	return c
+}
+
+// auditSQL is the execution logic for the orm audit-sql command.
+func (o *Orm) auditSQL(c *cobra.Command, _ []string) error {
+	tables, err := c.Flags().GetStringArray("tables")
+	if err != nil {
+		return err
+	}
+	suffix, err := c.Flags().GetString("suffix")
+	if err != nil {
+		return err
+	}
+	if suffix == "" {
+		return usage("generating audit SQL", errors.New("the shadow table suffix must not be empty"))
+	}
+	out, err := c.Flags().GetString("out")
+	if err != nil {
+		return err
+	}
+
+	closeConn, err := o.prepare(c)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	sql, err := o.execAuditSQL(tables, suffix)
+	if err != nil {
+		return fail("generating audit SQL", err)
+	}
+	if out == "" {
+		fmt.Print(sql)
+		return nil
+	}
+	if err := writeFileAtomic(out, []byte(sql), 0644); err != nil {
+		return fail("generating audit SQL", err)
+	}
+	color.Green("Wrote %s\n", out)
+	return nil
+}
+
+// execAuditSQL introspects the selected tables and renders their audit SQL.
+func (o *Orm) execAuditSQL(tables []string, suffix string) (string, error) {
+	if name := o.meta.Dialector.Name(); name != "mysql" {
+		return "", fmt.Errorf("audit triggers are only generated for mysql, not %s", name)
+	}
+	all, err := o.meta.Migrator().GetTables()
+	if err != nil {
+		return "", err
+	}
+	if tables, err = expandTableFiles(tables); err != nil {
+		return "", err
+	}
+	qualified, err := o.qualifiedTables(tables)
+	if err != nil {
+		return "", err
+	}
+	all = append(all, qualified...)
+	for _, table := range qualified {
+		o.rules.aliasTableRules(table)
+	}
+	if tables, err = o.resolveTables(tables, all); err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, val := range tables {
+		name, _, _ := strings.Cut(val, "@")
+		if !slices.Contains(all, name) {
+			return "", &UnknownTableError{Table: name, Suggestions: suggest(name, all)}
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if err := o.introspect(names, 50); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("-- Audit tables and triggers generated by command orm audit-sql.\n")
+	b.WriteString("-- Review before running, the tool never executes these statements.\n")
+	for _, table := range names {
+		if err := o.auditTableSQL(&b, table, suffix); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// auditTableSQL renders the shadow table and the triggers of one table.
+func (o *Orm) auditTableSQL(b *strings.Builder, table, suffix string) error {
+	var columns []columnMeta
+	for _, col := range o.columns[table] {
+		if slices.Contains(auditColumns, strings.ToLower(col.Name)) {
+			return fmt.Errorf("column %s of table %s clashes with the audit columns %s", col.Name, table, strings.Join(auditColumns, ", "))
+		}
+		if o.rules.ignoreRules[table][col.Name] == "" && o.rules.ignoreRules["*"][col.Name] == "" {
+			columns = append(columns, col)
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("the ignore rules leave no column of table %s to audit", table)
+	}
+
+	shadow := table + suffix
+	fmt.Fprintf(b, "\n-- %s\n", table)
+	fmt.Fprintf(b, "CREATE TABLE IF NOT EXISTS %s (\n", quoteTable(shadow))
+	fmt.Fprintf(b, "  %s BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,\n", quoteIdent("audit_id"))
+	for _, col := range columns {
+		typ := col.Type
+		if typ == "" {
+			typ = col.DataType
+		}
+		fmt.Fprintf(b, "  %s %s NULL,\n", quoteIdent(col.Name), typ)
+	}
+	fmt.Fprintf(b, "  %s ENUM('UPDATE','DELETE') NOT NULL,\n", quoteIdent("action"))
+	fmt.Fprintf(b, "  %s DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n", quoteIdent("changed_at"))
+	fmt.Fprintf(b, "  %s VARCHAR(288) NOT NULL,\n", quoteIdent("changed_by"))
+	fmt.Fprintf(b, "  PRIMARY KEY (%s)\n);\n", quoteIdent("audit_id"))
+
+	names := make([]string, len(columns))
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdent(col.Name)
+		values[i] = "OLD." + quoteIdent(col.Name)
+	}
+	for _, action := range []string{"UPDATE", "DELETE"} {
+		trigger := shadow + "_" + strings.ToLower(action)
+		fmt.Fprintf(b, "DROP TRIGGER IF EXISTS %s;\n", quoteTable(trigger))
+		fmt.Fprintf(b, "CREATE TRIGGER %s AFTER %s ON %s FOR EACH ROW\n", quoteTable(trigger), action, quoteTable(table))
+		fmt.Fprintf(b, "  INSERT INTO %s (%s, %s, %s)\n", quoteTable(shadow), strings.Join(names, ", "), quoteIdent("action"), quoteIdent("changed_by"))
+		fmt.Fprintf(b, "  VALUES (%s, '%s', CURRENT_USER());\n", strings.Join(values, ", "), action)
+	}
+	return nil
+}
+
+// quoteIdent quotes a MySQL identifier, doubling embedded backticks.
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// quoteTable quotes a table name, qualified with its schema when it has one.
+func quoteTable(name string) string {
+	if schema, table := splitTable(name); schema != "" {
+		return quoteIdent(schema) + "." + quoteIdent(table)
+	}
+	return quoteIdent(name)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+)
+
+// autoTimeTags are the gorm tags of the WithAutoTime modes.
+var autoTimeTags = map[string]string{
+	"create": "autoCreateTime",
+	"update": "autoUpdateTime",
+}
+
+// autoTimeSuffixes select the unix time precision from a column name suffix.
+var autoTimeSuffixes = map[string]string{
+	"_ms":    "milli",
+	"_milli": "milli",
+	"_ns":    "nano",
+	"_nano":  "nano",
+}
+
+// parseAutoTime parses a WithAutoTime mode, "create" or "update" with an
+// optional ":milli" or ":nano" precision.
+func parseAutoTime(mode string) (tag, precision string, ok bool) {
+	mode, precision, _ = strings.Cut(mode, ":")
+	tag, ok = autoTimeTags[mode]
+	if precision != "" && precision != "milli" && precision != "nano" {
+		return "", "", false
+	}
+	return tag, precision, ok
+}
+
+// autoTimePrecision returns the unix time precision of a column without an
+// explicit one, requested by a name suffix or by its comment.
+func autoTimePrecision(column, comment string) string {
+	for suffix, precision := range autoTimeSuffixes {
+		if strings.HasSuffix(column, suffix) {
+			return precision
+		}
+	}
+	comment = strings.ToLower(comment)
+	switch {
+	case strings.Contains(comment, "milli"):
+		return "milli"
+	case strings.Contains(comment, "nano"):
+		return "nano"
+	}
+	return ""
+}
+
+// autoTimeOpts returns the options tagging the auto time columns of a table.
+// A reGromTags rule on the same column wins, with a warning.
+func (o *Orm) autoTimeOpts(table string, columns []columnMeta) []gen.ModelOpt {
+	comments := make(map[string]string, len(columns))
+	for _, col := range columns {
+		comments[col.Name] = col.Comment
+	}
+
+	var opts []gen.ModelOpt
+	for _, key := range []string{"*", table} {
+		for _, r := range o.rules.autoTimes[key] {
+			column, mode := r[0], r[1]
+			comment, ok := comments[column]
+			if !ok && len(columns) > 0 {
+				continue
+			}
+			if o.rules.gormTagged[table][column] || o.rules.gormTagged["*"][column] {
+				o.warn(warnRules, "Warning: auto time %s of %s.%s ignored, the reGromTags rule of the column wins\n", mode, table, column)
+				continue
+			}
+
+			tag, precision, _ := parseAutoTime(mode)
+			if precision == "" {
+				precision = autoTimePrecision(column, comment)
+			}
+			opts = append(opts, gen.FieldGORMTag(column, func(t field.GormTag) field.GormTag {
+				if precision == "" {
+					return t.Set(tag)
+				}
+				return t.Set(tag, precision)
+			}))
+		}
+	}
+	return opts
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// autoTimeSchema is the events table of the auto time round trip.
+const autoTimeSchema = "CREATE TABLE events (id integer PRIMARY KEY, name text, created_ts bigint, updated_ts bigint, updated_ms bigint)"
+
+// autoTimeProgram creates an event through the generated model, then turns
+// its clock back, updates it and prints the unix times after each step.
+const autoTimeProgram = `package main
+
+import (
+	"fmt"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"MODEL"
+)
+
+func main() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.Exec("SCHEMA").Error; err != nil {
+		panic(err)
+	}
+	ev := model.Event{Name: "created"}
+	if err := db.Create(&ev).Error; err != nil {
+		panic(err)
+	}
+	fmt.Println(ev.CreatedTs, ev.UpdatedTs, ev.UpdatedMs)
+
+	if err := db.Exec("UPDATE events SET created_ts = 1, updated_ts = 1, updated_ms = 1").Error; err != nil {
+		panic(err)
+	}
+	if err := db.First(&ev, ev.ID).Error; err != nil {
+		panic(err)
+	}
+	if err := db.Model(&ev).Update("name", "updated").Error; err != nil {
+		panic(err)
+	}
+	var out model.Event
+	if err := db.First(&out, ev.ID).Error; err != nil {
+		panic(err)
+	}
+	fmt.Println(out.CreatedTs, out.UpdatedTs, out.UpdatedMs)
+}
+`
+
+func TestAutoTimeRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(autoTimeSchema).Error; err != nil {
+		t.Fatal(err)
+	}
+	files := generateBuilt(t, db, []string{"-t", "events"}, WithAutoTime(map[string]string{
+		"*->created_ts":      "create",
+		"*->updated_ts":      "update",
+		"events->updated_ms": "update",
+	}))
+	model := files["model/events.gen.go"]
+	for field, tag := range map[string]string{"CreatedTs": "autoCreateTime", "UpdatedTs": "autoUpdateTime", "UpdatedMs": "autoUpdateTime:milli"} {
+		if line := fieldLine(model, field); !strings.Contains(line, tag+";") && !strings.Contains(line, tag+`"`) {
+			t.Fatalf("%s = %q, want %s", field, line, tag)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := strings.Replace(autoTimeProgram, "MODEL", "command/cmd/orm/testdata/"+filepath.Base(wd)+"/model", 1)
+	program = strings.Replace(program, "SCHEMA", autoTimeSchema, 1)
+	if err := os.MkdirAll("roundtrip", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("roundtrip", "main.go"), []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	out, err := exec.Command("go", "run", "./roundtrip").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+
+	var created, updated [3]int64
+	if _, err := fmt.Sscan(string(out), &created[0], &created[1], &created[2], &updated[0], &updated[1], &updated[2]); err != nil {
+		t.Fatalf("output %q: %v", out, err)
+	}
+	secs, millis := start.Unix(), start.UnixMilli()
+	if created[0] < secs || created[1] < secs || created[2] < millis {
+		t.Errorf("times not set on create: %v, want after %d", created, secs)
+	}
+	// The update bumps the update times only
+	if updated[0] != 1 {
+		t.Errorf("created_ts changed on update to %d", updated[0])
+	}
+	if updated[1] < secs || updated[2] < millis {
+		t.Errorf("times not bumped on update: %v, want after %d", updated, secs)
+	}
+}
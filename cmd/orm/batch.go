@@ -0,0 +1,511 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/pflag"
+)
+
+// batchLimits bound the models held in memory by a run, flushed to disk
+// once either limit is reached.
+type batchLimits struct {
+	// size is the number of tables per batch, 0 for no limit
+	size int
+	// maxMemory is the heap size flushing the batch early, 0 for no limit
+	maxMemory uint64
+}
+
+// batchLimits reads the limits of --batch-size and --max-memory.
+func (o *Orm) batchLimits(args *pflag.FlagSet) (batchLimits, error) {
+	size, err := args.GetInt("batch-size")
+	if err != nil {
+		return batchLimits{}, err
+	}
+	if size < 0 {
+		return batchLimits{}, fmt.Errorf("invalid --batch-size %d, must be 0 or more tables", size)
+	}
+	maxMemory, err := args.GetString("max-memory")
+	if err != nil {
+		return batchLimits{}, err
+	}
+	b := batchLimits{size: size}
+	if maxMemory != "" {
+		if b.maxMemory, err = parseByteSize(maxMemory); err != nil {
+			return batchLimits{}, fmt.Errorf("invalid --max-memory %q: %w", maxMemory, err)
+		}
+	}
+	return b, nil
+}
+
+// enabled reports whether the run is batched.
+func (b batchLimits) enabled() bool {
+	return b.size > 0 || b.maxMemory > 0
+}
+
+// full reports whether a batch of pending tables is to be flushed.
+func (b batchLimits) full(pending int) bool {
+	if b.size > 0 && pending >= b.size {
+		return true
+	}
+	if b.maxMemory == 0 {
+		return false
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc >= b.maxMemory
+}
+
+// byteUnits are the multipliers of the units of parseByteSize.
+var byteUnits = map[string]uint64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1000, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1000 * 1000, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1000 * 1000 * 1000, "GIB": 1 << 30,
+}
+
+// parseByteSize parses a size such as 512MiB, 2GB or 1G, a bare number
+// being bytes.
+func parseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("size must be a positive number followed by B, KiB, MiB, GiB, KB, MB or GB")
+	}
+	unit, ok := byteUnits[strings.ToUpper(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q, use B, KiB, MiB, GiB, KB, MB or GB", s[i:])
+	}
+	return uint64(n * float64(unit)), nil
+}
+
+// checkBatching rejects the options needing every model of the run at once.
+func (o *Orm) checkBatching(args *pflag.FlagSet) error {
+	atomic, err := args.GetBool("atomic")
+	if err != nil {
+		return err
+	}
+	switch {
+	case o.planning != nil:
+		return &BatchingError{Feature: "--dry-run, --diff, plan and apply", Reason: "they compare the output of the whole run"}
+	case atomic:
+		return &BatchingError{Feature: "--atomic", Reason: "the batches are moved into place one after the other"}
+	case o.opt.gconf.WithUnitTest:
+		return &BatchingError{Feature: "gen.Config.WithUnitTest", Reason: "the unit test of the query package covers every table"}
+	}
+	return nil
+}
+
+// execBatches generates the selected tables batch after batch: the models
+// and daos of a batch are generated, post-processed and moved into place
+// before the next one, and gen releases them, bounding the peak memory of
+// huge schemas. The column metadata, a few strings per column, is read
+// upfront so the rules are checked before anything is written.
+//
+// Tables linked by a declared or, with --with-relations, a foreign key
+// relation are generated in the same batch, which may exceed --batch-size.
+// The query file of gen listing every dao, gen.go by default, is merged
+// across the batches of a query directory and written with the last one.
+//
+// With --out-template the tables are batched by the model and dao
+// directories their templates render, so each batch generates the packages
+// of a single pair of directories.
+func (o *Orm) execBatches(ctx context.Context, args *pflag.FlagSet, style string, tables []string, limits batchLimits) error {
+	if err := o.checkBatching(args); err != nil {
+		return err
+	}
+	all, tables, err := o.selectTables(tables)
+	if err != nil {
+		return err
+	}
+	groups, err := o.batchGroups(tables)
+	if err != nil {
+		return err
+	}
+	dao := style != "model"
+	if dao && !slices.ContainsFunc(tables, func(t string) bool {
+		name, _, _ := strings.Cut(t, "@")
+		return selected(o.opt.daoTables, name)
+	}) {
+		return &EmptyGenerationError{Style: "dao", Reason: "no matching structs found for DAO generation"}
+	}
+
+	// The directories of the batches, the ones of the config without templates
+	outPath, modelPkgPath := o.opt.gconf.OutPath, o.opt.gconf.ModelPkgPath
+	defer func() {
+		o.opt.gconf.OutPath, o.opt.gconf.ModelPkgPath = outPath, modelPkgPath
+	}()
+	dirs := func([]string) outputPaths { return outputPaths{DaoDir: outPath, ModelDir: modelPkgPath} }
+	var paths map[string]outputPaths
+	if o.layout != nil {
+		if paths, err = o.layout.render(o, tables); err != nil {
+			return err
+		}
+		if groups, err = layoutGroups(groups, paths); err != nil {
+			return err
+		}
+		dirs = func(group []string) outputPaths {
+			name, _, _ := strings.Cut(group[0], "@")
+			return outputPaths{DaoDir: paths[name].DaoDir, ModelDir: paths[name].ModelDir}
+		}
+	}
+
+	release, err := o.lockOutput(ctx, args)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var flushed []any
+	var queries []byte
+	// renew starts a batch with a new generator writing to the directories
+	renew := func(d outputPaths) {
+		o.opt.gconf.OutPath, o.opt.gconf.ModelPkgPath = d.DaoDir, d.ModelDir
+		o.generator = o.newGenerator()
+		applyRules(o.generator, o.rules, (o.rules.qualified || o.rules.prefix != "") && o.schemaPrefix)
+		if paths != nil {
+			o.generator.WithFileNameStrategy(func(table string) string { return paths[table].File })
+		}
+		o.importPaths()
+	}
+	flush := func(last bool) error {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("generation interrupted, the previous batches are written: %w", err)
+		}
+		batchDao := dao && slices.ContainsFunc(o.structs, func(meta any) bool {
+			return selected(o.opt.daoTables, metaString(meta, "TableName"))
+		})
+		if batchDao {
+			if err := o.dao(); err != nil {
+				return err
+			}
+		}
+		if err := o.checkRun(args, batchDao); err != nil {
+			return err
+		}
+		if err := o.sampleRun(ctx, args); err != nil {
+			return err
+		}
+		if queries, err = o.flushBatch(queries, last); err != nil {
+			return err
+		}
+		if last {
+			queries = nil
+		}
+
+		// Only what the steps after the generation read of the models is kept
+		modelDir, err := o.modelOutPath()
+		if err != nil {
+			return err
+		}
+		outDir, err := filepath.Abs(o.opt.gconf.OutPath)
+		if err != nil {
+			return err
+		}
+		for _, meta := range o.structs {
+			flushed = append(flushed, compactMeta(meta, modelDir, outDir))
+		}
+		o.structs = nil
+		if limits.maxMemory > 0 {
+			runtime.GC()
+		}
+		return nil
+	}
+
+	pending := 0
+	for i, group := range groups {
+		d := dirs(group)
+		switch {
+		case i == 0:
+			renew(d)
+		case d != dirs(groups[i-1]):
+			// The query file is complete once its directory is done
+			if err := flush(d.DaoDir != dirs(groups[i-1]).DaoDir); err != nil {
+				return err
+			}
+			renew(d)
+			pending = 0
+		case limits.full(pending):
+			if err := flush(false); err != nil {
+				return err
+			}
+			renew(d)
+			pending = 0
+		}
+		if err := o.generateModels(all, group); err != nil {
+			return err
+		}
+		pending += len(group)
+	}
+	if err := flush(true); err != nil {
+		return err
+	}
+	o.structs = flushed
+	return nil
+}
+
+// layoutGroups splits the groups of tables by the directories of their
+// output paths, and orders them by query then model directory so each query
+// directory is generated by consecutive batches. A relation between tables
+// of different directories cannot be generated.
+func layoutGroups(groups [][]string, paths map[string]outputPaths) ([][]string, error) {
+	for _, group := range groups {
+		first, _, _ := strings.Cut(group[0], "@")
+		for _, entry := range group[1:] {
+			table, _, _ := strings.Cut(entry, "@")
+			if paths[table].ModelDir != paths[first].ModelDir || paths[table].DaoDir != paths[first].DaoDir {
+				return nil, &LayoutError{Table: table, Reason: "related to table " + first + ", whose model or dao is generated into another directory"}
+			}
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b []string) int {
+		ta, _, _ := strings.Cut(a[0], "@")
+		tb, _, _ := strings.Cut(b[0], "@")
+		pa, pb := paths[ta], paths[tb]
+		return cmp.Or(strings.Compare(pa.DaoDir, pb.DaoDir), strings.Compare(pa.ModelDir, pb.ModelDir))
+	})
+	return groups, nil
+}
+
+// flushBatch generates the models and daos of the batch in a staging
+// directory and moves them into place, but for the query file listing every
+// dao, merged into queries and written with the last batch. It returns the
+// merged query file.
+func (o *Orm) flushBatch(queries []byte, last bool) ([]byte, error) {
+	s, err := o.generateStaged()
+	if err != nil {
+		return nil, err
+	}
+	// The query file is read with the import paths of the target
+	if err := rewriteImports(s.stagedOut, o.importReplacements(s.dirs())); err != nil {
+		s.abort(o)
+		return nil, err
+	}
+	staged := filepath.Join(s.stagedOut, filepath.Base(s.outFile))
+	src, err := os.ReadFile(staged)
+	switch {
+	case err == nil:
+		if queries, err = mergeQueryFiles(queries, src); err != nil {
+			s.abort(o)
+			return nil, fmt.Errorf("merging %s: %w", filepath.Base(s.outFile), err)
+		}
+	case !os.IsNotExist(err):
+		s.abort(o)
+		return nil, err
+	}
+	if last && queries != nil {
+		if err := os.MkdirAll(s.stagedOut, 0755); err != nil {
+			s.abort(o)
+			return nil, err
+		}
+		if err := os.WriteFile(staged, queries, 0640); err != nil {
+			s.abort(o)
+			return nil, err
+		}
+	}
+
+	target := filepath.Join(s.out, filepath.Base(s.outFile))
+	return queries, s.commit(o, func(path string) bool {
+		return last || path != target
+	})
+}
+
+// mergeQueryFiles merges two query files of gen listing the daos of
+// different tables. The files share their skeleton, and list the daos of
+// their tables sorted in the same places: the lines missing from the other
+// file are merged in order, lines being compared without their alignment.
+func mergeQueryFiles(a, b []byte) ([]byte, error) {
+	if a == nil {
+		return b, nil
+	}
+	linesA, linesB := splitLines(a), splitLines(b)
+	inA, inB := make(map[string]bool), make(map[string]bool)
+	for _, line := range linesA {
+		inA[strings.Join(strings.Fields(line), " ")] = true
+	}
+	for _, line := range linesB {
+		inB[strings.Join(strings.Fields(line), " ")] = true
+	}
+
+	var out bytes.Buffer
+	i, j := 0, 0
+	for i < len(linesA) || j < len(linesB) {
+		var keyA, keyB string
+		if i < len(linesA) {
+			keyA = strings.Join(strings.Fields(linesA[i]), " ")
+		}
+		if j < len(linesB) {
+			keyB = strings.Join(strings.Fields(linesB[j]), " ")
+		}
+		switch {
+		case i < len(linesA) && j < len(linesB) && keyA == keyB:
+			out.WriteString(linesA[i])
+			i, j = i+1, j+1
+		case i < len(linesA) && !inB[keyA] && (j == len(linesB) || inA[keyB] || keyA < keyB):
+			out.WriteString(linesA[i])
+			i++
+		case j < len(linesB) && !inA[keyB]:
+			out.WriteString(linesB[j])
+			j++
+		default:
+			return nil, fmt.Errorf("the files differ beyond their tables at lines %d and %d", i+1, j+1)
+		}
+		out.WriteByte('\n')
+	}
+	return format.Source(out.Bytes())
+}
+
+// batchGroups groups the selected entries by the tables linked by the
+// declared relations and, with --with-relations, the foreign keys, so the
+// models of a relation are generated in the same batch. The groups keep the
+// order of the entries.
+func (o *Orm) batchGroups(tables []string) ([][]string, error) {
+	var names []string
+	for _, t := range tables {
+		if name, _, _ := strings.Cut(t, "@"); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	parent := make(map[string]string, len(names))
+	for _, name := range names {
+		parent[name] = name
+	}
+	var root func(string) string
+	root = func(t string) string {
+		if parent[t] != t {
+			parent[t] = root(parent[t])
+		}
+		return parent[t]
+	}
+	link := func(a, b string) {
+		if _, ok := parent[a]; !ok {
+			return
+		}
+		if _, ok := parent[b]; !ok {
+			return
+		}
+		parent[root(a)] = root(b)
+	}
+
+	for _, r := range o.opt.relations {
+		link(r.Table, r.Target)
+	}
+	if _, replay := snapshotOf(o.meta); o.withRelations && !replay && foreignKeySQL[o.meta.Dialector.Name()] != "" {
+		fks, err := o.foreignKeys(names)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range fks {
+			if !fk.skipped(o.skipRelations) {
+				link(fk.Table, fk.RefTable)
+			}
+		}
+	}
+
+	var groups [][]string
+	index := make(map[string]int)
+	for _, t := range tables {
+		name, _, _ := strings.Cut(t, "@")
+		r := root(name)
+		i, ok := index[r]
+		if !ok {
+			i = len(groups)
+			index[r] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	return groups, nil
+}
+
+type (
+	// flushedMeta is what the steps after the generation read of the struct
+	// meta of a model written by a previous batch.
+	flushedMeta struct {
+		TableName       string
+		ModelStructName string
+		QueryStructName string
+		FileName        string
+		TableComment    string
+		Fields          []*flushedField
+		// modelDir and outDir are the directories the model and dao were
+		// written to
+		modelDir, outDir string
+	}
+	// flushedField is a field of a flushedMeta.
+	flushedField struct {
+		Name       string
+		Type       string
+		ColumnName string
+		tags       string
+	}
+)
+
+// Tags returns the struct tags of the field.
+func (f *flushedField) Tags() string {
+	return f.tags
+}
+
+// compactMeta returns the flushedMeta of a struct meta of gen written to
+// the model and dao directories.
+func compactMeta(meta any, modelDir, outDir string) any {
+	if meta == nil {
+		return nil
+	}
+	m := &flushedMeta{
+		TableName:       metaString(meta, "TableName"),
+		ModelStructName: metaString(meta, "ModelStructName"),
+		QueryStructName: metaString(meta, "QueryStructName"),
+		FileName:        metaString(meta, "FileName"),
+		TableComment:    metaString(meta, "TableComment"),
+		modelDir:        modelDir,
+		outDir:          outDir,
+	}
+	for column, f := range modelFields(meta) {
+		m.Fields = append(m.Fields, &flushedField{Name: f[0], Type: f[1], ColumnName: column, tags: f[2]})
+	}
+	return m
+}
+
+// outputDirs returns the directories the model and dao of a struct meta are
+// written to, the ones of the config unless a batch wrote them elsewhere.
+func (o *Orm) outputDirs(meta any) (modelDir, outDir string, err error) {
+	if m, ok := meta.(*flushedMeta); ok {
+		return m.modelDir, m.outDir, nil
+	}
+	if modelDir, err = o.modelOutPath(); err != nil {
+		return "", "", err
+	}
+	outDir, err = filepath.Abs(o.opt.gconf.OutPath)
+	return modelDir, outDir, err
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// largeSchema replaces the tables of a snapshot with n copies of its first
+// table, each with columns more varchar columns.
+func largeSchema(n, columns int) func(*schemaSnapshot) {
+	return func(snap *schemaSnapshot) {
+		base := snap.Tables[0]
+		snap.Tables = nil
+		for i := range n {
+			table := base
+			table.Name = fmt.Sprintf("table_%04d", i)
+			table.Columns = append([]snapshotColumn(nil), base.Columns...)
+			for j := range columns {
+				typ := "varchar(255)"
+				table.Columns = append(table.Columns, snapshotColumn{NameValue: fmt.Sprintf("column_%02d", j), DataTypeValue: "varchar", ColumnTypeValue: &typ})
+			}
+			table.Indexes = []snapshotIndex{{TableValue: table.Name, NameValue: "PRIMARY", ColumnList: []string{"id"}}}
+			snap.Tables = append(snap.Tables, table)
+		}
+	}
+}
+
+func TestBatchOutput(t *testing.T) {
+	db := openFixtureWith(t, "users", largeSchema(30, 5))
+	args := []string{"-t", "table_*", "--style", "dao"}
+	opts := WithDaoTables([]string{"*"})
+	want := generate(t, db, args, opts)
+	if len(want) != 61 {
+		t.Fatalf("generated %d files, want the models and daos of 30 tables and gen.go", len(want))
+	}
+
+	for _, limit := range [][]string{{"--batch-size", "7"}, {"--max-memory", "1B"}} {
+		t.Run(limit[0], func(t *testing.T) {
+			got := generate(t, db, append(limit, args...), opts)
+			if len(got) != len(want) {
+				t.Errorf("batched run generated %v", keys(got))
+			}
+			for name, src := range want {
+				if got[name] != src {
+					t.Errorf("%s differs from the run without batches:\n%s", name, got[name])
+				}
+			}
+		})
+	}
+}
+
+// peakHeap returns the peak heap size sampled while fn runs.
+func peakHeap(fn func()) uint64 {
+	runtime.GC()
+	done := make(chan struct{})
+	peak := make(chan uint64)
+	go func() {
+		var m runtime.MemStats
+		var highest uint64
+		for {
+			runtime.ReadMemStats(&m)
+			highest = max(highest, m.HeapAlloc)
+			select {
+			case <-done:
+				peak <- highest
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+	}()
+	fn()
+	close(done)
+	return <-peak
+}
+
+// TestBatchMemory compares the peak heap of a synthetic schema of fifty
+// wide tables generated at once and in batches.
+func TestBatchMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a large schema")
+	}
+	db := openFixtureWith(t, "users", largeSchema(50, 80))
+	args := []string{"-t", "table_*", "--style", "dao"}
+	opts := WithDaoTables([]string{"*"})
+
+	all := peakHeap(func() { generate(t, db, args, opts) })
+	batched := peakHeap(func() { generate(t, db, append([]string{"--batch-size", "5"}, args...), opts) })
+	t.Logf("peak heap: %d MiB at once, %d MiB in batches of 5 tables", all>>20, batched>>20)
+	if batched >= all {
+		t.Errorf("peak heap of the batches %d MiB, not below the %d MiB of the run at once", batched>>20, all>>20)
+	}
+}
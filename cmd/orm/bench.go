@@ -0,0 +1,287 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// benchTemplate renders the benchmarks of one dao table.
+// Each benchmark sets up an in-memory sqlite schema from the model, inserts
+// b.N rows, made by the factory of the model with --with-factories, and calls
+// the method with zero-value arguments.
+var benchTemplate = template.Must(template.New("bench").Parse(`// Scaffolded by command orm --with-benchmarks. This file is never overwritten, edit freely.
+
+package {{.Package}}
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+{{range .Imports}}
+	"{{.}}"{{end}}
+)
+
+// setup{{.Model}}Bench creates an in-memory {{.Table}} table holding n rows.
+func setup{{.Model}}Bench(b *testing.B, n int) *Query {
+	b.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := db.AutoMigrate(&{{.ModelPkg}}.{{.Model}}{}); err != nil {
+		b.Fatal(err)
+	}
+	rows := make([]{{.ModelPkg}}.{{.Model}}, n)
+{{- if .Factories}}
+	for i := range rows {
+		rows[i] = *{{.ModelPkg}}.Fake{{.Model}}(i)
+	}
+{{- end}}
+	if err := db.CreateInBatches(rows, 100).Error; err != nil {
+		b.Fatal(err)
+	}
+	return Use(db)
+}
+{{range .Methods}}
+func Benchmark{{$.Model}}{{.Name}}(b *testing.B) {
+	q := setup{{$.Model}}Bench(b, b.N)
+{{range $i, $t := .Params}}	var a{{$i}} {{$t}}
+{{end}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		{{.Assign}}q.{{$.Model}}.WithContext(context.Background()).{{.Name}}({{.Args}})
+	}
+}
+{{end}}`))
+
+type (
+	// benchFile is the data of a generated benchmark file.
+	benchFile struct {
+		Package  string
+		ModelPkg string
+		Table    string
+		Model    string
+		Imports  []string
+		Methods  []benchMethod
+		// Factories makes the rows with the generated factory of the model
+		Factories bool
+	}
+	// benchMethod is a benchmarked annotae method.
+	benchMethod struct {
+		Name   string
+		Params []string
+		Args   string
+		Assign string
+	}
+)
+
+// benchmarks writes benchmark files for the annotae methods of the selected tables.
+// Existing files are left untouched so customized benchmarks persist.
+func (o *Orm) benchmarks(tables []string) error {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return err
+	}
+	modelDir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	keep, err := loadKeep(out)
+	if err != nil {
+		return err
+	}
+	modelImport, err := o.importPath(modelDir)
+	if err != nil {
+		return err
+	}
+
+	for _, meta := range o.structs {
+		table := metaString(meta, "TableName")
+		if table == "" || !selected(o.opt.daoTables, table) || !selected(tables, table) {
+			continue
+		}
+
+		bf := benchFile{
+			Package:   filepath.Base(out),
+			ModelPkg:  filepath.Base(modelDir),
+			Table:     table,
+			Model:     metaString(meta, "ModelStructName"),
+			Imports:   []string{modelImport},
+			Factories: o.withFactories,
+		}
+		for _, key := range []string{"*", table} {
+			if err := bf.addMethods(o.opt.daoApi[key], o.rlsParams[table]); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+		if len(bf.Methods) == 0 {
+			continue
+		}
+
+		path := filepath.Join(out, metaString(meta, "FileName")+"_bench_test.go")
+		if _, err := os.Stat(path); err == nil || keep.Kept(path) {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := benchTemplate.Execute(&buf, bf); err != nil {
+			return err
+		}
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := o.writeFile(path, src, 0640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMethods adds the methods of an annotae interface, given as func(Interface){}.
+// Under row-level security the methods take the rls parameter first.
+func (bf *benchFile) addMethods(annotae any, rls rlsParam) error {
+	if annotae == nil {
+		return nil
+	}
+	iface, err := annotaeInterface(annotae)
+	if err != nil {
+		return err
+	}
+	for i := range iface.NumMethod() {
+		m := iface.Method(i)
+		if slices.ContainsFunc(bf.Methods, func(b benchMethod) bool { return b.Name == m.Name }) {
+			continue
+		}
+
+		bm := benchMethod{Name: m.Name}
+		var args []string
+		if rls.Type != "" {
+			bm.Params = append(bm.Params, rls.Type)
+			args = append(args, "a0")
+			if rls.Import != "" && !slices.Contains(bf.Imports, rls.Import) {
+				bf.Imports = append(bf.Imports, rls.Import)
+			}
+		}
+		for j := range m.Type.NumIn() {
+			in := m.Type.In(j)
+			bf.addImports(in)
+			bm.Params = append(bm.Params, in.String())
+			args = append(args, fmt.Sprintf("a%d", len(args)))
+		}
+		bm.Args = strings.Join(args, ", ")
+		if n := m.Type.NumOut(); n > 0 {
+			bm.Assign = strings.Repeat("_, ", n-1) + "_ = "
+		}
+		bf.Methods = append(bf.Methods, bm)
+	}
+	return nil
+}
+
+// annotaeInterface returns the interface of a dao api given as func(Interface){}.
+func annotaeInterface(annotae any) (reflect.Type, error) {
+	fn := reflect.TypeOf(annotae)
+	if fn.Kind() != reflect.Func || fn.NumIn() != 1 || fn.In(0).Kind() != reflect.Interface {
+		return nil, errors.New("dao api must be a func taking an interface")
+	}
+	return fn.In(0), nil
+}
+
+// addImports adds the packages a parameter type refers to.
+func (bf *benchFile) addImports(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		bf.addImports(t.Elem())
+		return
+	case reflect.Map:
+		bf.addImports(t.Key())
+		bf.addImports(t.Elem())
+		return
+	}
+	if pkg := t.PkgPath(); pkg != "" && !slices.Contains(bf.Imports, pkg) {
+		bf.Imports = append(bf.Imports, pkg)
+	}
+}
+
+// selected reports whether a table is matched by a table selector,
+// where "*" selects every table.
+func selected(selector []string, table string) bool {
+	return slices.Contains(selector, "*") || slices.Contains(selector, table)
+}
+
+// importPath returns the import path of a directory from the nearest go.mod.
+func importPath(dir string) (string, error) {
+	root, module, err := moduleRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	return joinImport(module, root, dir)
+}
+
+// moduleRoot returns the directory of the nearest go.mod of dir and the
+// module path it declares.
+func moduleRoot(dir string) (root, module string, err error) {
+	for root = dir; ; {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			module = modulePath(data)
+			if module == "" {
+				return "", "", fmt.Errorf("%s: no module directive", filepath.Join(root, "go.mod"))
+			}
+			return root, module, nil
+		}
+
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", "", fmt.Errorf("no go.mod found for %s", dir)
+		}
+		root = parent
+	}
+}
+
+// joinImport returns the import path of dir in the module rooted at root.
+func joinImport(module, root, dir string) (string, error) {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return module, nil
+	}
+	return module + "/" + filepath.ToSlash(rel), nil
+}
+
+// modulePath returns the module path declared in a go.mod file.
+func modulePath(gomod []byte) string {
+	for line := range strings.Lines(string(gomod)) {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/annotae"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+)
+
+// benchArgs generate the dao of users with its benchmarks.
+var benchArgs = []string{"-t", "users", "--style", "dao", "--with-benchmarks", "--benchmark-tables", "users"}
+
+// benchOpts are the options of benchArgs.
+func benchOpts() []IOrmOption {
+	return []IOrmOption{
+		WithDaoTables([]string{"users"}),
+		WithDaoApi(map[string]any{"*": func(annotae.Querier) {}}),
+	}
+}
+
+func TestBenchmarks(t *testing.T) {
+	files := generateBuilt(t, openFixture(t, "users"), append(benchArgs, "--with-factories"), benchOpts()...)
+	src := files["dao/users_bench_test.go"]
+	for _, want := range []string{"func BenchmarkUserGetByID(b *testing.B)", "rows[i] = *model.FakeUser(i)"} {
+		if !strings.Contains(src, want) {
+			t.Fatalf("benchmarks lack %q:\n%s", want, src)
+		}
+	}
+
+	// The scaffolded benchmarks run on the model schema
+	bench := exec.Command("go", "test", "-run", "^$", "-bench", ".", "-benchtime", "2x", "./dao")
+	out, err := bench.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go test -bench: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "BenchmarkUserGetByID") {
+		t.Errorf("BenchmarkUserGetByID did not run:\n%s", out)
+	}
+
+	// A customized benchmark file persists
+	if err := os.WriteFile("dao/users_bench_test.go", []byte("package dao\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	files = generateHere(t, openFixture(t, "users"), benchArgs, benchOpts()...)
+	if files["dao/users_bench_test.go"] != "package dao\n" {
+		t.Error("customized benchmarks overwritten")
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, style := range []string{"model", "dao"} {
+		b.Run(style, func(b *testing.B) {
+			// gen reads the annotae interfaces from their package in the module
+			dir, err := os.MkdirTemp(testdata, "bench-")
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(func() { os.RemoveAll(dir) })
+			b.Chdir(dir)
+			db := openFixture(b, "users")
+			for b.Loop() {
+				c := NewOrmCommand(append(benchOpts(), WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}))...).Command()
+				c.SetArgs([]string{"-t", "users", "--style", style, "--config", "", "--lockfile", ""})
+				c.SilenceUsage, c.SilenceErrors = true, true
+				if err := c.Execute(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/pflag"
+)
+
+// bundleVersion is the version of the capture bundle format.
+const bundleVersion = 1
+
+// Entries of a capture bundle.
+const (
+	bundleSchema   = "schema.json"
+	bundleSettings = "settings.json"
+	bundleReport   = "report.json"
+	bundleRules    = "rules/"
+)
+
+// credentialSettings are the config file settings left out of a bundle.
+var credentialSettings = []string{"dsn", "introspect-dsn", "ssh-host", "ssh-user", "ssh-key", "ssh-known-hosts"}
+
+type (
+	// bundleSettingsFile is the settings.json entry of a capture bundle, the
+	// effective settings of the captured run.
+	bundleSettingsFile struct {
+		Version int `json:"version"`
+		// Flags are the generation flags of the run, replayed as given
+		Flags   map[string][]string `json:"flags,omitempty"`
+		Options captureOptions      `json:"options"`
+	}
+	// captureOptions are the options of the run, the code options with the
+	// config file rules merged in. The paths are relative to the module root.
+	captureOptions struct {
+		Module            string            `json:"module,omitempty"`
+		OutPath           string            `json:"outPath"`
+		OutFile           string            `json:"outFile,omitempty"`
+		ModelPkgPath      string            `json:"modelPkgPath"`
+		WithUnitTest      bool              `json:"withUnitTest,omitempty"`
+		FieldNullable     bool              `json:"fieldNullable,omitempty"`
+		FieldCoverable    bool              `json:"fieldCoverable,omitempty"`
+		FieldSignable     bool              `json:"fieldSignable,omitempty"`
+		FieldWithIndexTag bool              `json:"fieldWithIndexTag,omitempty"`
+		FieldWithTypeTag  bool              `json:"fieldWithTypeTag,omitempty"`
+		Mode              []string          `json:"mode,omitempty"`
+		Rename            map[string]string `json:"rename,omitempty"`
+		Ignore            []string          `json:"ignore,omitempty"`
+		OnlyFields        []string          `json:"onlyFields,omitempty"`
+		Retags            []string          `json:"retags,omitempty"`
+		ReGormTags        []string          `json:"regormtags,omitempty"`
+		DaoTables         []string          `json:"daoTables,omitempty"`
+		Redact            []string          `json:"redact,omitempty"`
+		FieldRename       map[string]string `json:"fieldRename,omitempty"`
+		Abbreviations     map[string]string `json:"abbreviations,omitempty"`
+		Acronyms          []string          `json:"acronyms,omitempty"`
+		Serializer        map[string]string `json:"serializer,omitempty"`
+		SerializerType    map[string]string `json:"serializerType,omitempty"`
+		JSONType          map[string]string `json:"jsonType,omitempty"`
+		AutoTime          map[string]string `json:"autoTime,omitempty"`
+		JSONOmit          []string          `json:"jsonOmit,omitempty"`
+		SoftDelete        []string          `json:"softDelete,omitempty"`
+		Nullable          []string          `json:"nullable,omitempty"`
+		NotNullable       []string          `json:"notNullable,omitempty"`
+		Relations         []Relation        `json:"relations,omitempty"`
+		ExcludeTables     []string          `json:"excludeTables,omitempty"`
+		Enums             bool              `json:"enums,omitempty"`
+		// DataTypes are the Go types given by the data type rules, by table
+		// and column, as the functions of WithDataType cannot be recorded
+		DataTypes map[string]map[string]string `json:"dataTypes,omitempty"`
+		// JSONTags are the tags of WithJSONTagStrategy by column
+		JSONTags map[string]string `json:"jsonTags,omitempty"`
+		// ModelNames are the names of WithModelNameStrategy by table
+		ModelNames map[string]string `json:"modelNames,omitempty"`
+	}
+	// bundleReportFile is the report.json entry of a capture bundle.
+	bundleReportFile struct {
+		Version   int       `json:"version"`
+		CreatedAt time.Time `json:"createdAt"`
+		Tables    []string  `json:"tables"`
+		// Files are the generated files by path relative to the module root
+		Files     []bundleFileSum `json:"files"`
+		Rewritten int             `json:"rewritten"`
+		Unchanged int             `json:"unchanged"`
+		// DaoApi are the methods of the annotae interfaces by table, which
+		// a replay cannot generate
+		DaoApi map[string][]string `json:"daoApi,omitempty"`
+		// Degraded are the features left out by --allow-old-db
+		Degraded []string `json:"degraded,omitempty"`
+	}
+	// bundleFileSum is a generated file of a capture report.
+	bundleFileSum struct {
+		Path   string   `json:"path"`
+		SHA256 string   `json:"sha256"`
+		Tables []string `json:"tables"`
+	}
+)
+
+// capture writes the bundle of --capture after a successful run: the schema
+// snapshot, the effective settings, the config files and the run report.
+// Credentials and rows are never read into it.
+func (o *Orm) capture(args *pflag.FlagSet) error {
+	name, err := args.GetString("capture")
+	if err != nil || name == "" {
+		return err
+	}
+	redact, err := args.GetBool("redact-comments")
+	if err != nil {
+		return err
+	}
+	if o.opt.fs != nil {
+		return errors.New("--capture reads the generated files from disk and cannot be used with WithFS")
+	}
+	style, err := args.GetString("style")
+	if err != nil {
+		return err
+	}
+
+	snap, err := o.snapshot(redact)
+	if err != nil {
+		return err
+	}
+	settings, root, err := o.captureSettings(args, snap)
+	if err != nil {
+		return err
+	}
+	report, err := o.captureReport(root, style != "model")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	entries := []struct {
+		name  string
+		value any
+	}{{bundleSchema, snap}, {bundleSettings, settings}, {bundleReport, report}}
+	for i, src := range o.configSources {
+		src.Config.Settings = maps.Clone(src.Config.Settings)
+		for _, key := range credentialSettings {
+			delete(src.Config.Settings, key)
+		}
+		src.Name = filepath.Base(src.Name)
+		entries = append(entries, struct {
+			name  string
+			value any
+		}{fmt.Sprintf("%s%02d-%s.json", bundleRules, i+1, src.Name), src})
+	}
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			return err
+		}
+		// Rules keep their arrows readable
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(e.value); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(name, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	color.Green("Captured the run to %s, replay it with: command orm replay %s\n", name, name)
+	return nil
+}
+
+// captureSettings returns the effective settings of the run and the module
+// root the paths are relative to.
+func (o *Orm) captureSettings(args *pflag.FlagSet, snap *schemaSnapshot) (*bundleSettingsFile, string, error) {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, "", err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return nil, "", err
+	}
+	// Outside a module the paths are relative to the working directory
+	root, module, err := moduleRoot(out)
+	if err != nil {
+		if root, err = os.Getwd(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	flags := generationFlagValues(args)
+	if tables, ok := flags["tables"]; ok {
+		if flags["tables"], err = expandTableFiles(tables); err != nil {
+			return nil, "", err
+		}
+	}
+	// The defaults of these flags come from the options of the caller
+	flags["schema-prefix-names"] = []string{strconv.FormatBool(o.schemaPrefix)}
+	if o.modulePath != "" {
+		flags["module-path"] = []string{o.modulePath}
+	}
+	if o.tablePrefix != "" {
+		flags["prefix"] = []string{o.tablePrefix}
+	}
+
+	conf := o.opt.gconf
+	opts := captureOptions{
+		Module:            module,
+		OutPath:           bundleDir(root, out),
+		OutFile:           conf.OutFile,
+		ModelPkgPath:      bundleDir(root, model),
+		WithUnitTest:      conf.WithUnitTest,
+		FieldNullable:     conf.FieldNullable,
+		FieldCoverable:    conf.FieldCoverable,
+		FieldSignable:     conf.FieldSignable,
+		FieldWithIndexTag: conf.FieldWithIndexTag,
+		FieldWithTypeTag:  conf.FieldWithTypeTag,
+		Rename:            o.opt.rename,
+		Ignore:            o.opt.ignore,
+		OnlyFields:        o.opt.onlyFields,
+		Retags:            o.opt.retags,
+		ReGormTags:        o.opt.reGromTags,
+		DaoTables:         o.opt.daoTables,
+		Redact:            o.opt.redact,
+		FieldRename:       o.opt.fieldRename,
+		Abbreviations:     o.opt.abbreviations,
+		Acronyms:          o.opt.acronyms,
+		Serializer:        o.opt.serializer,
+		SerializerType:    o.opt.serializerType,
+		JSONType:          o.opt.jsonType,
+		AutoTime:          o.opt.autoTime,
+		JSONOmit:          o.opt.jsonOmit,
+		SoftDelete:        o.opt.softDelete,
+		Nullable:          o.opt.nullable,
+		NotNullable:       o.opt.notNullable,
+		Relations:         o.opt.relations,
+		ExcludeTables:     o.opt.excludeTables,
+		Enums:             o.opt.enums,
+	}
+	for _, name := range slices.Sorted(maps.Keys(genModeNames)) {
+		if conf.Mode&genModeNames[name] != 0 {
+			opts.Mode = append(opts.Mode, name)
+		}
+	}
+
+	// The rule functions are recorded by their result for every column
+	for table := range o.columns {
+		t, err := snap.table(table)
+		if err != nil {
+			return nil, "", err
+		}
+		if o.opt.modelNameStrategy != nil {
+			if opts.ModelNames == nil {
+				opts.ModelNames = make(map[string]string)
+			}
+			_, name := splitTable(trimTablePrefix(table, o.tablePrefix))
+			opts.ModelNames[name] = o.opt.modelNameStrategy(name)
+		}
+		for _, col := range t.Columns {
+			if o.opt.jsonTagStrategy != nil {
+				if opts.JSONTags == nil {
+					opts.JSONTags = make(map[string]string)
+				}
+				opts.JSONTags[col.NameValue] = o.opt.jsonTagStrategy(col.NameValue)
+			}
+			fn, ok := o.rules.types[table][col.DataTypeValue]
+			if !ok {
+				fn, ok = o.rules.globalTypes[col.DataTypeValue]
+			}
+			if tfn, found := o.rules.globalTableTypes[col.DataTypeValue]; !ok && found {
+				fn, ok = bindTable(tfn, table), true
+			}
+			if !ok {
+				continue
+			}
+			if opts.DataTypes == nil {
+				opts.DataTypes = make(map[string]map[string]string)
+			}
+			if opts.DataTypes[table] == nil {
+				opts.DataTypes[table] = make(map[string]string)
+			}
+			opts.DataTypes[table][col.NameValue] = fn(col)
+		}
+	}
+	return &bundleSettingsFile{Version: bundleVersion, Flags: flags, Options: opts}, root, nil
+}
+
+// captureReport returns the report of the run with the checksums of the
+// generated files.
+func (o *Orm) captureReport(root string, dao bool) (*bundleReportFile, error) {
+	sums, err := o.fileSums(root, dao)
+	if err != nil {
+		return nil, err
+	}
+	report := &bundleReportFile{
+		Version:   bundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Tables:    sortedKeys(o.columns),
+		Files:     sums,
+		Rewritten: o.rewritten,
+		Unchanged: o.unchanged,
+		Degraded:  o.degraded,
+	}
+	for table, api := range o.opt.daoApi {
+		if methods := annotaeMethods(api); len(methods) > 0 {
+			if report.DaoApi == nil {
+				report.DaoApi = make(map[string][]string)
+			}
+			report.DaoApi[table] = methods
+		}
+	}
+	return report, nil
+}
+
+// fileSums returns the checksums of the generated files by bundle path.
+func (o *Orm) fileSums(root string, dao bool) ([]bundleFileSum, error) {
+	files, err := o.generatedFiles(dao)
+	if err != nil {
+		return nil, err
+	}
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make([]bundleFileSum, 0, len(files))
+	for _, name := range sortedKeys(files) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		sums = append(sums, bundleFileSum{Path: bundleFile(root, name, model, out), SHA256: hex.EncodeToString(sum[:]), Tables: files[name]})
+	}
+	slices.SortFunc(sums, func(a, b bundleFileSum) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return sums, nil
+}
+
+// annotaeMethods returns the method names of the interfaces taken by an
+// annotae function such as func(api.Querier){}.
+func annotaeMethods(api any) []string {
+	t := reflect.TypeOf(api)
+	if t == nil || t.Kind() != reflect.Func {
+		return nil
+	}
+	var methods []string
+	for i := range t.NumIn() {
+		in := t.In(i)
+		if in.Kind() != reflect.Interface {
+			continue
+		}
+		for j := range in.NumMethod() {
+			methods = append(methods, in.Name()+"."+in.Method(j).Name)
+		}
+	}
+	return methods
+}
+
+// bundleDir returns dir relative to the module root, or its base name when
+// it lies outside the module, as a replay recreates it.
+func bundleDir(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return filepath.Base(dir)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// bundleFile returns the bundle path of a generated file, under the bundle
+// directory of the first of dirs holding it.
+func bundleFile(root, name string, dirs ...string) string {
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(dir, name); err == nil && filepath.IsLocal(rel) {
+			return path.Join(bundleDir(root, dir), filepath.ToSlash(rel))
+		}
+	}
+	return bundleDir(root, name)
+}
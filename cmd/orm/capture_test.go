@@ -0,0 +1,61 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCaptureReplay(t *testing.T) {
+	for _, style := range []string{"model", "dao"} {
+		t.Run(style, func(t *testing.T) {
+			// The capture runs in a module, as the replay does
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("go.mod", []byte("module repro\n\ngo 1.25\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			captured := generateHere(t, openFixture(t, "users"), []string{"-t", "users", "--style", style, "--capture", "bundle.zip"}, WithDaoTables([]string{"users"}))
+			if _, err := os.Stat("bundle.zip"); err != nil {
+				t.Fatal(err)
+			}
+
+			// The replay regenerates the run from the bundle alone
+			c := NewOrmCommand().Command()
+			c.SetArgs([]string{"replay", "bundle.zip", "-o", "repro", "--config", ""})
+			c.SilenceUsage, c.SilenceErrors = true, true
+			var err error
+			out := captureOutput(t, func() { err = c.Execute() })
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+			if !strings.Contains(out, "identical, 0 differ, 0 missing") {
+				t.Errorf("replay does not match the capture:\n%s", out)
+			}
+
+			replayed := readTree(t, "repro")
+			if len(replayed) != len(captured) {
+				t.Errorf("replayed %v, captured %v", keys(replayed), keys(captured))
+			}
+			for name, src := range captured {
+				if replayed[name] != src {
+					t.Errorf("%s differs from the capture:\n%s\nreplayed:\n%s", name, src, replayed[name])
+				}
+			}
+		})
+	}
+}
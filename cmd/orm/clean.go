@@ -0,0 +1,232 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bufio"
+	"bytes"
+	"command/cmd"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// generatedBanner matches the first line of the files written by gen and by
+// the command, which the command may delete.
+var generatedBanner = regexp.MustCompile(`^// Code generated by (gorm\.io/gen|command orm[^.]*)\. DO NOT EDIT\.$`)
+
+// scaffoldBanner starts the files the command scaffolds once for the user
+// to edit, which are never deleted.
+var scaffoldBanner = []byte("// Scaffolded by command orm")
+
+// cleanEntry is a path orm clean removes or skips, and why.
+type cleanEntry struct {
+	Path   string
+	Reason string
+}
+
+// isGenerated reports whether data starts with the banner of a generated file.
+func isGenerated(data []byte) bool {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	return generatedBanner.Match(bytes.TrimRight(line, "\r"))
+}
+
+// cleanCommand returns the orm clean subcommand.
+func (o *Orm) cleanCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove every file generated by the command from the output directories",
+		Long: `Remove the files generated by gen and by the command from the query, model
+and mocks directories of the configuration, recognized by their "Code
+generated ... DO NOT EDIT." banner, and the staging directories left by
+interrupted runs. Files without the banner, the scaffolded benchmarks and
+examples meant to be edited, and the paths listed in .ormkeep are kept. The
+--graph and --docs-site outputs live outside the output directories and are
+not removed.
+
+The files are listed and removed after a confirmation, which --yes skips.
+A run holding the lock of the query directory makes the clean fail.
+
+` + cmd.ExitCodesHelp,
+		Example: `# List what would be removed
+command orm clean --dry-run
+
+# Remove the generated files without confirmation
+command orm clean --yes`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).clean),
+	}
+	c.Flags().BoolP("yes", "y", false, cmd.T("orm.clean.flag.yes"))
+	c.Flags().Bool("dry-run", false, cmd.T("orm.clean.flag.dry-run"))
+	return c
+}
+
+// clean is the execution logic for the orm clean command.
+func (o *Orm) clean(c *cobra.Command, _ []string) error {
+	yes, err := c.Flags().GetBool("yes")
+	if err != nil {
+		return err
+	}
+	dryRun, err := c.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+	if err := o.loadConfigFile(c.Flags()); err != nil {
+		return usage(cmd.T("orm.loading_config"), err)
+	}
+	if err := o.normalizeConfig(); err != nil {
+		return usage("cleaning", err)
+	}
+
+	removed, skipped, err := o.cleanTargets()
+	if err != nil {
+		return fail("cleaning", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fail("cleaning", err)
+	}
+	for _, e := range skipped {
+		color.HiBlack("skip    %s: %s\n", relPath(cwd, e.Path), e.Reason)
+	}
+	for _, e := range removed {
+		fmt.Printf("remove  %s: %s\n", relPath(cwd, e.Path), e.Reason)
+	}
+	switch {
+	case len(removed) == 0:
+		color.Green("\nNothing to clean, %d files skipped.\n\n", len(skipped))
+		return nil
+	case dryRun:
+		color.Green("\n%d files would be removed, %d skipped.\n\n", len(removed), len(skipped))
+		return nil
+	}
+
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Remove %d files?", len(removed)))
+		if err != nil {
+			return cmd.Exit(cmd.ExitRefused, fmt.Errorf("cleaning: %w", err))
+		}
+		if !ok {
+			color.Yellow("\nNothing removed.\n\n")
+			return nil
+		}
+	}
+
+	// Runs writing to the query directory must not see their files vanish
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return fail("cleaning", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		release, err := o.lock(c.Context(), 0)
+		if err != nil {
+			return fail("cleaning", err)
+		}
+		defer release()
+	}
+	for _, e := range removed {
+		if err := os.RemoveAll(e.Path); err != nil {
+			return fail("cleaning", err)
+		}
+	}
+	// The mocks package holds nothing else
+	os.Remove(filepath.Join(out, mocksPackage))
+	color.Green("\nRemoved %d files, skipped %d.\n\n", len(removed), len(skipped))
+	return nil
+}
+
+// cleanTargets returns the generated files of the output directories and the
+// staging directories of interrupted runs, and the files kept with the
+// reason.
+func (o *Orm) cleanTargets() (removed, skipped []cleanEntry, err error) {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, dir := range []string{out, filepath.Join(out, mocksPackage), model} {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		keep, err := loadKeep(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+				continue
+			}
+			if keep.Kept(e.Name()) {
+				skipped = append(skipped, cleanEntry{path, "protected by .ormkeep"})
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch {
+			case isGenerated(data):
+				removed = append(removed, cleanEntry{path, "generated"})
+			case bytes.HasPrefix(data, scaffoldBanner):
+				skipped = append(skipped, cleanEntry{path, "scaffolded to be edited"})
+			default:
+				skipped = append(skipped, cleanEntry{path, "no generated banner"})
+			}
+		}
+	}
+
+	stages, err := filepath.Glob(filepath.Join(filepath.Dir(out), ".ormstage-*"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, dir := range stages {
+		removed = append(removed, cleanEntry{dir, "staging directory of an interrupted run"})
+	}
+	slices.SortFunc(skipped, func(a, b cleanEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return removed, skipped, nil
+}
+
+// confirm asks a yes or no question on the terminal.
+func confirm(question string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, errors.New("stdin is not a terminal, confirm with --yes")
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
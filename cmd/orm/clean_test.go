@@ -0,0 +1,115 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/annotae"
+	"command/cmd"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+)
+
+// cleanRun runs orm clean with args in the working directory and returns its
+// output.
+func cleanRun(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	c := NewOrmCommand(WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})).Command()
+	c.SetArgs(append([]string{"clean", "--config", ""}, args...))
+	c.SilenceUsage, c.SilenceErrors = true, true
+	var err error
+	out := captureOutput(t, func() { err = c.Execute() })
+	return out, err
+}
+
+func TestClean(t *testing.T) {
+	generated := generateBuilt(t, openFixture(t, "users"), []string{"-t", "users", "--style", "dao", "--with-mocks"},
+		WithDaoTables([]string{"users"}),
+		WithDaoApi(map[string]any{"*": func(annotae.Querier) {}}),
+	)
+	for _, name := range []string{"dao/users.gen.go", "dao/gen.go", "dao/mocks/users.gen.go", "model/users.gen.go"} {
+		if _, ok := generated[name]; !ok {
+			t.Fatalf("no %s in %v", name, keys(generated))
+		}
+	}
+
+	hand := map[string]string{
+		"dao/users.go":            "package dao\n",
+		"dao/users_bench_test.go": "// Scaffolded by command orm --with-benchmarks. This file is never overwritten, edit freely.\n\npackage dao\n",
+		"dao/.ormkeep":            "gen.go\n",
+		"model/.ormkeep":          "",
+		"model/user_hooks.go":     "package model\n",
+	}
+	for name, content := range hand {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(".ormstage-1", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The dry run lists the files and removes none
+	out, err := cleanRun(t, "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"remove  dao/users.gen.go: generated",
+		"remove  dao/mocks/users.gen.go: generated",
+		"remove  model/users.gen.go: generated",
+		"remove  .ormstage-1: staging directory of an interrupted run",
+		"skip    dao/gen.go: protected by .ormkeep",
+		"skip    dao/users.go: no generated banner",
+		"skip    dao/users_bench_test.go: scaffolded to be edited",
+		"skip    model/user_hooks.go: no generated banner",
+		"4 files would be removed, 4 skipped.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output lacks %q:\n%s", want, out)
+		}
+	}
+	if files := readTree(t, "."); len(files) != len(generated)+3 {
+		t.Errorf("the dry run removed files: %v", keys(files))
+	}
+
+	// Without a terminal the clean needs --yes
+	if _, err := cleanRun(t); cmd.ExitCode(err, true) != cmd.ExitRefused {
+		t.Errorf("clean without --yes = %v, want exit code %d", err, cmd.ExitRefused)
+	}
+
+	if _, err := cleanRun(t, "--yes"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"dao/gen.go", "dao/users.go", "dao/users_bench_test.go", "model/user_hooks.go"}
+	if got := keys(readTree(t, ".")); !slices.Equal(got, want) {
+		t.Errorf("after the clean %v, want %v", got, want)
+	}
+	for _, dir := range []string{".ormstage-1", filepath.Join("dao", mocksPackage)} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s is left: %v", dir, err)
+		}
+	}
+
+	// A second clean has nothing left to remove
+	if out, err := cleanRun(t, "--yes"); err != nil || !strings.Contains(out, "Nothing to clean, 4 files skipped.") {
+		t.Errorf("second clean = %v:\n%s", err, out)
+	}
+}
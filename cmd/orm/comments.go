@@ -0,0 +1,159 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gorm.io/gen"
+)
+
+// commentLines splits a database comment into the lines of a Go comment,
+// trimming the trailing whitespace of each line and the whitespace around.
+func commentLines(comment string) []string {
+	comment = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(strings.TrimSpace(comment))
+	if comment == "" {
+		return nil
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return lines
+}
+
+// commentsOpt drops the comments gen puts after the fields, which the
+// comments pass writes as doc comments instead.
+func (o *Orm) commentsOpt() gen.ModelOpt {
+	return gen.FieldModify(func(f gen.Field) gen.Field {
+		f.ColumnComment, f.MultilineComment = "", false
+		return f
+	})
+}
+
+// setTableComment sets the comment of the model struct of a table to its
+// database comment on one line, which the comments pass expands. Without
+// field comments, the struct keeps the "mapped from table" comment of gen.
+func (o *Orm) setTableComment(meta any, table string) {
+	v := reflect.ValueOf(meta)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return
+	}
+	comment := v.Elem().FieldByName("TableComment")
+	if !comment.CanSet() {
+		return
+	}
+	if o.opt.noFieldComments || o.isDegraded(degradedComments) {
+		comment.SetString("")
+		return
+	}
+	o.tableComments[table] = commentLines(comment.String())
+	comment.SetString(strings.Join(strings.Fields(comment.String()), " "))
+}
+
+// commentsPass writes the database comments of the columns as the doc
+// comments of their fields, and the comment of the table as the doc comment
+// of the model struct.
+func (o *Orm) commentsPass(table string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make(map[string][]string)
+	for _, col := range o.columns[table] {
+		comments[col.Name] = commentLines(col.Comment)
+	}
+	columns := o.fields[table]
+
+	// Replaced ranges of the source by start offset, the end being excluded
+	type edit struct {
+		end  int
+		text string
+	}
+	edits := make(map[int]edit)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			if lines := o.tableComments[table]; len(lines) > 1 && gd.Doc != nil {
+				start, end := fset.Position(gd.Doc.Pos()).Offset, fset.Position(gd.Doc.End()).Offset
+				lines = slices.Concat([]string{ts.Name.Name + " " + lines[0]}, lines[1:])
+				edits[start] = edit{end, docComment("", lines)}
+			}
+
+			for _, f := range st.Fields.List {
+				if len(f.Names) != 1 {
+					continue
+				}
+				lines := comments[columns[f.Names[0].Name]]
+				if len(lines) == 0 {
+					continue
+				}
+				// Above the doc comment of the other passes, such as Deprecated
+				pos := f.Pos()
+				if f.Doc != nil {
+					pos = f.Doc.Pos()
+					lines = slices.Concat(lines, []string{""})
+				}
+				start := fset.Position(pos).Offset
+				start = bytes.LastIndexByte(src[:start], '\n') + 1
+				edits[start] = edit{start, docComment("\t", lines) + "\n"}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < len(src); i++ {
+		if e, ok := edits[i]; ok {
+			buf.WriteString(e.text)
+			if e.end > i {
+				i = e.end - 1
+				continue
+			}
+		}
+		buf.WriteByte(src[i])
+	}
+	return buf.Bytes(), nil
+}
+
+// docComment formats lines as a // comment with the indent, empty lines
+// separating paragraphs.
+func docComment(indent string, lines []string) string {
+	formatted := make([]string, len(lines))
+	for i, line := range lines {
+		formatted[i] = strings.TrimRight(indent+"// "+line, " ")
+	}
+	return strings.Join(formatted, "\n")
+}
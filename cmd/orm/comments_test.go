@@ -0,0 +1,60 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFieldComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		golden  string
+	}{
+		{name: "enabled", enabled: true, golden: "comments_enabled.golden"},
+		{name: "disabled", golden: "comments_disabled.golden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generate(t, openFixture(t, "comments"), []string{"-t", "articles"}, WithFieldComments(tt.enabled))
+			src := files["model/articles.gen.go"]
+			golden(t, tt.golden, src)
+			if got := strings.Contains(src, "見出し"); got != tt.enabled {
+				t.Errorf("non-ASCII column comment written: %v", got)
+			}
+		})
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    []string
+	}{
+		{comment: "", want: nil},
+		{comment: " \t\n", want: nil},
+		{comment: "  Ünïcödé slug\t", want: []string{"Ünïcödé slug"}},
+		{comment: "first   \r\nsecond\rthird\n\nfourth\n", want: []string{"first", "second", "third", "", "fourth"}},
+		{comment: "  indented\n    code", want: []string{"indented", "    code"}},
+	}
+	for _, tt := range tests {
+		if got := commentLines(tt.comment); strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("commentLines(%q) = %q, want %q", tt.comment, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gorm.io/gen"
+)
+
+// stdinSource names the config document read with --stdin-config.
+const stdinSource = "<stdin>"
+
+// defaultConfigFile is the config file loaded, when it exists, unless
+// --config names another.
+const defaultConfigFile = "./czx.yaml"
+
+// genModeNames maps the gen.mode values of the config file to the gen mode bits.
+var genModeNames = map[string]gen.GenerateMode{
+	"default_query":   gen.WithDefaultQuery,
+	"without_context": gen.WithoutContext,
+	"query_interface": gen.WithQueryInterface,
+}
+
+// loadConfigFile merges the config file given by --config, or the JSON
+// document read from stdin with --stdin-config, into the options.
+// Settings are resolved in the order flags, environment, then file.
+// The default config file is optional, a missing file named by --config is
+// an error.
+func (o *Orm) loadConfigFile(args *pflag.FlagSet) error {
+	if err := applyEnv(args); err != nil {
+		return err
+	}
+
+	path, err := args.GetString("config")
+	if err != nil {
+		return err
+	}
+	stdin, err := args.GetBool("stdin-config")
+	if err != nil {
+		return err
+	}
+	profile, err := args.GetString("profile")
+	if err != nil {
+		return err
+	}
+	explicit := args.Changed("config")
+	if stdin && explicit && path != "" {
+		return errors.New("--config and --stdin-config are mutually exclusive")
+	}
+	if !stdin && !explicit {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return noProfile(profile)
+		}
+	}
+	if !stdin && path == "" {
+		return noProfile(profile)
+	}
+
+	noRemote, err := args.GetBool("no-remote-includes")
+	if err != nil {
+		return err
+	}
+	offline, err := args.GetBool("offline-includes")
+	if err != nil {
+		return err
+	}
+
+	remote := newIncludeFetcher(noRemote, offline)
+	var conf fileConfig
+	if stdin {
+		conf, err = loadStdinConfig(os.Stdin, profile, remote)
+	} else {
+		conf, err = loadConfig(path, profile, remote)
+	}
+	if err != nil {
+		return err
+	}
+	if err := applySettings(args, conf.Settings); err != nil {
+		return err
+	}
+	o.configSources = conf.sources
+	return conf.apply(&o.opt)
+}
+
+// noProfile fails when --profile selects a profile without a config file.
+func noProfile(profile string) error {
+	if profile != "" {
+		return fmt.Errorf("--profile %s needs a config file", profile)
+	}
+	return nil
+}
+
+// fileConfig is the layout of the orm config file.
+//
+//	include:
+//	  - ./shared/rules.yaml
+//	  - https://example.com/org-rules.yaml
+//	ignore:
+//	  - "*->created_at,updated_at"
+//	only_fields:
+//	  - "audit_log->id,action"
+//	retags:
+//	  - "*->created_at->c_date"
+//	regormtags:
+//	  - "*->created_at->-"
+//	rename:
+//	  user: user_base
+//	dao_tables:
+//	  - user
+//	data_types:
+//	  "*->tinyint": bool
+//	gen:
+//	  out_path: ./internal/dao
+//	  model_pkg_path: ./internal/model
+//	  field_nullable: true
+//	  mode: [default_query, query_interface]
+//	settings:
+//	  style: dao
+//	  dsn: ${DEV_DSN}
+//	  tables: [user, game]
+//	profiles:
+//	  prod:
+//	    gen:
+//	      out_path: ${OUT_PATH:-./internal/dao}
+//	    settings:
+//	      dsn: ${PROD_DSN}
+//
+// The settings are keyed by flag name and only apply to the flags given
+// neither on the command line nor in the environment. The gen section sets
+// the gen.Config fields it names over those of WithConfig. A file named
+// *.toml is read as TOML with the same keys, and the same layout is read as
+// JSON with --stdin-config.
+//
+// Each file is resolved before its includes are merged: the profile named by
+// --profile is deep-merged over the document first, then ${VAR} and
+// ${VAR:-default} are substituted in its string values, so a profile can
+// override a value holding a variable the environment lacks. A variable
+// without default must be set.
+type fileConfig struct {
+	Include    []string          `yaml:"include" json:"include" toml:"include"`
+	Ignore     []string          `yaml:"ignore" json:"ignore" toml:"ignore"`
+	OnlyFields []string          `yaml:"only_fields" json:"only_fields" toml:"only_fields"`
+	Retags     []string          `yaml:"retags" json:"retags" toml:"retags"`
+	ReGromTags []string          `yaml:"regormtags" json:"regormtags" toml:"regormtags"`
+	Rename     map[string]string `yaml:"rename" json:"rename" toml:"rename"`
+	DaoTables  []string          `yaml:"dao_tables" json:"dao_tables" toml:"dao_tables"`
+	DataTypes  map[string]string `yaml:"data_types" json:"data_types" toml:"data_types"`
+	SoftDelete []string          `yaml:"soft_delete" json:"soft_delete" toml:"soft_delete"`
+	Gen        genSettings       `yaml:"gen" json:"gen" toml:"gen"`
+	Settings   map[string]any    `yaml:"settings" json:"settings" toml:"settings"`
+	// Profiles are overlays of the document selected with --profile
+	Profiles map[string]fileConfig `yaml:"profiles" json:"profiles" toml:"profiles"`
+	// sources are the files merged into the config, includes first
+	sources []configSource
+}
+
+// configSource is a config file or document as decoded, before its includes.
+type configSource struct {
+	Name   string     `json:"name"`
+	Config fileConfig `json:"config"`
+}
+
+// genSettings are the gen.Config fields of the config file. Unset fields
+// keep the value given by WithConfig.
+type genSettings struct {
+	OutPath           string   `yaml:"out_path" json:"out_path" toml:"out_path"`
+	OutFile           string   `yaml:"out_file" json:"out_file" toml:"out_file"`
+	ModelPkgPath      string   `yaml:"model_pkg_path" json:"model_pkg_path" toml:"model_pkg_path"`
+	WithUnitTest      *bool    `yaml:"with_unit_test" json:"with_unit_test" toml:"with_unit_test"`
+	FieldNullable     *bool    `yaml:"field_nullable" json:"field_nullable" toml:"field_nullable"`
+	FieldCoverable    *bool    `yaml:"field_coverable" json:"field_coverable" toml:"field_coverable"`
+	FieldSignable     *bool    `yaml:"field_signable" json:"field_signable" toml:"field_signable"`
+	FieldWithIndexTag *bool    `yaml:"field_with_index_tag" json:"field_with_index_tag" toml:"field_with_index_tag"`
+	FieldWithTypeTag  *bool    `yaml:"field_with_type_tag" json:"field_with_type_tag" toml:"field_with_type_tag"`
+	Mode              []string `yaml:"mode" json:"mode" toml:"mode"`
+}
+
+// configLoader loads config files and resolves their includes.
+type configLoader struct {
+	remote includeFetcher
+	loaded map[string]bool
+	stack  []string
+	// profile is the profile of --profile, found once a file defines it
+	profile      string
+	profileFound bool
+}
+
+// loadConfig loads the config file at path with its includes merged in order
+// before its own rules, so the rules of the including file win.
+func loadConfig(path, profile string, remote includeFetcher) (fileConfig, error) {
+	l := &configLoader{remote: remote, loaded: make(map[string]bool), profile: profile}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fileConfig{}, err
+	}
+	conf, err := l.load(abs)
+	if err != nil {
+		return fileConfig{}, err
+	}
+	return conf, l.checkProfile()
+}
+
+// loadStdinConfig loads the JSON config document read from r, its relative
+// includes are resolved from the working directory.
+func loadStdinConfig(r io.Reader, profile string, remote includeFetcher) (fileConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", stdinSource, err)
+	}
+	conf, err := decodeJSONConfig(data)
+	if err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", stdinSource, err)
+	}
+
+	l := &configLoader{remote: remote, loaded: make(map[string]bool), stack: []string{stdinSource}, profile: profile}
+	if conf, err = l.resolve(conf); err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", stdinSource, err)
+	}
+	conf.sources = []configSource{{Name: stdinSource, Config: conf}}
+	if conf, err = l.includes(filepath.Join(".", stdinSource), conf); err != nil {
+		return fileConfig{}, err
+	}
+	return conf, l.checkProfile()
+}
+
+// checkProfile fails when no loaded file defines the profile of --profile.
+func (l *configLoader) checkProfile() error {
+	if l.profile != "" && !l.profileFound {
+		return fmt.Errorf("profile %q is not defined by the profiles of any config file", l.profile)
+	}
+	return nil
+}
+
+// load reads and merges one config file or URL.
+func (l *configLoader) load(source string) (fileConfig, error) {
+	if i := slices.Index(l.stack, source); i >= 0 {
+		return fileConfig{}, fmt.Errorf("include cycle: %s", chain(append(l.stack[i:], source)))
+	}
+	if l.loaded[source] {
+		// A file included twice, as in a diamond, is merged once
+		return fileConfig{}, nil
+	}
+	l.loaded[source] = true
+	l.stack = append(l.stack, source)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	var data []byte
+	var err error
+	if isRemote(source) {
+		data, err = l.remote.fetch(source)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", chain(l.stack), err)
+	}
+
+	conf, err := decodeConfig(source, data)
+	if err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", chain(l.stack), err)
+	}
+	if conf, err = l.resolve(conf); err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", chain(l.stack), err)
+	}
+	conf.sources = []configSource{{Name: source, Config: conf}}
+
+	return l.includes(source, conf)
+}
+
+// includes merges the includes of conf, loaded from source, before its rules.
+func (l *configLoader) includes(source string, conf fileConfig) (fileConfig, error) {
+	var merged fileConfig
+	for _, inc := range conf.Include {
+		inc, err := resolveInclude(source, inc)
+		if err != nil {
+			return fileConfig{}, fmt.Errorf("%s: %w", chain(l.stack), err)
+		}
+		included, err := l.load(inc)
+		if err != nil {
+			return fileConfig{}, err
+		}
+		merged = merged.merge(included)
+	}
+	return merged.merge(conf), nil
+}
+
+// merge returns c with the rules of other appended, other winning on conflicts.
+func (c fileConfig) merge(other fileConfig) fileConfig {
+	c.Ignore = append(c.Ignore, other.Ignore...)
+	c.OnlyFields = append(c.OnlyFields, other.OnlyFields...)
+	c.Retags = append(c.Retags, other.Retags...)
+	c.ReGromTags = append(c.ReGromTags, other.ReGromTags...)
+	c.DaoTables = append(c.DaoTables, other.DaoTables...)
+	c.SoftDelete = append(c.SoftDelete, other.SoftDelete...)
+	if len(other.Settings) > 0 {
+		settings := maps.Clone(c.Settings)
+		if settings == nil {
+			settings = make(map[string]any)
+		}
+		maps.Copy(settings, other.Settings)
+		c.Settings = settings
+	}
+	if len(other.Rename) > 0 {
+		rename := maps.Clone(c.Rename)
+		if rename == nil {
+			rename = make(map[string]string)
+		}
+		maps.Copy(rename, other.Rename)
+		c.Rename = rename
+	}
+	if len(other.DataTypes) > 0 {
+		types := maps.Clone(c.DataTypes)
+		if types == nil {
+			types = make(map[string]string)
+		}
+		maps.Copy(types, other.DataTypes)
+		c.DataTypes = types
+	}
+	c.Gen = c.Gen.merge(other.Gen)
+	c.Include = nil
+	c.sources = append(slices.Clip(c.sources), other.sources...)
+	return c
+}
+
+// merge returns g with the fields set in other replaced.
+func (g genSettings) merge(other genSettings) genSettings {
+	g.OutPath = cmp.Or(other.OutPath, g.OutPath)
+	g.OutFile = cmp.Or(other.OutFile, g.OutFile)
+	g.ModelPkgPath = cmp.Or(other.ModelPkgPath, g.ModelPkgPath)
+	g.WithUnitTest = cmp.Or(other.WithUnitTest, g.WithUnitTest)
+	g.FieldNullable = cmp.Or(other.FieldNullable, g.FieldNullable)
+	g.FieldCoverable = cmp.Or(other.FieldCoverable, g.FieldCoverable)
+	g.FieldSignable = cmp.Or(other.FieldSignable, g.FieldSignable)
+	g.FieldWithIndexTag = cmp.Or(other.FieldWithIndexTag, g.FieldWithIndexTag)
+	g.FieldWithTypeTag = cmp.Or(other.FieldWithTypeTag, g.FieldWithTypeTag)
+	if other.Mode != nil {
+		g.Mode = other.Mode
+	}
+	return g
+}
+
+// apply appends the config file rules to the code options, the renames, data
+// types and gen settings of the file win over those of the code.
+func (c fileConfig) apply(opt *OrmOption) error {
+	if err := c.Gen.apply(&opt.gconf); err != nil {
+		return err
+	}
+	opt.ignore = append(opt.ignore, c.Ignore...)
+	opt.onlyFields = append(opt.onlyFields, c.OnlyFields...)
+	opt.retags = append(opt.retags, c.Retags...)
+	opt.reGromTags = append(opt.reGromTags, c.ReGromTags...)
+	opt.daoTables = append(opt.daoTables, c.DaoTables...)
+	opt.softDelete = append(opt.softDelete, c.SoftDelete...)
+	if len(c.Rename) > 0 {
+		rename := maps.Clone(opt.rename)
+		if rename == nil {
+			rename = make(map[string]string)
+		}
+		maps.Copy(rename, c.Rename)
+		opt.rename = rename
+	}
+	if len(c.DataTypes) > 0 {
+		types := maps.Clone(opt.dataType)
+		if types == nil {
+			types = make(map[string]DataTypeFn)
+		}
+		for key, typ := range c.DataTypes {
+			types[key] = fixedType(typ)
+		}
+		opt.dataType = types
+	}
+	return nil
+}
+
+// apply sets the fields of conf given in the gen section.
+func (g genSettings) apply(conf *gen.Config) error {
+	var mode gen.GenerateMode
+	for i, name := range g.Mode {
+		bit, ok := genModeNames[name]
+		if !ok {
+			return fmt.Errorf("$.gen.mode[%d]: invalid value %q, must be one of %s", i, name, strings.Join(slices.Sorted(maps.Keys(genModeNames)), ", "))
+		}
+		mode |= bit
+	}
+	if g.Mode != nil {
+		conf.Mode = mode
+	}
+
+	conf.OutPath = cmp.Or(g.OutPath, conf.OutPath)
+	conf.OutFile = cmp.Or(g.OutFile, conf.OutFile)
+	conf.ModelPkgPath = cmp.Or(g.ModelPkgPath, conf.ModelPkgPath)
+	for field, set := range map[*bool]*bool{
+		&conf.WithUnitTest:      g.WithUnitTest,
+		&conf.FieldNullable:     g.FieldNullable,
+		&conf.FieldCoverable:    g.FieldCoverable,
+		&conf.FieldSignable:     g.FieldSignable,
+		&conf.FieldWithIndexTag: g.FieldWithIndexTag,
+		&conf.FieldWithTypeTag:  g.FieldWithTypeTag,
+	} {
+		if set != nil {
+			*field = *set
+		}
+	}
+	return nil
+}
+
+// isRemote reports whether an include refers to a URL. Only https URLs are
+// fetched, a plain http include is refused by the fetcher.
+func isRemote(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// chain formats an include chain.
+func chain(sources []string) string {
+	return strings.Join(sources, " -> ")
+}
+
+// resolveInclude resolves an include relative to the file or URL including it.
+func resolveInclude(source, inc string) (string, error) {
+	if isRemote(inc) || filepath.IsAbs(inc) {
+		return inc, nil
+	}
+	if !isRemote(source) {
+		return filepath.Join(filepath.Dir(source), inc), nil
+	}
+
+	base, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(inc)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
@@ -0,0 +1,213 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// exampleYAMLConfig is the commented config file written by orm config init.
+const exampleYAMLConfig = `# Generation rules of command orm, loaded from ./czx.yaml or --config.
+# Rules are written as table->column->value, * matches every table.
+
+# Config files merged before this one, their rules lose on conflicts.
+# include:
+#   - ./shared/rules.yaml
+
+# Columns left out of the models.
+ignore:
+  - "*->deleted_at"
+
+# JSON tags of columns.
+retags:
+  - "*->created_at->c_date"
+
+# gorm column tags of columns, - drops the column from gorm.
+regormtags: []
+
+# File names of tables.
+rename: {}
+
+# Tables getting a dao, * for all.
+dao_tables:
+  - "*"
+
+# Go types of database types, by table.
+data_types:
+  "*->tinyint": bool
+
+# Nullable timestamps mapped to gorm.DeletedAt, *->deleted_at when empty.
+# soft_delete:
+#   - "*->deleted_at"
+
+# gen.Config fields, paths are relative to the working directory.
+gen:
+  out_path: ./dao
+  model_pkg_path: ./model
+  # out_file: gen.go
+  field_nullable: false
+  field_coverable: false
+  field_signable: false
+  field_with_index_tag: false
+  field_with_type_tag: true
+  # default_query, without_context and query_interface
+  mode: [default_query, query_interface]
+
+# Flag values by flag name, flags and CZX_ORM_* variables win.
+settings:
+  driver: mysql
+  # style: dao
+  # dsn: root:root@tcp(127.0.0.1:3306)/amg
+  # tables: [user, game]
+
+# Overlays selected with --profile, deep-merged before ${VAR} and
+# ${VAR:-default} are substituted from the environment.
+# profiles:
+#   prod:
+#     gen:
+#       out_path: ${OUT_PATH:-./internal/dao}
+#     settings:
+#       dsn: ${PROD_DSN}
+`
+
+// exampleTOMLConfig is exampleYAMLConfig written as TOML.
+const exampleTOMLConfig = `# Generation rules of command orm, loaded with --config.
+# Rules are written as table->column->value, * matches every table.
+
+# Config files merged before this one, their rules lose on conflicts.
+# include = ["./shared/rules.toml"]
+
+# Columns left out of the models.
+ignore = ["*->deleted_at"]
+
+# JSON tags of columns.
+retags = ["*->created_at->c_date"]
+
+# gorm column tags of columns, - drops the column from gorm.
+regormtags = []
+
+# Tables getting a dao, * for all.
+dao_tables = ["*"]
+
+# Nullable timestamps mapped to gorm.DeletedAt, *->deleted_at when empty.
+# soft_delete = ["*->deleted_at"]
+
+# File names of tables.
+[rename]
+
+# Go types of database types, by table.
+[data_types]
+"*->tinyint" = "bool"
+
+# gen.Config fields, paths are relative to the working directory.
+[gen]
+out_path = "./dao"
+model_pkg_path = "./model"
+# out_file = "gen.go"
+field_nullable = false
+field_coverable = false
+field_signable = false
+field_with_index_tag = false
+field_with_type_tag = true
+# default_query, without_context and query_interface
+mode = ["default_query", "query_interface"]
+
+# Flag values by flag name, flags and CZX_ORM_* variables win.
+[settings]
+driver = "mysql"
+# style = "dao"
+# dsn = "root:root@tcp(127.0.0.1:3306)/amg"
+# tables = ["user", "game"]
+
+# Overlays selected with --profile, deep-merged before ${VAR} and
+# ${VAR:-default} are substituted from the environment.
+# [profiles.prod.gen]
+# out_path = "${OUT_PATH:-./internal/dao}"
+# [profiles.prod.settings]
+# dsn = "${PROD_DSN}"
+`
+
+// configCommand returns the orm config subcommand.
+func (o *Orm) configCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the orm config file",
+		Args:  cobra.NoArgs,
+	}
+	c.AddCommand(o.configInitCommand())
+	return c
+}
+
+// configInitCommand returns the orm config init subcommand.
+func (o *Orm) configInitCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "init",
+		Short: "Write a commented example config file",
+		Long: `Write a commented example config file to the path of --config, ./czx.yaml
+by default. A path ending in .toml gets the example in TOML. An existing file
+is only replaced with --force.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Write ./czx.yaml
+command orm config init
+
+# Write the example as TOML
+command orm config init --config ./orm.toml`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).configInit),
+	}
+	c.Flags().Bool("force", false, cmd.T("orm.config.init.flag.force"))
+	return c
+}
+
+// configInit is the execution logic for the orm config init command.
+func (o *Orm) configInit(c *cobra.Command, _ []string) error {
+	path, err := c.Flags().GetString("config")
+	if err != nil {
+		return err
+	}
+	force, err := c.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return usage("writing the config", errors.New("--config is empty"))
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) && !force {
+		return usage("writing the config", fmt.Errorf("%s exists, pass --force to replace it", path))
+	}
+
+	example := exampleYAMLConfig
+	if isTOML(path) {
+		example = exampleTOMLConfig
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fail("writing the config", err)
+	}
+	if err := writeFileAtomic(path, []byte(example), 0644); err != nil {
+		return fail("writing the config", err)
+	}
+	color.Green("Wrote %s\n", path)
+	return nil
+}
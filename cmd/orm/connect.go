@@ -0,0 +1,218 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// maxConnectBackoff caps the delay between two connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
+// dialer opens a new database connection.
+type dialer func() (*gorm.DB, error)
+
+// open resolves the database connections for the current run.
+// A connection injected with WithDB is used as-is, otherwise a new one is
+// opened from the --dsn flag with the --driver dialector and pinged. Metadata is read from the --introspect-dsn
+// connection when given, from the same connection otherwise. New connections
+// optionally go through an SSH tunnel, are retried with exponential backoff
+// and read in a read-only transaction where the driver has one, begun by
+// every request instead with txPerRequest. The metadata
+// server must be at least the --min-db-version of its flavor.
+// The returned func ends the transactions and releases the tunnel. Queries of
+// the metadata connection are rate limited by --introspect-qps, writes to
+// either connection fail with --read-only.
+func (o *Orm) open(ctx context.Context, args *pflag.FlagSet) (func(), error) {
+	qps, err := args.GetFloat64("introspect-qps")
+	if err != nil {
+		return nil, err
+	}
+	readOnly, err := args.GetBool("read-only")
+	if err != nil {
+		return nil, err
+	}
+	closeConn, err := o.dialAll(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.checkDBVersion(args); err != nil {
+		closeConn()
+		return nil, err
+	}
+	if err := o.limitQPS(qps); err != nil {
+		closeConn()
+		return nil, err
+	}
+	if readOnly {
+		for _, db := range []*gorm.DB{o.opt.db, o.meta} {
+			if err := guardWrites(db); err != nil {
+				closeConn()
+				return nil, err
+			}
+		}
+	}
+	return closeConn, nil
+}
+
+// dialAll sets the generation and metadata connections.
+func (o *Orm) dialAll(ctx context.Context, args *pflag.FlagSet) (func(), error) {
+	dsn, err := args.GetString("dsn")
+	if err != nil {
+		return nil, err
+	}
+	introspectDSN, err := args.GetString("introspect-dsn")
+	if err != nil {
+		return nil, err
+	}
+	driver, err := args.GetString("driver")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dialector(driver, ""); err != nil {
+		return nil, err
+	}
+	if o.opt.db == nil && dsn == "" {
+		return nil, ErrNoDB
+	}
+	if o.opt.db != nil && introspectDSN == "" {
+		o.meta = o.opt.db
+		return func() {}, nil
+	}
+
+	retries, err := args.GetInt("connect-retries")
+	if err != nil {
+		return nil, err
+	}
+	backoff, err := args.GetDuration("connect-backoff")
+	if err != nil {
+		return nil, err
+	}
+
+	rewrite, closeTunnel, err := tunnel(args, driver)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConnect, err)
+	}
+	dial := func(dsn string) (*gorm.DB, error) {
+		dsn, err := rewrite(dsn)
+		if err != nil {
+			return nil, err
+		}
+		d, err := dialector(driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connect(ctx, func() (*gorm.DB, error) {
+			return gorm.Open(d)
+		}, retries, backoff)
+	}
+
+	// The opened connections read in read-only transactions, ended before
+	// the tunnel is released
+	ends := []func(){closeTunnel}
+	end := func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+	open := func(dsn string) (*gorm.DB, error) {
+		db, err := dial(dsn)
+		if err != nil || o.txPerRequest {
+			return db, err
+		}
+		tx, endTx, err := readOnlyTx(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("begin read-only transaction: %w", err)
+		}
+		ends = append(ends, endTx)
+		return tx, nil
+	}
+
+	if o.opt.db == nil {
+		if o.opt.db, err = open(dsn); err != nil {
+			end()
+			return nil, err
+		}
+	}
+	o.meta = o.opt.db
+	if introspectDSN != "" {
+		if o.meta, err = open(introspectDSN); err != nil {
+			end()
+			return nil, err
+		}
+	}
+	return end, nil
+}
+
+// connect dials the database and pings it, retrying up to retries times.
+// The wait between attempts doubles every time, with up to 50% jitter added,
+// and is aborted as soon as ctx is cancelled.
+func connect(ctx context.Context, dial dialer, retries int, backoff time.Duration) (*gorm.DB, error) {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var db *gorm.DB
+		cmd.Debugf("Connecting to database (attempt %d/%d)", attempt+1, retries+1)
+		if db, err = dial(); err == nil {
+			if err = ping(ctx, db); err == nil {
+				return db, nil
+			}
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			break
+		}
+
+		wait := backoffDelay(backoff, attempt)
+		cmd.Debugf("Connection failed: %v, retrying in %s", err, wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return nil, fmt.Errorf("%w: connect after %d attempt(s): %w", ErrConnect, retries+1, err)
+}
+
+// ping checks the connection is alive and closes it when it is not.
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return err
+	}
+	return nil
+}
+
+// backoffDelay returns the wait before the next attempt.
+func backoffDelay(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+	delay := backoff << attempt
+	if delay <= 0 || delay > maxConnectBackoff {
+		delay = maxConnectBackoff
+	}
+	return delay + rand.N(delay/2+1)
+}
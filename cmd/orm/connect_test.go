@@ -0,0 +1,108 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// failingDialer fails the first n dials, then opens an in-memory database.
+type failingDialer struct {
+	n     int
+	calls int
+}
+
+func (d *failingDialer) dial() (*gorm.DB, error) {
+	d.calls++
+	if d.calls <= d.n {
+		return nil, errors.New("connection refused")
+	}
+	return gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+}
+
+func TestConnectRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		retries  int
+		calls    int
+		ok       bool
+	}{
+		{name: "first attempt", failures: 0, retries: 0, calls: 1, ok: true},
+		{name: "no retries", failures: 1, retries: 0, calls: 1},
+		{name: "recovers", failures: 2, retries: 3, calls: 3, ok: true},
+		{name: "last attempt", failures: 3, retries: 3, calls: 4, ok: true},
+		{name: "exhausted", failures: 5, retries: 3, calls: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &failingDialer{n: tt.failures}
+			db, err := connect(context.Background(), d.dial, tt.retries, time.Millisecond)
+			if d.calls != tt.calls {
+				t.Errorf("dialed %d times, want %d", d.calls, tt.calls)
+			}
+			if tt.ok {
+				if err != nil || db == nil {
+					t.Fatalf("connect = %v, %v", db, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrConnect) {
+				t.Errorf("connect error = %v, want ErrConnect", err)
+			}
+		})
+	}
+}
+
+func TestConnectCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &failingDialer{n: 100}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := connect(ctx, d.dial, 100, time.Hour)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("connect error = %v, want context.Canceled", err)
+	}
+	if d.calls != 1 || time.Since(start) > 10*time.Second {
+		t.Errorf("cancelled wait: %d dials in %s", d.calls, time.Since(start))
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	if got := backoffDelay(0, 3); got != 0 {
+		t.Errorf("no backoff waits %s", got)
+	}
+	for attempt, base := range []time.Duration{100, 200, 400, 800} {
+		base *= time.Millisecond
+		got := backoffDelay(100*time.Millisecond, attempt)
+		if got < base || got > base+base/2 {
+			t.Errorf("attempt %d waits %s, want [%s, %s]", attempt, got, base, base+base/2)
+		}
+	}
+	if got := backoffDelay(time.Second, 62); got > maxConnectBackoff+maxConnectBackoff/2 {
+		t.Errorf("overflowing backoff waits %s", got)
+	}
+}
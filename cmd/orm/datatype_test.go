@@ -0,0 +1,119 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestDataTypesScopedToTable(t *testing.T) {
+	typ := func(name string) DataTypeFn {
+		return func(gorm.ColumnType) string { return name }
+	}
+	tests := []struct {
+		name  string
+		types map[string]DataTypeFn
+		// fields are the expected field types by table and field
+		fields map[string]map[string]string
+	}{
+		{
+			name:  "table",
+			types: map[string]DataTypeFn{"customers->datetime": typ("int64")},
+			fields: map[string]map[string]string{
+				"customers": {"CreatedAt": "int64"},
+				"orders":    {"CreatedAt": "time.Time"},
+			},
+		},
+		{
+			name:  "global and table",
+			types: map[string]DataTypeFn{"*->varchar": typ("[]byte"), "orders->varchar": typ("sql.NullString")},
+			fields: map[string]map[string]string{
+				"customers": {"Status": "[]byte", "Email": "[]byte", "CreatedAt": "time.Time"},
+				"orders":    {"Status": "sql.NullString", "Total": "float64"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, order := range [][]string{{"customers", "orders"}, {"orders", "customers"}} {
+			files := generate(t, openFixture(t, "shop"), []string{"-t", order[0], "-t", order[1]}, WithDataType(tt.types))
+			for table, fields := range tt.fields {
+				for name, want := range fields {
+					if f := strings.Fields(fieldLine(files["model/"+table+".gen.go"], name)); len(f) < 2 || f[1] != want {
+						t.Errorf("%s %v: %s.%s = %v, want %s", tt.name, order, table, name, f, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTableDataTypes(t *testing.T) {
+	byTable := func(table string, _ gorm.ColumnType) string {
+		if table == "orders" {
+			return "sql.NullString"
+		}
+		return "[]byte"
+	}
+	tests := []struct {
+		name  string
+		types map[string]TableDataTypeFn
+		// fields are the expected field types by table and field
+		fields map[string]map[string]string
+	}{
+		{
+			name:  "global",
+			types: map[string]TableDataTypeFn{"*->varchar": byTable},
+			fields: map[string]map[string]string{
+				"customers": {"Status": "[]byte", "Email": "[]byte"},
+				"orders":    {"Status": "sql.NullString"},
+			},
+		},
+		{
+			name:  "table",
+			types: map[string]TableDataTypeFn{"orders->varchar": byTable},
+			fields: map[string]map[string]string{
+				"customers": {"Status": "string"},
+				"orders":    {"Status": "sql.NullString"},
+			},
+		},
+		{
+			name: "global and table",
+			types: map[string]TableDataTypeFn{
+				"*->varchar":         byTable,
+				"customers->varchar": func(string, gorm.ColumnType) string { return "int64" },
+			},
+			fields: map[string]map[string]string{
+				"customers": {"Status": "int64"},
+				"orders":    {"Status": "sql.NullString"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, order := range [][]string{{"customers", "orders"}, {"orders", "customers"}} {
+			files := generate(t, openFixture(t, "shop"), []string{"-t", order[0], "-t", order[1]}, WithTableDataType(tt.types))
+			for table, fields := range tt.fields {
+				for name, want := range fields {
+					if f := strings.Fields(fieldLine(files["model/"+table+".gen.go"], name)); len(f) < 2 || f[1] != want {
+						t.Errorf("%s %v: %s.%s = %v, want %s", tt.name, order, table, name, f, want)
+					}
+				}
+			}
+		}
+	}
+}
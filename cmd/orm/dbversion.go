@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// Features left out of the run on a server older than the minimum version
+// with --allow-old-db.
+const (
+	degradedComments  = "comments"
+	degradedGenerated = "generated-column detection"
+)
+
+var (
+	// minDBVersions are the oldest supported server versions by flavor.
+	minDBVersions = map[string]string{
+		"mysql":    "5.7",
+		"mariadb":  "10.2",
+		"postgres": "12",
+	}
+	// serverVersionSQL selects the server version by dialect. The dialects
+	// without one are not checked.
+	serverVersionSQL = map[string]string{
+		"mysql":    "SELECT VERSION()",
+		"postgres": "SHOW server_version",
+	}
+	// dbVersionNumber matches the leading major.minor.patch of a version.
+	dbVersionNumber = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?`)
+)
+
+// queryServerVersion returns the version string of the server of db.
+var queryServerVersion = func(db *gorm.DB) (string, error) {
+	var version string
+	err := db.Raw(serverVersionSQL[db.Dialector.Name()]).Row().Scan(&version)
+	return version, err
+}
+
+// dbVersion is the version of a database server.
+type dbVersion struct {
+	// Flavor is mysql, mariadb or postgres
+	Flavor string
+	Parts  [3]int
+}
+
+// parseDBVersion parses the version string of a server of dialect, such as
+// "8.0.36-0ubuntu0.22.04.1", "12.3 (Debian 12.3-1.pgdg100+1)" or the MariaDB
+// ones reported by the mysql dialect, "10.6.12-MariaDB-1:10.6.12+maria~ubu2004"
+// and "5.5.5-10.3.39-MariaDB" when prefixed for the replication protocol.
+func parseDBVersion(dialect, s string) (dbVersion, error) {
+	v := dbVersion{Flavor: dialect}
+	s = strings.TrimSpace(s)
+	if dialect == "mysql" && strings.Contains(strings.ToLower(s), "mariadb") {
+		v.Flavor = "mariadb"
+		s = strings.TrimPrefix(s, "5.5.5-")
+	}
+	m := dbVersionNumber.FindStringSubmatch(s)
+	if m == nil {
+		return v, fmt.Errorf("unrecognized %s server version %q", dialect, s)
+	}
+	for i, part := range m[1:] {
+		if part != "" {
+			v.Parts[i], _ = strconv.Atoi(part)
+		}
+	}
+	return v, nil
+}
+
+// less reports whether v is older than w.
+func (v dbVersion) less(w dbVersion) bool {
+	for i := range v.Parts {
+		if v.Parts[i] != w.Parts[i] {
+			return v.Parts[i] < w.Parts[i]
+		}
+	}
+	return false
+}
+
+func (v dbVersion) String() string {
+	// Trailing zero parts are dropped: 5.7, 12
+	n := 1
+	for i := 1; i < len(v.Parts); i++ {
+		if v.Parts[i] != 0 {
+			n = i + 1
+		}
+	}
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = strconv.Itoa(v.Parts[i])
+	}
+	return strings.Join(parts, ".")
+}
+
+// minDBVersion returns the minimum version of a flavor, overridden by the
+// --min-db-version entries: flavor=version, or a bare version applying to
+// the flavor of the server.
+func minDBVersion(flavor string, overrides []string) (dbVersion, error) {
+	minimum := minDBVersions[flavor]
+	for _, entry := range overrides {
+		name, version, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, version = flavor, entry
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := minDBVersions[name]; !known {
+			return dbVersion{}, cmd.Exit(cmd.ExitUsage, fmt.Errorf("--min-db-version %q: unknown flavor %s, use mysql, mariadb or postgres", entry, name))
+		}
+		if _, err := parseDBVersion(name, version); err != nil {
+			return dbVersion{}, cmd.Exit(cmd.ExitUsage, fmt.Errorf("--min-db-version %q: %w", entry, err))
+		}
+		if name == flavor {
+			minimum = version
+		}
+	}
+	return parseDBVersion(flavor, minimum)
+}
+
+// checkDBVersion compares the version of the metadata server to the minimum
+// of its flavor. An older server fails the run, or with --allow-old-db leaves
+// out the comments and the generated-column detection, which its metadata
+// may lack, recording them in the report of the run. Replays and the
+// dialects without a version query are not checked.
+func (o *Orm) checkDBVersion(args *pflag.FlagSet) error {
+	if _, ok := snapshotOf(o.meta); ok {
+		return nil
+	}
+	if _, ok := serverVersionSQL[o.meta.Dialector.Name()]; !ok {
+		return nil
+	}
+	overrides, err := args.GetStringSlice("min-db-version")
+	if err != nil {
+		return err
+	}
+	allowOld, err := args.GetBool("allow-old-db")
+	if err != nil {
+		return err
+	}
+
+	raw, err := queryServerVersion(o.meta)
+	if err != nil {
+		return fmt.Errorf("reading the server version: %w", err)
+	}
+	version, err := parseDBVersion(o.meta.Dialector.Name(), raw)
+	if err != nil {
+		return err
+	}
+	minimum, err := minDBVersion(version.Flavor, overrides)
+	if err != nil {
+		return err
+	}
+	if !version.less(minimum) {
+		return nil
+	}
+
+	if !allowOld {
+		return &OldDBError{Flavor: version.Flavor, Version: raw, Minimum: minimum.String()}
+	}
+	o.degraded = []string{degradedComments, degradedGenerated}
+	if o.progress != nil {
+		o.progress.degraded = o.degraded
+	}
+	o.warn(warnDatabase, "Warning: %s server %s is older than the supported %s, generating without %s\n",
+		version.Flavor, raw, minimum, strings.Join(o.degraded, " and "))
+	return nil
+}
+
+// isDegraded reports whether a feature is left out by --allow-old-db.
+func (o *Orm) isDegraded(feature string) bool {
+	return slices.Contains(o.degraded, feature)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// namedDialector is a sqlite dialector reporting the name of another dialect.
+type namedDialector struct {
+	*sqlite.Dialector
+	name string
+}
+
+func (d namedDialector) Name() string {
+	return d.name
+}
+
+func TestParseDBVersion(t *testing.T) {
+	for _, tt := range []struct {
+		dialect, version string
+		flavor           string
+		parts            [3]int
+	}{
+		{"mysql", "8.0.36-0ubuntu0.22.04.1", "mysql", [3]int{8, 0, 36}},
+		{"mysql", "5.7", "mysql", [3]int{5, 7, 0}},
+		{"mysql", "10.6.12-MariaDB-1:10.6.12+maria~ubu2004", "mariadb", [3]int{10, 6, 12}},
+		{"mysql", "5.5.5-10.3.39-MariaDB", "mariadb", [3]int{10, 3, 39}},
+		{"postgres", "12.3 (Debian 12.3-1.pgdg100+1)", "postgres", [3]int{12, 3, 0}},
+		{"postgres", " 16beta1\n", "postgres", [3]int{16, 0, 0}},
+	} {
+		v, err := parseDBVersion(tt.dialect, tt.version)
+		if err != nil {
+			t.Errorf("parse %q: %v", tt.version, err)
+			continue
+		}
+		if v.Flavor != tt.flavor || v.Parts != tt.parts {
+			t.Errorf("parse %q = %s %v, want %s %v", tt.version, v.Flavor, v.Parts, tt.flavor, tt.parts)
+		}
+	}
+	if _, err := parseDBVersion("mysql", "unknown"); err == nil {
+		t.Error("parsed an unrecognized version")
+	}
+}
+
+func TestCheckDBVersion(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		dialect  string
+		version  string
+		queryErr error
+		args     []string
+		err      string
+		code     int
+		degraded bool
+	}{
+		{name: "supported mysql", dialect: "mysql", version: "8.0.36"},
+		{name: "minimum mysql", dialect: "mysql", version: "5.7.0-log"},
+		{name: "old mysql", dialect: "mysql", version: "5.5.62", err: "mysql server 5.5.62 is older than the supported 5.7", code: cmd.ExitRefused},
+		{name: "old mysql allowed", dialect: "mysql", version: "5.5.62", args: []string{"--allow-old-db"}, degraded: true},
+		{name: "supported mariadb", dialect: "mysql", version: "5.5.5-10.3.39-MariaDB"},
+		{name: "old mariadb", dialect: "mysql", version: "10.1.48-MariaDB", err: "mariadb server 10.1.48-MariaDB is older than the supported 10.2", code: cmd.ExitRefused},
+		{name: "supported postgres", dialect: "postgres", version: "12.3 (Debian 12.3-1.pgdg100+1)"},
+		{name: "old postgres", dialect: "postgres", version: "11.22", err: "postgres server 11.22 is older than the supported 12", code: cmd.ExitRefused},
+		{name: "raised minimum", dialect: "mysql", version: "5.7.44", args: []string{"--min-db-version", "mysql=8.0"}, err: "older than the supported 8", code: cmd.ExitRefused},
+		{name: "lowered minimum", dialect: "mysql", version: "5.5.62", args: []string{"--min-db-version", "5.5"}},
+		{name: "minimum of another flavor", dialect: "mysql", version: "5.5.62", args: []string{"--min-db-version", "postgres=9.6"}, err: "older than the supported 5.7", code: cmd.ExitRefused},
+		{name: "unknown flavor", dialect: "mysql", version: "8.0.36", args: []string{"--min-db-version", "oracle=19"}, err: "unknown flavor oracle", code: cmd.ExitUsage},
+		{name: "invalid minimum", dialect: "mysql", version: "8.0.36", args: []string{"--min-db-version", "mysql=latest"}, err: `unrecognized mysql server version "latest"`, code: cmd.ExitUsage},
+		{name: "unrecognized version", dialect: "mysql", version: "unknown", err: `unrecognized mysql server version "unknown"`, code: cmd.ExitFailure},
+		{name: "query failure", dialect: "postgres", queryErr: errors.New("permission denied"), err: "reading the server version: permission denied", code: cmd.ExitFailure},
+		{name: "dialect without version query", dialect: "sqlite", version: "1.0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			queried := false
+			query := queryServerVersion
+			queryServerVersion = func(*gorm.DB) (string, error) {
+				queried = true
+				return tt.version, tt.queryErr
+			}
+			defer func() { queryServerVersion = query }()
+
+			db, err := gorm.Open(namedDialector{sqlite.Open(":memory:").(*sqlite.Dialector), tt.dialect}, &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := NewOrmCommand().Command()
+			if err := c.PersistentFlags().Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			o := newOrm(OrmOption{})
+			o.meta = db
+
+			err = o.checkDBVersion(c.PersistentFlags())
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("check = %v, want an error with %q", err, tt.err)
+				}
+				if code := cmd.ExitCode(err, true); code != tt.code {
+					t.Errorf("exit code %d, want %d", code, tt.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if queried != (tt.dialect != "sqlite") {
+				t.Errorf("version queried: %v", queried)
+			}
+			want := []string(nil)
+			if tt.degraded {
+				want = []string{degradedComments, degradedGenerated}
+			}
+			if !slices.Equal(o.degraded, want) {
+				t.Errorf("degraded %v, want %v", o.degraded, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"gorm.io/gen"
+)
+
+// deprecatedMarker marks a soon-to-be-dropped column in its comment.
+const deprecatedMarker = "[deprecated]"
+
+// Handling of deprecated columns, selected with --deprecated.
+const (
+	// deprecatedIgnore generates deprecated columns like any other column
+	deprecatedIgnore = "ignore"
+	// deprecatedComment adds a "Deprecated:" doc comment to the field
+	deprecatedComment = "comment"
+	// deprecatedExclude drops deprecated columns like an ignore rule
+	deprecatedExclude = "exclude"
+)
+
+// deprecatedOpts records the deprecated columns of a table and returns the
+// model options of the selected deprecation mode.
+func (o *Orm) deprecatedOpts(table string, columns []columnMeta) []gen.ModelOpt {
+	if o.deprecatedMode == deprecatedIgnore {
+		return nil
+	}
+
+	var deprecated []string
+	for _, col := range columns {
+		if strings.Contains(strings.ToLower(col.Comment), deprecatedMarker) {
+			deprecated = append(deprecated, col.Name)
+		}
+	}
+	if len(deprecated) == 0 {
+		return nil
+	}
+
+	o.deprecated[table] = deprecated
+	if o.deprecatedMode == deprecatedExclude {
+		return []gen.ModelOpt{gen.FieldIgnore(deprecated...)}
+	}
+	return nil
+}
+
+// deprecatedPass adds a "Deprecated:" doc comment above the deprecated fields
+// of a table, so staticcheck flags their usage.
+func (o *Orm) deprecatedPass(table string, src []byte) ([]byte, error) {
+	deprecated, ok := o.deprecated[table]
+	if !ok || o.deprecatedMode != deprecatedComment {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the start of line offset of every deprecated field
+	lines := make(map[int]string)
+	columns := o.fields[table]
+	ast.Inspect(file, func(n ast.Node) bool {
+		st, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, f := range st.Fields.List {
+			if len(f.Names) != 1 {
+				continue
+			}
+			column := columns[f.Names[0].Name]
+			if !slices.Contains(deprecated, column) {
+				continue
+			}
+			start := fset.Position(f.Pos()).Offset
+			start = bytes.LastIndexByte(src[:start], '\n') + 1
+			lines[start] = fmt.Sprintf("\t// Deprecated: column %s is marked %s and will be dropped.\n", column, deprecatedMarker)
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	for i, b := range src {
+		if line, ok := lines[i]; ok {
+			buf.WriteString(line)
+		}
+		buf.WriteByte(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// deprecatedSummary prints the deprecated columns encountered during the run.
+func (o *Orm) deprecatedSummary() {
+	if len(o.deprecated) == 0 {
+		return
+	}
+
+	var columns []string
+	for table, cols := range o.deprecated {
+		for _, col := range cols {
+			columns = append(columns, table+"."+col)
+		}
+	}
+	sort.Strings(columns)
+	color.Yellow("\nDeprecated columns (%s): %s\n", o.deprecatedMode, strings.Join(columns, ", "))
+}
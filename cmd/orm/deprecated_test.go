@@ -0,0 +1,39 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeprecatedModes(t *testing.T) {
+	for _, mode := range []string{deprecatedIgnore, deprecatedComment, deprecatedExclude} {
+		t.Run(mode, func(t *testing.T) {
+			files := generate(t, openFixture(t, "deprecated"), []string{"-t", "accounts", "--style", "dao", "--deprecated", mode}, WithDaoTables([]string{"accounts"}))
+			golden(t, "deprecated_"+mode+".golden", files["model/accounts.gen.go"])
+
+			// Excluded columns are left out of the queries too
+			dao := files["dao/accounts.gen.go"]
+			if dao == "" {
+				t.Fatalf("no dao generated: %v", keys(files))
+			}
+			if got := strings.Contains(dao, "LegacyCode"); got == (mode == deprecatedExclude) {
+				t.Errorf("dao mentions LegacyCode: %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type (
+	// describeTable is the introspected metadata of a table and the model
+	// the current rules generate from it.
+	describeTable struct {
+		Table     string           `json:"table"`
+		Model     string           `json:"model"`
+		RLSColumn string           `json:"rlsColumn,omitempty"`
+		Columns   []describeColumn `json:"columns"`
+	}
+	// describeColumn is a column and the field generated for it, without a
+	// field when the rules ignore the column.
+	describeColumn struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Nullable bool   `json:"nullable"`
+		Key      string `json:"key,omitempty"`
+		Extra    string `json:"extra,omitempty"`
+		Comment  string `json:"comment,omitempty"`
+		Field    string `json:"field,omitempty"`
+		GoType   string `json:"goType,omitempty"`
+		Tags     string `json:"tags,omitempty"`
+		// Examples are the sampled values of the column with --sample
+		Examples []string `json:"examples,omitempty"`
+	}
+)
+
+// describeCommand returns the orm describe subcommand.
+func (o *Orm) describeCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "describe",
+		Short: "Preview the introspected tables and the fields the rules generate",
+		Long: `Print the column metadata read by the introspection of the listed tables,
+or of all tables, with the field name, Go type and tags the current rule set
+generates for each column. Nothing is written.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Describe the user table
+command orm describe -t user
+
+# Describe every table as JSON
+command orm describe --output json`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).describe),
+	}
+	c.Flags().StringArrayP("tables", "t", nil, cmd.T("orm.describe.flag.tables"))
+	c.Flags().String("output", "text", cmd.T("orm.describe.flag.output"))
+	return c
+}
+
+// describe is the execution logic for the orm describe command.
+func (o *Orm) describe(c *cobra.Command, _ []string) error {
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output != "text" && output != "json" {
+		return usage("describing tables", fmt.Errorf("invalid output: %s, must be text or json", output))
+	}
+	tables, err := c.Flags().GetStringArray("tables")
+	if err != nil {
+		return err
+	}
+
+	closeConn, err := o.prepare(c)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	described, err := o.execDescribe(tables)
+	if err != nil {
+		return fail("describing tables", err)
+	}
+	if output == "json" {
+		data, err := json.MarshalIndent(described, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printDescribe(described)
+	return nil
+}
+
+// execDescribe runs the model generation without writing files and pairs the
+// introspected columns with the generated fields.
+func (o *Orm) execDescribe(tables []string) ([]describeTable, error) {
+	o.deprecatedMode = deprecatedComment
+	o.introspectBatch = 50
+	o.acronyms = mergeAcronyms(o.opt.acronyms, nil)
+	if err := o.model(tables...); err != nil {
+		return nil, err
+	}
+	return o.describeStructs(), nil
+}
+
+// describeStructs pairs the introspected columns of the generated structs
+// with their fields.
+func (o *Orm) describeStructs() []describeTable {
+	var described []describeTable
+	for _, meta := range o.structs {
+		table := metaString(meta, "TableName")
+		if table == "" {
+			continue
+		}
+		fields := modelFields(meta)
+		dt := describeTable{Table: table, Model: metaString(meta, "ModelStructName"), RLSColumn: o.rls[table], Columns: []describeColumn{}}
+		for _, col := range o.columns[table] {
+			dc := describeColumn{
+				Name:     col.Name,
+				Type:     col.Type,
+				Nullable: col.Nullable == "YES",
+				Key:      col.Key,
+				Extra:    col.Extra,
+				Comment:  col.Comment,
+				Examples: o.samples[table][col.Name],
+			}
+			if dc.Type == "" {
+				dc.Type = col.DataType
+			}
+			if f, ok := fields[col.Name]; ok {
+				dc.Field, dc.GoType, dc.Tags = f[0], f[1], f[2]
+			}
+			dt.Columns = append(dt.Columns, dc)
+		}
+		described = append(described, dt)
+	}
+	return described
+}
+
+// modelFields returns the name, Go type and tags of the fields of a generated
+// struct meta by column name.
+func modelFields(meta any) map[string][3]string {
+	fields := make(map[string][3]string)
+	v := reflect.ValueOf(meta)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fields
+	}
+	list := v.Elem().FieldByName("Fields")
+	if list.Kind() != reflect.Slice {
+		return fields
+	}
+	for i := range list.Len() {
+		f := list.Index(i)
+		if f.IsNil() {
+			continue
+		}
+		tags := ""
+		if m := f.MethodByName("Tags"); m.IsValid() {
+			tags = m.Call(nil)[0].String()
+		}
+		e := f.Elem()
+		fields[e.FieldByName("ColumnName").String()] = [3]string{
+			e.FieldByName("Name").String(),
+			e.FieldByName("Type").String(),
+			tags,
+		}
+	}
+	return fields
+}
+
+// printDescribe writes the described tables as aligned text.
+func printDescribe(tables []describeTable) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, t := range tables {
+		if t.RLSColumn != "" {
+			fmt.Fprintf(w, "%s (%s, row-level security on %s)\n", t.Table, t.Model, t.RLSColumn)
+		} else {
+			fmt.Fprintf(w, "%s (%s)\n", t.Table, t.Model)
+		}
+		fmt.Fprintln(w, "  COLUMN\tTYPE\tNULL\tKEY\tFIELD\tGO TYPE\tTAGS\tCOMMENT")
+		for _, c := range t.Columns {
+			null, field := "NO", c.Field
+			if c.Nullable {
+				null = "YES"
+			}
+			if field == "" {
+				field = "-"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.Name, c.Type, null, c.Key, field, c.GoType, c.Tags, c.Comment)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
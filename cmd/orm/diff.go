@@ -0,0 +1,287 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// diffContext is the number of unchanged lines around the changes of a
+	// hunk.
+	diffContext = 3
+	// diffMaxEdits bounds the edit distance searched, larger rewrites are
+	// shown as the removal of every line followed by the new ones.
+	diffMaxEdits = 4000
+)
+
+// diffLine is a line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// printDiffs prints the unified diff of every file a plan creates or updates
+// against its target and returns the number of differing files. With
+// deletions set, the generated files of the output directories the run no
+// longer generates are shown as deleted.
+func (o *Orm) printDiffs(s *staging, changes []planChange, deletions bool) (int, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+	var differ int
+	for _, c := range changes {
+		if c.Action == planSkip {
+			continue
+		}
+		staged, err := os.ReadFile(c.staged)
+		if err != nil {
+			return 0, err
+		}
+		var current []byte
+		if c.Action == planUpdate {
+			if current, err = os.ReadFile(filepath.Join(cwd, c.Path)); err != nil {
+				return 0, err
+			}
+			// Provenance comments make no difference
+			current, staged = stripProvenance(current), stripProvenance(staged)
+		}
+		printCause(o.planning.upgrade.attribute(c.Path, c.sum, c.Table), c.Path)
+		printDiff(c.Path, c.Action == planCreate, false, current, staged)
+		differ++
+	}
+	if !deletions {
+		return differ, nil
+	}
+
+	for from, to := range s.dirs() {
+		if _, err := os.Stat(from); os.IsNotExist(err) {
+			continue
+		}
+		deleted, err := deletedFiles(from, to)
+		if err != nil {
+			return 0, err
+		}
+		for _, path := range deleted {
+			current, err := os.ReadFile(path)
+			if err != nil {
+				return 0, err
+			}
+			printCause(o.planning.upgrade.attribute(relPath(cwd, path), "", ""), relPath(cwd, path))
+			printDiff(relPath(cwd, path), false, true, current, nil)
+			differ++
+		}
+	}
+	return differ, nil
+}
+
+// printCause prints the cause of the change of a diffed file after an
+// upgrade of the command.
+func printCause(cause, path string) {
+	if cause != "" {
+		color.Yellow("%s changed with the %s\n", path, cause)
+	}
+}
+
+// deletedFiles returns the generated files of the target directory to that
+// the staged directory from lacks, but the ones protected by .ormkeep.
+func deletedFiles(from, to string) ([]string, error) {
+	targets, err := filepath.Glob(filepath.Join(to, "*.gen.go"))
+	if err != nil {
+		return nil, err
+	}
+	keep, err := loadKeep(to)
+	if err != nil {
+		return nil, err
+	}
+	var deleted []string
+	for _, target := range targets {
+		if _, err := os.Stat(filepath.Join(from, filepath.Base(target))); !os.IsNotExist(err) || keep.Kept(filepath.Base(target)) {
+			continue
+		}
+		data, err := os.ReadFile(target)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(data) {
+			deleted = append(deleted, target)
+		}
+	}
+	slices.Sort(deleted)
+	return deleted, nil
+}
+
+// printDiff prints the unified diff of a file from current to staged, a new
+// file being created and a deleted one removed.
+func printDiff(path string, created, deleted bool, current, staged []byte) {
+	header := color.New(color.Bold)
+	switch {
+	case created:
+		header.Printf("new file: %s\n--- /dev/null\n+++ b/%s\n", path, path)
+	case deleted:
+		header.Printf("deleted: %s\n--- a/%s\n+++ /dev/null\n", path, path)
+	default:
+		header.Printf("--- a/%s\n+++ b/%s\n", path, path)
+	}
+	fmt.Print(unifiedHunks(splitLines(current), splitLines(staged)))
+}
+
+// unifiedHunks renders the colorized hunks of the edit script from a to b.
+func unifiedHunks(a, b []string) string {
+	lines := diffLines(a, b)
+	var buf strings.Builder
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// A hunk spans the changes closer than twice the context
+		start := max(i-diffContext, 0)
+		end, kept := i, 0
+		for j := i; j < len(lines) && kept <= 2*diffContext; j++ {
+			if lines[j].op == ' ' {
+				kept++
+				continue
+			}
+			end, kept = j+1, 0
+		}
+		end = min(end+diffContext, len(lines))
+
+		aStart, bStart := position(lines[:start])
+		aLen, bLen := position(lines[start:end])
+		fmt.Fprint(&buf, color.CyanString("@@ -%s +%s @@", hunkRange(aStart, aLen), hunkRange(bStart, bLen)), "\n")
+		for _, l := range lines[start:end] {
+			switch l.op {
+			case '-':
+				buf.WriteString(color.RedString("-%s", l.text) + "\n")
+			case '+':
+				buf.WriteString(color.GreenString("+%s", l.text) + "\n")
+			default:
+				buf.WriteString(" " + l.text + "\n")
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// position returns the number of lines of a and b an edit script spans.
+func position(lines []diffLine) (a, b int) {
+	for _, l := range lines {
+		if l.op != '+' {
+			a++
+		}
+		if l.op != '-' {
+			b++
+		}
+	}
+	return a, b
+}
+
+// hunkRange formats the line range of a hunk, starting after the first n
+// lines of the file.
+func hunkRange(n, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", n)
+	}
+	return fmt.Sprintf("%d,%d", n+1, length)
+}
+
+// splitLines splits data into lines without their line breaks.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// diffLines returns the shortest edit script from a to b, computed with the
+// Myers algorithm.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	limit := min(n+m, diffMaxEdits)
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+
+	// Too many edits, replace every line
+	lines := make([]diffLine, 0, n+m)
+	for _, text := range a {
+		lines = append(lines, diffLine{'-', text})
+	}
+	for _, text := range b {
+		lines = append(lines, diffLine{'+', text})
+	}
+	return lines
+}
+
+// backtrack walks the trace of diffLines back from the end of a and b.
+func backtrack(trace [][]int, a, b []string, offset int) []diffLine {
+	var lines []diffLine
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prev := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prev = k + 1
+		}
+		prevX := v[offset+prev]
+		prevY := prevX - prev
+		for x > prevX && y > prevY {
+			lines = append(lines, diffLine{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			lines = append(lines, diffLine{'+', b[y-1]})
+			y--
+		} else {
+			lines = append(lines, diffLine{'-', a[x-1]})
+			x--
+		}
+	}
+	slices.Reverse(lines)
+	return lines
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"cmp"
+	"command/cmd"
+	"embed"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// docsFS holds the templates and assets of --docs-site.
+//
+//go:embed docs
+var docsFS embed.FS
+
+//go:generate go run ../gen_manifest.go -pkg orm -var docsManifest -o docs_manifest.gen.go docs
+
+// docsTemplates parses the page templates of the docs site once their files
+// are checked against the manifest of the build.
+var docsTemplates = sync.OnceValues(func() (*template.Template, error) {
+	if err := verifyDocs(); err != nil {
+		return nil, err
+	}
+	return template.ParseFS(docsFS, "docs/*.html.tmpl")
+})
+
+func init() {
+	cmd.RegisterAssets("orm/docs", docsFS, docsManifest)
+}
+
+// verifyDocs checks the embedded files of the docs site.
+func verifyDocs() error {
+	entries, err := docsFS.ReadDir("docs")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cmd.VerifyAsset("orm/docs", "docs/"+e.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type (
+	// docsSite is the data of the index page of the docs site.
+	docsSite struct {
+		Database         string
+		ModelPkg, DaoPkg string
+		Tables           []docsTable
+	}
+	// docsTable is the data of the page of a table.
+	docsTable struct {
+		describeTable
+		Comment string
+		// Page is the file of the table page under tables/
+		Page string
+		// Search are the lower-cased words the index search matches
+		Search           string
+		ModelPkg, DaoPkg string
+		// ModelFile and QueryFile are the generated files under the
+		// directory of their package
+		ModelFile, QueryFile string
+		// Query is the field of the dao Query struct, empty without dao
+		Query string
+		// Sampled is set when the columns have example values
+		Sampled bool
+	}
+)
+
+// docsSite renders a static site documenting the generated tables into dir:
+// an index with a client-side search and a page per table with its columns,
+// types and tags and the generated Go names. Every asset is embedded and the
+// output only depends on the schema and the rules, so the site can be
+// committed. The pages of tables no longer generated are removed.
+func (o *Orm) docsSite(dir string, dao bool) error {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	site := docsSite{Database: cmp.Or(o.schemaName, o.meta.Migrator().CurrentDatabase()), ModelPkg: filepath.Base(model)}
+	if dao {
+		site.DaoPkg = filepath.Base(out)
+	}
+
+	for _, dt := range o.describeStructs() {
+		t := docsTable{describeTable: dt, Page: dt.Table + ".html", ModelPkg: site.ModelPkg, DaoPkg: site.DaoPkg, Sampled: o.samples[dt.Table] != nil}
+		// Tables whose comment cannot be read are documented without it
+		if tt, err := o.meta.Migrator().TableType(dt.Table); err == nil && !o.isDegraded(degradedComments) {
+			t.Comment, _ = tt.Comment()
+		}
+		for _, meta := range o.structs {
+			if metaString(meta, "TableName") != dt.Table {
+				continue
+			}
+			file := metaString(meta, "FileName") + ".gen.go"
+			t.ModelFile = filepath.ToSlash(filepath.Join(site.ModelPkg, file))
+			if dao && selected(o.opt.daoTables, dt.Table) {
+				t.Query, t.QueryFile = dt.Model, filepath.ToSlash(filepath.Join(site.DaoPkg, file))
+			}
+		}
+		words := []string{t.Table, t.Model, t.Comment}
+		for _, c := range t.Columns {
+			words = append(words, c.Name, c.Field, c.Comment)
+		}
+		t.Search = strings.ToLower(strings.Join(words, " "))
+		site.Tables = append(site.Tables, t)
+	}
+	slices.SortFunc(site.Tables, func(a, b docsTable) int {
+		return strings.Compare(a.Table, b.Table)
+	})
+
+	files := make(map[string][]byte)
+	if files["index.html"], err = renderDocs("index.html.tmpl", site); err != nil {
+		return err
+	}
+	for _, t := range site.Tables {
+		if files["tables/"+t.Page], err = renderDocs("table.html.tmpl", t); err != nil {
+			return err
+		}
+	}
+	for _, asset := range []string{"style.css", "search.js"} {
+		if files["assets/"+asset], err = docsFS.ReadFile("docs/" + asset); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, files[name], 0644); err != nil {
+			return err
+		}
+	}
+	return removeStalePages(filepath.Join(dir, "tables"), files)
+}
+
+// renderDocs executes a page template.
+func renderDocs(name string, data any) ([]byte, error) {
+	tmpl, err := docsTemplates()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// removeStalePages removes the table pages of dir not rendered by the run.
+func removeStalePages(dir string, files map[string][]byte) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, ok := files["tables/"+e.Name()]; ok || e.IsDir() || filepath.Ext(e.Name()) != ".html" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// Filters the tables of the index by the words typed in the search box.
+document.addEventListener("DOMContentLoaded", function () {
+  var input = document.getElementById("search");
+  var rows = document.querySelectorAll("#tables tbody tr");
+  var empty = document.getElementById("empty");
+  input.addEventListener("input", function () {
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    var shown = 0;
+    rows.forEach(function (row) {
+      var text = row.getAttribute("data-search");
+      var match = words.every(function (w) { return text.indexOf(w) >= 0; });
+      row.hidden = !match;
+      if (match) {
+        shown++;
+      }
+    });
+    empty.hidden = shown > 0;
+  });
+});
@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Checks implements cmd.Checker.
+func (o *Orm) Checks() []cmd.Check {
+	return []cmd.Check{
+		{Name: "orm.config", Run: o.checkConfig},
+		{Name: "db.connect", Run: o.checkConnect},
+		{Name: "db.privileges", Run: o.checkPrivileges},
+		{Name: "orm.freshness", Run: o.checkFreshness},
+	}
+}
+
+// doctorRun returns a fresh invocation with the environment and config file
+// settings loaded, and the command holding them.
+func (o *Orm) doctorRun() (*Orm, *cobra.Command, error) {
+	inv, c := o.invocation(), o.Command()
+	// Merges the persistent flags into c.Flags()
+	if err := c.ParseFlags(nil); err != nil {
+		return nil, nil, err
+	}
+	if err := inv.loadConfigFile(c.Flags()); err != nil {
+		return nil, nil, err
+	}
+	return inv, c, nil
+}
+
+// doctorConnect is doctorRun connected to the database. The returned result
+// is set when the connection failed.
+func (o *Orm) doctorConnect(ctx context.Context) (*Orm, *cobra.Command, func(), *cmd.CheckResult) {
+	inv, c, err := o.doctorRun()
+	if err != nil {
+		return nil, nil, nil, &cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "fix the config first, see the orm.config check"}
+	}
+	closeConn, err := inv.open(ctx, c.Flags())
+	switch {
+	case errors.Is(err, ErrNoDB):
+		return nil, nil, nil, &cmd.CheckResult{Status: cmd.CheckWarn, Message: "no database configured", Hint: "set CZX_ORM_DSN, or the dsn setting of the config file"}
+	case err != nil:
+		return nil, nil, nil, &cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "check the DSN, the network path to the database and its credentials"}
+	}
+	return inv, c, closeConn, nil
+}
+
+// checkConfig loads the config file and parses its rules.
+func (o *Orm) checkConfig(context.Context) cmd.CheckResult {
+	inv, c, err := o.doctorRun()
+	if err != nil {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "fix the config file named by CZX_ORM_CONFIG"}
+	}
+	if _, err := inv.parseRules(); err != nil {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "fix the rule, see command orm --help for the rule syntax"}
+	}
+	path, _ := c.Flags().GetString("config")
+	if _, err := os.Stat(path); path != "" && (err == nil || c.Flags().Changed("config")) {
+		return cmd.CheckResult{Status: cmd.CheckPass, Message: path + " and its rules are valid"}
+	}
+	return cmd.CheckResult{Status: cmd.CheckPass, Message: "no config file, the rules of the options are valid"}
+}
+
+// checkConnect connects to the database and pings it.
+func (o *Orm) checkConnect(ctx context.Context) cmd.CheckResult {
+	inv, _, closeConn, result := o.doctorConnect(ctx)
+	if result != nil {
+		return *result
+	}
+	defer closeConn()
+	return cmd.CheckResult{Status: cmd.CheckPass, Message: "connected to the " + inv.meta.Dialector.Name() + " database"}
+}
+
+// checkPrivileges checks that the tables and their columns can be read.
+func (o *Orm) checkPrivileges(ctx context.Context) cmd.CheckResult {
+	inv, _, closeConn, result := o.doctorConnect(ctx)
+	if result != nil {
+		return *result
+	}
+	defer closeConn()
+
+	hint := "grant SELECT on the schema, e.g. GRANT SELECT ON amg.* TO 'app'@'%'"
+	tables, err := userTables(inv.meta.WithContext(ctx))
+	if err != nil {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: "cannot list the tables: " + err.Error(), Hint: hint}
+	}
+	if len(tables) == 0 {
+		return cmd.CheckResult{Status: cmd.CheckWarn, Message: "no table is visible", Hint: "create the schema, or " + hint}
+	}
+	inv.meta = inv.meta.WithContext(ctx)
+	if err := inv.introspect(tables[:1], 1); err != nil {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: fmt.Sprintf("cannot read the columns of %s: %v", tables[0], err), Hint: hint}
+	}
+	if len(inv.columns[tables[0]]) == 0 {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: "no column of " + tables[0] + " is visible", Hint: hint}
+	}
+	return cmd.CheckResult{Status: cmd.CheckPass, Message: fmt.Sprintf("%d tables and their columns are readable", len(tables))}
+}
+
+// checkFreshness runs orm verify in its fast mode: the generated files of
+// the lockfile must be unchanged on disk and the columns of their tables in
+// the database.
+func (o *Orm) checkFreshness(ctx context.Context) cmd.CheckResult {
+	inv, c, closeConn, result := o.doctorConnect(ctx)
+	if result != nil {
+		return *result
+	}
+	defer closeConn()
+
+	path, _ := c.Flags().GetString("lockfile")
+	if path == "" {
+		return cmd.CheckResult{Status: cmd.CheckWarn, Message: "no lockfile is written, --lockfile is empty", Hint: "set CZX_ORM_LOCKFILE to the lockfile of the generated code"}
+	}
+	l, err := readLockfile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return cmd.CheckResult{Status: cmd.CheckWarn, Message: "no lockfile at " + path, Hint: "generate the code with command orm, which writes it"}
+	case err != nil:
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "generate the code with command orm to rewrite it"}
+	}
+	stale, err := inv.verifyFast(ctx, c.Flags(), l)
+	if err != nil {
+		return cmd.CheckResult{Status: cmd.CheckFail, Message: err.Error(), Hint: "run command orm verify to see the failure in full"}
+	}
+	if len(stale) > 0 {
+		return cmd.CheckResult{
+			Status:  cmd.CheckWarn,
+			Message: fmt.Sprintf("%d of %d generated files are stale", len(stale), len(l.Files)),
+			Hint:    "list them with command orm verify, then regenerate with command orm",
+		}
+	}
+	return cmd.CheckResult{Status: cmd.CheckPass, Message: fmt.Sprintf("%d generated files match %s", len(l.Files), path)}
+}
@@ -0,0 +1,302 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// driftTypes maps the Go field types with an unambiguous MySQL column type.
+// Other types produce a TODO in the migration instead of a guessed type.
+var driftTypes = map[string]string{
+	"bool":      "tinyint(1)",
+	"int8":      "tinyint",
+	"int16":     "smallint",
+	"int32":     "int",
+	"int":       "bigint",
+	"int64":     "bigint",
+	"uint8":     "tinyint unsigned",
+	"uint16":    "smallint unsigned",
+	"uint32":    "int unsigned",
+	"uint":      "bigint unsigned",
+	"uint64":    "bigint unsigned",
+	"float32":   "float",
+	"float64":   "double",
+	"time.Time": "datetime",
+}
+
+type (
+	// modelColumn is a column derived from a field of a generated model.
+	modelColumn struct {
+		Name    string
+		GoType  string
+		SQLType string
+	}
+	// modelTable is a table derived from a generated model file.
+	modelTable struct {
+		Name    string
+		File    string
+		Columns []modelColumn
+	}
+)
+
+// driftCommand returns the orm drift subcommand.
+func (o *Orm) driftCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "drift",
+		Short: "Propose migrations for models edited by hand",
+		Long: `Compare the generated model files with the live schema and write up/down
+migration stubs adding the columns that only exist in the models.
+
+Column types come from the gorm type tag, or from the Go type when it maps
+to a single column type. Ambiguous types produce a TODO comment instead.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Write migration stubs into ./migrations
+command orm drift --dsn "root:root@tcp(127.0.0.1:3306)/amg"
+
+# Print the migrations without writing them
+command orm drift --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).drift),
+	}
+	c.Flags().String("migrate-dir", "./migrations", cmd.T("orm.drift.flag.migrate-dir"))
+	c.Flags().Bool("dry-run", false, cmd.T("orm.drift.flag.dry-run"))
+	return c
+}
+
+// drift is the execution logic for the orm drift command.
+func (o *Orm) drift(cmd *cobra.Command, _ []string) error {
+	if err := o.loadConfigFile(cmd.Flags()); err != nil {
+		return usage("loading config", err)
+	}
+
+	closeConn, err := o.open(cmd.Context(), cmd.Flags())
+	if err != nil {
+		return fail("connecting to database", err)
+	}
+	defer closeConn()
+
+	if err := o.execDrift(cmd); err != nil {
+		return fail("detecting drift", err)
+	}
+	return nil
+}
+
+// execDrift compares the model files with the live schema.
+func (o *Orm) execDrift(cmd *cobra.Command) error {
+	dir, err := cmd.Flags().GetString("migrate-dir")
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	modelDir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	tables, err := parseModels(modelDir)
+	if err != nil {
+		return err
+	}
+
+	live, err := userTables(o.meta)
+	if err != nil {
+		return err
+	}
+
+	var drifted int
+	version := time.Now().Format("20060102150405")
+	for _, t := range tables {
+		if !slices.Contains(live, t.Name) {
+			color.Yellow("Skipping %s: table %s does not exist\n", t.File, t.Name)
+			continue
+		}
+		columns, err := o.meta.Migrator().ColumnTypes(t.Name)
+		if err != nil {
+			return err
+		}
+
+		existing := make(map[string]bool, len(columns))
+		for _, col := range columns {
+			existing[col.Name()] = true
+		}
+		var added []modelColumn
+		for _, col := range t.Columns {
+			if !existing[col.Name] {
+				added = append(added, col)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		drifted++
+		up, down := driftMigration(t.Name, added)
+		if dryRun {
+			fmt.Printf("-- %s up\n%s\n-- %s down\n%s\n", t.Name, up, t.Name, down)
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		base := filepath.Join(dir, version+"_"+t.Name+"_drift")
+		if err := writeFileAtomic(base+".up.sql", []byte(up), 0644); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(base+".down.sql", []byte(down), 0644); err != nil {
+			return err
+		}
+		color.Green("Wrote %s.{up,down}.sql\n", base)
+	}
+
+	if drifted == 0 {
+		color.Green("\nNo drift detected.\n\n")
+	}
+	return nil
+}
+
+// driftMigration renders the up and down migrations adding columns to a table.
+func driftMigration(table string, columns []modelColumn) (string, string) {
+	var up, down strings.Builder
+	for _, col := range columns {
+		typ := col.SQLType
+		if typ == "" {
+			typ = driftTypes[col.GoType]
+		}
+		if typ == "" {
+			fmt.Fprintf(&up, "-- TODO: choose the column type of %s.%s (Go type %s)\n", table, col.Name, col.GoType)
+			fmt.Fprintf(&up, "-- ALTER TABLE `%s` ADD COLUMN `%s` <type>;\n", table, col.Name)
+		} else {
+			fmt.Fprintf(&up, "ALTER TABLE `%s` ADD COLUMN `%s` %s;\n", table, col.Name, typ)
+		}
+		fmt.Fprintf(&down, "ALTER TABLE `%s` DROP COLUMN `%s`;\n", table, col.Name)
+	}
+	return up.String(), down.String()
+}
+
+// parseModels derives the tables and columns of the generated model files in
+// dir, skipping the files listed in its .ormkeep file.
+func parseModels(dir string) ([]modelTable, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.gen.go"))
+	if err != nil {
+		return nil, err
+	}
+	keep, err := loadKeep(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []modelTable
+	for _, path := range files {
+		if keep.Kept(path) {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		// const TableName<Model> = "<table>"
+		names := make(map[string]string)
+		structs := make(map[string]*ast.StructType)
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					if len(spec.Names) != 1 || len(spec.Values) != 1 {
+						continue
+					}
+					lit, ok := spec.Values[0].(*ast.BasicLit)
+					model, found := strings.CutPrefix(spec.Names[0].Name, "TableName")
+					if !ok || !found || lit.Kind != token.STRING {
+						continue
+					}
+					names[model], _ = strconv.Unquote(lit.Value)
+				case *ast.TypeSpec:
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						structs[spec.Name.Name] = st
+					}
+				}
+			}
+		}
+
+		for model, table := range names {
+			st, ok := structs[model]
+			if !ok {
+				continue
+			}
+			tables = append(tables, modelTable{Name: table, File: path, Columns: modelColumns(st)})
+		}
+	}
+	return tables, nil
+}
+
+// modelColumns derives the columns of a model struct from its gorm tags.
+func modelColumns(st *ast.StructType) []modelColumn {
+	var columns []modelColumn
+	for _, f := range st.Fields.List {
+		if len(f.Names) != 1 || f.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		gormTag := reflect.StructTag(tag).Get("gorm")
+		if gormTag == "-" || strings.Contains(gormTag, "foreignKey") || strings.Contains(gormTag, "many2many") {
+			continue
+		}
+
+		col := modelColumn{GoType: strings.TrimPrefix(types.ExprString(f.Type), "*")}
+		for _, part := range strings.Split(gormTag, ";") {
+			key, value, _ := strings.Cut(part, ":")
+			switch strings.TrimSpace(key) {
+			case "column":
+				col.Name = value
+			case "type":
+				col.SQLType = value
+			}
+		}
+		if col.Name == "" || col.Name == "-" {
+			continue
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"slices"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// drivers are the dialectors of the --driver flag by name.
+var drivers = map[string]func(dsn string) gorm.Dialector{
+	"mysql":     mysql.Open,
+	"postgres":  postgres.Open,
+	"sqlite":    sqlite.Open,
+	"sqlserver": sqlserverOpen,
+}
+
+// driverTypes are the default data type mappings of the dialects whose
+// column types gen does not know, by dialector name. Data type rules win.
+var driverTypes = map[string]map[string]DataTypeFn{
+	"postgres":  postgresTypes,
+	"sqlite":    caseVariants(sqliteTypes),
+	"sqlserver": caseVariants(sqlserverTypes),
+}
+
+// driverImports are the packages of the types of driverTypes, by dialector
+// name. gen drops them from the model files not using them.
+var driverImports = map[string][]string{
+	"sqlserver": {`mssql "github.com/microsoft/go-mssqldb"`},
+}
+
+// internalTables report the tables a dialect keeps for itself, by dialector
+// name. They are never generated.
+var internalTables = map[string]func(table string) bool{
+	"sqlite": func(table string) bool { return strings.HasPrefix(table, "sqlite_") },
+}
+
+// postgresTypes maps the Postgres column types, as named by the udt_name of
+// information_schema.columns, to Go types. Serial columns are reported as
+// their integer type.
+var postgresTypes = map[string]DataTypeFn{
+	"int2":        fixedType("int16"),
+	"int4":        fixedType("int32"),
+	"int8":        fixedType("int64"),
+	"smallserial": fixedType("int16"),
+	"serial":      fixedType("int32"),
+	"bigserial":   fixedType("int64"),
+	"float4":      fixedType("float32"),
+	"float8":      fixedType("float64"),
+	"numeric":     fixedType("float64"),
+	"bool":        fixedType("bool"),
+	"bpchar":      fixedType("string"),
+	"varchar":     fixedType("string"),
+	"text":        fixedType("string"),
+	"uuid":        fixedType("string"),
+	"json":        fixedType("string"),
+	"jsonb":       fixedType("string"),
+	"inet":        fixedType("string"),
+	"cidr":        fixedType("string"),
+	"interval":    fixedType("string"),
+	"bytea":       fixedType("[]byte"),
+	"date":        fixedType("time.Time"),
+	"time":        fixedType("time.Time"),
+	"timetz":      fixedType("time.Time"),
+	"timestamp":   fixedType("time.Time"),
+	"timestamptz": fixedType("time.Time"),
+}
+
+// sqliteTypes maps the declared SQLite column types to Go types. SQLite
+// stores integers in up to 8 bytes and NUMERIC columns may hold fractions,
+// so they map wider than the MySQL types of the same name.
+var sqliteTypes = map[string]DataTypeFn{
+	"integer":   fixedType("int64"),
+	"int":       fixedType("int64"),
+	"tinyint":   fixedType("int64"),
+	"smallint":  fixedType("int64"),
+	"mediumint": fixedType("int64"),
+	"bigint":    fixedType("int64"),
+	"real":      fixedType("float64"),
+	"double":    fixedType("float64"),
+	"float":     fixedType("float64"),
+	"numeric":   fixedType("float64"),
+	"decimal":   fixedType("float64"),
+	"boolean":   fixedType("bool"),
+	"text":      fixedType("string"),
+	"clob":      fixedType("string"),
+	"char":      fixedType("string"),
+	"varchar":   fixedType("string"),
+	"nchar":     fixedType("string"),
+	"nvarchar":  fixedType("string"),
+	"blob":      fixedType("[]byte"),
+	"date":      fixedType("time.Time"),
+	"datetime":  fixedType("time.Time"),
+	"timestamp": fixedType("time.Time"),
+}
+
+// sqlserverTypes maps the SQL Server column types to Go types. The driver
+// reads UNIQUEIDENTIFIER columns in the byte order of SQL Server, which
+// mssql.UniqueIdentifier converts, and TIMESTAMP is the rowversion type.
+var sqlserverTypes = map[string]DataTypeFn{
+	"bit":              fixedType("bool"),
+	"tinyint":          fixedType("uint8"),
+	"smallint":         fixedType("int16"),
+	"int":              fixedType("int32"),
+	"bigint":           fixedType("int64"),
+	"real":             fixedType("float32"),
+	"float":            fixedType("float64"),
+	"decimal":          fixedType("float64"),
+	"numeric":          fixedType("float64"),
+	"money":            fixedType("float64"),
+	"smallmoney":       fixedType("float64"),
+	"char":             fixedType("string"),
+	"varchar":          fixedType("string"),
+	"text":             fixedType("string"),
+	"nchar":            fixedType("string"),
+	"nvarchar":         fixedType("string"),
+	"ntext":            fixedType("string"),
+	"xml":              fixedType("string"),
+	"uniqueidentifier": fixedType("mssql.UniqueIdentifier"),
+	"binary":           fixedType("[]byte"),
+	"varbinary":        fixedType("[]byte"),
+	"image":            fixedType("[]byte"),
+	"timestamp":        fixedType("[]byte"),
+	"date":             fixedType("time.Time"),
+	"time":             fixedType("time.Time"),
+	"datetime":         fixedType("time.Time"),
+	"datetime2":        fixedType("time.Time"),
+	"smalldatetime":    fixedType("time.Time"),
+	"datetimeoffset":   fixedType("time.Time"),
+}
+
+// caseVariants returns types keyed by both the lower and upper case names, as
+// SQLite reports column types the way the DDL spells them and the SQL Server
+// driver upper-cases them.
+func caseVariants(types map[string]DataTypeFn) map[string]DataTypeFn {
+	variants := make(map[string]DataTypeFn, 2*len(types))
+	for name, fn := range types {
+		variants[name] = fn
+		variants[strings.ToUpper(name)] = fn
+	}
+	return variants
+}
+
+// userTables returns the tables of db, less the internal tables of its dialect.
+func userTables(db *gorm.DB) ([]string, error) {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return nil, err
+	}
+	if internal, ok := internalTables[db.Dialector.Name()]; ok {
+		tables = slices.DeleteFunc(tables, internal)
+	}
+	return tables, nil
+}
+
+// dialector returns the dialector of a --driver name for dsn.
+func dialector(driver, dsn string) (gorm.Dialector, error) {
+	open, ok := drivers[driver]
+	if !ok {
+		return nil, &UnknownDriverError{Driver: driver, Known: sortedKeys(drivers)}
+	}
+	return open(dsn), nil
+}
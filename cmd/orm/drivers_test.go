@@ -0,0 +1,83 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostgresTypes(t *testing.T) {
+	tests := []struct {
+		udt  string
+		want string
+	}{
+		{"int2", "int16"},
+		{"int4", "int32"},
+		{"int8", "int64"},
+		{"smallserial", "int16"},
+		{"serial", "int32"},
+		{"bigserial", "int64"},
+		{"float4", "float32"},
+		{"float8", "float64"},
+		{"numeric", "float64"},
+		{"bool", "bool"},
+		{"bpchar", "string"},
+		{"varchar", "string"},
+		{"text", "string"},
+		{"uuid", "string"},
+		{"json", "string"},
+		{"jsonb", "string"},
+		{"inet", "string"},
+		{"cidr", "string"},
+		{"interval", "string"},
+		{"bytea", "[]byte"},
+		{"date", "time.Time"},
+		{"time", "time.Time"},
+		{"timetz", "time.Time"},
+		{"timestamp", "time.Time"},
+		{"timestamptz", "time.Time"},
+	}
+
+	// One column per type, named after it, in a Postgres snapshot
+	db := openFixtureWith(t, "users", func(snap *schemaSnapshot) {
+		snap.Driver = "postgres"
+		table := &snap.Tables[0]
+		table.Columns = table.Columns[:1]
+		table.Columns[0].DataTypeValue = "bigserial"
+		for _, tt := range tests {
+			notNull := false
+			table.Columns = append(table.Columns, snapshotColumn{NameValue: "c_" + tt.udt, DataTypeValue: tt.udt, NullableValue: &notNull})
+		}
+	})
+	model := generate(t, db, []string{"-t", "users"})["model/users.gen.go"]
+	for _, tt := range tests {
+		t.Run(tt.udt, func(t *testing.T) {
+			var f []string
+			for line := range strings.Lines(model) {
+				if strings.Contains(line, `gorm:"column:c_`+tt.udt+`;`) {
+					f = strings.Fields(line)
+				}
+			}
+			if len(f) < 2 || f[1] != tt.want {
+				t.Errorf("%s column = %v, want %s", tt.udt, f, tt.want)
+			}
+		})
+	}
+	if f := strings.Fields(fieldLine(model, "ID")); len(f) < 2 || f[1] != "int64" {
+		t.Errorf("bigserial primary key = %v, want int64", f)
+	}
+}
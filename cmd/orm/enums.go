@@ -0,0 +1,294 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/imports"
+	"gorm.io/gorm"
+)
+
+// enumsFileSuffix ends the name of the enums file of a table, next to its
+// model file.
+const enumsFileSuffix = "_enums.gen.go"
+
+// enumType is the Go type generated for an ENUM or SET column with
+// WithEnums.
+type enumType struct {
+	// Name is the type declared in the model package, e.g. OrderStatus
+	Name   string
+	Column string
+	// Set is true for a SET column, generated as a slice of its values
+	Set    bool
+	Values []string
+	// Consts are the names of the constants of Values, in the same order
+	Consts []string
+}
+
+// parseEnumType returns the values of an ENUM or SET column type such as
+// enum('pending','paid'), and whether it is a SET. Quotes are escaped by
+// doubling them or with a backslash.
+func parseEnumType(typ string) (values []string, set, ok bool) {
+	lower := strings.ToLower(strings.TrimSpace(typ))
+	switch {
+	case strings.HasPrefix(lower, "enum("):
+	case strings.HasPrefix(lower, "set("):
+		set = true
+	default:
+		return nil, false, false
+	}
+	body := strings.TrimSpace(typ)
+	body = body[strings.IndexByte(body, '(')+1:]
+	if !strings.HasSuffix(body, ")") {
+		return nil, false, false
+	}
+	body = body[:len(body)-1]
+
+	values = []string{}
+	for i := 0; i < len(body); {
+		if body[i] != '\'' {
+			return nil, false, false
+		}
+		var value strings.Builder
+		closed := false
+		for i++; i < len(body); i++ {
+			c := body[i]
+			if c == '\\' && i+1 < len(body) {
+				i++
+				value.WriteByte(body[i])
+				continue
+			}
+			if c == '\'' {
+				if i+1 < len(body) && body[i+1] == '\'' {
+					i++
+					value.WriteByte('\'')
+					continue
+				}
+				closed = true
+				i++
+				break
+			}
+			value.WriteByte(c)
+		}
+		if !closed {
+			return nil, false, false
+		}
+		values = append(values, value.String())
+		for i < len(body) && (body[i] == ',' || body[i] == ' ') {
+			i++
+		}
+	}
+	return values, set, len(values) > 0
+}
+
+// enumConsts names the constants of the values of an enum type: the type
+// followed by the words of the value, "Empty" for the empty value, suffixed
+// with a number when two values give the same name.
+func enumConsts(typ string, values []string, acronyms []string) []string {
+	consts := make([]string, len(values))
+	taken := make(map[string]bool, len(values))
+	for i, value := range values {
+		var words strings.Builder
+		for _, word := range strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			words.WriteString(string(runes))
+		}
+		name := typ + acronymize(words.String(), acronyms)
+		if value == "" {
+			name = typ + "Empty"
+		} else if words.Len() == 0 {
+			name = typ + "Value" + strconv.Itoa(i+1)
+		}
+		for base, n := name, 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s%d", base, n)
+		}
+		taken[name] = true
+		consts[i] = name
+	}
+	return consts
+}
+
+// reserveModelNames records the model names of the selected entries, which
+// the enum types must not take.
+func (o *Orm) reserveModelNames(tables []string) {
+	for _, val := range tables {
+		vals := strings.Split(val, "@")
+		name := vals[len(vals)-1]
+		if len(vals) == 1 {
+			name = o.qualifiedModelName(vals[0], o.schemaPrefix)
+		}
+		o.enumNames[name] = "model " + name
+	}
+}
+
+// tableEnums declares the enum types of the ENUM and SET columns of a table
+// generating model. A type is named after the model and the field, or after
+// the table and the field when the name is taken by a model or by the enum
+// of another table, then suffixed with a number.
+func (o *Orm) tableEnums(table, model string, columns []columnMeta) {
+	enums := make(map[string]*enumType)
+	for _, col := range columns {
+		values, set, ok := parseEnumType(col.Type)
+		if !ok {
+			continue
+		}
+		field := o.structFieldName(table, col.Name)
+		owner := table + "." + col.Name
+		candidates := []string{model + field, acronymize(sanitizeIdentifier(columnFieldName(o.meta, strings.ReplaceAll(table, ".", "_"))), o.acronyms) + field}
+		name := ""
+		for _, c := range candidates {
+			if o.enumNames[c] == "" || o.enumNames[c] == owner {
+				name = c
+				break
+			}
+		}
+		for n := 2; name == ""; n++ {
+			if c := fmt.Sprintf("%s%d", candidates[1], n); o.enumNames[c] == "" || o.enumNames[c] == owner {
+				name = c
+			}
+		}
+		o.enumNames[name] = owner
+		enums[col.Name] = &enumType{
+			Name:   name,
+			Column: col.Name,
+			Set:    set,
+			Values: values,
+			Consts: enumConsts(name, values, o.acronyms),
+		}
+	}
+	if len(enums) > 0 {
+		o.enums[table] = enums
+	}
+}
+
+// enumDataTypes maps the ENUM and SET columns of the table to their enum
+// types, and the other ones as types does.
+func (o *Orm) enumDataTypes(table string, types map[string]DataTypeFn) map[string]DataTypeFn {
+	mapping := make(map[string]DataTypeFn)
+	for _, typ := range []string{"enum", "set"} {
+		prev := types[typ]
+		mapping[typ] = func(column gorm.ColumnType) string {
+			if e, ok := o.enums[table][column.Name()]; ok {
+				return e.Name
+			}
+			if prev != nil {
+				return prev(column)
+			}
+			return "string"
+		}
+	}
+	return caseVariants(mapping)
+}
+
+// writeEnums writes the enum types of each generated table to its enums
+// file in the model package.
+func (o *Orm) writeEnums() error {
+	if len(o.enums) == 0 {
+		return nil
+	}
+	dir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		enums, ok := o.enums[table]
+		if table == "" || file == "" || !ok {
+			continue
+		}
+		path := filepath.Join(dir, file+enumsFileSuffix)
+		src, err := imports.Process(path, renderEnums(filepath.Base(dir), table, o.columns[table], enums), nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, src, 0640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderEnums renders the enums file of a table, the types in the order of
+// the columns.
+func renderEnums(pkg, table string, columns []columnMeta, enums map[string]*enumType) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by command orm. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n\t\"database/sql/driver\"\n\t\"fmt\"\n\t\"strings\"\n)\n", pkg)
+
+	for _, col := range columns {
+		e, ok := enums[col.Name]
+		if !ok {
+			continue
+		}
+		if !e.Set {
+			fmt.Fprintf(&buf, "\n// %s is the ENUM column %s of table %s.\ntype %s string\n", e.Name, e.Column, table, e.Name)
+			fmt.Fprintf(&buf, "\n// Values of %s.\nconst (\n", e.Name)
+			for i, v := range e.Values {
+				fmt.Fprintf(&buf, "\t%s %s = %s\n", e.Consts[i], e.Name, strconv.Quote(v))
+			}
+			buf.WriteString(")\n")
+			continue
+		}
+
+		fmt.Fprintf(&buf, "\n// %s is the SET column %s of table %s, the values it holds.\ntype %s []string\n", e.Name, e.Column, table, e.Name)
+		fmt.Fprintf(&buf, "\n// Values of the elements of %s.\nconst (\n", e.Name)
+		for i, v := range e.Values {
+			fmt.Fprintf(&buf, "\t%s = %s\n", e.Consts[i], strconv.Quote(v))
+		}
+		buf.WriteString(")\n")
+		fmt.Fprintf(&buf, `
+// Scan implements sql.Scanner.
+func (s *%[1]s) Scan(src any) error {
+	var str string
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return fmt.Errorf("cannot scan %%T into %[1]s", src)
+	}
+	*s = %[1]s{}
+	if str != "" {
+		*s = strings.Split(str, ",")
+	}
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (s %[1]s) Value() (driver.Value, error) {
+	return strings.Join(s, ","), nil
+}
+`, e.Name)
+	}
+	return buf.Bytes()
+}
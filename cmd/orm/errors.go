@@ -0,0 +1,372 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"command/policy"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoDB is returned when neither WithDB nor --dsn provide a database.
+	ErrNoDB = errors.New("database connection is not provided, set --dsn or use WithDB")
+	// ErrConnect wraps failures to reach the database or the SSH tunnel.
+	ErrConnect = errors.New("database is unreachable")
+)
+
+type (
+	// RuleSyntaxError reports a malformed rule option.
+	RuleSyntaxError struct {
+		Rule   string
+		Reason string
+	}
+	// UnknownTableError reports a selected table missing from the database.
+	UnknownTableError struct {
+		Table       string
+		Suggestions []string
+	}
+	// ConfigError reports an invalid field of the gen.Config of WithConfig.
+	ConfigError struct {
+		Field  string
+		Value  string
+		Reason string
+		Fix    string
+	}
+	// UnknownDriverError reports a --driver without a dialector.
+	UnknownDriverError struct {
+		Driver string
+		Known  []string
+	}
+	// EmptyGenerationError reports a run that matched nothing to generate.
+	EmptyGenerationError struct {
+		Style  string
+		Reason string
+	}
+	// PolicyError reports the items of a run denied by the policy.
+	PolicyError struct {
+		Violations []policy.Violation
+	}
+	// MissingRLSError reports the tables without a row-level security marker
+	// with --require-rls.
+	MissingRLSError struct {
+		Tables []string
+	}
+	// KeptPathError reports a generated file listed in a .ormkeep file.
+	KeptPathError struct {
+		Table string
+		Path  string
+		Keep  string
+	}
+	// StalePlanError reports a recorded plan that no longer matches the run.
+	StalePlanError struct {
+		Plan   string
+		Reason string
+	}
+	// StaleOutputError reports a dry run that would create or change files.
+	StaleOutputError struct {
+		Created, Updated, Deleted int
+	}
+	// StaleLockError reports generated files that no longer match the
+	// lockfile.
+	StaleLockError struct {
+		Lockfile string
+		Stale    int
+		// Schema and Tool count the stale files changed with the schema
+		// and with the tool, after an upgrade of the command
+		Schema, Tool int
+	}
+	// ReadOnlyError reports a statement that would write with --read-only.
+	ReadOnlyError struct {
+		Statement string
+	}
+	// LockedError reports an output directory locked by another run for
+	// longer than --lock-wait.
+	LockedError struct {
+		Path    string
+		PID     int
+		Host    string
+		Started time.Time
+		Wait    time.Duration
+	}
+	// UnmatchedRulesError reports the rules matching nothing in the schema
+	// with --strict-rules.
+	UnmatchedRulesError struct {
+		Rules []string
+	}
+	// ResultTypeError reports a row struct returned by an annotae method that
+	// the generated dao cannot scan into.
+	ResultTypeError struct {
+		Method string
+		Type   string
+		Reason string
+	}
+	// OldDBError reports a database server older than the minimum version
+	// without --allow-old-db.
+	OldDBError struct {
+		Flavor  string
+		Version string
+		Minimum string
+	}
+	// BatchingError reports an option needing every model of the run at
+	// once with --batch-size, --max-memory or --out-template.
+	BatchingError struct {
+		Feature string
+		Reason  string
+	}
+	// LayoutError reports an invalid output template of --out-template, or
+	// the output paths it renders for a table.
+	LayoutError struct {
+		Table  string
+		Path   string
+		Reason string
+	}
+	// ModulePathError reports an invalid --module-path, or a generated file
+	// importing a package that does not resolve under it.
+	ModulePathError struct {
+		Module string
+		File   string
+		Import string
+		Reason string
+	}
+)
+
+func (e *RuleSyntaxError) Error() string {
+	return fmt.Sprintf("invalid rule %q: %s", e.Rule, e.Reason)
+}
+
+func (e *UnknownTableError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("table %q does not exist", e.Table)
+	}
+	return fmt.Sprintf("table %q does not exist, did you mean %s?", e.Table, strings.Join(e.Suggestions, ", "))
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("gen.Config.%s %q %s: %s", e.Field, e.Value, e.Reason, e.Fix)
+}
+
+func (e *UnknownDriverError) Error() string {
+	return fmt.Sprintf("unknown driver %q, supported drivers: %s", e.Driver, strings.Join(e.Known, ", "))
+}
+
+func (e *EmptyGenerationError) Error() string {
+	return fmt.Sprintf("nothing to generate for style %s: %s", e.Style, e.Reason)
+}
+
+func (e *PolicyError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = "  " + v.Error()
+	}
+	return fmt.Sprintf("%d policy violation(s):\n%s", len(e.Violations), strings.Join(lines, "\n"))
+}
+
+func (e *MissingRLSError) Error() string {
+	return fmt.Sprintf("--require-rls: %d table(s) without a [rls:<column>] marker in their comment: %s", len(e.Tables), strings.Join(e.Tables, ", "))
+}
+
+func (e *KeptPathError) Error() string {
+	return fmt.Sprintf("table %s would overwrite %s, which is listed in %s", e.Table, e.Path, e.Keep)
+}
+
+func (e *StalePlanError) Error() string {
+	return fmt.Sprintf("plan %s is stale: %s, make a new plan", e.Plan, e.Reason)
+}
+
+func (e *StaleOutputError) Error() string {
+	if e.Deleted > 0 {
+		return fmt.Sprintf("dry run: %d files would be created, %d changed and %d are no longer generated", e.Created, e.Updated, e.Deleted)
+	}
+	return fmt.Sprintf("dry run: %d files would be created and %d changed", e.Created, e.Updated)
+}
+
+func (e *StaleLockError) Error() string {
+	if e.Schema+e.Tool > 0 {
+		return fmt.Sprintf("%d generated files do not match %s, %d changed with the schema and %d with the tool, regenerate them with command orm", e.Stale, e.Lockfile, e.Schema, e.Tool)
+	}
+	return fmt.Sprintf("%d generated files do not match %s, regenerate them with command orm", e.Stale, e.Lockfile)
+}
+
+func (e *ReadOnlyError) Error() string {
+	stmt := e.Statement
+	if len(stmt) > 80 {
+		stmt = stmt[:77] + "..."
+	}
+	return fmt.Sprintf("refusing to write with --read-only: %s", stmt)
+}
+
+func (e *LockedError) Error() string {
+	holder := "another run"
+	if e.PID > 0 {
+		holder = fmt.Sprintf("pid %d on %s", e.PID, e.Host)
+	}
+	return fmt.Sprintf("%s is held by %s since %s, still held after waiting %s: retry later or raise --lock-wait",
+		e.Path, holder, e.Started.Local().Format(time.DateTime), e.Wait)
+}
+
+func (e *UnmatchedRulesError) Error() string {
+	return fmt.Sprintf("%d rules match nothing in the schema:\n  %s", len(e.Rules), strings.Join(e.Rules, "\n  "))
+}
+
+func (e *ResultTypeError) Error() string {
+	return fmt.Sprintf("%s returns %s: %s", e.Method, e.Type, e.Reason)
+}
+
+func (e *OldDBError) Error() string {
+	return fmt.Sprintf("%s server %s is older than the supported %s: upgrade the server, lower --min-db-version, "+
+		"or pass --allow-old-db to generate without comments and generated-column detection", e.Flavor, e.Version, e.Minimum)
+}
+
+func (e *BatchingError) Error() string {
+	return fmt.Sprintf("%s cannot be used with --batch-size, --max-memory or --out-template: %s", e.Feature, e.Reason)
+}
+
+func (e *LayoutError) Error() string {
+	switch {
+	case e.Table == "":
+		return "invalid output template: " + e.Reason
+	case e.Path == "":
+		return fmt.Sprintf("output template of table %s: %s", e.Table, e.Reason)
+	}
+	return fmt.Sprintf("output template of table %s renders %s: %s", e.Table, e.Path, e.Reason)
+}
+
+func (e *ModulePathError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("invalid module path %q: %s", e.Module, e.Reason)
+	}
+	return fmt.Sprintf("%s imports %q, which does not resolve to a package under module path %q", e.File, e.Import, e.Module)
+}
+
+// ExitCode implements cmd.ExitCoder.
+func (e *RuleSyntaxError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *UnknownTableError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *ConfigError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *UnknownDriverError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *PolicyError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *MissingRLSError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *KeptPathError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *StalePlanError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *StaleOutputError) ExitCode() int { return cmd.ExitStale }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *StaleLockError) ExitCode() int { return cmd.ExitStale }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *ReadOnlyError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *LockedError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *UnmatchedRulesError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *ResultTypeError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *OldDBError) ExitCode() int { return cmd.ExitRefused }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *BatchingError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *LayoutError) ExitCode() int { return cmd.ExitUsage }
+
+// ExitCode implements cmd.ExitCoder.
+func (e *ModulePathError) ExitCode() int { return cmd.ExitUsage }
+
+// exitCode returns the exit code matching the kind of err:
+// 2 for usage and rule errors, 3 for connectivity errors, 4 for refused
+// writes, 1 otherwise.
+func exitCode(err error) int {
+	var coder cmd.ExitCoder
+	switch {
+	case errors.Is(err, ErrConnect):
+		return cmd.ExitExternal
+	case errors.Is(err, ErrNoDB):
+		return cmd.ExitUsage
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	default:
+		return cmd.ExitFailure
+	}
+}
+
+// fail returns err prefixed with what failed, classified with its exit code.
+func fail(prefix string, err error) error {
+	return cmd.Exit(exitCode(err), fmt.Errorf("%s: %w", prefix, err))
+}
+
+// usage returns err prefixed with what failed, classified as a usage error.
+func usage(prefix string, err error) error {
+	return cmd.Exit(cmd.ExitUsage, fmt.Errorf("%s: %w", prefix, err))
+}
+
+// suggest returns the candidates close to name, by edit distance or prefix.
+func suggest(name string, candidates []string) []string {
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, name) || strings.HasPrefix(name, c) || distance(name, c) <= 2 {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) > 3 {
+		matches = matches[:3]
+	}
+	return matches
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
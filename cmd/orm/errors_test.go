@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"command/cmd"
+
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+// run runs the orm command with args and opts in a temporary directory and
+// returns its error, db nil leaving the connection to the flags.
+func run(t *testing.T, db *gorm.DB, args []string, opts ...IOrmOption) error {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	opts = append([]IOrmOption{WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})}, opts...)
+	if db != nil {
+		opts = append(opts, WithDB(db))
+	}
+	c := NewOrmCommand(opts...).Command()
+	c.SetArgs(append(args, "--config", ""))
+	c.SilenceUsage, c.SilenceErrors = true, true
+	return c.ExecuteContext(context.Background())
+}
+
+func TestErrNoDB(t *testing.T) {
+	err := run(t, nil, nil)
+	if !errors.Is(err, ErrNoDB) {
+		t.Fatalf("run = %v, want ErrNoDB", err)
+	}
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage {
+		t.Errorf("exit code %d, want %d", code, cmd.ExitUsage)
+	}
+}
+
+func TestErrConnect(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "missing", "app.db")
+	err := run(t, nil, []string{"--driver", "sqlite", "--dsn", dsn})
+	if !errors.Is(err, ErrConnect) {
+		t.Fatalf("run = %v, want ErrConnect", err)
+	}
+	if code := cmd.ExitCode(err, true); code != cmd.ExitExternal {
+		t.Errorf("exit code %d, want %d", code, cmd.ExitExternal)
+	}
+}
+
+func TestUnknownDriverError(t *testing.T) {
+	err := run(t, nil, []string{"--driver", "oracle", "--dsn", "x"})
+	var driver *UnknownDriverError
+	if !errors.As(err, &driver) || driver.Driver != "oracle" || !slices.Contains(driver.Known, "sqlite") {
+		t.Fatalf("run = %v, want an UnknownDriverError of oracle", err)
+	}
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage {
+		t.Errorf("exit code %d, want %d", code, cmd.ExitUsage)
+	}
+}
+
+func TestRuleSyntaxError(t *testing.T) {
+	err := run(t, openFixture(t, "users"), nil, WithOnlyFields([]string{"users->id", "users->name"}))
+	var syntax *RuleSyntaxError
+	if !errors.As(err, &syntax) || syntax.Rule != "users->name" {
+		t.Fatalf("run = %v, want a RuleSyntaxError of users->name", err)
+	}
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage {
+		t.Errorf("exit code %d, want %d", code, cmd.ExitUsage)
+	}
+}
+
+func TestUnknownTableError(t *testing.T) {
+	err := run(t, openFixture(t, "users"), []string{"-t", "user"})
+	var unknown *UnknownTableError
+	if !errors.As(err, &unknown) || unknown.Table != "user" || !slices.Contains(unknown.Suggestions, "users") {
+		t.Fatalf("run = %v, want an UnknownTableError of user suggesting users", err)
+	}
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage {
+		t.Errorf("exit code %d, want %d", code, cmd.ExitUsage)
+	}
+}
+
+func TestEmptyGenerationError(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		style string
+	}{
+		{"no table selected", []string{"--exclude", "users"}, "model"},
+		{"no struct for the dao", []string{"--style", "dao"}, "dao"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(t, openFixture(t, "users"), tt.args)
+			var empty *EmptyGenerationError
+			if !errors.As(err, &empty) || empty.Style != tt.style {
+				t.Fatalf("run = %v, want an EmptyGenerationError of the %s style", err, tt.style)
+			}
+			if code := cmd.ExitCode(err, true); code != cmd.ExitFailure {
+				t.Errorf("exit code %d, want %d", code, cmd.ExitFailure)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/imports"
+	"gorm.io/gen"
+)
+
+// examplesFile is the example file written to the query directory.
+const examplesFile = "example_test.go"
+
+// exampleReserved are the names taken by the locals of an example, a
+// parameter named after one gets an Arg suffix.
+var exampleReserved = []string{"ctx", "db", "q", "err", "result"}
+
+// tableExamples are the annotae methods of the dao of a table.
+type tableExamples struct {
+	Table, Model string
+	Methods      []mockMethod
+}
+
+// examples writes Example functions calling the annotae methods of the
+// selected tables to example_test.go in the query directory. The examples
+// compile without running, as they have no Output comment. The file is
+// only created when absent, so edited examples persist.
+func (o *Orm) examples() error {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(out, examplesFile)
+	keep, err := loadKeep(out)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil || keep.Kept(path) {
+		return nil
+	}
+	daoImport, err := o.importPath(out)
+	if err != nil {
+		return err
+	}
+
+	var tables []tableExamples
+	var specs []string
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		if table == "" || !selected(o.opt.daoTables, table) {
+			continue
+		}
+		var annotae []any
+		for _, key := range []string{"*", table} {
+			if api, ok := o.opt.daoApi[key]; ok {
+				annotae = append(annotae, api)
+			}
+		}
+		if len(annotae) == 0 {
+			continue
+		}
+
+		query := filepath.Join(out, file+".gen.go")
+		src, err := os.ReadFile(query)
+		if err != nil {
+			return err
+		}
+		model := metaString(meta, "ModelStructName")
+		ifaces, fileSpecs, err := mockInterfaces(src, model, metaString(meta, "QueryStructName")+"Do", annotae, o.acronyms)
+		if err != nil {
+			return fmt.Errorf("%s: %w", query, err)
+		}
+		te := tableExamples{Table: table, Model: model}
+		for _, mi := range ifaces {
+			for _, m := range mi.Methods {
+				if !slices.ContainsFunc(te.Methods, func(e mockMethod) bool { return e.Name == m.Name }) {
+					te.Methods = append(te.Methods, m)
+				}
+			}
+		}
+		tables = append(tables, te)
+		for _, spec := range fileSpecs {
+			if !slices.Contains(specs, spec) {
+				specs = append(specs, spec)
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	withContext := o.opt.gconf.Mode&gen.WithoutContext == 0
+	src, err := imports.Process(path, renderExamples(filepath.Base(out), daoImport, specs, tables, withContext), nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return o.writeFile(path, src, 0640)
+}
+
+// renderExamples renders the example file of the query package daoPkg.
+// With withContext the methods are called on the dao bound to a context,
+// else on the dao itself.
+// The imports of the query files are kept, imports.Process drops the unused.
+func renderExamples(daoPkg, daoImport string, specs []string, tables []tableExamples, withContext bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("// Scaffolded by command orm --with-examples. This file is never overwritten, edit freely.\n\n")
+	fmt.Fprintf(&buf, "package %s_test\n\nimport (\n\t\"context\"\n\t\"fmt\"\n\t\"log\"\n\n\t\"gorm.io/gorm\"\n", daoPkg)
+	for _, spec := range specs {
+		if !slices.Contains([]string{`"context"`, `"fmt"`, `"log"`, `"gorm.io/gorm"`}, spec) {
+			fmt.Fprintf(&buf, "\t%s\n", spec)
+		}
+	}
+	fmt.Fprintf(&buf, "\t%q\n)\n", daoImport)
+
+	for _, t := range tables {
+		for _, m := range t.Methods {
+			buf.WriteString(m.example(daoPkg, t, withContext))
+		}
+	}
+	return buf.Bytes()
+}
+
+// example renders the Example function of the method of a table. It is
+// attached to Use so go vet resolves its name in the query package.
+func (m mockMethod) example(daoPkg string, t tableExamples, withContext bool) string {
+	model := []rune(t.Model)
+	model[0] = unicode.ToLower(model[0])
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// This example calls %s on the %s dao.\n", m.Name, t.Table)
+	fmt.Fprintf(&buf, "func ExampleUse_%s%s() {\n", string(model), m.Name)
+	buf.WriteString("\tvar db *gorm.DB // opened with gorm.Open\n")
+	fmt.Fprintf(&buf, "\tq := %s.Use(db)\n", daoPkg)
+	dao := "q." + t.Model
+	if withContext {
+		buf.WriteString("\tctx := context.Background()\n")
+		dao += ".WithContext(ctx)"
+	}
+
+	var args []string
+	if len(m.Params) > 0 {
+		buf.WriteString("\n")
+	}
+	for i, p := range m.Params {
+		name := p.Name
+		if slices.Contains(exampleReserved, name) {
+			name += "Arg"
+		}
+		fmt.Fprintf(&buf, "\tvar %s %s\n", name, p.Type)
+		if m.Variadic && i == len(m.Params)-1 {
+			name += "..."
+		}
+		args = append(args, name)
+	}
+	call := fmt.Sprintf("%s.%s(%s)", dao, m.Name, strings.Join(args, ", "))
+
+	var results []string
+	printed := false
+	for i, r := range m.Results {
+		switch {
+		case r == "error" && i == len(m.Results)-1:
+			results = append(results, "err")
+		case len(m.Results) == 1 || len(m.Results) == 2 && m.Results[1] == "error":
+			results, printed = append(results, "result"), true
+		default:
+			results = append(results, "_")
+		}
+	}
+
+	buf.WriteString("\n")
+	switch {
+	case len(results) == 0:
+		fmt.Fprintf(&buf, "\t%s\n", call)
+	case slices.Equal(results, []string{"err"}):
+		fmt.Fprintf(&buf, "\tif err := %s; err != nil {\n\t\tlog.Fatal(err)\n\t}\n", call)
+	default:
+		assign := "="
+		if slices.ContainsFunc(results, func(r string) bool { return r != "_" }) {
+			assign = ":="
+		}
+		fmt.Fprintf(&buf, "\t%s %s %s\n", strings.Join(results, ", "), assign, call)
+		if slices.Contains(results, "err") {
+			buf.WriteString("\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n")
+		}
+	}
+	if printed {
+		buf.WriteString("\tfmt.Println(result)\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/annotae"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+)
+
+func TestExamples(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		mode gen.GenerateMode
+		call string
+	}{
+		{name: "context", call: "q.User.WithContext(ctx).GetByID(id)"},
+		{name: "no context", mode: gen.WithoutContext, call: "q.User.GetByID(id)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generateBuilt(t, openFixture(t, "users"), []string{"-t", "users", "--style", "dao", "--with-examples"},
+				WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model", Mode: tt.mode}),
+				WithDaoTables([]string{"users"}),
+				WithDaoApi(map[string]any{"*": func(annotae.Querier) {}}),
+			)
+			src, ok := files["dao/"+examplesFile]
+			if !ok {
+				t.Fatalf("no %s in %v", examplesFile, keys(files))
+			}
+			for _, want := range []string{"package dao_test", "func ExampleUse_userGetByID() {", tt.call} {
+				if !strings.Contains(src, want) {
+					t.Errorf("examples lack %q:\n%s", want, src)
+				}
+			}
+
+			// The examples are vetted and compiled by go test, without
+			// running as they have no Output comment
+			for _, args := range [][]string{{"vet", "./dao"}, {"test", "-run", "^Example", "./dao"}} {
+				if out, err := exec.Command("go", args...).CombinedOutput(); err != nil {
+					t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/imports"
+)
+
+// factoryFileSuffix ends the name of the factory file of a table, next to
+// its model.
+const factoryFileSuffix = "_factory.gen.go"
+
+// factoryPoolSize is the number of default values of a pool without samples.
+const factoryPoolSize = 3
+
+// sampleTimeLayouts are the layouts sampled time values are parsed with.
+var sampleTimeLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+// factoryField is a model field set by a factory, with the Go literals of
+// its value pool.
+type factoryField struct {
+	Name    string
+	Type    string
+	Pointer bool
+	Values  []string
+}
+
+// writeFactories writes the factory of the model of each generated table to
+// its factory file in the model package. The value pools of the fields are
+// the --sample values of their columns, or defaults of their type.
+func (o *Orm) writeFactories() error {
+	if !o.withFactories {
+		return nil
+	}
+	dir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	for _, meta := range o.structs {
+		table, file, model := metaString(meta, "TableName"), metaString(meta, "FileName"), metaString(meta, "ModelStructName")
+		if table == "" || file == "" {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(dir, file+".gen.go"))
+		if err != nil {
+			return err
+		}
+		fields, err := o.factoryFields(table, model, src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file+".gen.go", err)
+		}
+		path := filepath.Join(dir, file+factoryFileSuffix)
+		out, err := imports.Process(path, renderFactory(filepath.Base(dir), table, model, fields, len(o.samples[table]) > 0), nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := writeFileAtomic(path, out, 0640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// factoryFields returns the fields of the model a factory sets: every field
+// of a column but the primary key, of a string, number, bool or time type.
+func (o *Orm) factoryFields(table, model string, src []byte) ([]factoryField, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, col := range o.columns[table] {
+		keys[col.Name] = col.primaryKey()
+	}
+
+	var fields []factoryField
+	ast.Inspect(file, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != model {
+			return true
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		for _, f := range st.Fields.List {
+			typ := types.ExprString(f.Type)
+			pointer := strings.HasPrefix(typ, "*")
+			typ = strings.TrimPrefix(typ, "*")
+			for _, name := range f.Names {
+				column, ok := o.fields[table][name.Name]
+				if !ok || keys[column] {
+					continue
+				}
+				values, ok := factoryValues(typ, column, o.samples[table][column])
+				if !ok {
+					continue
+				}
+				fields = append(fields, factoryField{Name: name.Name, Type: typ, Pointer: pointer, Values: values})
+			}
+		}
+		return false
+	})
+	return fields, nil
+}
+
+// factoryValues returns the Go literals of the pool of a field of type typ:
+// the sampled values of its column that parse as typ, or defaults of typ.
+// It reports false for the types a factory leaves zero.
+func factoryValues(typ, column string, samples []string) ([]string, bool) {
+	var literal func(v string) (string, bool)
+	var defaults func(i int) string
+	switch typ {
+	case "string":
+		// Values cut by the sampling are left out
+		literal = func(v string) (string, bool) { return strconv.Quote(v), v != "NULL" && !strings.HasSuffix(v, "…") }
+		defaults = func(i int) string { return strconv.Quote(fmt.Sprintf("%s %d", column, i)) }
+	case "int", "int8", "int16", "int32", "int64":
+		literal = func(v string) (string, bool) {
+			_, err := strconv.ParseInt(v, 10, 64)
+			return v, err == nil
+		}
+		defaults = strconv.Itoa
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		literal = func(v string) (string, bool) {
+			_, err := strconv.ParseUint(v, 10, 64)
+			return v, err == nil
+		}
+		defaults = strconv.Itoa
+	case "float32", "float64":
+		literal = func(v string) (string, bool) {
+			f, err := strconv.ParseFloat(v, 64)
+			return strconv.FormatFloat(f, 'g', -1, 64), err == nil
+		}
+		defaults = func(i int) string { return strconv.Itoa(i) + ".5" }
+	case "bool":
+		literal = func(v string) (string, bool) {
+			b, err := strconv.ParseBool(v)
+			return strconv.FormatBool(b), err == nil
+		}
+		defaults = func(i int) string { return strconv.FormatBool(i%2 == 1) }
+	case "time.Time":
+		literal = func(v string) (string, bool) {
+			for _, layout := range sampleTimeLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return timeLiteral(t.UTC()), true
+				}
+			}
+			return "", false
+		}
+		defaults = func(i int) string { return timeLiteral(time.Date(2024, 1, i, 0, 0, 0, 0, time.UTC)) }
+	default:
+		return nil, false
+	}
+
+	var values []string
+	for _, s := range samples {
+		if v, ok := literal(s); ok && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	if len(values) > 0 {
+		return values, true
+	}
+	for i := 1; i <= factoryPoolSize; i++ {
+		if v := defaults(i); !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values, true
+}
+
+// timeLiteral returns the Go expression of t.
+func timeLiteral(t time.Time) string {
+	return fmt.Sprintf("time.Date(%d, %d, %d, %d, %d, %d, 0, time.UTC)", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
+}
+
+// renderFactory renders the factory file of the model of a table.
+func renderFactory(pkg, table, model string, fields []factoryField, sampled bool) []byte {
+	pools := strings.ToLower(model[:1]) + model[1:] + "Pools"
+	source := "defaults of their types"
+	if sampled {
+		source = "sampled from table " + table + " when the column had values"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by command orm. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n", pkg)
+	if len(fields) > 0 {
+		fmt.Fprintf(&buf, "\n// %s are the values Fake%s picks from, %s.\n", pools, model, source)
+		fmt.Fprintf(&buf, "var %s = struct {\n", pools)
+		for _, f := range fields {
+			fmt.Fprintf(&buf, "\t%s []%s\n", f.Name, f.Type)
+		}
+		buf.WriteString("}{\n")
+		for _, f := range fields {
+			fmt.Fprintf(&buf, "\t%s: []%s{%s},\n", f.Name, f.Type, strings.Join(f.Values, ", "))
+		}
+		buf.WriteString("}\n")
+	}
+
+	fmt.Fprintf(&buf, "\n// Fake%s returns a %s of realistic values for tests and seeds, the\n", model, model)
+	buf.WriteString("// n-th value of each pool, n not negative. The primary key is left zero.\n")
+	fmt.Fprintf(&buf, "func Fake%s(n int) *%s {\n", model, model)
+	fmt.Fprintf(&buf, "\treturn &%s{\n", model)
+	for _, f := range fields {
+		pick := fmt.Sprintf("%s.%s[n%%len(%s.%s)]", pools, f.Name, pools, f.Name)
+		if f.Pointer {
+			pick = fmt.Sprintf("func(v %s) *%s { return &v }(%s)", f.Type, f.Type, pick)
+		}
+		fmt.Fprintf(&buf, "\t\t%s: %s,\n", f.Name, pick)
+	}
+	buf.WriteString("\t}\n}\n")
+	return buf.Bytes()
+}
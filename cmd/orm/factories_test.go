@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFactoriesSampled(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL, email text NOT NULL, phone text, created_at datetime NOT NULL)",
+		"INSERT INTO users (name, email, phone, created_at) VALUES ('ada', 'ada@example.com', '555-0100', '2024-03-01 10:00:00')",
+		"INSERT INTO users (name, email, phone, created_at) VALUES ('grace', 'grace@example.com', NULL, '2024-03-02 11:30:00')",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := generateBuilt(t, db, []string{"--with-factories", "--sample", "2", "--sample-redact", "email"})
+	src, ok := files["model/users"+factoryFileSuffix]
+	if !ok {
+		t.Fatalf("no factory file in %v", keys(files))
+	}
+	for _, want := range []string{"func FakeUser(n int) *User", `"ada"`, `"grace"`, `"555-0100"`, `"<redacted>"`} {
+		if !strings.Contains(src, want) {
+			t.Errorf("factory lacks %s:\n%s", want, src)
+		}
+	}
+	for _, leak := range []string{"ada@example.com", "grace@example.com", "ID:"} {
+		if strings.Contains(src, leak) {
+			t.Errorf("factory contains %s:\n%s", leak, src)
+		}
+	}
+}
+
+func TestFactoriesDefaults(t *testing.T) {
+	files := generate(t, openFixture(t, "users"), []string{"--with-factories"})
+	src := files["model/users"+factoryFileSuffix]
+	for _, want := range []string{"func FakeUser(n int) *User", `"name 1"`, `"name 3"`, "time.Date(2024"} {
+		if !strings.Contains(src, want) {
+			t.Errorf("factory lacks %s:\n%s", want, src)
+		}
+	}
+
+	files = generate(t, openFixture(t, "users"), nil)
+	if _, ok := files["model/users"+factoryFileSuffix]; ok {
+		t.Error("factory written without --with-factories")
+	}
+}
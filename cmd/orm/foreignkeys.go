@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jinzhu/inflection"
+)
+
+// foreignKeySQL selects the foreign keys of tables of a schema by dialect,
+// one row per column. The referenced table must be in the same schema.
+var foreignKeySQL = map[string]string{
+	"mysql": "SELECT constraint_name AS name, constraint_name AS grp, table_name AS table_name, " +
+		"column_name AS column_name, referenced_table_name AS ref_table, referenced_column_name AS ref_column " +
+		"FROM information_schema.key_column_usage " +
+		"WHERE table_schema = ? AND referenced_table_schema = table_schema AND table_name IN ? " +
+		"ORDER BY table_name, constraint_name, ordinal_position",
+	"postgres": "SELECT c.conname AS name, c.conname AS grp, t.relname AS table_name, a.attname AS column_name, " +
+		"rt.relname AS ref_table, ra.attname AS ref_column " +
+		"FROM pg_constraint c " +
+		"JOIN pg_class t ON t.oid = c.conrelid " +
+		"JOIN pg_namespace n ON n.oid = t.relnamespace " +
+		"JOIN pg_class rt ON rt.oid = c.confrelid " +
+		"JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = ANY(c.conkey) " +
+		"JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = c.confkey[array_position(c.conkey, a.attnum)] " +
+		"WHERE c.contype = 'f' AND rt.relnamespace = t.relnamespace " +
+		"AND n.nspname = COALESCE(NULLIF(?, ''), current_schema()) AND t.relname IN ? " +
+		"ORDER BY t.relname, c.conname",
+	"sqlite": "SELECT '' AS name, m.name || '#' || p.id AS grp, m.name AS table_name, p.\"from\" AS column_name, " +
+		"p.\"table\" AS ref_table, COALESCE(p.\"to\", '') AS ref_column " +
+		"FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) p " +
+		"WHERE ? = '' AND m.type = 'table' AND m.name IN ? " +
+		"ORDER BY m.name, p.id, p.seq",
+}
+
+// foreignKey is a single-column foreign key read from the database.
+type foreignKey struct {
+	Name string `gorm:"column:name"`
+	// Group identifies the constraint of the column
+	Group     string `gorm:"column:grp"`
+	Table     string `gorm:"column:table_name"`
+	Column    string `gorm:"column:column_name"`
+	RefTable  string `gorm:"column:ref_table"`
+	RefColumn string `gorm:"column:ref_column"`
+}
+
+// String returns the constraint as name: table.column -> table.column.
+func (fk foreignKey) String() string {
+	s := fk.Table + "." + fk.Column + " -> " + fk.RefTable + "." + fk.RefColumn
+	if fk.Name != "" {
+		s = fk.Name + ": " + s
+	}
+	return s
+}
+
+// skipped reports whether the foreign key is listed by --skip-relations, by
+// constraint name or as table.column.
+func (fk foreignKey) skipped(skip []string) bool {
+	return slices.Contains(skip, fk.Table+"."+fk.Column) || (fk.Name != "" && slices.Contains(skip, fk.Name))
+}
+
+// foreignKeys reads the single-column foreign keys of tables, the composite
+// ones having no relation to infer.
+func (o *Orm) foreignKeys(tables []string) ([]foreignKey, error) {
+	query, ok := foreignKeySQL[o.meta.Dialector.Name()]
+	if !ok {
+		o.warn(warnRelations, "Warning: --with-relations does not support the %s dialect, no relation inferred\n", o.meta.Dialector.Name())
+		return nil, nil
+	}
+
+	var schemas []string
+	bySchema := make(map[string][]string)
+	for _, table := range tables {
+		schema, bare := splitTable(table)
+		if _, ok := bySchema[schema]; !ok {
+			schemas = append(schemas, schema)
+		}
+		bySchema[schema] = append(bySchema[schema], bare)
+	}
+
+	var fks []foreignKey
+	for _, schema := range schemas {
+		db := schema
+		if db == "" && o.meta.Dialector.Name() == "mysql" {
+			db = o.meta.Migrator().CurrentDatabase()
+		}
+		var rows []foreignKey
+		if err := o.meta.Raw(query, db, bySchema[schema]).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("reading the foreign keys: %w", err)
+		}
+		columns := make(map[string]int)
+		for _, fk := range rows {
+			columns[fk.Group]++
+		}
+		for _, fk := range rows {
+			if columns[fk.Group] > 1 {
+				continue
+			}
+			if schema != "" {
+				fk.Table, fk.RefTable = schema+"."+fk.Table, schema+"."+fk.RefTable
+			}
+			fks = append(fks, fk)
+		}
+	}
+	return fks, nil
+}
+
+// inferRelations returns the relations of the foreign keys between the
+// generated tables: the model of the table holding the key belongs to the
+// referenced model, named after the key column less its "_id" suffix, and
+// the referenced model has many of them, named after the plural of their
+// model prefixed by the role of the key. Foreign keys listed in skip, to tables left out of the run, or whose
+// field is taken by a column or a declared relation infer nothing.
+func (o *Orm) inferRelations(generated map[string]any, skip []string) ([]Relation, error) {
+	if _, ok := snapshotOf(o.meta); ok {
+		o.warn(warnRelations, "Warning: --with-relations skipped, the run has no database connection\n")
+		return nil, nil
+	}
+	fks, err := o.foreignKeys(sortedKeys(generated))
+	if err != nil {
+		return nil, err
+	}
+
+	// Field names taken by the columns and the declared relations by table
+	taken := make(map[string][]string)
+	for table := range generated {
+		for field := range o.fields[table] {
+			taken[table] = append(taken[table], field)
+		}
+	}
+	for _, r := range o.opt.relations {
+		taken[r.Table] = append(taken[r.Table], r.Field)
+	}
+	fieldOf := func(table, column string) string {
+		for field, col := range o.fields[table] {
+			if col == column {
+				return field
+			}
+		}
+		return ""
+	}
+
+	var relations []Relation
+	var lines []string
+	add := func(r Relation, fk foreignKey) {
+		if slices.Contains(taken[r.Table], r.Field) {
+			o.warn(warnRelations, "Warning: relation %s.%s of %s not inferred, the field exists\n", r.Table, r.Field, fk)
+			return
+		}
+		taken[r.Table] = append(taken[r.Table], r.Field)
+		relations = append(relations, r)
+		lines = append(lines, fmt.Sprintf("  %s.%s %s %s (%s)", r.Table, r.Field, strings.ReplaceAll(r.Type, "_", " "), r.Target, fk))
+	}
+
+	for _, fk := range fks {
+		if fk.skipped(skip) {
+			continue
+		}
+		if _, ok := generated[fk.RefTable]; !ok {
+			continue
+		}
+		if fk.RefColumn == "" {
+			for _, col := range o.columns[fk.RefTable] {
+				if col.primaryKey() {
+					fk.RefColumn = col.Name
+				}
+			}
+		}
+		key, ref := fieldOf(fk.Table, fk.Column), fieldOf(fk.RefTable, fk.RefColumn)
+		if key == "" || ref == "" {
+			continue
+		}
+		tag := "foreignKey:" + key + ";references:" + ref
+
+		target := metaString(generated[fk.RefTable], "ModelStructName")
+		name := target
+		if base, ok := strings.CutSuffix(strings.ToLower(fk.Column), "_id"); ok && base != "" {
+			name = acronymize(o.meta.NamingStrategy.SchemaName(base), o.acronyms)
+		}
+		add(Relation{Table: fk.Table, Type: BelongsTo, Field: name, Target: fk.RefTable, GormTag: tag}, fk)
+
+		// Keys naming a role, like seller_id, prefix the other side: SellerOrders
+		many := inflection.Plural(metaString(generated[fk.Table], "ModelStructName"))
+		if name != target && fk.Table != fk.RefTable {
+			many = name + many
+		}
+		add(Relation{Table: fk.RefTable, Type: HasMany, Field: many, Target: fk.Table, GormTag: tag}, fk)
+	}
+
+	if len(lines) > 0 {
+		color.Cyan("Inferred %d relations from the foreign keys:\n", len(relations))
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	}
+	return relations, nil
+}
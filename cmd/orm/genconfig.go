@@ -0,0 +1,87 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gen"
+)
+
+// Defaults of the omitted gen.Config paths.
+const (
+	defaultOutPath      = "./dao"
+	defaultModelPkgPath = "./model"
+)
+
+// genModes are the mode bits gen knows.
+const genModes = gen.WithDefaultQuery | gen.WithoutContext | gen.WithQueryInterface
+
+// normalizeConfig fills the omitted paths of the gen.Config of the options
+// and checks it, so a mistake fails before gen runs with an error naming the
+// field and the fix.
+func (o *Orm) normalizeConfig() error {
+	conf := &o.opt.gconf
+	if strings.TrimSpace(conf.OutPath) == "" {
+		conf.OutPath = defaultOutPath
+	}
+	if strings.TrimSpace(conf.ModelPkgPath) == "" {
+		conf.ModelPkgPath = defaultModelPkgPath
+	}
+
+	out, err := filepath.Abs(conf.OutPath)
+	if err != nil {
+		return &ConfigError{Field: "OutPath", Value: conf.OutPath, Reason: err.Error(), Fix: "use a valid directory path"}
+	}
+	if fi, err := os.Stat(out); err == nil && !fi.IsDir() {
+		return &ConfigError{Field: "OutPath", Value: conf.OutPath, Reason: "is a file", Fix: "point it at a directory, such as " + defaultOutPath}
+	}
+	if name := filepath.Base(out); !token.IsIdentifier(name) {
+		return &ConfigError{Field: "OutPath", Value: conf.OutPath, Reason: fmt.Sprintf("names the query package %q, which is not a Go identifier", name), Fix: "end the path with a valid package name, such as dao"}
+	}
+	if conf.OutFile != "" && !strings.HasSuffix(conf.OutFile, ".go") {
+		return &ConfigError{Field: "OutFile", Value: conf.OutFile, Reason: "is not a Go file", Fix: "use a name ending in .go, or leave it empty for gen.go"}
+	}
+
+	model, err := o.modelOutPath()
+	if err != nil {
+		return &ConfigError{Field: "ModelPkgPath", Value: conf.ModelPkgPath, Reason: err.Error(), Fix: "use a valid directory path"}
+	}
+	if model == out {
+		return &ConfigError{Field: "ModelPkgPath", Value: conf.ModelPkgPath, Reason: "resolves to the OutPath directory, mixing models and queries in one package", Fix: "use a sibling directory, such as " + defaultModelPkgPath}
+	}
+	if name := filepath.Base(model); !token.IsIdentifier(name) {
+		return &ConfigError{Field: "ModelPkgPath", Value: conf.ModelPkgPath, Reason: fmt.Sprintf("names the model package %q, which is not a Go identifier", name), Fix: "end the path with a valid package name, such as model"}
+	}
+
+	if unknown := conf.Mode &^ genModes; unknown != 0 {
+		return &ConfigError{Field: "Mode", Value: fmt.Sprintf("%#x", uint(conf.Mode)), Reason: fmt.Sprintf("has the unknown bits %#x", uint(unknown)), Fix: "combine gen.WithDefaultQuery, gen.WithoutContext and gen.WithQueryInterface only"}
+	}
+
+	if conf.FieldNullable && len(o.opt.nullable) > 0 {
+		return &ConfigError{Field: "FieldNullable", Value: "true", Reason: "conflicts with WithNullable, which picks the pointer columns one by one", Fix: "unset it and list every pointer column with WithNullable, or keep it and list the exceptions with WithNotNullable"}
+	}
+
+	cmd.Debugf("gen.Config: OutPath=%s OutFile=%s ModelPkgPath=%s Mode=%#x FieldNullable=%t FieldCoverable=%t FieldSignable=%t FieldWithIndexTag=%t FieldWithTypeTag=%t\n",
+		conf.OutPath, conf.OutFile, conf.ModelPkgPath, uint(conf.Mode),
+		conf.FieldNullable, conf.FieldCoverable, conf.FieldSignable, conf.FieldWithIndexTag, conf.FieldWithTypeTag)
+	return nil
+}
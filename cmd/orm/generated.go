@@ -0,0 +1,63 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+)
+
+// generatedColumns returns the virtual and stored generated columns of the
+// given table. DEFAULT_GENERATED (expression defaults such as
+// CURRENT_TIMESTAMP) is not a generated column and stays writable.
+// Only MySQL reports them, other dialects and servers older than the minimum
+// version return an empty list.
+func (o *Orm) generatedColumns(table string) []string {
+	if o.isDegraded(degradedGenerated) {
+		return nil
+	}
+	var columns []string
+	for _, col := range o.columns[table] {
+		if strings.Contains(col.Extra, "VIRTUAL GENERATED") || strings.Contains(col.Extra, "STORED GENERATED") {
+			columns = append(columns, col.Name)
+		}
+	}
+	return columns
+}
+
+// generatedOpts returns the model options for the generated columns of a table.
+// The columns are either dropped or marked read-only, so they are still scanned
+// but never written by create or update statements.
+func (o *Orm) generatedOpts(table string) []gen.ModelOpt {
+	columns := o.generatedColumns(table)
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if o.skipGenerated {
+		return []gen.ModelOpt{gen.FieldIgnore(columns...)}
+	}
+
+	var opts []gen.ModelOpt
+	for _, col := range columns {
+		opts = append(opts, gen.FieldGORMTag(col, func(tag field.GormTag) field.GormTag {
+			return tag.Set("->").Set("<-", "false")
+		}))
+	}
+	return opts
+}
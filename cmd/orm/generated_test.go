@@ -0,0 +1,62 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratedColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		readOnly map[string]string
+		missing  []string
+	}{
+		{
+			name:     "read-only",
+			readOnly: map[string]string{"Total": "total", "SkuUpper": "sku_upper"},
+		},
+		{
+			name:    "skip",
+			args:    []string{"--skip-generated"},
+			missing: []string{"Total", "SkuUpper"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generate(t, openFixture(t, "generated"), append([]string{"-t", "order_lines"}, tt.args...))
+			src := files["model/order_lines.gen.go"]
+			for name, column := range tt.readOnly {
+				if line := fieldLine(src, name); !strings.Contains(line, "column:"+column+";->;<-:false") {
+					t.Errorf("%s is not read-only: %q", name, line)
+				}
+			}
+			for _, name := range tt.missing {
+				if line := fieldLine(src, name); line != "" {
+					t.Errorf("%s not skipped: %q", name, line)
+				}
+			}
+			// Writable columns, DEFAULT_GENERATED included, keep their tags
+			for _, name := range []string{"ID", "Price", "Qty", "CreatedAt"} {
+				if line := fieldLine(src, name); line == "" || strings.Contains(line, "<-:false") {
+					t.Errorf("%s is not writable: %q", name, line)
+				}
+			}
+		})
+	}
+}
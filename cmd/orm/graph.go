@@ -0,0 +1,237 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// graphFile is a generated file and the tables it was generated from.
+	graphFile struct {
+		Path   string   `json:"path"`
+		Tables []string `json:"tables"`
+	}
+	// graphNode is a package containing generated files, or a package of the
+	// module imported by them.
+	graphNode struct {
+		Package   string      `json:"package"`
+		Dir       string      `json:"dir,omitempty"`
+		Generated bool        `json:"generated"`
+		Files     []graphFile `json:"files,omitempty"`
+	}
+	// graphEdge is an import of package To by generated files of package From.
+	graphEdge struct {
+		From   string   `json:"from"`
+		To     string   `json:"to"`
+		Tables []string `json:"tables"`
+	}
+	// depGraph is the dependency graph written by --graph.
+	depGraph struct {
+		Nodes []*graphNode `json:"nodes"`
+		Edges []*graphEdge `json:"edges"`
+	}
+)
+
+// writeGraph writes the dependency graph of the generated packages to path.
+// The edges are read from the imports of the written files, so they match
+// the emitted code, and everything is sorted to keep the output stable.
+func (o *Orm) writeGraph(path string, dao bool) error {
+	files, err := o.generatedFiles(dao)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	nodes := make(map[string]*graphNode)
+	edges := make(map[string]*graphEdge)
+	var module string
+	for _, file := range sortedKeys(files) {
+		tables := files[file]
+		dir := filepath.Dir(file)
+		pkg, err := o.importPath(dir)
+		if err != nil {
+			return err
+		}
+		if module == "" {
+			if _, module, err = moduleRoot(dir); err != nil {
+				return err
+			}
+			module = cmp.Or(o.modulePath, module)
+		}
+
+		node, ok := nodes[pkg]
+		if !ok {
+			node = &graphNode{Package: pkg}
+			nodes[pkg] = node
+		}
+		node.Dir, node.Generated = relPath(cwd, dir), true
+		node.Files = append(node.Files, graphFile{Path: relPath(cwd, file), Tables: tables})
+
+		imports, err := fileImports(file)
+		if err != nil {
+			return err
+		}
+		for _, imp := range imports {
+			if imp == pkg || (imp != module && !strings.HasPrefix(imp, module+"/")) {
+				continue
+			}
+			if _, ok := nodes[imp]; !ok {
+				nodes[imp] = &graphNode{Package: imp}
+			}
+			edge, ok := edges[pkg+" "+imp]
+			if !ok {
+				edge = &graphEdge{From: pkg, To: imp}
+				edges[pkg+" "+imp] = edge
+			}
+			edge.Tables = mergeSorted(edge.Tables, tables)
+		}
+	}
+
+	var graph depGraph
+	for _, key := range sortedKeys(nodes) {
+		graph.Nodes = append(graph.Nodes, nodes[key])
+	}
+	for _, key := range sortedKeys(edges) {
+		graph.Edges = append(graph.Edges, edges[key])
+	}
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0644)
+}
+
+// generatedFiles returns the files written by the current run with the
+// tables each was generated from.
+func (o *Orm) generatedFiles(dao bool) (map[string][]string, error) {
+	outFile := o.opt.gconf.OutFile
+	if outFile == "" {
+		outFile = "gen.go"
+	}
+
+	files := make(map[string][]string)
+	add := func(path, table string) error {
+		// Files sent to a WriteFS are reported by their name
+		if o.written != nil {
+			if o.written[path] {
+				files[fsName(path)] = mergeSorted(files[fsName(path)], []string{table})
+			}
+			return nil
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		files[path] = mergeSorted(files[path], []string{table})
+		return nil
+	}
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		if table == "" {
+			continue
+		}
+		modelDir, outDir, err := o.outputDirs(meta)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range []string{
+			filepath.Join(modelDir, file+".gen.go"),
+			filepath.Join(modelDir, file+enumsFileSuffix),
+			filepath.Join(modelDir, file+factoryFileSuffix),
+		} {
+			if err := add(path, table); err != nil {
+				return nil, err
+			}
+		}
+		if !dao || !selected(o.opt.daoTables, table) {
+			continue
+		}
+		for _, path := range []string{
+			filepath.Join(outDir, file+".gen.go"),
+			filepath.Join(outDir, file+"_bench_test.go"),
+			filepath.Join(outDir, mocksPackage, file+".gen.go"),
+			filepath.Join(outDir, examplesFile),
+			filepath.Join(outDir, outFile),
+		} {
+			if err := add(path, table); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// fileImports returns the import paths of a Go file.
+func fileImports(path string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	imports := make([]string, 0, len(file.Imports))
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, p)
+	}
+	return imports, nil
+}
+
+// relPath returns path relative to base in slash form, or path itself.
+func relPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeSorted returns the sorted union of a and b.
+func mergeSorted(a, b []string) []string {
+	for _, v := range b {
+		if !slices.Contains(a, v) {
+			a = append(a, v)
+		}
+	}
+	sort.Strings(a)
+	return a
+}
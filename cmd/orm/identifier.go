@@ -0,0 +1,171 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gorm.io/gen"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// identifierPrefix is prepended to field names that would not start with an
+// upper case letter.
+const identifierPrefix = "Col"
+
+// defaultAcronyms are always upper-cased in generated names.
+var defaultAcronyms = []string{"ID", "URL", "API", "UUID", "IP", "HTTP"}
+
+// titleWord matches the title-cased words of an identifier.
+var titleWord = regexp.MustCompile(`[A-Z][a-z0-9]*`)
+
+// sanitizeIdentifier turns the field name gen derives from a column into a
+// valid exported Go identifier:
+//
+//   - runes that are not letters, digits or underscores are replaced with "_"
+//   - names starting with a digit, an underscore or a caseless letter get the
+//     "Col" prefix, so "2fa_enabled" becomes "Col2FaEnabled"
+//   - keywords need no handling, gen title-cases every name ("type" becomes
+//     "Type"), and names clashing with dao methods are suffixed by gen itself
+func sanitizeIdentifier(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name)
+
+	for _, r := range name {
+		if !unicode.IsUpper(r) {
+			return identifierPrefix + name
+		}
+		break
+	}
+	return name
+}
+
+// identifierOpts returns the options naming the fields of a table: sanitized
+// names with upper-cased acronyms, then the explicit WithFieldRename entries
+// which always win.
+// The JSON and gorm tags keep referencing the original column.
+func (o *Orm) identifierOpts(table string) []gen.ModelOpt {
+	renames := make(map[string]string)
+	for _, key := range []string{"*", table} {
+		for column, name := range o.rules.fieldRenames[key] {
+			renames[column] = name
+		}
+	}
+
+	return []gen.ModelOpt{gen.FieldModify(func(f gen.Field) gen.Field {
+		if name, ok := renames[f.ColumnName]; ok {
+			f.Name = name
+			return f
+		}
+		if name := fieldName(o.meta, f); name != "" {
+			f.Name = acronymize(sanitizeIdentifier(name), o.acronyms)
+		}
+		return f
+	})}
+}
+
+// renameNamer is the naming strategy of gen, which runs the field names of
+// identifierOpts through it once more. It keeps the explicit WithFieldRename
+// names it would change, so "ApiUrl" is not turned into "APIURL".
+type renameNamer struct {
+	schema.Namer
+	db   *gorm.DB
+	keep map[string]bool
+}
+
+func (n renameNamer) SchemaName(name string) string {
+	if n.keep[name] {
+		return name
+	}
+	return columnFieldName(n.db, name)
+}
+
+// generatorDB returns the connection gen reads db through, with the naming
+// strategy keeping the explicit field names.
+func (o *Orm) generatorDB(db *gorm.DB) *gorm.DB {
+	keep := make(map[string]bool)
+	for _, name := range o.opt.fieldRename {
+		if columnFieldName(db, name) != name {
+			keep[name] = true
+		}
+	}
+	if len(keep) == 0 {
+		return db
+	}
+	named := db.Session(&gorm.Session{NewDB: true})
+	conf := *named.Config
+	conf.NamingStrategy = renameNamer{Namer: db.NamingStrategy, db: db, keep: keep}
+	named.Config = &conf
+	return named
+}
+
+// structFieldName returns the struct field name of a column of the table,
+// as named by identifierOpts.
+func (o *Orm) structFieldName(table, column string) string {
+	for _, key := range []string{table, "*"} {
+		if name, ok := o.rules.fieldRenames[key][column]; ok {
+			return name
+		}
+	}
+	if name := columnFieldName(o.meta, column); name != "" {
+		return acronymize(sanitizeIdentifier(name), o.acronyms)
+	}
+	return column
+}
+
+// acronymize upper-cases the title-cased words of name that are acronyms,
+// so "UserSkuId" becomes "UserSKUID" with SKU and ID registered.
+func acronymize(name string, acronyms []string) string {
+	return titleWord.ReplaceAllStringFunc(name, func(word string) string {
+		if upper := strings.ToUpper(word); slices.Contains(acronyms, upper) {
+			return upper
+		}
+		return word
+	})
+}
+
+// mergeAcronyms returns the default acronyms with the given lists, upper-cased.
+func mergeAcronyms(lists ...[]string) []string {
+	acronyms := slices.Clone(defaultAcronyms)
+	for _, list := range lists {
+		for _, a := range list {
+			if a = strings.ToUpper(strings.TrimSpace(a)); a != "" && !slices.Contains(acronyms, a) {
+				acronyms = append(acronyms, a)
+			}
+		}
+	}
+	return acronyms
+}
+
+// modelName returns the model name of a table, named by WithModelNameStrategy
+// or else by the naming strategy of the database like gen does, with
+// upper-cased acronyms.
+func (o *Orm) modelName(table string) string {
+	if o.opt.modelNameStrategy != nil {
+		if name := o.opt.modelNameStrategy(table); name != "" {
+			return name
+		}
+	}
+	return acronymize(o.meta.NamingStrategy.SchemaName(table), o.acronyms)
+}
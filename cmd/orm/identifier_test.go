@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHostileColumnNames(t *testing.T) {
+	files := generateBuilt(t, openFixture(t, "hostile"), []string{"-t", "settings", "--style", "dao"},
+		WithDaoTables([]string{"settings"}),
+		WithFieldRename(map[string]string{"settings->user-name": "Login"}),
+	)
+	src := files["model/settings.gen.go"]
+
+	// The tags keep the original column names
+	for field, column := range map[string]string{
+		"Type":          "type",
+		"Func":          "func",
+		"Range":         "range",
+		"Col2FaEnabled": "2fa_enabled",
+		"Internal":      "_internal",
+		"Login":         "user-name",
+		"Price":         "price$",
+		"Select":        "select",
+	} {
+		line := fieldLine(src, field)
+		if !strings.Contains(line, `gorm:"column:`+column+";") && !strings.Contains(line, `gorm:"column:`+column+`"`) {
+			t.Errorf("field %s of column %s: %q", field, column, line)
+		}
+		if !strings.Contains(line, `json:"`+column) {
+			t.Errorf("JSON tag of column %s: %q", column, line)
+		}
+	}
+}
+
+func TestSanitizeIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"Type":       "Type",
+		"2faEnabled": "Col2faEnabled",
+		"_internal":  "Col_internal",
+		"User-name":  "User_name",
+		"Ümlaut":     "Ümlaut",
+		"日本":         "Col日本",
+		"Price$":     "Price_",
+	}
+	for in, want := range tests {
+		if got := sanitizeIdentifier(in); got != want {
+			t.Errorf("sanitizeIdentifier(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// includeTimeout bounds the fetch of a remote include.
+const includeTimeout = 10 * time.Second
+
+// includeFetcher fetches remote config includes through an on-disk cache.
+// Cached copies are revalidated with their ETag, and served without any
+// network access in offline mode.
+type includeFetcher struct {
+	// disabled rejects every remote include
+	disabled bool
+	// offline serves remote includes from the cache only
+	offline bool
+	// cacheDir stores the fetched includes and their ETags
+	cacheDir string
+	client   *http.Client
+}
+
+// newIncludeFetcher creates an include fetcher caching into the user cache directory.
+func newIncludeFetcher(disabled, offline bool) includeFetcher {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return includeFetcher{
+		disabled: disabled,
+		offline:  offline,
+		cacheDir: filepath.Join(dir, "czx-command", "includes"),
+		client:   &http.Client{Timeout: includeTimeout},
+	}
+}
+
+// fetch returns the content of a remote include.
+func (f includeFetcher) fetch(url string) ([]byte, error) {
+	if f.disabled {
+		return nil, errors.New("remote includes are disabled")
+	}
+	if !strings.HasPrefix(url, "https://") {
+		return nil, errors.New("remote includes must be fetched over https")
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	key := filepath.Join(f.cacheDir, hex.EncodeToString(sum[:]))
+	cached, cacheErr := os.ReadFile(key)
+	if f.offline {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("include is not cached for offline use: %w", cacheErr)
+		}
+		return cached, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if etag, err := os.ReadFile(key + ".etag"); err == nil && cacheErr == nil {
+		req.Header.Set("If-None-Match", string(etag))
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cacheErr != nil {
+			return nil, cacheErr
+		}
+		return cached, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Caching is best effort, a failed write only costs a refetch
+	if err := os.MkdirAll(f.cacheDir, 0755); err == nil {
+		if err := writeFileAtomic(key, data, 0644); err == nil {
+			if etag := resp.Header.Get("ETag"); etag != "" {
+				writeFileAtomic(key+".etag", []byte(etag), 0644)
+			} else {
+				os.Remove(key + ".etag")
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// includeServer serves config includes over https with an ETag, answering
+// the requests revalidating the current version with 304.
+type includeServer struct {
+	mu       sync.Mutex
+	files    map[string]string
+	etag     string
+	requests []string
+}
+
+func (s *includeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	match := r.Header.Get("If-None-Match")
+	s.requests = append(s.requests, r.URL.Path+" "+match)
+	content, ok := s.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if match != "" && match == s.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	w.Write([]byte(content))
+}
+
+// startIncludeServer returns the includes server and a fetcher of it with
+// an empty cache.
+func startIncludeServer(t *testing.T, files map[string]string) (*includeServer, *httptest.Server, includeFetcher) {
+	t.Helper()
+	s := &includeServer{files: files, etag: `"v1"`}
+	srv := httptest.NewTLSServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv, includeFetcher{cacheDir: t.TempDir(), client: srv.Client()}
+}
+
+func TestIncludeFetcherCache(t *testing.T) {
+	s, srv, f := startIncludeServer(t, map[string]string{"/rules.yaml": "ignore: [users->password]\n"})
+	url := srv.URL + "/rules.yaml"
+
+	for i, want := range []string{"/rules.yaml ", `/rules.yaml "v1"`} {
+		data, err := f.fetch(url)
+		if err != nil {
+			t.Fatalf("fetch %d: %v", i, err)
+		}
+		if string(data) != s.files["/rules.yaml"] {
+			t.Errorf("fetch %d = %q", i, data)
+		}
+		if got := s.requests[len(s.requests)-1]; got != want {
+			t.Errorf("request %d = %q, want %q", i, got, want)
+		}
+	}
+
+	// A new version replaces the cached copy
+	s.files["/rules.yaml"], s.etag = "ignore: [users->token]\n", `"v2"`
+	if data, err := f.fetch(url); err != nil || string(data) != s.files["/rules.yaml"] {
+		t.Errorf("fetch of the new version = %q, %v", data, err)
+	}
+
+	// Offline, the cached copy is served without a request
+	requests := len(s.requests)
+	f.offline = true
+	if data, err := f.fetch(url); err != nil || string(data) != "ignore: [users->token]\n" {
+		t.Errorf("offline fetch = %q, %v", data, err)
+	}
+	if _, err := f.fetch(srv.URL + "/other.yaml"); err == nil || !strings.Contains(err.Error(), "not cached for offline use") {
+		t.Errorf("offline fetch of an uncached include = %v", err)
+	}
+	if len(s.requests) != requests {
+		t.Errorf("offline fetches sent %v", s.requests[requests:])
+	}
+}
+
+func TestIncludeFetcherRefused(t *testing.T) {
+	_, srv, f := startIncludeServer(t, map[string]string{"/rules.yaml": "ignore: []\n"})
+
+	for _, tt := range []struct {
+		name, url, err string
+		disabled       bool
+	}{
+		{name: "plain http", url: "http://example.com/rules.yaml", err: "must be fetched over https"},
+		{name: "disabled", url: srv.URL + "/rules.yaml", disabled: true, err: "remote includes are disabled"},
+		{name: "not found", url: srv.URL + "/missing.yaml", err: "unexpected status 404"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f.disabled = tt.disabled
+			if _, err := f.fetch(tt.url); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("fetch = %v, want an error with %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigIncludes(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		files  map[string]string
+		ignore []string
+		err    string
+	}{
+		{
+			name: "includes before the rules of the file",
+			files: map[string]string{
+				"orm.yaml":          "include: [shared/rules.yaml]\nignore: [a]\n",
+				"shared/rules.yaml": "include: [base.yaml]\nignore: [b]\n",
+				"shared/base.yaml":  "ignore: [c]\n",
+			},
+			ignore: []string{"c", "b", "a"},
+		},
+		{
+			name: "diamond",
+			files: map[string]string{
+				"orm.yaml": "include: [b.yaml, c.yaml]\nignore: [a]\n",
+				"b.yaml":   "include: [d.yaml]\nignore: [b]\n",
+				"c.yaml":   "include: [d.yaml]\nignore: [c]\n",
+				"d.yaml":   "ignore: [d]\n",
+			},
+			ignore: []string{"d", "b", "c", "a"},
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"orm.yaml": "include: [b.yaml]\n",
+				"b.yaml":   "include: [c.yaml]\n",
+				"c.yaml":   "include: [b.yaml]\n",
+			},
+			err: "include cycle: ",
+		},
+		{
+			name:  "self include",
+			files: map[string]string{"orm.yaml": "include: [orm.yaml]\n"},
+			err:   "include cycle: ",
+		},
+		{
+			name:  "missing include",
+			files: map[string]string{"orm.yaml": "include: [missing.yaml]\n"},
+			err:   "missing.yaml: open ",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigs(t, dir, tt.files)
+			conf, err := loadConfig(filepath.Join(dir, "orm.yaml"), "", newIncludeFetcher(true, false))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("load = %v, want an error with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(conf.Ignore, tt.ignore) {
+				t.Errorf("ignore = %v, want %v", conf.Ignore, tt.ignore)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRemoteIncludes(t *testing.T) {
+	s, srv, f := startIncludeServer(t, map[string]string{
+		"/org/rules.yaml": "include: [base.yaml]\nignore: [b]\n",
+		"/org/base.yaml":  "ignore: [c]\n",
+	})
+	dir := t.TempDir()
+	writeConfigs(t, dir, map[string]string{"orm.yaml": "include: [" + srv.URL + "/org/rules.yaml]\nignore: [a]\n"})
+
+	// The relative include of a URL resolves against it
+	conf, err := loadConfig(filepath.Join(dir, "orm.yaml"), "", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c", "b", "a"}; !slices.Equal(conf.Ignore, want) {
+		t.Errorf("ignore = %v, want %v", conf.Ignore, want)
+	}
+	if len(s.requests) != 2 {
+		t.Errorf("requests = %v", s.requests)
+	}
+
+	writeConfigs(t, dir, map[string]string{"orm.yaml": "include: [http://example.com/rules.yaml]\n"})
+	if _, err := loadConfig(filepath.Join(dir, "orm.yaml"), "", f); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("load of a plain http include = %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"gorm.io/gorm"
+)
+
+// introspectColumnSQL selects the column metadata of several tables at once,
+// in the order of a per-table query. The columns are aliased as MySQL 8
+// reports information_schema column names in upper case.
+const introspectColumnSQL = "SELECT table_name AS table_name, column_name AS column_name, " +
+	"data_type AS data_type, column_type AS column_type, is_nullable AS is_nullable, " +
+	"column_comment AS column_comment, column_key AS column_key, extra AS extra " +
+	"FROM information_schema.columns " +
+	"WHERE table_schema = ? AND table_name IN ? " +
+	"ORDER BY table_name, ORDINAL_POSITION"
+
+// columnMeta is the metadata of a column read during introspection.
+type columnMeta struct {
+	Table    string `gorm:"column:table_name"`
+	Name     string `gorm:"column:column_name"`
+	DataType string `gorm:"column:data_type"`
+	// Type is the full column type, such as varchar(64) or int unsigned
+	Type     string `gorm:"column:column_type"`
+	Nullable string `gorm:"column:is_nullable"`
+	Comment  string `gorm:"column:column_comment"`
+	Key      string `gorm:"column:column_key"`
+	Extra    string `gorm:"column:extra"`
+}
+
+// primaryKey reports whether the column is part of the primary key.
+func (c columnMeta) primaryKey() bool {
+	return c.Key == "PRI"
+}
+
+// introspect loads the column metadata of tables, batch tables per statement
+// where the dialect allows it, and reports the progress on large schemas.
+// The comments are dropped on a server too old to trust them.
+func (o *Orm) introspect(tables []string, batch int) error {
+	defer o.phase(phaseIntrospect)()
+	o.columns = make(map[string][]columnMeta, len(tables))
+	if batch < 1 {
+		batch = 1
+	}
+
+	// Schema-qualified tables are read per schema
+	var schemas []string
+	bySchema := make(map[string][]string)
+	for _, table := range tables {
+		schema, _ := splitTable(table)
+		if _, ok := bySchema[schema]; !ok {
+			schemas = append(schemas, schema)
+		}
+		bySchema[schema] = append(bySchema[schema], table)
+	}
+
+	progress := len(tables) > batch && !o.quiet
+	done := 0
+	for _, schema := range schemas {
+		group := bySchema[schema]
+		for start := 0; start < len(group); start += batch {
+			chunk := group[start:min(start+batch, len(group))]
+			if err := o.introspectChunk(schema, chunk); err != nil {
+				return err
+			}
+			done += len(chunk)
+			if progress {
+				fmt.Fprint(os.Stderr, color.HiBlackString("\rIntrospected %d/%d tables", done, len(tables)))
+			}
+		}
+	}
+	if progress {
+		fmt.Fprintln(os.Stderr)
+	}
+	if o.isDegraded(degradedComments) {
+		for _, columns := range o.columns {
+			for i := range columns {
+				columns[i].Comment = ""
+			}
+		}
+	}
+	return nil
+}
+
+// introspectChunk loads the column metadata of a chunk of tables of schema,
+// the current one when empty.
+func (o *Orm) introspectChunk(schema string, tables []string) error {
+	// A replay reads the metadata recorded by the capture
+	if snap, ok := snapshotOf(o.meta); ok {
+		for _, table := range tables {
+			t, err := snap.table(table)
+			if err != nil {
+				return err
+			}
+			o.columns[table] = append([]columnMeta{}, t.Meta...)
+		}
+		return nil
+	}
+
+	if o.meta.Dialector.Name() == "mysql" {
+		db, bare := schema, make([]string, len(tables))
+		if db == "" {
+			db = o.meta.Migrator().CurrentDatabase()
+		}
+		for i, table := range tables {
+			_, bare[i] = splitTable(table)
+			o.columns[table] = []columnMeta{}
+		}
+
+		var columns []columnMeta
+		if err := o.meta.Raw(introspectColumnSQL, db, bare).Scan(&columns).Error; err != nil {
+			return err
+		}
+		for _, col := range columns {
+			if schema != "" {
+				col.Table = schema + "." + col.Table
+			}
+			o.columns[col.Table] = append(o.columns[col.Table], col)
+		}
+		return nil
+	}
+
+	// Other dialects are queried per table through the migrator
+	for _, table := range tables {
+		types, err := o.meta.Migrator().ColumnTypes(table)
+		if err != nil {
+			return err
+		}
+		columns := make([]columnMeta, 0, len(types))
+		for _, ct := range types {
+			comment, _ := ct.Comment()
+			col := columnMeta{Table: table, Name: ct.Name(), DataType: ct.DatabaseTypeName(), Comment: comment, Nullable: "NO"}
+			col.Type, _ = ct.ColumnType()
+			if pk, _ := ct.PrimaryKey(); pk {
+				col.Key = "PRI"
+			}
+			if nullable, _ := ct.Nullable(); nullable {
+				col.Nullable = "YES"
+			}
+			columns = append(columns, col)
+		}
+		o.columns[table] = columns
+	}
+	return nil
+}
+
+// limiter spaces out the metadata queries to at most qps per second.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next query is allowed or ctx is done.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := now
+	if l.next.After(now) {
+		at = l.next
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	if at.Equal(now) {
+		return nil
+	}
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// qpsCallback is the name of the callbacks rate limiting the metadata
+// connection with --introspect-qps.
+const qpsCallback = "czx:introspect_qps"
+
+// limitQPS rate limits every query of the metadata connection, including the
+// ones issued by gen, to qps queries per second. A connection passed with
+// WithDB keeps the callbacks of the previous runs, whose limit is replaced by
+// the one of this run, none when qps is not positive.
+func (o *Orm) limitQPS(qps float64) error {
+	wait := func(*gorm.DB) {}
+	if qps > 0 {
+		l := &limiter{interval: time.Duration(float64(time.Second) / qps)}
+		wait = func(db *gorm.DB) {
+			ctx := db.Statement.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if err := l.wait(ctx); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	}
+
+	cb := o.meta.Callback()
+	if cb.Query().Get(qpsCallback) != nil {
+		return errors.Join(
+			cb.Query().Replace(qpsCallback, wait),
+			cb.Row().Replace(qpsCallback, wait),
+			cb.Raw().Replace(qpsCallback, wait),
+		)
+	}
+	if qps <= 0 {
+		return nil
+	}
+	if err := cb.Query().Before("gorm:query").Register(qpsCallback, wait); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register(qpsCallback, wait); err != nil {
+		return err
+	}
+	return cb.Raw().Before("gorm:raw").Register(qpsCallback, wait)
+}
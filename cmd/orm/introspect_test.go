@@ -0,0 +1,136 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// infoSchema is a sqlite dialector reporting itself as MySQL, so that the
+// columns are read from an information_schema.columns table.
+type infoSchema struct {
+	*sqlite.Dialector
+}
+
+func (infoSchema) Name() string {
+	return "mysql"
+}
+
+func TestIntrospectBatches(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mysql, err := gorm.Open(infoSchema{sqlite.Open(":memory:").(*sqlite.Dialector)}, &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mysql.Exec("ATTACH ':memory:' AS information_schema").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := mysql.Exec("CREATE TABLE information_schema.columns (table_schema, table_name, column_name, " +
+		"data_type, column_type, is_nullable, column_comment, column_key, extra, ordinal_position)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var tables []string
+	for i := range 5 {
+		name := fmt.Sprintf("t%d", i)
+		ddl := fmt.Sprintf("CREATE TABLE %s (id integer PRIMARY KEY, name varchar(%d) NOT NULL, note text)", name, 10+i)
+		if err := db.Exec(ddl).Error; err != nil {
+			t.Fatal(err)
+		}
+		// Inserted out of order, as the columns must be sorted by position
+		for pos, col := range []string{"note", "id", "name"} {
+			err := mysql.Exec("INSERT INTO information_schema.columns VALUES (?, ?, ?, 'varchar', ?, 'YES', ?, '', '', ?)",
+				mysql.Migrator().CurrentDatabase(), name, col, fmt.Sprintf("varchar(%d)", i), "comment of "+col, (pos+2)%3).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		tables = append(tables, name)
+	}
+
+	for _, meta := range []*gorm.DB{db, mysql} {
+		perTable := &Orm{meta: meta, quiet: true}
+		if err := perTable.introspect(tables, 1); err != nil {
+			t.Fatal(err)
+		}
+		if len(perTable.columns) != len(tables) || perTable.columns["t0"][0].Name != "id" {
+			t.Fatalf("%s: introspected %v", meta.Dialector.Name(), perTable.columns)
+		}
+		for _, batch := range []int{2, 3, len(tables), 50} {
+			chunked := &Orm{meta: meta, quiet: true}
+			if err := chunked.introspect(tables, batch); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(chunked.columns, perTable.columns) {
+				t.Errorf("%s: batch %d = %v, per table %v", meta.Dialector.Name(), batch, chunked.columns, perTable.columns)
+			}
+		}
+	}
+}
+
+// warnings records the warnings logged by gorm.
+type warnings struct {
+	logger.Interface
+	got []string
+}
+
+func (w *warnings) Warn(_ context.Context, msg string, args ...any) {
+	w.got = append(w.got, fmt.Sprintf(msg, args...))
+}
+
+func TestLimitQPSRerun(t *testing.T) {
+	logs := &warnings{Interface: logger.Discard}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs reusing a WithDB connection replace the limit of the previous one
+	for _, qps := range []float64{0.5, 0.5, 0} {
+		if err := (&Orm{meta: db}).limitQPS(qps); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, msg := range logs.got {
+		if strings.Contains(msg, qpsCallback) {
+			t.Errorf("callback registered twice: %s", msg)
+		}
+	}
+
+	// At 0.5 queries per second, a leftover limit would delay these by seconds
+	start := time.Now()
+	for range 3 {
+		var n int
+		if err := db.Raw("SELECT 1").Scan(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("queries took %v after the limit was lifted", elapsed)
+	}
+}
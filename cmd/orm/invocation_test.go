@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+)
+
+func TestInvocationRerun(t *testing.T) {
+	o := NewOrmCommand(
+		WithDB(openFixture(t, "shop")),
+		WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}),
+		WithDaoTables([]string{"customers", "orders"}),
+		WithIgnore([]string{"customers->email"}),
+	)
+	runs := []struct {
+		table string
+		files []string
+	}{
+		{table: "customers", files: []string{"dao/customers.gen.go", "dao/gen.go", "model/customers.gen.go"}},
+		{table: "orders", files: []string{"dao/gen.go", "dao/orders.gen.go", "model/orders.gen.go"}},
+		{table: "customers", files: []string{"dao/customers.gen.go", "dao/gen.go", "model/customers.gen.go"}},
+	}
+	for _, run := range runs {
+		t.Chdir(t.TempDir())
+		c := o.Command()
+		c.SetArgs([]string{"-t", run.table, "--style", "dao", "--config", ""})
+		c.SilenceUsage, c.SilenceErrors = true, true
+		if err := c.Execute(); err != nil {
+			t.Fatalf("-t %s: %v", run.table, err)
+		}
+
+		files := readTree(t, ".")
+		if got := keys(files); !slices.Equal(got, run.files) {
+			t.Errorf("-t %s generated %v, want %v", run.table, got, run.files)
+		}
+		for _, other := range []string{"Customer", "Order"} {
+			if strings.HasPrefix(run.table, strings.ToLower(other)) {
+				continue
+			}
+			if strings.Contains(files["dao/gen.go"], other) {
+				t.Errorf("-t %s: dao/gen.go references %s:\n%s", run.table, other, files["dao/gen.go"])
+			}
+		}
+		if src := files["model/customers.gen.go"]; src != "" && fieldLine(src, "Email") != "" {
+			t.Errorf("-t %s: ignored column generated:\n%s", run.table, src)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// jsonOmitProgram stores a user through the generated model, reads it back
+// and prints the password hash read and the user as JSON.
+const jsonOmitProgram = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"MODEL"
+)
+
+func main() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, name text, email text, phone text, password_hash text, created_at datetime)").Error; err != nil {
+		panic(err)
+	}
+	in := model.User{Name: "ada", Email: "ada@example.com", PasswordHash: "s3cret", CreatedAt: time.Unix(0, 0).UTC()}
+	if err := db.Create(&in).Error; err != nil {
+		panic(err)
+	}
+	var out model.User
+	if err := db.First(&out, in.ID).Error; err != nil {
+		panic(err)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out.PasswordHash)
+	fmt.Println(string(data))
+}
+`
+
+func TestJSONOmitRoundTrip(t *testing.T) {
+	files := generateBuilt(t, openFixture(t, "users"), []string{"-t", "users"}, WithJSONOmit([]string{"users->password_hash"}))
+	line := fieldLine(files["model/users.gen.go"], "PasswordHash")
+	if !strings.Contains(line, `gorm:"column:password_hash;not null"`) || !strings.Contains(line, `json:"-"`) {
+		t.Fatalf("PasswordHash = %q", line)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := strings.Replace(jsonOmitProgram, "MODEL", "command/cmd/orm/testdata/"+filepath.Base(wd)+"/model", 1)
+	if err := os.MkdirAll("roundtrip", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("roundtrip", "main.go"), []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("go", "run", "./roundtrip").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+
+	hash, data, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if hash != "s3cret" {
+		t.Errorf("password hash read back as %q", hash)
+	}
+	if strings.Contains(data, "password") || strings.Contains(data, "s3cret") || !strings.Contains(data, `"email":"ada@example.com"`) {
+		t.Errorf("marshaled %s", data)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"slices"
+	"strings"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+)
+
+// jsonStyles are the JSON tag strategies of --json-style by name.
+var jsonStyles = map[string]func(string) string{
+	"snake":               JSONSnake,
+	"camel":               JSONCamel,
+	"lower-camel-no-omit": JSONLowerCamelNoOmit,
+}
+
+// JSONSnake is a JSON tag strategy for WithJSONTagStrategy naming the fields
+// in snake case with omitempty, user_id,omitempty for userId.
+func JSONSnake(column string) string {
+	return snakeCase(column) + ",omitempty"
+}
+
+// JSONCamel is a JSON tag strategy for WithJSONTagStrategy naming the fields
+// in lower camel case with omitempty, userId,omitempty for user_id.
+func JSONCamel(column string) string {
+	return lowerCamelCase(column) + ",omitempty"
+}
+
+// JSONLowerCamelNoOmit is a JSON tag strategy for WithJSONTagStrategy naming
+// the fields in lower camel case without omitempty, so zero values such as
+// false are always written.
+func JSONLowerCamelNoOmit(column string) string {
+	return lowerCamelCase(column)
+}
+
+// jsonStyleNames returns the names of the --json-style values, sorted.
+func jsonStyleNames() []string {
+	names := make([]string, 0, len(jsonStyles))
+	for name := range jsonStyles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// retagOpt returns the option setting the JSON tag of a retag rule. The tag
+// is computed when the model is generated, once --json-style is known.
+func (o *Orm) retagOpt(column, tag string) gen.ModelOpt {
+	return gen.FieldModify(func(f gen.Field) gen.Field {
+		if f.ColumnName == column {
+			f.Tag.Set(field.TagKeyJson, o.retagValue(column, tag))
+		}
+		return f
+	})
+}
+
+// retagValue returns the JSON tag of a retag rule: the tag with the options
+// the JSON tag strategy gives the column, omitempty by default. A tag with
+// options of its own is kept as is.
+func (o *Orm) retagValue(column, tag string) string {
+	if strings.Contains(tag, ",") {
+		return tag
+	}
+	if _, opts, ok := strings.Cut(o.jsonTagStrategy()(column), ","); ok {
+		return tag + "," + opts
+	}
+	return tag
+}
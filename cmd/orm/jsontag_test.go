@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+)
+
+func TestJSONTagStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		opts []IOrmOption
+		// tags are the expected JSON tags by field
+		tags map[string]string
+	}{
+		{
+			name: "default",
+			tags: map[string]string{"Email": "email,omitempty", "PasswordHash": "password_hash,omitempty"},
+		},
+		{
+			name: "strategy",
+			opts: []IOrmOption{WithJSONTagStrategy(JSONLowerCamelNoOmit)},
+			tags: map[string]string{"Email": "email", "PasswordHash": "passwordHash"},
+		},
+		{
+			name: "retag",
+			opts: []IOrmOption{WithRetags([]string{"users->email->mail"})},
+			tags: map[string]string{"Email": "mail,omitempty", "PasswordHash": "password_hash,omitempty"},
+		},
+		{
+			name: "strategy and retag",
+			opts: []IOrmOption{
+				WithJSONTagStrategy(JSONLowerCamelNoOmit),
+				WithRetags([]string{"users->email->mail", "users->phone->tel,string"}),
+			},
+			tags: map[string]string{"Email": "mail", "Phone": "tel,string", "PasswordHash": "passwordHash"},
+		},
+		{
+			name: "retag with the options of the strategy",
+			opts: []IOrmOption{
+				WithJSONTagStrategy(func(column string) string { return column + ",omitzero" }),
+				WithRetags([]string{"users->email->mail"}),
+			},
+			tags: map[string]string{"Email": "mail,omitzero", "PasswordHash": "password_hash,omitzero"},
+		},
+		{
+			name: "flag wins",
+			args: []string{"--json-style", "snake"},
+			opts: []IOrmOption{WithJSONTagStrategy(JSONLowerCamelNoOmit)},
+			tags: map[string]string{"PasswordHash": "password_hash,omitempty"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-t", "users"}, tt.args...)
+			src := generate(t, openFixture(t, "users"), args, tt.opts...)["model/users.gen.go"]
+			for name, tag := range tt.tags {
+				if line := fieldLine(src, name); !strings.Contains(line, `json:"`+tag+`"`) {
+					t.Errorf("%s = %q, want json:%q", name, line, tag)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONStyleInvalid(t *testing.T) {
+	t.Chdir(t.TempDir())
+	c := NewOrmCommand(WithDB(openFixture(t, "users")), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})).Command()
+	c.SetArgs([]string{"-t", "users", "--json-style", "kebab", "--config", ""})
+	c.SilenceUsage, c.SilenceErrors = true, true
+	err := c.ExecuteContext(context.Background())
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage || !strings.Contains(err.Error(), "lower-camel-no-omit") {
+		t.Errorf("exit code %d, err = %v, want %d listing the styles", code, err, cmd.ExitUsage)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"command/cmd"
+)
+
+// keepFile lists, in gitignore syntax, the paths of an output directory the
+// tool must never delete, overwrite or compare.
+const keepFile = ".ormkeep"
+
+type (
+	// keepPattern is a single line of a .ormkeep file.
+	keepPattern struct {
+		line     string
+		glob     string
+		negate   bool
+		dirOnly  bool
+		anchored bool
+	}
+	// keepList holds the patterns of the .ormkeep file of a directory.
+	keepList struct {
+		dir      string
+		patterns []keepPattern
+	}
+)
+
+// loadKeep reads the .ormkeep file of dir. A missing file keeps nothing.
+func loadKeep(dir string) (*keepList, error) {
+	k := &keepList{dir: dir}
+	f, err := os.Open(filepath.Join(dir, keepFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p := keepPattern{line: line}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasPrefix(p.line, "!") {
+			p.negate, line = true, line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		// A slash at the beginning or in the middle anchors the pattern
+		// to the directory of the .ormkeep file.
+		if strings.Contains(line, "/") {
+			p.anchored, line = true, strings.TrimPrefix(line, "/")
+		}
+		p.glob = line
+		k.patterns = append(k.patterns, p)
+	}
+	return k, scanner.Err()
+}
+
+// Kept reports whether the path, absolute or relative to the directory of
+// the list, is excluded by the .ormkeep patterns. As with gitignore the last
+// matching pattern wins, and a path below a kept directory is always kept.
+func (k *keepList) Kept(name string) bool {
+	if k == nil || len(k.patterns) == 0 {
+		return false
+	}
+	if filepath.IsAbs(name) {
+		rel, err := filepath.Rel(k.dir, name)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return false
+		}
+		name = rel
+	}
+	name = filepath.ToSlash(name)
+
+	parts := strings.Split(name, "/")
+	for i := range parts {
+		isDir := i < len(parts)-1
+		if pattern, ok := k.match(strings.Join(parts[:i+1], "/"), isDir); ok {
+			cmd.Debugf("%s is kept by %s pattern %q\n", name, keepFile, pattern)
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the last pattern matching name, unless it is a negation.
+func (k *keepList) match(name string, isDir bool) (string, bool) {
+	var kept bool
+	var line string
+	for _, p := range k.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.matches(name) {
+			kept, line = !p.negate, p.line
+		}
+	}
+	return line, kept
+}
+
+// matches reports whether the pattern matches the slash separated name.
+func (p keepPattern) matches(name string) bool {
+	if !p.anchored {
+		return globMatch(p.glob, path.Base(name))
+	}
+	return globMatch(p.glob, name)
+}
+
+// globMatch matches name against a glob where "**" spans any number of
+// path segments.
+func globMatch(glob, name string) bool {
+	if !strings.Contains(glob, "**") {
+		ok, _ := path.Match(glob, name)
+		return ok
+	}
+
+	globParts, nameParts := strings.Split(glob, "/"), strings.Split(name, "/")
+	var match func(g, n []string) bool
+	match = func(g, n []string) bool {
+		for len(g) > 0 {
+			if g[0] == "**" {
+				for i := 0; i <= len(n); i++ {
+					if match(g[1:], n[i:]) {
+						return true
+					}
+				}
+				return false
+			}
+			if len(n) == 0 {
+				return false
+			}
+			if ok, _ := path.Match(g[0], n[0]); !ok {
+				return false
+			}
+			g, n = g[1:], n[1:]
+		}
+		return len(n) == 0
+	}
+	return match(globParts, nameParts)
+}
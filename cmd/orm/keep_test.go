@@ -0,0 +1,91 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeepNegation(t *testing.T) {
+	tests := []struct {
+		name  string
+		keep  string
+		kept  []string
+		freed []string
+	}{
+		{
+			name:  "negated pattern",
+			keep:  "*.gen.go\n!users.gen.go\n",
+			kept:  []string{"orders.gen.go", "sub/orders.gen.go"},
+			freed: []string{"users.gen.go", "sub/users.gen.go"},
+		},
+		{
+			name: "re-included pattern",
+			keep: "*.gen.go\n!users.gen.go\nusers.gen.go\n",
+			kept: []string{"orders.gen.go", "users.gen.go"},
+		},
+		{
+			name: "negation before the pattern loses",
+			keep: "!users.gen.go\n*.gen.go\n",
+			kept: []string{"orders.gen.go", "users.gen.go"},
+		},
+		{
+			name:  "anchored negation",
+			keep:  "*.gen.go\n!/users.gen.go\n",
+			kept:  []string{"sub/users.gen.go"},
+			freed: []string{"users.gen.go"},
+		},
+		{
+			name:  "negation below a kept directory",
+			keep:  "custom/\n!custom/users.gen.go\n",
+			kept:  []string{"custom/users.gen.go", "custom/orders.gen.go"},
+			freed: []string{"users.gen.go"},
+		},
+		{
+			name:  "escaped exclamation mark",
+			keep:  "\\!users.gen.go\n",
+			kept:  []string{"!users.gen.go"},
+			freed: []string{"users.gen.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, keepFile), []byte(tt.keep), 0640); err != nil {
+				t.Fatal(err)
+			}
+			keep, err := loadKeep(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.kept {
+				if !keep.Kept(name) {
+					t.Errorf("%s not kept", name)
+				}
+				if !keep.Kept(filepath.Join(dir, name)) {
+					t.Errorf("absolute %s not kept", name)
+				}
+			}
+			for _, name := range tt.freed {
+				if keep.Kept(name) {
+					t.Errorf("%s kept", name)
+				}
+			}
+		})
+	}
+}
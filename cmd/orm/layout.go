@@ -0,0 +1,234 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"cmp"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/pflag"
+)
+
+// domainMarker names, in the comment of a table, the domain of the table
+// for the output templates, e.g. "[domain:billing]".
+var domainMarker = regexp.MustCompile(`\[domain:\s*([\w-]+)\s*\]`)
+
+type (
+	// outputLayout computes the output paths of the tables from the templates
+	// of --out-template and --dao-out-template.
+	outputLayout struct {
+		model *template.Template
+		// dao renders the directory of the daos, nil for the directory of
+		// the query package below the directory of the model
+		dao *template.Template
+		// groups route the tables to the Group of the templates, in order
+		groups []outputGroup
+	}
+	// outputGroup routes the tables matching a selector to a group.
+	outputGroup struct {
+		selector tableSelector
+		group    string
+	}
+	// outputTable is the context of the output templates of a table.
+	outputTable struct {
+		// Schema is the schema of a qualified table, empty otherwise
+		Schema string
+		// Table is the name of the table without its schema
+		Table string
+		Model string
+		// Domain is the domain of the [domain:<name>] marker of the table comment
+		Domain string
+		// Group is the group of the first --out-group matching the table
+		Group string
+	}
+	// outputPaths are the rendered output of a table.
+	outputPaths struct {
+		// ModelDir and DaoDir are the absolute directories of the model and
+		// dao packages
+		ModelDir, DaoDir string
+		// File is the file name of the model and dao, without .gen.go
+		File string
+	}
+)
+
+// outputLayout reads the output templates, nil without --out-template.
+func (o *Orm) outputLayout(args *pflag.FlagSet) (*outputLayout, error) {
+	modelTmpl, err := args.GetString("out-template")
+	if err != nil {
+		return nil, err
+	}
+	daoTmpl, err := args.GetString("dao-out-template")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := args.GetStringArray("out-group")
+	if err != nil {
+		return nil, err
+	}
+	if modelTmpl == "" {
+		if daoTmpl != "" || len(groups) > 0 {
+			return nil, &LayoutError{Reason: "--dao-out-template and --out-group require --out-template"}
+		}
+		return nil, nil
+	}
+
+	l := &outputLayout{}
+	if l.model, err = template.New("out-template").Option("missingkey=error").Parse(modelTmpl); err != nil {
+		return nil, &LayoutError{Reason: err.Error()}
+	}
+	if daoTmpl != "" {
+		if l.dao, err = template.New("dao-out-template").Option("missingkey=error").Parse(daoTmpl); err != nil {
+			return nil, &LayoutError{Reason: err.Error()}
+		}
+	}
+	for _, route := range groups {
+		pattern, group, ok := strings.Cut(route, "=")
+		if !ok || group == "" {
+			return nil, &LayoutError{Reason: fmt.Sprintf("--out-group %q must be <table pattern>=<group>", route)}
+		}
+		s, err := parseTableSelector(pattern)
+		if err != nil || s.negative || s.model != "" {
+			return nil, &LayoutError{Reason: fmt.Sprintf("--out-group %q: the pattern must be a table name or pattern", route)}
+		}
+		l.groups = append(l.groups, outputGroup{selector: s, group: group})
+	}
+	return l, nil
+}
+
+// render returns the output paths of the selected entries by table, checked
+// before anything is generated: every path must stay below the working
+// directory, no two tables may share a file, the model and dao packages of
+// the run never share a directory and their names must be identifiers.
+func (l *outputLayout) render(o *Orm, tables []string) (map[string]outputPaths, error) {
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		return nil, err
+	}
+	query := filepath.Base(o.opt.gconf.OutPath)
+
+	paths := make(map[string]outputPaths, len(tables))
+	models, daos := make(map[string]string), make(map[string]string)
+	for _, entry := range tables {
+		table, model, _ := strings.Cut(entry, "@")
+		ctx := outputTable{Model: cmp.Or(model, o.qualifiedModelName(table, o.schemaPrefix))}
+		ctx.Schema, ctx.Table = splitTable(table)
+		if m := domainMarker.FindStringSubmatch(o.tableComment(table)); m != nil {
+			ctx.Domain = m[1]
+		}
+		for _, g := range l.groups {
+			if g.selector.match(table) {
+				ctx.Group = g.group
+				break
+			}
+		}
+
+		file, err := renderPath(l.model, ctx, cwd)
+		if err != nil {
+			return nil, &LayoutError{Table: table, Reason: err.Error()}
+		}
+		name := filepath.Base(file)
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			return nil, &LayoutError{Table: table, Path: file, Reason: "the model file must be a .go file other than a test"}
+		}
+		p := outputPaths{ModelDir: filepath.Dir(file), File: strings.TrimSuffix(strings.TrimSuffix(name, ".go"), ".gen")}
+		if p.File == "" {
+			return nil, &LayoutError{Table: table, Path: file, Reason: "empty file name"}
+		}
+		p.DaoDir = filepath.Join(p.ModelDir, query)
+		if l.dao != nil {
+			if p.DaoDir, err = renderPath(l.dao, ctx, cwd); err != nil {
+				return nil, &LayoutError{Table: table, Reason: err.Error()}
+			}
+		}
+
+		for _, dir := range []string{p.ModelDir, p.DaoDir} {
+			if pkg := filepath.Base(dir); !token.IsIdentifier(pkg) {
+				return nil, &LayoutError{Table: table, Path: dir, Reason: fmt.Sprintf("package name %q of the directory is not an identifier", pkg)}
+			}
+		}
+		modelFile, daoFile := filepath.Join(p.ModelDir, p.File+".gen.go"), filepath.Join(p.DaoDir, p.File+".gen.go")
+		if other, ok := models[modelFile]; ok {
+			return nil, &LayoutError{Table: table, Path: modelFile, Reason: "the model file of table " + other + " as well"}
+		}
+		if other, ok := daos[daoFile]; ok && selected(o.opt.daoTables, table) {
+			return nil, &LayoutError{Table: table, Path: daoFile, Reason: "the dao file of table " + other + " as well"}
+		}
+		models[modelFile] = table
+		if selected(o.opt.daoTables, table) {
+			daos[daoFile] = table
+		}
+		paths[table] = p
+	}
+
+	// A directory holds the models or the daos of the run, not both
+	modelDirs := make(map[string]bool)
+	for _, p := range paths {
+		modelDirs[p.ModelDir] = true
+	}
+	for _, table := range sortedKeys(paths) {
+		if p := paths[table]; modelDirs[p.DaoDir] && selected(o.opt.daoTables, table) {
+			return nil, &LayoutError{Table: table, Path: p.DaoDir, Reason: "the directory holds models as well as daos"}
+		}
+	}
+	return paths, nil
+}
+
+// renderPath renders a path template, which must stay below the working
+// directory cwd, and returns the absolute path.
+func renderPath(t *template.Template, ctx outputTable, cwd string) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, ctx); err != nil {
+		return "", err
+	}
+	rendered := b.String()
+	if filepath.IsAbs(rendered) {
+		return "", fmt.Errorf("%s renders the absolute path %s", t.Name(), rendered)
+	}
+	if slices.Contains(strings.Split(filepath.ToSlash(rendered), "/"), "..") {
+		return "", fmt.Errorf("%s renders %s, which leaves the working directory", t.Name(), rendered)
+	}
+	if strings.ContainsAny(rendered, "\x00\n\r") {
+		return "", fmt.Errorf("%s renders %q, not a path", t.Name(), rendered)
+	}
+	return filepath.Join(cwd, rendered), nil
+}
+
+// tableComment returns the comment of a table, empty when it cannot be read.
+func (o *Orm) tableComment(table string) string {
+	tt, err := o.meta.Migrator().TableType(table)
+	if err != nil {
+		return ""
+	}
+	comment, _ := tt.Comment()
+	return comment
+}
+
+// checkLayout rejects the options that expect a single model and dao
+// directory with --out-template.
+func (o *Orm) checkLayout(args *pflag.FlagSet) error {
+	for _, name := range []string{"prune", "with-examples", "with-benchmarks", "docs-site"} {
+		if f := args.Lookup(name); f != nil && f.Value.String() != "false" && f.Value.String() != "" {
+			return &LayoutError{Reason: "--" + name + " expects a single model and dao directory and cannot be used with --out-template"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// layoutTree lists the files of a run with their package clause and imports
+// of generated packages, which show where each model and dao went and that
+// the daos import their models.
+func layoutTree(files map[string]string) string {
+	var b strings.Builder
+	for _, name := range keys(files) {
+		fmt.Fprintf(&b, "%s:", name)
+		for line := range strings.Lines(files[name]) {
+			line = strings.TrimSpace(line)
+			if pkg, ok := strings.CutPrefix(line, "package "); ok {
+				fmt.Fprintf(&b, " package %s", pkg)
+			}
+			if strings.HasSuffix(line, `/model"`) || strings.Contains(line, `"layout/`) {
+				fmt.Fprintf(&b, " import %s", line)
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// shopDomains sets the domain markers of the comments of the shop tables.
+func shopDomains(snap *schemaSnapshot) {
+	for i, domain := range map[string]string{"customers": "crm", "orders": "billing"} {
+		for j := range snap.Tables {
+			if snap.Tables[j].Name == i {
+				comment := "Rows of " + i + " [domain:" + domain + "]"
+				snap.Tables[j].Comment = &comment
+			}
+		}
+	}
+}
+
+func TestLayoutNested(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		fixture string
+		edit    func(*schemaSnapshot)
+		args    []string
+	}{
+		{
+			name:    "schema_table",
+			fixture: "schemas",
+			args:    []string{"-t", "core.*", "-t", "audit.*", "--out-template", "gen/{{.Schema}}/{{.Table}}/model.go"},
+		},
+		{
+			name:    "domain",
+			fixture: "shop",
+			edit:    shopDomains,
+			args:    []string{"-t", "*", "--out-template", "internal/{{.Domain}}/model/{{.Table}}.go", "--dao-out-template", "internal/{{.Domain}}/query"},
+		},
+		{
+			name:    "group",
+			fixture: "shop",
+			args:    []string{"-t", "*", "--out-template", "{{.Group}}/model/{{.Model}}.go", "--out-group", "cust*=crm", "--out-group", "*=core"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("go.mod", []byte("module layout\n\ngo 1.25\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			files := generateHere(t, openFixtureWith(t, tt.fixture, tt.edit), append(tt.args, "--style", "dao"), WithDaoTables([]string{"*"}))
+			golden(t, "layout_"+tt.name+".golden", layoutTree(files))
+		})
+	}
+}
+
+func TestLayoutCollisions(t *testing.T) {
+	shop := func(t *testing.T) *gorm.DB { return openFixture(t, "shop") }
+	var report strings.Builder
+	for _, tt := range []struct {
+		name string
+		args []string
+	}{
+		{"model file", []string{"--out-template", "models/all.go"}},
+		{"dao file", []string{"--out-template", "models/{{.Table}}/model.go", "--dao-out-template", "query"}},
+		{"models and daos in a directory", []string{"--out-template", "models/{{.Table}}.go", "--dao-out-template", "models"}},
+		{"parent directory", []string{"--out-template", "../{{.Table}}.go"}},
+		{"absolute path", []string{"--out-template", "/tmp/{{.Table}}.go"}},
+		{"package name", []string{"--out-template", "my-models/{{.Table}}.go"}},
+		{"test file", []string{"--out-template", "model/{{.Table}}_test.go"}},
+		{"missing key", []string{"--out-template", "model/{{.Team}}.go"}},
+		{"group without template", []string{"--out-group", "*=core"}},
+	} {
+		err := run(t, shop(t), append([]string{"-t", "*", "--style", "dao"}, tt.args...), WithDaoTables([]string{"*"}))
+		var layout *LayoutError
+		if !errors.As(err, &layout) {
+			t.Errorf("%s: run = %v, want a LayoutError", tt.name, err)
+			continue
+		}
+		if code := cmd.ExitCode(err, true); code != cmd.ExitUsage {
+			t.Errorf("%s: exit code %d, want %d", tt.name, code, cmd.ExitUsage)
+		}
+
+		// Nothing is generated once a path is rejected
+		if files := readTree(t, "."); len(files) > 0 {
+			t.Errorf("%s: generated %v", tt.name, keys(files))
+		}
+		cwd, _ := os.Getwd()
+		fmt.Fprintf(&report, "%s: %s\n", tt.name, strings.ReplaceAll(layout.Error(), cwd+string(filepath.Separator), ""))
+	}
+	golden(t, "layout_collisions.golden", report.String())
+}
@@ -0,0 +1,200 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"syscall"
+	"time"
+)
+
+const (
+	// lockFile is the advisory lock of a run in the output directory.
+	lockFile = ".orm.lock"
+	// lockStale is the age after which a lock is broken whatever its holder.
+	lockStale = time.Hour
+	// lockPoll is the interval between two attempts to take a held lock.
+	lockPoll = 100 * time.Millisecond
+)
+
+// lockHolder is the content of a lock file.
+type lockHolder struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// lock takes the lock file of the output directories, waiting up to wait for
+// a run holding it. Stale locks, of a dead process of this host or older than
+// lockStale, are broken with a warning. The returned function releases the
+// lock, and only removes it while it is still the one of this run.
+func (o *Orm) lock(ctx context.Context, wait time.Duration) (func(), error) {
+	dirs, err := o.lockDirs()
+	if err != nil {
+		return nil, err
+	}
+	var releases []func()
+	release := func() {
+		for _, r := range slices.Backward(releases) {
+			r()
+		}
+	}
+	for _, dir := range dirs {
+		r, err := o.lockDir(ctx, dir, wait)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
+
+// lockDirs returns the directories locked by a run: the closest common parent
+// of the query and model directories, so runs sharing either take turns, or
+// both sorted when they only share the file system root.
+func (o *Orm) lockDirs() ([]string, error) {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return nil, err
+	}
+	for dir := out; filepath.Dir(dir) != dir; dir = filepath.Dir(dir) {
+		if rel, err := filepath.Rel(dir, model); err == nil && filepath.IsLocal(rel) {
+			return []string{dir}, nil
+		}
+	}
+	dirs := []string{out, model}
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// lockDir takes the lock file of dir, creating dir when missing.
+func (o *Orm) lockDir(ctx context.Context, dir string, wait time.Duration) (func(), error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, lockFile)
+	host, _ := os.Hostname()
+	own, err := json.Marshal(lockHolder{PID: os.Getpid(), Host: host, Started: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(own)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return func() { removeLock(path, own) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		data, holder, err := readLock(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Released meanwhile
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if reason := holder.stale(host, time.Now()); reason != "" {
+			o.warn(warnLock, "Warning: breaking the lock %s of pid %d on %s, %s\n", path, holder.PID, holder.Host, reason)
+			removeLock(path, data)
+			continue
+		}
+		if !time.Now().Before(deadline) {
+			return nil, &LockedError{Path: path, PID: holder.PID, Host: holder.Host, Started: holder.Started, Wait: wait}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
+
+// readLock reads a lock file. A lock being written, or written by another
+// tool, dates from the modification time of the file.
+func readLock(path string) ([]byte, lockHolder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, lockHolder{}, err
+	}
+	var holder lockHolder
+	if json.Unmarshal(data, &holder) != nil || holder.Started.IsZero() {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, lockHolder{}, err
+		}
+		holder = lockHolder{Started: fi.ModTime()}
+	}
+	return data, holder, nil
+}
+
+// removeLock removes the lock file at path while it holds data, so a lock
+// taken by another run after it was broken is kept.
+func removeLock(path string, data []byte) {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		os.Remove(path)
+	}
+}
+
+// stale returns why the lock of the holder can be broken, empty when it is
+// held.
+func (h lockHolder) stale(host string, now time.Time) string {
+	if age := now.Sub(h.Started); age > lockStale {
+		return fmt.Sprintf("taken %s ago", age.Round(time.Second))
+	}
+	if h.PID > 0 && h.Host == host && !processAlive(h.PID) {
+		return "whose process has exited"
+	}
+	return ""
+}
+
+// processAlive reports whether a process of this host is running.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Windows only finds running processes
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gen"
+)
+
+// lockingOrm returns an Orm writing its queries to out and its models to
+// model, relative to out like gen.
+func lockingOrm(out, model string) *Orm {
+	return NewOrmCommand(WithConfig(gen.Config{OutPath: out, ModelPkgPath: model}))
+}
+
+// holdLock writes the lock file of a holder into dir.
+func holdLock(t *testing.T, dir string, holder lockHolder) {
+	t.Helper()
+	data, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, lockFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockDirs(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		out, model string
+		want       []string
+	}{
+		{out: "dao", model: "model", want: []string{root}},
+		{out: "db/dao", model: "model", want: []string{filepath.Join(root, "db")}},
+		{out: "dao", model: filepath.Join(root, "dao", "model"), want: []string{filepath.Join(root, "dao")}},
+		{out: "internal/dao", model: filepath.Join(root, "pkg", "model"), want: []string{root}},
+	}
+	t.Chdir(root)
+	for _, tt := range tests {
+		got, err := lockingOrm(tt.out, tt.model).lockDirs()
+		if err != nil || len(got) != len(tt.want) || got[0] != tt.want[0] {
+			t.Errorf("%s and %s: lock %v, %v, want %v", tt.out, tt.model, got, err, tt.want)
+		}
+	}
+}
+
+func TestLockContention(t *testing.T) {
+	t.Chdir(t.TempDir())
+	first, second := lockingOrm("db/dao", "model"), lockingOrm("db/query", "model")
+
+	release, err := first.lock(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The runs share their model directory, so the second one waits
+	var locked *LockedError
+	if _, err := second.lock(context.Background(), 200*time.Millisecond); !errors.As(err, &locked) {
+		t.Fatalf("second lock: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked.PID != os.Getpid() || locked.Path != filepath.Join(wd, "db", lockFile) {
+		t.Errorf("holder %+v", locked)
+	}
+
+	time.AfterFunc(200*time.Millisecond, release)
+	again, err := second.lock(context.Background(), 5*time.Second)
+	if err != nil {
+		t.Fatalf("lock released by the first run: %v", err)
+	}
+	again()
+	if _, err := os.Stat(filepath.Join("db", lockFile)); !os.IsNotExist(err) {
+		t.Errorf("lock left behind: %v", err)
+	}
+
+	// A canceled run stops waiting
+	release, err = first.lock(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := second.lock(ctx, time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled lock: %v", err)
+	}
+}
+
+func TestLockStale(t *testing.T) {
+	exited := exec.Command("go", "version")
+	if err := exited.Run(); err != nil {
+		t.Skip("needs a process that exited:", err)
+	}
+	host, _ := os.Hostname()
+	for name, holder := range map[string]lockHolder{
+		"exited":  {PID: exited.Process.Pid, Host: host, Started: time.Now()},
+		"old":     {PID: os.Getpid(), Host: "elsewhere", Started: time.Now().Add(-2 * lockStale)},
+		"garbled": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if name == "garbled" {
+				if err := os.WriteFile(lockFile, []byte("pid?"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				old := time.Now().Add(-2 * lockStale)
+				if err := os.Chtimes(lockFile, old, old); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				holdLock(t, ".", holder)
+			}
+
+			o := lockingOrm("dao", "model")
+			release, err := o.lock(context.Background(), 0)
+			if err != nil {
+				t.Fatalf("stale lock not broken: %v", err)
+			}
+			release()
+			if o.warnings[warnLock] != 1 {
+				t.Errorf("warnings = %v", o.warnings)
+			}
+		})
+	}
+
+	// A live holder of another host is waited for
+	t.Chdir(t.TempDir())
+	holdLock(t, ".", lockHolder{PID: 1, Host: "elsewhere", Started: time.Now()})
+	if _, err := lockingOrm("dao", "model").lock(context.Background(), 0); err == nil {
+		t.Error("held lock broken")
+	}
+}
+
+func TestLockCreatesNoQueryDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	db := openFixture(t, "users")
+	c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "db/dao", ModelPkgPath: "model"})).Command()
+	c.SetArgs([]string{"-t", "users", "--style", "model", "--config", ""})
+	c.SilenceUsage, c.SilenceErrors = true, true
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("db/model/users.gen.go"); err != nil {
+		t.Error(err)
+	}
+	for _, path := range []string{"db/dao", filepath.Join("db", lockFile)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left by a model run: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"runtime/debug"
+	"slices"
+
+	"github.com/spf13/pflag"
+)
+
+// lockfileVersion is the version of the lockfile format.
+const lockfileVersion = 1
+
+// defaultLockfile is the lockfile written by the generating runs.
+const defaultLockfile = "./czx.lock"
+
+type (
+	// lockfile records the last generating run: the version of the command,
+	// the effective gen.Config, defaults filled, the generation flags, the
+	// metadata checksum of every table and the checksum of every file.
+	lockfile struct {
+		Version     int          `json:"version"`
+		ToolVersion string       `json:"toolVersion"`
+		Config      lockedConfig `json:"config"`
+		// Flags are the generation flags of the run, replayed by orm verify
+		Flags map[string][]string `json:"flags,omitempty"`
+		// Tables are the checksums of the column metadata per table
+		Tables map[string]string `json:"tables"`
+		Files  []lockedFile      `json:"files"`
+	}
+	// lockedConfig is the effective gen.Config of a run.
+	lockedConfig struct {
+		OutPath           string   `json:"outPath"`
+		OutFile           string   `json:"outFile,omitempty"`
+		ModelPkgPath      string   `json:"modelPkgPath"`
+		WithUnitTest      bool     `json:"withUnitTest,omitempty"`
+		FieldNullable     bool     `json:"fieldNullable,omitempty"`
+		FieldCoverable    bool     `json:"fieldCoverable,omitempty"`
+		FieldSignable     bool     `json:"fieldSignable,omitempty"`
+		FieldWithIndexTag bool     `json:"fieldWithIndexTag,omitempty"`
+		FieldWithTypeTag  bool     `json:"fieldWithTypeTag,omitempty"`
+		Mode              []string `json:"mode,omitempty"`
+	}
+	// upgrade attributes the generated files changed since a lockfile
+	// written by another version of the command to the schema, when the
+	// columns of one of their tables changed, or to the tool, when only the
+	// output did.
+	upgrade struct {
+		from, to string
+		// tables are the recorded and the current metadata checksums
+		tables, current map[string]string
+		files           map[string]lockedFile
+		// schema and tool count the attributed files
+		schema, tool int
+	}
+	// lockedFile is a generated file of a lockfile, by path relative to the
+	// working directory. The checksum ignores the provenance comments.
+	lockedFile struct {
+		Path   string   `json:"path"`
+		SHA256 string   `json:"sha256"`
+		Tables []string `json:"tables"`
+	}
+)
+
+// lockfilePath returns the --lockfile of the run, empty when disabled or
+// when the run writes to WithFS.
+func (o *Orm) lockfilePath(args *pflag.FlagSet) (string, error) {
+	if o.opt.fs != nil {
+		return "", nil
+	}
+	return args.GetString("lockfile")
+}
+
+// writeLockfile records the run in the --lockfile after it generated.
+func (o *Orm) writeLockfile(args *pflag.FlagSet) error {
+	path, err := o.lockfilePath(args)
+	if err != nil || path == "" {
+		return err
+	}
+	style, err := args.GetString("style")
+	if err != nil {
+		return err
+	}
+	l, err := o.lockfile(style != "model")
+	if err != nil {
+		return err
+	}
+	l.Flags = generationFlagValues(args)
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0644)
+}
+
+// lockfile returns the lockfile of the run.
+func (o *Orm) lockfile(dao bool) (*lockfile, error) {
+	files, err := o.generatedFiles(dao)
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	l := &lockfile{
+		Version:     lockfileVersion,
+		ToolVersion: toolVersion(),
+		Config:      o.lockedConfig(),
+		Tables:      o.tableHashes(),
+		Files:       make([]lockedFile, 0, len(files)),
+	}
+	for _, name := range sortedKeys(files) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		l.Files = append(l.Files, lockedFile{Path: relPath(cwd, name), SHA256: contentSum(data), Tables: files[name]})
+	}
+	return l, nil
+}
+
+// lockedConfig returns the effective gen.Config of the run.
+func (o *Orm) lockedConfig() lockedConfig {
+	conf := o.opt.gconf
+	locked := lockedConfig{
+		OutPath:           conf.OutPath,
+		OutFile:           conf.OutFile,
+		ModelPkgPath:      conf.ModelPkgPath,
+		WithUnitTest:      conf.WithUnitTest,
+		FieldNullable:     conf.FieldNullable,
+		FieldCoverable:    conf.FieldCoverable,
+		FieldSignable:     conf.FieldSignable,
+		FieldWithIndexTag: conf.FieldWithIndexTag,
+		FieldWithTypeTag:  conf.FieldWithTypeTag,
+	}
+	for _, name := range slices.Sorted(maps.Keys(genModeNames)) {
+		if conf.Mode&genModeNames[name] != 0 {
+			locked.Mode = append(locked.Mode, name)
+		}
+	}
+	return locked
+}
+
+// readLockfile reads the lockfile at path.
+func readLockfile(path string) (*lockfile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w, generate the code with command orm first", err)
+	} else if err != nil {
+		return nil, err
+	}
+	var l lockfile
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if l.Version != lockfileVersion {
+		return nil, fmt.Errorf("%s: unsupported lockfile version %d", path, l.Version)
+	}
+	return &l, nil
+}
+
+// contentSum returns the checksum of a generated file without its
+// provenance comments.
+func contentSum(data []byte) string {
+	sum := sha256.Sum256(stripProvenance(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// tableHashes returns the checksum of the introspected column metadata of
+// every table.
+func (o *Orm) tableHashes() map[string]string {
+	hashes := make(map[string]string, len(o.columns))
+	for table, columns := range o.columns {
+		data, _ := json.Marshal(columns)
+		sum := sha256.Sum256(data)
+		hashes[table] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// toolVersion returns the version of the running binary, with the commit of
+// development builds.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && version == "(devel)" {
+			version += " " + s.Value
+		}
+	}
+	return version
+}
+
+// newUpgrade returns the upgrade from the lockfile l to the run, nil when l
+// was written by this version. The run must have introspected its tables.
+func (o *Orm) newUpgrade(l *lockfile) *upgrade {
+	if l == nil || l.ToolVersion == "" || l.ToolVersion == toolVersion() {
+		return nil
+	}
+	u := &upgrade{from: l.ToolVersion, to: toolVersion(), tables: l.Tables, current: o.tableHashes(), files: make(map[string]lockedFile, len(l.Files))}
+	for _, f := range l.Files {
+		u.files[f.Path] = f
+	}
+	return u
+}
+
+// attribute returns the cause of the change of the file at path, with the
+// checksum sum of its content, empty when no longer generated, and the table
+// it is generated from: "schema", "tool", or empty when it is the recorded
+// content or u is nil.
+func (u *upgrade) attribute(path, sum, table string) string {
+	if u == nil {
+		return ""
+	}
+	f, ok := u.files[path]
+	if ok && f.SHA256 == sum {
+		return ""
+	}
+	// A file missing from the lockfile is the one of a new table
+	if !ok || slices.ContainsFunc(f.Tables, u.drifted) || table != "" && u.drifted(table) {
+		u.schema++
+		return "schema"
+	}
+	u.tool++
+	return "tool"
+}
+
+// drifted reports whether the metadata of table changed.
+func (u *upgrade) drifted(table string) bool {
+	sum, ok := u.tables[table]
+	return !ok || sum != u.current[table]
+}
+
+// String returns the summary of the attributed files.
+func (u *upgrade) String() string {
+	return fmt.Sprintf("The lockfile was written by version %s, this is %s: %d files changed with the schema, %d with the tool", u.from, u.to, u.schema, u.tool)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"gorm.io/gen"
+)
+
+func TestLockfileRecordsConfig(t *testing.T) {
+	db := openFixture(t, "users")
+	generate(t, db, []string{"-t", "users"}, WithConfig(gen.Config{OutPath: "dao", FieldSignable: true, Mode: gen.WithoutContext}))
+
+	data, err := os.ReadFile(defaultLockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l lockfile
+	if err := json.Unmarshal(data, &l); err != nil {
+		t.Fatal(err)
+	}
+	if l.Version != lockfileVersion || l.ToolVersion != toolVersion() {
+		t.Errorf("lockfile version %d, tool %q, want %d and %q", l.Version, l.ToolVersion, lockfileVersion, toolVersion())
+	}
+	// The omitted ModelPkgPath is recorded with its default
+	want := lockedConfig{OutPath: "dao", ModelPkgPath: defaultModelPkgPath, FieldSignable: true, Mode: []string{"without_context"}}
+	if got := l.Config; got.OutPath != want.OutPath || got.ModelPkgPath != want.ModelPkgPath || !got.FieldSignable || !slices.Equal(got.Mode, want.Mode) {
+		t.Errorf("locked config %+v, want %+v", got, want)
+	}
+}
+
+func TestLockfileNotWritten(t *testing.T) {
+	db := openFixture(t, "users")
+	for name, args := range map[string][]string{
+		"disabled": {"-t", "users", "--lockfile", ""},
+		"dry run":  {"-t", "users", "--dry-run"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})).Command()
+			c.SetArgs(append(args, "--config", ""))
+			c.SilenceUsage, c.SilenceErrors = true, true
+			// A dry run of new files fails as stale
+			_ = c.ExecuteContext(context.Background())
+			if _, err := os.Stat(defaultLockfile); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("lockfile written: %v", err)
+			}
+		})
+	}
+}
+
+func TestFieldNullableConflict(t *testing.T) {
+	t.Chdir(t.TempDir())
+	c := NewOrmCommand(
+		WithDB(openFixture(t, "users")),
+		WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model", FieldNullable: true}),
+		WithNullable([]string{"users->nickname"}),
+	).Command()
+	c.SetArgs([]string{"-t", "users", "--config", ""})
+	c.SilenceUsage, c.SilenceErrors = true, true
+	err := c.ExecuteContext(context.Background())
+	var confErr *ConfigError
+	if !errors.As(err, &confErr) || confErr.Field != "FieldNullable" {
+		t.Fatalf("err = %v, want a ConfigError of FieldNullable", err)
+	}
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage {
+		t.Errorf("exit code %d, want %d", code, cmd.ExitUsage)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Phases of a run, the phase label of the duration metric.
+const (
+	phaseConnect    = "connect"
+	phaseIntrospect = "introspect"
+	phaseGenerate   = "generate"
+	phasePrune      = "prune"
+	phaseCapture    = "capture"
+	phaseTotal      = "total"
+)
+
+// metricPhases are the phases of a run, in the order of the metrics.
+var metricPhases = []string{phaseConnect, phaseIntrospect, phaseGenerate, phasePrune, phaseCapture, phaseTotal}
+
+// metricsHelp documents the metrics of --metrics in the long help.
+const metricsHelp = `With --metrics the run writes a snapshot of its metrics in the Prometheus
+text format, for the textfile collector of the node exporter, replaced
+atomically at the end of every run, failed or not. The metrics are gauges
+of the last run, the _total ones included:
+
+  czx_orm_success                  1 when the run succeeded, else 0
+  czx_orm_tables_total             tables selected for generation
+  czx_orm_files_written_total      files written, the unchanged ones excluded
+  czx_orm_warnings_total{category} warnings by category: rules, tables,
+                                   relations, database, lock
+  czx_orm_duration_seconds{phase}  seconds by phase: connect, introspect,
+                                   generate (without introspect), prune,
+                                   capture, total
+  czx_orm_schema_hash_info{hash}   1, labeled with the checksum of the
+                                   introspected schema of orm plan`
+
+// phase starts timing a phase of the run and returns the function ending it.
+func (o *Orm) phase(name string) func() {
+	start := time.Now()
+	return func() {
+		if o.phases == nil {
+			o.phases = make(map[string]time.Duration)
+		}
+		o.phases[name] += time.Since(start)
+	}
+}
+
+// metrics returns the metrics of the run in the Prometheus text format.
+func (o *Orm) metrics(elapsed time.Duration, err error) []byte {
+	var b strings.Builder
+	family := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	success := 1
+	if err != nil {
+		success = 0
+	}
+	family("czx_orm_success", "gauge", "Whether the last orm run succeeded.")
+	fmt.Fprintf(&b, "czx_orm_success %d\n", success)
+
+	family("czx_orm_tables_total", "gauge", "Tables selected for generation by the last orm run.")
+	fmt.Fprintf(&b, "czx_orm_tables_total %d\n", o.models.total)
+
+	family("czx_orm_files_written_total", "gauge", "Files written by the last orm run.")
+	fmt.Fprintf(&b, "czx_orm_files_written_total %d\n", o.rewritten)
+
+	family("czx_orm_warnings_total", "gauge", "Warnings of the last orm run by category.")
+	for _, category := range warnCategories {
+		fmt.Fprintf(&b, "czx_orm_warnings_total{category=%q} %d\n", category, o.warnings[category])
+	}
+
+	family("czx_orm_duration_seconds", "gauge", "Duration of the phases of the last orm run.")
+	for _, name := range metricPhases {
+		d := o.phases[name]
+		switch name {
+		case phaseGenerate:
+			d -= o.phases[phaseIntrospect]
+		case phaseTotal:
+			d = elapsed
+		}
+		fmt.Fprintf(&b, "czx_orm_duration_seconds{phase=%q} %g\n", name, max(d, 0).Seconds())
+	}
+
+	family("czx_orm_schema_hash_info", "gauge", "Checksum of the schema introspected by the last orm run.")
+	hash := ""
+	if o.columns != nil {
+		hash = o.schemaHash()
+	}
+	fmt.Fprintf(&b, "czx_orm_schema_hash_info{hash=%q} 1\n", hash)
+	return []byte(b.String())
+}
+
+// finishMetrics writes the metrics of the run, ended with err, to the file
+// of --metrics when set.
+func (o *Orm) finishMetrics(args *pflag.FlagSet, start time.Time, err error) error {
+	path, ferr := args.GetString("metrics")
+	if ferr != nil || path == "" {
+		return ferr
+	}
+	return writeFileAtomic(path, o.metrics(time.Since(start), err), 0644)
+}
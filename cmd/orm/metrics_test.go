@@ -0,0 +1,239 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// metricFamily is a family of an exposition read by parseExposition.
+type metricFamily struct {
+	typ  string
+	help string
+	// samples are the values by label set, formatted as name="value",...
+	samples map[string]float64
+}
+
+// parseExposition parses a metrics file in the Prometheus text format,
+// failing the test on any deviation from the format.
+func parseExposition(t *testing.T, text string) map[string]*metricFamily {
+	t.Helper()
+	if !strings.HasSuffix(text, "\n") {
+		t.Fatalf("exposition does not end with a newline: %q", text)
+	}
+	families := make(map[string]*metricFamily)
+	var current string
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		n := i + 1
+		if rest, ok := strings.CutPrefix(line, "# "); ok {
+			kind, rest, _ := strings.Cut(rest, " ")
+			name, value, _ := strings.Cut(rest, " ")
+			if !metricName.MatchString(name) {
+				t.Fatalf("line %d: invalid metric name %q", n, name)
+			}
+			f := families[name]
+			if f == nil {
+				f = &metricFamily{samples: make(map[string]float64)}
+				families[name] = f
+			} else if name != current {
+				t.Fatalf("line %d: family %s is not contiguous", n, name)
+			}
+			current = name
+			switch kind {
+			case "HELP":
+				if f.help != "" {
+					t.Fatalf("line %d: second HELP of %s", n, name)
+				}
+				f.help = value
+			case "TYPE":
+				if f.typ != "" || len(f.samples) > 0 {
+					t.Fatalf("line %d: TYPE of %s after its samples or another TYPE", n, name)
+				}
+				if !slices.Contains([]string{"counter", "gauge", "histogram", "summary", "untyped"}, value) {
+					t.Fatalf("line %d: unknown type %q", n, value)
+				}
+				f.typ = value
+			default:
+				t.Fatalf("line %d: unknown comment %q", n, line)
+			}
+			continue
+		}
+
+		name, labels, value := parseSample(t, n, line)
+		f := families[name]
+		if f == nil || name != current {
+			t.Fatalf("line %d: sample of %s outside of its family", n, name)
+		}
+		if _, ok := f.samples[labels]; ok {
+			t.Fatalf("line %d: duplicate series %s{%s}", n, name, labels)
+		}
+		f.samples[labels] = value
+	}
+	return families
+}
+
+// parseSample parses a sample line without timestamp.
+func parseSample(t *testing.T, n int, line string) (name, labels string, value float64) {
+	t.Helper()
+	end := strings.IndexAny(line, "{ ")
+	if end < 0 {
+		t.Fatalf("line %d: no value in %q", n, line)
+	}
+	name, rest := line[:end], line[end:]
+	if !metricName.MatchString(name) {
+		t.Fatalf("line %d: invalid metric name %q", n, name)
+	}
+
+	var pairs []string
+	if inner, ok := strings.CutPrefix(rest, "{"); ok {
+		rest = inner
+		seen := make(map[string]bool)
+		for !strings.HasPrefix(rest, "}") {
+			label, after, ok := strings.Cut(rest, `="`)
+			if !ok || !labelName.MatchString(label) || strings.HasPrefix(label, "__") || seen[label] {
+				t.Fatalf("line %d: invalid or duplicate label in %q", n, line)
+			}
+			seen[label] = true
+			var v strings.Builder
+			i := 0
+			for ; i < len(after) && after[i] != '"'; i++ {
+				if after[i] == '\\' {
+					i++
+					if i == len(after) || !strings.ContainsRune(`\"n`, rune(after[i])) {
+						t.Fatalf("line %d: invalid escape in %q", n, line)
+					}
+				}
+				v.WriteByte(after[i])
+			}
+			if i == len(after) {
+				t.Fatalf("line %d: unterminated label value in %q", n, line)
+			}
+			pairs = append(pairs, fmt.Sprintf("%s=%q", label, v.String()))
+			rest = strings.TrimPrefix(after[i+1:], ",")
+		}
+		rest = rest[1:]
+	}
+
+	text, ok := strings.CutPrefix(rest, " ")
+	if !ok || strings.Contains(text, " ") {
+		t.Fatalf("line %d: expected a single value in %q", n, line)
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil && !slices.Contains([]string{"+Inf", "-Inf", "NaN"}, text) {
+		t.Fatalf("line %d: invalid value %q", n, text)
+	}
+	slices.Sort(pairs)
+	return name, strings.Join(pairs, ","), value
+}
+
+// readMetrics parses the metrics file at path.
+func readMetrics(t *testing.T, path string) map[string]*metricFamily {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseExposition(t, string(data))
+}
+
+// checkMetrics checks the documented families and label sets of a run.
+func checkMetrics(t *testing.T, families map[string]*metricFamily, success float64, tables int) {
+	t.Helper()
+	want := map[string][]string{
+		"czx_orm_success":             {""},
+		"czx_orm_tables_total":        {""},
+		"czx_orm_files_written_total": {""},
+		"czx_orm_warnings_total":      {},
+		"czx_orm_duration_seconds":    {},
+		"czx_orm_schema_hash_info":    nil,
+	}
+	for _, category := range warnCategories {
+		want["czx_orm_warnings_total"] = append(want["czx_orm_warnings_total"], fmt.Sprintf("category=%q", category))
+	}
+	for _, phase := range metricPhases {
+		want["czx_orm_duration_seconds"] = append(want["czx_orm_duration_seconds"], fmt.Sprintf("phase=%q", phase))
+	}
+	if got := slices.Sorted(maps.Keys(families)); !slices.Equal(got, slices.Sorted(maps.Keys(want))) {
+		t.Fatalf("families = %v", got)
+	}
+	for name, f := range families {
+		// The values are the ones of the last run, not accumulated
+		if f.typ != "gauge" || f.help == "" {
+			t.Errorf("%s: type %q, help %q", name, f.typ, f.help)
+		}
+		for _, labels := range want[name] {
+			if v, ok := f.samples[labels]; !ok || v < 0 || math.IsNaN(v) {
+				t.Errorf("%s{%s} = %v, %v", name, labels, v, ok)
+			}
+		}
+	}
+	if len(families["czx_orm_schema_hash_info"].samples) != 1 {
+		t.Errorf("schema hash series: %v", families["czx_orm_schema_hash_info"].samples)
+	}
+	if got := families["czx_orm_success"].samples[""]; got != success {
+		t.Errorf("czx_orm_success = %v, want %v", got, success)
+	}
+	if got := families["czx_orm_tables_total"].samples[""]; got != float64(tables) {
+		t.Errorf("czx_orm_tables_total = %v, want %d", got, tables)
+	}
+}
+
+func TestMetricsExposition(t *testing.T) {
+	db := openFixture(t, "shop")
+	generate(t, db, []string{"-t", "customers", "-t", "orders", "--metrics", "run.prom"})
+	families := readMetrics(t, "run.prom")
+	checkMetrics(t, families, 1, 2)
+	if got := families["czx_orm_files_written_total"].samples[""]; got < 2 {
+		t.Errorf("czx_orm_files_written_total = %v", got)
+	}
+
+	// A failed run replaces the file with success=0
+	c := NewOrmCommand(
+		WithDB(db),
+		WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}),
+		WithDataType(map[string]DataTypeFn{"*->varchar": func(gorm.ColumnType) string { return "map[" }}),
+	).Command()
+	c.SetArgs([]string{"-t", "customers", "--metrics", "run.prom", "--config", ""})
+	c.SilenceUsage, c.SilenceErrors = true, true
+	if err := c.ExecuteContext(context.Background()); err == nil {
+		t.Fatal("run with an invalid type succeeded")
+	}
+	checkMetrics(t, readMetrics(t, "run.prom"), 0, 1)
+}
+
+func TestParseExposition(t *testing.T) {
+	o := newOrm(OrmOption{})
+	o.models.total, o.rewritten = 3, 2
+	o.warnings = map[string]int{warnCategories[0]: 4}
+	checkMetrics(t, parseExposition(t, string(o.metrics(0, nil))), 1, 3)
+}
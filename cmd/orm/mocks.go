@@ -0,0 +1,323 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/imports"
+)
+
+// mocksPackage is the subpackage of the query directory holding the mocks.
+const mocksPackage = "mocks"
+
+type (
+	// mockInterface is an annotae interface applied to the dao of a table, with
+	// the method signatures gen generated for it.
+	mockInterface struct {
+		// Name is the interface declared in the mocks package, e.g. UserQuerier
+		Name    string
+		Methods []mockMethod
+	}
+	// mockMethod is a method of a mocked interface.
+	mockMethod struct {
+		Name     string
+		Params   []mockParam
+		Results  []string
+		Variadic bool
+	}
+	// mockParam is a parameter of a mocked method, Field is its name in the
+	// recorded calls.
+	mockParam struct {
+		Name, Field, Type string
+	}
+)
+
+// mocks writes a mock of every annotae interface applied to the dao of each
+// selected table, in the mocks subpackage of the query directory. The method
+// signatures are read from the generated query files, so the mocks always
+// match the output of the same run.
+func (o *Orm) mocks() error {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return err
+	}
+	daoImport, err := importPath(out)
+	if err != nil {
+		return err
+	}
+
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		if table == "" || !selected(o.opt.daoTables, table) {
+			continue
+		}
+		var annotae []any
+		for _, key := range []string{"*", table} {
+			if api, ok := o.opt.daoApi[key]; ok {
+				annotae = append(annotae, api)
+			}
+		}
+		if len(annotae) == 0 {
+			continue
+		}
+
+		path := filepath.Join(out, file+".gen.go")
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		model := metaString(meta, "ModelStructName")
+		ifaces, specs, err := mockInterfaces(src, model, metaString(meta, "QueryStructName")+"Do", annotae, o.acronyms)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		target := filepath.Join(out, mocksPackage, file+".gen.go")
+		mock, err := imports.Process(target, renderMocks(filepath.Base(out), daoImport, specs, model, table, ifaces), nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", target, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(target, mock, 0640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mockInterfaces returns the interfaces to mock for the annotae of a table
+// model and the import specs of its generated query file src, in which the
+// methods are implemented on the do type.
+func mockInterfaces(src []byte, model, do string, annotae []any, acronyms []string) ([]mockInterface, []string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var specs []string
+	for _, imp := range file.Imports {
+		spec := imp.Path.Value
+		if imp.Name != nil {
+			spec = imp.Name.Name + " " + spec
+		}
+		specs = append(specs, spec)
+	}
+	signatures := make(map[string]*ast.FuncType)
+	for _, decl := range file.Decls {
+		d, ok := decl.(*ast.FuncDecl)
+		if !ok || d.Recv == nil || len(d.Recv.List) != 1 {
+			continue
+		}
+		recv := d.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		if id, ok := recv.(*ast.Ident); ok && id.Name == do {
+			signatures[d.Name.Name] = d.Type
+		}
+	}
+
+	var ifaces []mockInterface
+	for _, api := range annotae {
+		iface, err := annotaeInterface(api)
+		if err != nil {
+			return nil, nil, err
+		}
+		mi := mockInterface{Name: model + iface.Name()}
+		for i := range iface.NumMethod() {
+			name := iface.Method(i).Name
+			ft, ok := signatures[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("method %s of %s not generated on %s", name, iface.Name(), do)
+			}
+			mm, err := mockSignature(fset, name, ft, acronyms)
+			if err != nil {
+				return nil, nil, err
+			}
+			mi.Methods = append(mi.Methods, mm)
+		}
+		ifaces = append(ifaces, mi)
+	}
+	return ifaces, specs, nil
+}
+
+// mockSignature converts the generated signature of a method. Unnamed
+// parameters are named a0, a1 and so on.
+func mockSignature(fset *token.FileSet, name string, ft *ast.FuncType, acronyms []string) (mockMethod, error) {
+	expr := func(e ast.Expr) (string, error) {
+		var buf bytes.Buffer
+		err := printer.Fprint(&buf, fset, e)
+		return buf.String(), err
+	}
+
+	mm := mockMethod{Name: name}
+	for _, f := range ft.Params.List {
+		typ := f.Type
+		if ell, ok := typ.(*ast.Ellipsis); ok {
+			mm.Variadic = true
+			typ = &ast.ArrayType{Elt: ell.Elt}
+		}
+		t, err := expr(typ)
+		if err != nil {
+			return mockMethod{}, err
+		}
+		names := f.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("a%d", len(mm.Params)))}
+		}
+		for _, n := range names {
+			field := []rune(n.Name)
+			field[0] = unicode.ToUpper(field[0])
+			mm.Params = append(mm.Params, mockParam{Name: n.Name, Field: acronymize(string(field), acronyms), Type: t})
+		}
+	}
+	if ft.Results != nil {
+		for _, f := range ft.Results.List {
+			t, err := expr(f.Type)
+			if err != nil {
+				return mockMethod{}, err
+			}
+			for range max(len(f.Names), 1) {
+				mm.Results = append(mm.Results, t)
+			}
+		}
+	}
+	return mm, nil
+}
+
+// renderMocks renders the mocks file of a table. Each interface gets a
+// compile-time check against the generated dao, so a mock that no longer
+// matches the query files fails to build.
+// The imports of the query file are kept, imports.Process drops the unused.
+func renderMocks(daoPkg, daoImport string, specs []string, model, table string, ifaces []mockInterface) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by command orm --with-mocks. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\nimport (\n\t\"context\"\n\t\"sync\"\n\n", mocksPackage)
+	for _, spec := range specs {
+		if spec != `"context"` {
+			fmt.Fprintf(&buf, "\t%s\n", spec)
+		}
+	}
+	fmt.Fprintf(&buf, "\t%q\n)\n", daoImport)
+
+	for _, mi := range ifaces {
+		fmt.Fprintf(&buf, "\n// %s holds the annotae methods of the %s dao.\n", mi.Name, table)
+		fmt.Fprintf(&buf, "type %s interface {\n", mi.Name)
+		for _, m := range mi.Methods {
+			fmt.Fprintf(&buf, "\t%s%s\n", m.Name, m.signature())
+		}
+		buf.WriteString("}\n")
+		fmt.Fprintf(&buf, "\nvar _ %s = (*%sMock)(nil)\n", mi.Name, mi.Name)
+		fmt.Fprintf(&buf, "\nfunc _(q *%s.Query) {\n\tvar _ %s = q.%s.WithContext(context.TODO())\n}\n", daoPkg, mi.Name, model)
+
+		fmt.Fprintf(&buf, "\n// %sMock is a mock of %s. A method calls its Func field when set,\n", mi.Name, mi.Name)
+		buf.WriteString("// else it returns the zero values. Calls are recorded in its Calls field.\n")
+		fmt.Fprintf(&buf, "type %sMock struct {\n\tmu sync.Mutex\n", mi.Name)
+		for _, m := range mi.Methods {
+			fmt.Fprintf(&buf, "\n\t%sFunc func%s\n", m.Name, m.signature())
+			fmt.Fprintf(&buf, "\t%sCalls []%s\n", m.Name, m.call())
+		}
+		buf.WriteString("}\n")
+
+		for _, m := range mi.Methods {
+			buf.WriteString(m.body(mi.Name + "Mock"))
+		}
+	}
+	return buf.Bytes()
+}
+
+// signature returns the parameters and results of the method.
+func (m mockMethod) signature() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.Name + " " + p.Type
+		if m.Variadic && i == len(m.Params)-1 {
+			params[i] = p.Name + " ..." + strings.TrimPrefix(p.Type, "[]")
+		}
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	switch len(m.Results) {
+	case 0:
+		return sig
+	case 1:
+		return sig + " " + m.Results[0]
+	}
+	return sig + " (" + strings.Join(m.Results, ", ") + ")"
+}
+
+// call returns the struct type of the recorded calls of the method.
+func (m mockMethod) call() string {
+	if len(m.Params) == 0 {
+		return "struct{}"
+	}
+	var buf strings.Builder
+	buf.WriteString("struct {\n")
+	for _, p := range m.Params {
+		fmt.Fprintf(&buf, "\t\t%s %s\n", p.Field, p.Type)
+	}
+	buf.WriteString("\t}")
+	return buf.String()
+}
+
+// body renders the mock method recording the call. The receiver and locals
+// are prefixed with an underscore so they never shadow a parameter.
+func (m mockMethod) body(mock string) string {
+	var names, args []string
+	for i, p := range m.Params {
+		names = append(names, p.Name)
+		arg := p.Name
+		if m.Variadic && i == len(m.Params)-1 {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// %s records the call and returns the result of %sFunc.\n", m.Name, m.Name)
+	fmt.Fprintf(&buf, "func (_m *%s) %s%s {\n", mock, m.Name, m.signature())
+	buf.WriteString("\t_m.mu.Lock()\n")
+	fmt.Fprintf(&buf, "\t_m.%sCalls = append(_m.%sCalls, %s{%s})\n", m.Name, m.Name, m.call(), strings.Join(names, ", "))
+	fmt.Fprintf(&buf, "\t_fn := _m.%sFunc\n", m.Name)
+	buf.WriteString("\t_m.mu.Unlock()\n")
+
+	call := fmt.Sprintf("_fn(%s)", strings.Join(args, ", "))
+	buf.WriteString("\tif _fn != nil {\n")
+	if len(m.Results) == 0 {
+		fmt.Fprintf(&buf, "\t\t%s\n\t}\n}\n", call)
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, "\t\treturn %s\n\t}\n", call)
+	zeros := make([]string, len(m.Results))
+	for i, r := range m.Results {
+		zeros[i] = fmt.Sprintf("_r%d", i)
+		fmt.Fprintf(&buf, "\tvar _r%d %s\n", i, r)
+	}
+	fmt.Fprintf(&buf, "\treturn %s\n}\n", strings.Join(zeros, ", "))
+	return buf.String()
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/annotae"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mockSampleTest is the sample test of a service against the generated
+// mock, asserting that GetByID was called with the given id.
+const mockSampleTest = `package service
+
+import (
+	"testing"
+
+	"MODULE/dao/mocks"
+	"MODULE/model"
+)
+
+// userName is the code under test, reading a user through the dao.
+func userName(q mocks.UserQuerier, id int) (string, error) {
+	user, err := q.GetByID(id)
+	return user.Name, err
+}
+
+func TestUserName(t *testing.T) {
+	m := &mocks.UserQuerierMock{
+		GetByIDFunc: func(id int) (model.User, error) { return model.User{Name: "ada"}, nil },
+	}
+	name, err := userName(m, 42)
+	if err != nil || name != "ada" {
+		t.Fatalf("userName = %q, %v", name, err)
+	}
+	if len(m.GetByIDCalls) != 1 || m.GetByIDCalls[0].ID != 42 {
+		t.Errorf("GetByID calls = %+v, want one with id 42", m.GetByIDCalls)
+	}
+
+	// Without a Func field the mock returns the zero values
+	if name, err := userName(&mocks.UserQuerierMock{}, 7); name != "" || err != nil {
+		t.Errorf("zero mock = %q, %v", name, err)
+	}
+}
+`
+
+func TestMockSample(t *testing.T) {
+	files := generateBuilt(t, openFixture(t, "users"), []string{"-t", "users", "--style", "dao", "--with-mocks"},
+		WithDaoTables([]string{"users"}),
+		WithDaoApi(map[string]any{"*": func(annotae.Querier) {}}),
+	)
+	if !strings.Contains(files["dao/mocks/users.gen.go"], "type UserQuerierMock struct") {
+		t.Fatalf("no mock of users in %v", keys(files))
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sample := strings.ReplaceAll(mockSampleTest, "MODULE", "command/cmd/orm/testdata/"+filepath.Base(wd))
+	if err := os.MkdirAll("service", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("service", "service_test.go"), []byte(sample), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("go", "test", "./service").CombinedOutput(); err != nil {
+		t.Fatalf("go test: %v\n%s", err, out)
+	}
+}
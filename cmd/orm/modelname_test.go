@@ -0,0 +1,56 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+func TestTableModelName(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "app.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	// users@Member generates the users table as the Member model
+	c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})).Command()
+	c.SetArgs([]string{"-t", "users@Member", "--config", ""})
+	c.SilenceUsage, c.SilenceErrors = true, true
+	if err := c.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join("model", "users.gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`const TableNameMember = "users"`, "type Member struct", "Name string"} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("model lacks %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// importPath returns the import path of a directory, under --module-path
+// when set instead of the module of the nearest go.mod.
+func (o *Orm) importPath(dir string) (string, error) {
+	if o.modulePath == "" {
+		return importPath(dir)
+	}
+	root, _, err := moduleRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	return joinImport(o.modulePath, root, dir)
+}
+
+// checkModulePath validates the syntax of --module-path.
+func checkModulePath(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := module.CheckImportPath(path); err != nil {
+		return &ModulePathError{Module: path, Reason: err.Error()}
+	}
+	return nil
+}
+
+// importReplacements returns the pairs of quoted import paths replacing the
+// import path of each from directory with the one of its to directory.
+func (o *Orm) importReplacements(dirs map[string]string) []string {
+	var replacements []string
+	for from, to := range dirs {
+		source, errSource := importPath(from)
+		target, errTarget := o.importPath(to)
+		if errSource == nil && errTarget == nil && source != target {
+			replacements = append(replacements, strconv.Quote(source), strconv.Quote(target))
+		}
+	}
+	return replacements
+}
+
+// rewriteImports applies the import replacements to the Go files under dir.
+func rewriteImports(dir string, replacements []string) error {
+	if len(replacements) == 0 {
+		return nil
+	}
+	replacer := strings.NewReplacer(replacements...)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(replacer.Replace(string(src))), 0640)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// checkImports verifies that every import under --module-path of the
+// generated files resolves to a package directory of the module layout, so a
+// wrong module path fails the run instead of the build of the consumer.
+func (o *Orm) checkImports(dao bool) error {
+	if o.modulePath == "" {
+		return nil
+	}
+	files, err := o.generatedFiles(dao)
+	if err != nil {
+		return err
+	}
+	for _, path := range sortedKeys(files) {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		root, _, err := moduleRoot(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, imp := range file.Imports {
+			pkg, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				return err
+			}
+			rel, ok := strings.CutPrefix(pkg, o.modulePath)
+			if !ok || rel != "" && !strings.HasPrefix(rel, "/") {
+				continue
+			}
+			if module.CheckImportPath(pkg) != nil || !hasGoFiles(filepath.Join(root, filepath.FromSlash(rel))) {
+				return &ModulePathError{Module: o.modulePath, File: path, Import: pkg}
+			}
+		}
+	}
+	return nil
+}
+
+// hasGoFiles reports whether dir holds a Go source file.
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata/golden")
+
+// testdata is the absolute path of the fixtures, the tests changing the
+// working directory.
+var testdata, _ = filepath.Abs("testdata")
+
+// openFixture opens the schema snapshot testdata/<name>.json, a connection
+// answering the schema queries without a database. The introspection
+// metadata of a table defaults to the one of its columns.
+func openFixture(t testing.TB, name string) *gorm.DB {
+	t.Helper()
+	return openFixtureWith(t, name, nil)
+}
+
+// openFixtureWith is openFixture with the snapshot changed by edit first.
+func openFixtureWith(t testing.TB, name string, edit func(*schemaSnapshot)) *gorm.DB {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(testdata, name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap schemaSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if edit != nil {
+		edit(&snap)
+	}
+	for i, table := range snap.Tables {
+		if len(table.Meta) > 0 {
+			continue
+		}
+		for _, col := range table.Columns {
+			meta := columnMeta{Table: table.Name, Name: col.NameValue, DataType: col.DataTypeValue, Nullable: "NO"}
+			meta.Type, _ = col.ColumnType()
+			meta.Comment, _ = col.Comment()
+			if nullable, _ := col.Nullable(); nullable {
+				meta.Nullable = "YES"
+			}
+			if pk, _ := col.PrimaryKey(); pk {
+				meta.Key = "PRI"
+			}
+			snap.Tables[i].Meta = append(snap.Tables[i].Meta, meta)
+		}
+	}
+	db, err := openSnapshot(&snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// generate runs the orm command on db with args and opts in a temporary
+// directory, models into ./model and queries into ./dao, and returns the
+// generated files by slash-separated path.
+func generate(t *testing.T, db *gorm.DB, args []string, opts ...IOrmOption) map[string]string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	return generateHere(t, db, args, opts...)
+}
+
+// generateBuilt is generate in a temporary directory of this module, whose
+// generated packages must then compile.
+func generateBuilt(t *testing.T, db *gorm.DB, args []string, opts ...IOrmOption) map[string]string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("building the generated code needs the go command")
+	}
+	dir, err := os.MkdirTemp(testdata, "build-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Chdir(dir)
+
+	files := generateHere(t, db, args, opts...)
+	build := exec.Command(goBin, "build", "./...")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("the generated code does not compile: %v\n%s", err, out)
+	}
+	return files
+}
+
+// generateHere is generate in the working directory.
+func generateHere(t *testing.T, db *gorm.DB, args []string, opts ...IOrmOption) map[string]string {
+	t.Helper()
+	opts = append([]IOrmOption{WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})}, opts...)
+	c := NewOrmCommand(opts...).Command()
+	c.SetArgs(append(args, "--config", ""))
+	c.SilenceUsage, c.SilenceErrors = true, true
+	if err := c.Execute(); err != nil {
+		t.Fatalf("orm %v: %v", args, err)
+	}
+	return readTree(t, ".")
+}
+
+// captureOutput returns what fn prints to the standard output, colored or
+// not.
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	defer func() { os.Stdout, color.Output = stdout, output }()
+
+	out := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-out)
+}
+
+// readTree returns the .go files under dir by slash-separated path.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// fieldLine returns the line of the struct field name in src, empty when the
+// field is missing.
+func fieldLine(src, name string) string {
+	for line := range strings.Lines(src) {
+		if f := strings.Fields(line); len(f) > 1 && f[0] == name {
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+// golden compares got with the golden file testdata/golden/<name>, which
+// go test -update rewrites.
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join(testdata, "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file, run go test -update after checking:\n%s", name, got)
+	}
+}
+
+// keys returns the sorted paths of files.
+func keys(files map[string]string) []string {
+	return slices.Sorted(maps.Keys(files))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// windowsMaxPath is the MAX_PATH limit of the Windows file APIs.
+const windowsMaxPath = 260
+
+// defaultMaxPath returns the default output path length limit,
+// only enforced on Windows.
+func defaultMaxPath() int {
+	if runtime.GOOS == "windows" {
+		return windowsMaxPath
+	}
+	return 0
+}
+
+// abbreviate replaces the underscore separated words of a file name
+// with their abbreviations.
+func abbreviate(name string, abbreviations map[string]string) string {
+	if len(abbreviations) == 0 {
+		return name
+	}
+	words := strings.Split(name, "_")
+	for i, w := range words {
+		if abbr, ok := abbreviations[w]; ok {
+			words[i] = abbr
+		}
+	}
+	return strings.Join(words, "_")
+}
+
+// checkPaths verifies, before anything is written, that the generated file
+// names are unique, that no output path exceeds maxPath and that no
+// generated file is listed in the .ormkeep file of its directory.
+func (o *Orm) checkPaths(maxPath int) error {
+	modelDir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	outDir, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return err
+	}
+	modelKeep, err := loadKeep(modelDir)
+	if err != nil {
+		return err
+	}
+	outKeep, err := loadKeep(outDir)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]string)
+	var long []string
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		if table == "" {
+			continue
+		}
+		if other, ok := files[file]; ok {
+			return fmt.Errorf("tables %s and %s both generate file %s, adjust the rename or abbreviation rules", other, table, file)
+		}
+		files[file] = table
+
+		if modelKeep.Kept(file + ".gen.go") {
+			return &KeptPathError{Table: table, Path: filepath.Join(modelDir, file+".gen.go"), Keep: filepath.Join(modelDir, keepFile)}
+		}
+		if outKeep.Kept(file + ".gen.go") {
+			return &KeptPathError{Table: table, Path: filepath.Join(outDir, file+".gen.go"), Keep: filepath.Join(outDir, keepFile)}
+		}
+
+		if maxPath <= 0 {
+			continue
+		}
+		for _, path := range []string{
+			filepath.Join(modelDir, file+".gen.go"),
+			filepath.Join(outDir, file+".gen.go"),
+		} {
+			if len(path) > maxPath {
+				long = append(long, fmt.Sprintf("%s: %s (%d)", table, path, len(path)))
+			}
+		}
+	}
+
+	if len(long) > 0 {
+		return fmt.Errorf("output paths exceed %d characters:\n  %s", maxPath, strings.Join(long, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+// Keys of the table picker.
+const (
+	keyCtrlA     = 0x01
+	keyCtrlC     = 0x03
+	keyCtrlN     = 0x0e
+	keyCtrlP     = 0x10
+	keyEnter     = '\r'
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
+)
+
+// picker is the state of the interactive table checklist.
+type picker struct {
+	tables   []string
+	selected map[string]bool
+	// query filters the tables by fuzzy match
+	query string
+	// shown are the tables matching the query, cursor and offset index them
+	shown  []string
+	cursor int
+	offset int
+	height int
+}
+
+// pickTables lets the user check the tables to generate among candidates on
+// the terminal: typing filters them by fuzzy match, the arrows move, space
+// checks the table under the cursor, ctrl-a checks every shown table or
+// unchecks them when all are, enter generates the checked tables and esc or
+// ctrl-c cancels. It fails without a terminal rather than waiting for input.
+func pickTables(candidates []string) ([]string, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stderr.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return nil, cmd.Exit(cmd.ExitUsage, errors.New("--interactive needs a terminal, select the tables with -t instead"))
+	}
+	if len(candidates) == 0 {
+		return nil, &EmptyGenerationError{Style: "model", Reason: "no table to pick from"}
+	}
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(in, state)
+
+	p := &picker{tables: candidates, selected: make(map[string]bool), height: 10}
+	if _, rows, err := term.GetSize(out); err == nil {
+		p.height = max(rows-4, 3)
+	}
+	p.filter()
+
+	// The checklist is drawn on the alternate screen, left on return
+	fmt.Fprint(os.Stderr, "\x1b[?1049h")
+	defer fmt.Fprint(os.Stderr, "\x1b[?1049l")
+	buf := make([]byte, 16)
+	for {
+		p.draw(os.Stderr)
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		done, err := p.key(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+
+	var picked []string
+	for _, table := range candidates {
+		if p.selected[table] {
+			picked = append(picked, table)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, &EmptyGenerationError{Style: "model", Reason: "no table picked"}
+	}
+	return picked, nil
+}
+
+// key applies the keys of one read, reporting whether the selection is done.
+func (p *picker) key(b []byte) (bool, error) {
+	// Arrow keys come in a single read as escape sequences
+	if len(b) >= 3 && b[0] == keyEscape && b[1] == '[' {
+		switch b[2] {
+		case 'A':
+			p.move(-1)
+		case 'B':
+			p.move(1)
+		}
+		return false, nil
+	}
+	for _, c := range b {
+		switch c {
+		case keyEnter, '\n':
+			return true, nil
+		case keyCtrlC, keyEscape:
+			return false, errors.New("table selection cancelled")
+		case keyCtrlP:
+			p.move(-1)
+		case keyCtrlN:
+			p.move(1)
+		case ' ':
+			if p.cursor < len(p.shown) {
+				table := p.shown[p.cursor]
+				p.selected[table] = !p.selected[table]
+			}
+		case keyCtrlA:
+			all := true
+			for _, table := range p.shown {
+				all = all && p.selected[table]
+			}
+			for _, table := range p.shown {
+				p.selected[table] = !all
+			}
+		case keyBackspace, '\b':
+			if p.query != "" {
+				p.query = p.query[:len(p.query)-1]
+				p.filter()
+			}
+		default:
+			if c < 0x80 && unicode.IsPrint(rune(c)) {
+				p.query += string(rune(c))
+				p.filter()
+			}
+		}
+	}
+	return false, nil
+}
+
+// move moves the cursor by delta, scrolling the list to keep it shown.
+func (p *picker) move(delta int) {
+	p.cursor = min(max(p.cursor+delta, 0), max(len(p.shown)-1, 0))
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+p.height {
+		p.offset = p.cursor - p.height + 1
+	}
+}
+
+// filter shows the tables matching the query and resets the cursor.
+func (p *picker) filter() {
+	p.shown = p.shown[:0]
+	for _, table := range p.tables {
+		if fuzzyMatch(p.query, table) {
+			p.shown = append(p.shown, table)
+		}
+	}
+	p.cursor, p.offset = 0, 0
+}
+
+// draw writes the checklist, with raw mode line endings.
+func (p *picker) draw(w io.Writer) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	count := 0
+	for _, checked := range p.selected {
+		if checked {
+			count++
+		}
+	}
+	fmt.Fprintf(&b, "Tables to generate (%d checked, %d shown): %s\r\n", count, len(p.shown), p.query)
+	for i := p.offset; i < len(p.shown) && i < p.offset+p.height; i++ {
+		cursor, box := "  ", "[ ]"
+		if i == p.cursor {
+			cursor = "> "
+		}
+		if p.selected[p.shown[i]] {
+			box = "[x]"
+		}
+		fmt.Fprintf(&b, "%s%s %s\r\n", cursor, box, p.shown[i])
+	}
+	if len(p.shown) == 0 {
+		b.WriteString("  no table matches\r\n")
+	}
+	b.WriteString("\x1b[2mtype to filter, space check, ctrl-a all, enter generate, esc cancel\x1b[0m")
+	io.WriteString(w, b.String())
+}
+
+// fuzzyMatch reports whether the letters of query appear in order in s,
+// ignoring case.
+func fuzzyMatch(query, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
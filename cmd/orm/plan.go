@@ -0,0 +1,492 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"command/policy"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// planVersion is the version of the plan file format.
+const planVersion = 1
+
+// Plan actions.
+const (
+	planCreate = "create"
+	planUpdate = "update"
+	planSkip   = "skip"
+)
+
+type (
+	// planFile is a generation plan, as written by orm plan --out.
+	planFile struct {
+		Version    int       `json:"version"`
+		CreatedAt  time.Time `json:"createdAt"`
+		SchemaHash string    `json:"schemaHash"`
+		// Flags are the generation flags of the plan, replayed by apply
+		Flags   map[string][]string `json:"flags,omitempty"`
+		Changes []planChange        `json:"changes"`
+	}
+	// planChange is what a run does to one file and why.
+	planChange struct {
+		Action string `json:"action"`
+		Path   string `json:"path"`
+		Table  string `json:"table,omitempty"`
+		Reason string `json:"reason"`
+		// SHA256 is the checksum of the planned content
+		SHA256 string `json:"sha256"`
+		// staged is the staged file of the planned content
+		staged string
+		// sum is the checksum of the planned content without provenance
+		sum string
+	}
+	// planning is the state of an orm plan or apply run.
+	planning struct {
+		// recorded is the plan executed by apply, nil when planning
+		recorded *planFile
+		// name is the file of the recorded plan
+		name string
+		// result is the plan of the run
+		result *planFile
+		// violations are the items denied by the policy
+		violations []policy.Violation
+		// content prints the planned content of the created and updated files
+		content bool
+		// diff prints the unified diff of the changed files, and of the
+		// deleted generated files with deletions
+		diff, deletions bool
+		// deleted counts the generated files the diffed run no longer generates
+		deleted int
+		// lockfile is the lockfile of the diffed run, nil when there is none
+		lockfile *lockfile
+		// upgrade attributes the diffed files when the lockfile was written
+		// by another version of the command
+		upgrade *upgrade
+	}
+)
+
+// planCommand returns the orm plan subcommand.
+func (o *Orm) planCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "plan",
+		Short: "List the files a generation run would create, update or skip",
+		Long: `Generate into a staging directory and compare the result with the output
+directories, listing every file the run would create, update or skip with the
+reason: a new table, changed content, unchanged, protected by .ormkeep or
+blocked by the policy. Nothing is written besides --out.
+
+The plan written with --out is executed by orm apply --plan.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Review the plan of a dao run
+command orm plan -t users -t orders --style dao
+
+# Record the plan for approval, then execute exactly that plan
+command orm plan -t users --out plan.json
+command orm apply --plan plan.json`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).plan),
+	}
+	o.generationFlags(c.Flags())
+	c.Flags().String("output", "text", cmd.T("orm.plan.flag.output"))
+	c.Flags().String("out", "", cmd.T("orm.plan.flag.out"))
+	return c
+}
+
+// applyCommand returns the orm apply subcommand.
+func (o *Orm) applyCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "apply",
+		Short: "Execute a plan recorded by orm plan",
+		Long: `Execute a plan written by orm plan --out with the generation flags it
+records, creating and updating exactly the files it lists. The run is refused
+when the schema changed since the plan was made or the run would differ from
+the plan in any other way.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Execute an approved plan
+command orm apply --plan plan.json`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).apply),
+	}
+	o.generationFlags(c.Flags())
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Hidden = true
+	})
+	c.Flags().String("plan", "", cmd.T("orm.apply.flag.plan"))
+	_ = c.MarkFlagRequired("plan")
+	return c
+}
+
+// plan is the execution logic for the orm plan command.
+func (o *Orm) plan(c *cobra.Command, _ []string) error {
+	output, err := c.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output != "text" && output != "json" {
+		return usage("planning", fmt.Errorf("invalid output: %s, must be text or json", output))
+	}
+	out, err := c.Flags().GetString("out")
+	if err != nil {
+		return err
+	}
+
+	o.planning = &planning{}
+	closeConn, err := o.prepare(c)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	if err := o.exec(c.Context(), c.Flags()); err != nil {
+		return fail("planning", err)
+	}
+
+	p := o.planning.result
+	p.Flags = generationFlagValues(c.Flags())
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		if err := writeFileAtomic(out, append(data, '\n'), 0644); err != nil {
+			return fail("writing plan", err)
+		}
+	}
+	if output == "json" {
+		fmt.Println(string(data))
+		return nil
+	}
+	printPlan(p)
+	if out != "" {
+		color.Green("\nPlan written to %s, execute it with: command orm apply --plan %s\n\n", out, out)
+	}
+	return nil
+}
+
+// apply is the execution logic for the orm apply command.
+func (o *Orm) apply(c *cobra.Command, _ []string) error {
+	name, err := c.Flags().GetString("plan")
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return usage("reading plan", err)
+	}
+	var recorded planFile
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return usage("reading plan", fmt.Errorf("%s: %w", name, err))
+	}
+	if recorded.Version != planVersion {
+		return usage("reading plan", fmt.Errorf("%s: unsupported plan version %d", name, recorded.Version))
+	}
+	if err := setGenerationFlags(c.Flags(), recorded.Flags); err != nil {
+		return usage("reading plan", fmt.Errorf("%s: %w", name, err))
+	}
+
+	o.planning = &planning{recorded: &recorded, name: name}
+	closeConn, err := o.prepare(c)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	if err := o.exec(c.Context(), c.Flags()); err != nil {
+		return fail("applying plan", err)
+	}
+	if err := o.writeLockfile(c.Flags()); err != nil {
+		return fail("writing the lockfile", err)
+	}
+	color.Green("\nPlan %s applied.\n\n", name)
+	return nil
+}
+
+// tolerates reports whether a plan run records err in the plan instead of
+// failing: policy violations and files protected by .ormkeep.
+func (p *planning) tolerates(err error) bool {
+	if p == nil {
+		return false
+	}
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		p.violations = policyErr.Violations
+		return true
+	}
+	var keptErr *KeptPathError
+	return errors.As(err, &keptErr)
+}
+
+// execPlan generates into a staging directory and plans the changes. A plan
+// run then drops the staging directory, an apply run checks the changes
+// against the recorded plan and moves the planned files into place.
+func (o *Orm) execPlan(dao bool) error {
+	s, err := o.generateStaged()
+	if err != nil {
+		return err
+	}
+	changes, err := o.planChanges(s, dao)
+	if err != nil {
+		s.abort(o)
+		return err
+	}
+	result := &planFile{
+		Version:    planVersion,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		SchemaHash: o.schemaHash(),
+		Changes:    changes,
+	}
+	o.planning.result = result
+
+	recorded := o.planning.recorded
+	if recorded == nil {
+		switch {
+		case o.planning.content:
+			err = printPlanned(changes)
+		case o.planning.diff:
+			o.planning.upgrade = o.newUpgrade(o.planning.lockfile)
+			var differ int
+			differ, err = o.printDiffs(s, changes, o.planning.deletions)
+			o.planning.deleted = differ - countChanges(changes)
+		}
+		s.abort(o)
+		return err
+	}
+	if err := o.planning.compare(result); err != nil {
+		s.abort(o)
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		s.abort(o)
+		return err
+	}
+	return s.commit(o, func(target string) bool {
+		path := relPath(cwd, target)
+		i := slices.IndexFunc(recorded.Changes, func(c planChange) bool { return c.Path == path })
+		return i >= 0 && recorded.Changes[i].Action != planSkip
+	})
+}
+
+// compare checks that result matches the recorded plan.
+func (p *planning) compare(result *planFile) error {
+	recorded := p.recorded
+	if result.SchemaHash != recorded.SchemaHash {
+		return &StalePlanError{Plan: p.name, Reason: "the schema changed since the plan was made"}
+	}
+	for _, c := range result.Changes {
+		i := slices.IndexFunc(recorded.Changes, func(r planChange) bool { return r.Path == c.Path })
+		if i < 0 {
+			return &StalePlanError{Plan: p.name, Reason: fmt.Sprintf("%s is not part of the plan", c.Path)}
+		}
+		if r := recorded.Changes[i]; r.Action != c.Action || r.SHA256 != c.SHA256 {
+			return &StalePlanError{Plan: p.name, Reason: fmt.Sprintf("%s would be a %s (%s), the plan has a %s (%s)", c.Path, c.Action, c.Reason, r.Action, r.Reason)}
+		}
+	}
+	if len(result.Changes) != len(recorded.Changes) {
+		return &StalePlanError{Plan: p.name, Reason: fmt.Sprintf("the run has %d files, the plan %d", len(result.Changes), len(recorded.Changes))}
+	}
+	return nil
+}
+
+// planChanges compares the staged files with their targets.
+func (o *Orm) planChanges(s *staging, dao bool) ([]planChange, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	tables, err := o.generatedFiles(dao)
+	if err != nil {
+		return nil, err
+	}
+	keeps := make(map[string]*keepList)
+
+	var changes []planChange
+	for from, to := range s.dirs() {
+		err := filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(from, path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(to, rel)
+			staged, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(staged)
+			change := planChange{Path: relPath(cwd, target), SHA256: hex.EncodeToString(sum[:]), staged: path, sum: contentSum(staged)}
+			if t := tables[path]; len(t) > 0 {
+				change.Table = t[0]
+			}
+
+			dir := filepath.Dir(target)
+			if _, ok := keeps[dir]; !ok {
+				if keeps[dir], err = loadKeep(dir); err != nil {
+					return err
+				}
+			}
+			current, err := os.ReadFile(target)
+			switch {
+			case keeps[dir].Kept(filepath.Base(target)):
+				change.Action, change.Reason = planSkip, "protected by .ormkeep"
+			case o.planning.blocked(change) != "":
+				change.Action, change.Reason = planSkip, "blocked by policy rule "+o.planning.blocked(change)
+			case errors.Is(err, fs.ErrNotExist):
+				change.Action, change.Reason = planCreate, "new file"
+				if change.Table != "" {
+					change.Reason = "new table " + change.Table
+				}
+			case err != nil:
+				return err
+			case sameGenerated(current, staged):
+				change.Action, change.Reason = planSkip, "unchanged"
+			default:
+				change.Action, change.Reason = planUpdate, "generated content changed"
+			}
+			changes = append(changes, change)
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// blocked returns the policy rule denying a change, empty when none does.
+func (p *planning) blocked(c planChange) string {
+	for _, v := range p.violations {
+		if v.Item.Path == c.Path || v.Item.Path == "" && v.Item.Table != "" && v.Item.Table == c.Table {
+			return v.Rule
+		}
+	}
+	return ""
+}
+
+// schemaHash returns the checksum of the introspected column metadata.
+func (o *Orm) schemaHash() string {
+	data, _ := json.Marshal(o.columns)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// generationFlagValues returns the generation flags set on the command line.
+func generationFlagValues(fs *pflag.FlagSet) map[string][]string {
+	names := pflag.NewFlagSet("generation", pflag.ContinueOnError)
+	(&Orm{}).generationFlags(names)
+
+	values := make(map[string][]string)
+	fs.Visit(func(f *pflag.Flag) {
+		// Deprecated flags are recorded as their replacement
+		if _, ok := cmd.Replacement(f); ok || names.Lookup(f.Name) == nil {
+			return
+		}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			values[f.Name] = s.GetSlice()
+			return
+		}
+		values[f.Name] = []string{f.Value.String()}
+	})
+	return values
+}
+
+// setGenerationFlags sets the recorded generation flags of a plan.
+func setGenerationFlags(fs *pflag.FlagSet, values map[string][]string) error {
+	for name, value := range values {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag --%s", name)
+		}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			if err := s.Replace(value); err != nil {
+				return err
+			}
+			f.Changed = true
+			continue
+		}
+		if len(value) != 1 {
+			return fmt.Errorf("invalid value of flag --%s", name)
+		}
+		if err := fs.Set(name, value[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan writes a plan as aligned text.
+func printPlan(p *planFile) {
+	counts := make(map[string]int)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tPATH\tTABLE\tREASON")
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Action, c.Path, c.Table, c.Reason)
+	}
+	w.Flush()
+	fmt.Printf("\nPlan: %d to create, %d to update, %d skipped.\n", counts[planCreate], counts[planUpdate], counts[planSkip])
+}
+
+// countChanges returns the number of files a plan creates or updates.
+func countChanges(changes []planChange) int {
+	var n int
+	for _, c := range changes {
+		if c.Action != planSkip {
+			n++
+		}
+	}
+	return n
+}
+
+// printPlanned prints the staged content of the files a plan creates or
+// updates.
+func printPlanned(changes []planChange) error {
+	for _, c := range changes {
+		if c.Action == planSkip {
+			continue
+		}
+		data, err := os.ReadFile(c.staged)
+		if err != nil {
+			return err
+		}
+		color.Cyan("==> %s (%s)\n", c.Path, c.Action)
+		os.Stdout.Write(data)
+		fmt.Println()
+	}
+	return nil
+}
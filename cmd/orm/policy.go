@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/policy"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/pflag"
+)
+
+// policyEnv names the policy file when --policy is not given.
+const policyEnv = "CZX_POLICY"
+
+// checkPolicy evaluates the policy given by --policy or CZX_POLICY against
+// the tables, output paths and dao methods of the run, before anything is
+// written. With --policy-report violations are only reported.
+func (o *Orm) checkPolicy(args *pflag.FlagSet, dao bool) error {
+	file, err := args.GetString("policy")
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		file = os.Getenv(policyEnv)
+	}
+	if file == "" {
+		return nil
+	}
+	report, err := args.GetBool("policy-report")
+	if err != nil {
+		return err
+	}
+
+	p, err := policy.Load(file)
+	if err != nil {
+		return err
+	}
+	items, err := o.policyItems(dao)
+	if err != nil {
+		return err
+	}
+
+	violations := p.Evaluate(items)
+	if len(violations) == 0 {
+		return nil
+	}
+	if !report {
+		return &PolicyError{Violations: violations}
+	}
+	color.Yellow("\nPolicy report, %d violation(s):\n", len(violations))
+	for _, v := range violations {
+		color.Yellow("  %v\n", v)
+	}
+	return nil
+}
+
+// policyItems lists what the run is about to generate.
+func (o *Orm) policyItems(dao bool) ([]policy.Item, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	modelDir, err := o.modelOutPath()
+	if err != nil {
+		return nil, err
+	}
+	outDir, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []policy.Item
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		if table == "" {
+			continue
+		}
+		items = append(items, policy.Item{Table: table, Style: "model", Path: relPath(cwd, filepath.Join(modelDir, file+".gen.go"))})
+		if !dao || !selected(o.opt.daoTables, table) {
+			continue
+		}
+		items = append(items, policy.Item{Table: table, Style: "dao", Path: relPath(cwd, filepath.Join(outDir, file+".gen.go"))})
+
+		for _, key := range []string{"*", table} {
+			annotae, ok := o.opt.daoApi[key]
+			if !ok {
+				continue
+			}
+			iface, err := annotaeInterface(annotae)
+			if err != nil {
+				return nil, err
+			}
+			for i := range iface.NumMethod() {
+				items = append(items, policy.Item{Table: table, Style: "dao", Method: iface.Method(i).Name})
+			}
+		}
+	}
+	return items, nil
+}
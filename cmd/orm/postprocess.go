@@ -0,0 +1,147 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/imports"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// pass rewrites the content of the generated model file of a table.
+type pass func(table string, src []byte) ([]byte, error)
+
+// postProcess runs the enabled passes over the generated model files.
+// It runs after gen has written its output, so gen templates stay untouched.
+func (o *Orm) postProcess() error {
+	var passes []pass
+	if o.schemaName != "" {
+		passes = append(passes, o.schemaPass)
+	}
+	if o.withProvenance {
+		passes = append(passes, o.provenancePass)
+	}
+	if o.deprecatedMode == deprecatedComment && len(o.deprecated) > 0 {
+		passes = append(passes, o.deprecatedPass)
+	}
+	if len(o.rls) > 0 {
+		passes = append(passes, o.rlsPass)
+	}
+	if !o.opt.noFieldComments {
+		passes = append(passes, o.commentsPass)
+	}
+	if o.valueModels {
+		passes = append(passes, o.viewsPass)
+	}
+	if o.withStringer {
+		passes = append(passes, o.stringerPass)
+	}
+	if len(passes) == 0 {
+		return nil
+	}
+
+	dir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		if table == "" || file == "" {
+			continue
+		}
+
+		path := filepath.Join(dir, file+".gen.go")
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, p := range passes {
+			if src, err = p(table, src); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if src, err = imports.Process(path, src, nil); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := writeFileAtomic(path, src, 0640); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldsOpt records the source column of every field of the table.
+// It must be the last option of the table so the recorded field names are final.
+func (o *Orm) fieldsOpt(table string) gen.ModelOpt {
+	fields := make(map[string]string)
+	o.fields[table] = fields
+	return gen.FieldModify(func(f gen.Field) gen.Field {
+		fields[fieldName(o.meta, f)] = f.ColumnName
+		return f
+	})
+}
+
+// modelOutPath returns the directory gen writes the model files to.
+func (o *Orm) modelOutPath() (string, error) {
+	pkg := o.opt.gconf.ModelPkgPath
+	if strings.TrimSpace(pkg) == "" {
+		pkg = "model"
+	}
+	if strings.Contains(pkg, string(os.PathSeparator)) {
+		return filepath.Abs(pkg)
+	}
+
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(out), pkg), nil
+}
+
+// metaString reads a string field of a generated struct meta.
+func metaString(meta any, name string) string {
+	v := reflect.ValueOf(meta)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ""
+	}
+	return v.Elem().FieldByName(name).String()
+}
+
+// fieldName returns the struct field name gen derives for a model field,
+// applying the naming strategy of the database the same way gen does.
+func fieldName(db *gorm.DB, f gen.Field) string {
+	return columnFieldName(db, f.Name)
+}
+
+// columnFieldName returns the struct field name gen derives from a column
+// name.
+func columnFieldName(db *gorm.DB, name string) string {
+	if ns, ok := db.NamingStrategy.(schema.NamingStrategy); ok {
+		ns.SingularTable = true
+		return ns.SchemaName(ns.TablePrefix + name)
+	}
+	if db.NamingStrategy != nil {
+		return db.NamingStrategy.SchemaName(name)
+	}
+	return name
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type (
+	// tableMeta is what gen reads of a table, fetched ahead of the
+	// generation.
+	tableMeta struct {
+		columns      []gorm.ColumnType
+		indexes      []gorm.Index
+		indexesErr   error
+		tableType    gorm.TableType
+		tableTypeErr error
+	}
+	// prefetchDialector answers the schema queries of gen for the fetched
+	// tables, and passes the others to the dialector of the connection.
+	prefetchDialector struct {
+		gorm.Dialector
+		tables map[string]*tableMeta
+	}
+	// prefetchMigrator is the migrator of a prefetchDialector.
+	prefetchMigrator struct {
+		gorm.Migrator
+		tables map[string]*tableMeta
+	}
+)
+
+// prefetch reads the metadata gen needs of tables with concurrency workers
+// and has the generator read it from memory, so the models are still
+// generated one after the other in the order of the tables. The first
+// failing table cancels the queries of the others.
+func (o *Orm) prefetch(tables []string, concurrency int) error {
+	if _, ok := snapshotOf(o.meta); ok || concurrency < 2 || len(tables) < 2 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(o.meta.Statement.Context)
+	defer cancel()
+	db := o.meta.WithContext(ctx)
+	indexed := o.opt.gconf.FieldWithIndexTag
+
+	fetched := make([]*tableMeta, len(tables))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	next := make(chan int)
+	for range min(concurrency, len(tables)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				m, err := fetchTableMeta(db, tables[i], indexed)
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("reading the columns of table %s: %w", tables[i], err)
+						cancel()
+					})
+					continue
+				}
+				fetched[i] = m
+			}
+		}()
+	}
+feed:
+	for i := range tables {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	byTable := make(map[string]*tableMeta, len(tables))
+	for i, table := range tables {
+		byTable[table] = fetched[i]
+	}
+	cached := o.generatorDB(o.meta).Session(&gorm.Session{NewDB: true})
+	conf := *cached.Config
+	conf.Dialector = prefetchDialector{Dialector: o.meta.Dialector, tables: byTable}
+	cached.Config = &conf
+	o.generator.UseDB(cached)
+	return nil
+}
+
+// fetchTableMeta reads the columns, the indexes when gen tags them, and the
+// comment of a table. Only the columns are required, gen does without the
+// rest.
+func fetchTableMeta(db *gorm.DB, table string, indexed bool) (*tableMeta, error) {
+	m := &tableMeta{}
+	var err error
+	if m.columns, err = db.Migrator().ColumnTypes(table); err != nil {
+		return nil, err
+	}
+	if indexed {
+		m.indexes, m.indexesErr = db.Migrator().GetIndexes(table)
+	}
+	m.tableType, m.tableTypeErr = db.Migrator().TableType(table)
+	return m, nil
+}
+
+// Migrator implements gorm.Dialector.
+func (d prefetchDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return prefetchMigrator{Migrator: d.Dialector.Migrator(db), tables: d.tables}
+}
+
+// ColumnTypes implements gorm.Migrator.
+func (m prefetchMigrator) ColumnTypes(value any) ([]gorm.ColumnType, error) {
+	if t, ok := m.lookup(value); ok {
+		return t.columns, nil
+	}
+	return m.Migrator.ColumnTypes(value)
+}
+
+// GetIndexes implements gorm.Migrator.
+func (m prefetchMigrator) GetIndexes(value any) ([]gorm.Index, error) {
+	if t, ok := m.lookup(value); ok && (t.indexes != nil || t.indexesErr != nil) {
+		return t.indexes, t.indexesErr
+	}
+	return m.Migrator.GetIndexes(value)
+}
+
+// TableType implements gorm.Migrator.
+func (m prefetchMigrator) TableType(value any) (gorm.TableType, error) {
+	if t, ok := m.lookup(value); ok {
+		return t.tableType, t.tableTypeErr
+	}
+	return m.Migrator.TableType(value)
+}
+
+// lookup returns the fetched metadata of a table given by name.
+func (m prefetchMigrator) lookup(value any) (*tableMeta, bool) {
+	name, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	t, ok := m.tables[name]
+	return t, ok
+}
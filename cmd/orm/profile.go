@@ -0,0 +1,189 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// envVariable matches the ${VAR} and ${VAR:-default} references of the
+// string values of a config file.
+var envVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// resolve deep-merges the profile of the loader over conf, then substitutes
+// the environment variables of the result.
+func (l *configLoader) resolve(conf fileConfig) (fileConfig, error) {
+	if profile, ok := conf.Profiles[l.profile]; ok && l.profile != "" {
+		if profile.Include != nil || profile.Profiles != nil {
+			return fileConfig{}, fmt.Errorf("$.profiles.%s: a profile cannot set include or profiles", l.profile)
+		}
+		conf = conf.overlay(profile)
+		l.profileFound = true
+	}
+	conf.Profiles = nil
+	if err := conf.substitute(); err != nil {
+		return fileConfig{}, err
+	}
+	return conf, nil
+}
+
+// overlay returns c with the values set in the profile p replacing its own:
+// lists are replaced, maps and the gen section are merged by key.
+func (c fileConfig) overlay(p fileConfig) fileConfig {
+	for _, list := range []struct{ base, over *[]string }{
+		{&c.Ignore, &p.Ignore},
+		{&c.Retags, &p.Retags},
+		{&c.ReGromTags, &p.ReGromTags},
+		{&c.DaoTables, &p.DaoTables},
+		{&c.SoftDelete, &p.SoftDelete},
+	} {
+		if *list.over != nil {
+			*list.base = *list.over
+		}
+	}
+	c.Rename = overlayMap(c.Rename, p.Rename)
+	c.DataTypes = overlayMap(c.DataTypes, p.DataTypes)
+	c.Settings = overlayMap(c.Settings, p.Settings)
+	c.Gen = c.Gen.merge(p.Gen)
+	return c
+}
+
+// overlayMap returns a copy of base with the keys of over replaced.
+func overlayMap[V any](base, over map[string]V) map[string]V {
+	if len(over) == 0 {
+		return base
+	}
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]V, len(over))
+	}
+	maps.Copy(merged, over)
+	return merged
+}
+
+// substitute replaces the environment variables of the string values of c.
+func (c *fileConfig) substitute() error {
+	for _, list := range []struct {
+		key    string
+		values []string
+	}{
+		{"include", c.Include},
+		{"ignore", c.Ignore},
+		{"retags", c.Retags},
+		{"regormtags", c.ReGromTags},
+		{"dao_tables", c.DaoTables},
+		{"soft_delete", c.SoftDelete},
+		{"gen.mode", c.Gen.Mode},
+	} {
+		for i, value := range list.values {
+			expanded, err := expandEnv(fmt.Sprintf("$.%s[%d]", list.key, i), value)
+			if err != nil {
+				return err
+			}
+			list.values[i] = expanded
+		}
+	}
+	for _, field := range []struct {
+		key   string
+		value *string
+	}{
+		{"gen.out_path", &c.Gen.OutPath},
+		{"gen.out_file", &c.Gen.OutFile},
+		{"gen.model_pkg_path", &c.Gen.ModelPkgPath},
+	} {
+		expanded, err := expandEnv("$."+field.key, *field.value)
+		if err != nil {
+			return err
+		}
+		*field.value = expanded
+	}
+
+	var err error
+	if c.Rename, err = substituteMap("rename", c.Rename); err != nil {
+		return err
+	}
+	if c.DataTypes, err = substituteMap("data_types", c.DataTypes); err != nil {
+		return err
+	}
+	if len(c.Settings) == 0 {
+		return nil
+	}
+
+	// Settings hold strings, scalars of other types and lists of them
+	settings := make(map[string]any, len(c.Settings))
+	for _, name := range slices.Sorted(maps.Keys(c.Settings)) {
+		key := "$.settings." + name
+		switch value := c.Settings[name].(type) {
+		case string:
+			if settings[name], err = expandEnv(key, value); err != nil {
+				return err
+			}
+		case []any:
+			values := slices.Clone(value)
+			for i, v := range values {
+				if s, ok := v.(string); ok {
+					if values[i], err = expandEnv(fmt.Sprintf("%s[%d]", key, i), s); err != nil {
+						return err
+					}
+				}
+			}
+			settings[name] = values
+		default:
+			settings[name] = value
+		}
+	}
+	c.Settings = settings
+	return nil
+}
+
+// substituteMap returns a copy of m with the environment variables of its
+// values replaced.
+func substituteMap(key string, m map[string]string) (map[string]string, error) {
+	if len(m) == 0 {
+		return m, nil
+	}
+	expanded := make(map[string]string, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		value, err := expandEnv(fmt.Sprintf("$.%s.%s", key, k), m[k])
+		if err != nil {
+			return nil, err
+		}
+		expanded[k] = value
+	}
+	return expanded, nil
+}
+
+// expandEnv replaces the variables of the value at key. A variable unset or
+// empty takes its default, an unset variable without default is an error.
+func expandEnv(key, value string) (string, error) {
+	var err error
+	expanded := envVariable.ReplaceAllStringFunc(value, func(ref string) string {
+		m := envVariable.FindStringSubmatch(ref)
+		v, ok := os.LookupEnv(m[1])
+		switch {
+		case m[2] != "" && v == "":
+			return m[3]
+		case !ok && err == nil:
+			err = fmt.Errorf("%s: unresolved variable %s, set it or give a default with ${%s:-value}", key, m[1], m[1])
+		}
+		return v
+	})
+	return expanded, err
+}
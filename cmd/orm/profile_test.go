@@ -0,0 +1,234 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigs writes the config files of a test into dir.
+func writeConfigs(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	t.Setenv("ORM_DSN", "file:dev.db")
+	t.Setenv("ORM_EMPTY", "")
+	t.Setenv("ORM_TABLE", "users")
+
+	for _, tt := range []struct {
+		name   string
+		config string
+		want   fileConfig
+		err    string
+	}{
+		{
+			name:   "set",
+			config: "settings:\n  dsn: ${ORM_DSN}\n",
+			want:   fileConfig{Settings: map[string]any{"dsn": "file:dev.db"}},
+		},
+		{
+			name:   "default of an unset variable",
+			config: "gen:\n  out_path: ${ORM_UNSET:-./dao}\n",
+			want:   fileConfig{Gen: genSettings{OutPath: "./dao"}},
+		},
+		{
+			name:   "default of an empty variable",
+			config: "gen:\n  model_pkg_path: ${ORM_EMPTY:-model}\n",
+			want:   fileConfig{Gen: genSettings{ModelPkgPath: "model"}},
+		},
+		{
+			name:   "set variable over the default",
+			config: "settings:\n  dsn: ${ORM_DSN:-file:other.db}\n",
+			want:   fileConfig{Settings: map[string]any{"dsn": "file:dev.db"}},
+		},
+		{
+			name:   "lists, maps and setting lists",
+			config: "ignore: [\"${ORM_TABLE}->password\"]\nrename:\n  ${ORM_TABLE}: ${ORM_TABLE}_v2\nsettings:\n  tables: [\"${ORM_TABLE}\", orders]\n",
+			want: fileConfig{
+				Ignore:   []string{"users->password"},
+				Rename:   map[string]string{"${ORM_TABLE}": "users_v2"},
+				Settings: map[string]any{"tables": []any{"users", "orders"}},
+			},
+		},
+		{
+			name:   "scalar settings are kept",
+			config: "settings:\n  sample: 3\n",
+			want:   fileConfig{Settings: map[string]any{"sample": 3}},
+		},
+		{
+			name:   "unset without default",
+			config: "settings:\n  dsn: ${ORM_UNSET}\n",
+			err:    "$.settings.dsn: unresolved variable ORM_UNSET",
+		},
+		{
+			name:   "unset in a list",
+			config: "dao_tables: [users, \"${ORM_UNSET}\"]\n",
+			err:    "$.dao_tables[1]: unresolved variable ORM_UNSET",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigs(t, dir, map[string]string{"orm.yaml": tt.config})
+			conf, err := loadConfig(filepath.Join(dir, "orm.yaml"), "", newIncludeFetcher(true, false))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("load = %v, want an error with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.sources = nil
+			if !reflect.DeepEqual(conf, tt.want) {
+				t.Errorf("load = %+v, want %+v", conf, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigProfiles(t *testing.T) {
+	t.Setenv("PROD_DSN", "file:prod.db")
+
+	base := `ignore: [users->password]
+dao_tables: [users]
+rename:
+  users: accounts
+  orders: purchases
+gen:
+  out_path: ./dao
+  model_pkg_path: model
+settings:
+  dsn: ${DEV_DSN}
+  style: dao
+profiles:
+  prod:
+    ignore: [users->token]
+    rename:
+      orders: sales
+    gen:
+      out_path: ${PROD_OUT:-./internal/dao}
+    settings:
+      dsn: ${PROD_DSN}
+  empty: {}
+`
+	for _, tt := range []struct {
+		name    string
+		files   map[string]string
+		profile string
+		want    fileConfig
+		err     string
+	}{
+		{
+			name:    "overlay",
+			files:   map[string]string{"orm.yaml": base},
+			profile: "prod",
+			want: fileConfig{
+				Ignore:    []string{"users->token"},
+				DaoTables: []string{"users"},
+				Rename:    map[string]string{"users": "accounts", "orders": "sales"},
+				Gen:       genSettings{OutPath: "./internal/dao", ModelPkgPath: "model"},
+				Settings:  map[string]any{"dsn": "file:prod.db", "style": "dao"},
+			},
+		},
+		{
+			name:    "empty profile",
+			files:   map[string]string{"orm.yaml": strings.Replace(base, "${DEV_DSN}", "file:dev.db", 1)},
+			profile: "empty",
+			want: fileConfig{
+				Ignore:    []string{"users->password"},
+				DaoTables: []string{"users"},
+				Rename:    map[string]string{"users": "accounts", "orders": "purchases"},
+				Gen:       genSettings{OutPath: "./dao", ModelPkgPath: "model"},
+				Settings:  map[string]any{"dsn": "file:dev.db", "style": "dao"},
+			},
+		},
+		{
+			// The variable overridden by the profile is never substituted
+			name:    "without profile",
+			files:   map[string]string{"orm.yaml": base},
+			profile: "",
+			err:     "$.settings.dsn: unresolved variable DEV_DSN",
+		},
+		{
+			name: "profile of an include",
+			files: map[string]string{
+				"orm.yaml":  "include: [prod.yaml]\nsettings:\n  style: model\n",
+				"prod.yaml": "profiles:\n  prod:\n    settings:\n      dsn: ${PROD_DSN}\n",
+			},
+			profile: "prod",
+			want:    fileConfig{Settings: map[string]any{"dsn": "file:prod.db", "style": "model"}},
+		},
+		{
+			name:    "undefined profile",
+			files:   map[string]string{"orm.yaml": strings.Replace(base, "${DEV_DSN}", "file:dev.db", 1)},
+			profile: "staging",
+			err:     `profile "staging" is not defined`,
+		},
+		{
+			name:    "profile setting includes",
+			files:   map[string]string{"orm.yaml": "profiles:\n  prod:\n    include: [other.yaml]\n"},
+			profile: "prod",
+			err:     "$.profiles.prod: a profile cannot set include or profiles",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigs(t, dir, tt.files)
+			conf, err := loadConfig(filepath.Join(dir, "orm.yaml"), tt.profile, newIncludeFetcher(true, false))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("load = %v, want an error with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.sources = nil
+			if !reflect.DeepEqual(conf, tt.want) {
+				t.Errorf("load = %+v, want %+v", conf, tt.want)
+			}
+		})
+	}
+}
+
+func TestStdinConfigProfile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("PROD_DSN", "file:prod.db")
+	r := strings.NewReader(`{"settings": {"dsn": "file:dev.db"}, "profiles": {"prod": {"settings": {"dsn": "${PROD_DSN}"}}}}`)
+	conf, err := loadStdinConfig(r, "prod", newIncludeFetcher(true, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Settings["dsn"] != "file:prod.db" {
+		t.Errorf("dsn = %v, want the one of the profile", conf.Settings["dsn"])
+	}
+}
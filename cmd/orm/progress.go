@@ -0,0 +1,227 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/pflag"
+)
+
+// Warning categories, the category label of the warnings metric.
+const (
+	warnRules     = "rules"
+	warnTables    = "tables"
+	warnRelations = "relations"
+	warnDatabase  = "database"
+	warnLock      = "lock"
+)
+
+// warnCategories are the warning categories, in the order of the metrics.
+var warnCategories = []string{warnRules, warnTables, warnRelations, warnDatabase, warnLock}
+
+// Progress event names, in the order they are emitted.
+const (
+	eventRunStarted    = "run-started"
+	eventTableStarted  = "table-started"
+	eventTableFinished = "table-finished"
+	eventWarning       = "warning"
+	eventRunFinished   = "run-finished"
+)
+
+type (
+	// progressEvent is a newline-delimited JSON event of --progress-socket
+	// and --progress-file. A consumer reads lines until run-finished:
+	//
+	//	ln, _ := net.Listen("unix", "/tmp/orm.sock")
+	//	conn, _ := ln.Accept()
+	//	for scanner := bufio.NewScanner(conn); scanner.Scan(); {
+	//		var ev struct{ Event, Table string }
+	//		json.Unmarshal(scanner.Bytes(), &ev)
+	//		if ev.Event == "run-finished" {
+	//			break
+	//		}
+	//	}
+	progressEvent struct {
+		Event string    `json:"event"`
+		Time  time.Time `json:"time"`
+		Table string    `json:"table,omitempty"`
+		// Category is the category of a warning, see warnCategories
+		Category string           `json:"category,omitempty"`
+		Files    []string         `json:"files,omitempty"`
+		Message  string           `json:"message,omitempty"`
+		Summary  *progressSummary `json:"summary,omitempty"`
+	}
+	// progressSummary is the summary of the run-finished event.
+	progressSummary struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error,omitempty"`
+		Tables int    `json:"tables"`
+		Files  int    `json:"files"`
+		// Unchanged is the number of files left untouched, same content
+		Unchanged int     `json:"unchanged"`
+		Warnings  int     `json:"warnings"`
+		Seconds   float64 `json:"seconds"`
+		// Degraded are the features left out by --allow-old-db
+		Degraded []string `json:"degraded,omitempty"`
+	}
+	// progress writes the events of a run, a nil progress discards them.
+	progress struct {
+		mu     sync.Mutex
+		w      io.WriteCloser
+		start  time.Time
+		tables int
+		files  int
+		// unchanged is set by the run once the files are in place
+		unchanged int
+		warnings  int
+		degraded  []string
+	}
+)
+
+// openProgress connects to the Unix socket or opens the file of the progress
+// flags, nil when neither is set.
+func openProgress(args *pflag.FlagSet) (*progress, error) {
+	socket, err := args.GetString("progress-socket")
+	if err != nil {
+		return nil, err
+	}
+	file, err := args.GetString("progress-file")
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.WriteCloser
+	switch {
+	case socket != "" && file != "":
+		return nil, errors.New("--progress-socket and --progress-file are mutually exclusive")
+	case socket != "":
+		w, err = net.Dial("unix", socket)
+	case file != "":
+		w, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening progress output: %w", err)
+	}
+	p := &progress{w: w, start: time.Now()}
+	p.emit(progressEvent{Event: eventRunStarted})
+	return p, nil
+}
+
+// emit writes one event as a line. Write errors drop the output, the run
+// itself goes on.
+func (p *progress) emit(ev progressEvent) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w == nil {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	// One write per line, nothing is buffered
+	if _, err := p.w.Write(append(data, '\n')); err != nil {
+		p.w.Close()
+		p.w = nil
+	}
+}
+
+// tableStarted reports that the generation of a table started.
+func (p *progress) tableStarted(table string) {
+	if p == nil {
+		return
+	}
+	p.emit(progressEvent{Event: eventTableStarted, Table: table})
+}
+
+// tablesFinished reports the generated files of every table, given as the
+// tables by file.
+func (p *progress) tablesFinished(files map[string][]string) {
+	if p == nil {
+		return
+	}
+	byTable := make(map[string][]string)
+	for file, tables := range files {
+		for _, table := range tables {
+			byTable[table] = append(byTable[table], file)
+		}
+	}
+	p.tables, p.files = len(byTable), len(files)
+	for _, table := range sortedKeys(byTable) {
+		slices.Sort(byTable[table])
+		p.emit(progressEvent{Event: eventTableFinished, Table: table, Files: byTable[table]})
+	}
+}
+
+// finish emits the run-finished event and closes the output. It is always
+// called, so consumers never wait for events that do not come.
+func (p *progress) finish(err error) {
+	if p == nil {
+		return
+	}
+	summary := &progressSummary{
+		OK:        err == nil,
+		Tables:    p.tables,
+		Files:     p.files,
+		Unchanged: p.unchanged,
+		Warnings:  p.warnings,
+		Seconds:   time.Since(p.start).Seconds(),
+		Degraded:  p.degraded,
+	}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	p.emit(progressEvent{Event: eventRunFinished, Summary: summary})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w != nil {
+		p.w.Close()
+		p.w = nil
+	}
+}
+
+// warn prints a warning of a category, counts it and reports it as a
+// progress event.
+func (o *Orm) warn(category, format string, a ...any) {
+	color.Yellow(format, a...)
+	if o.warnings == nil {
+		o.warnings = make(map[string]int)
+	}
+	o.warnings[category]++
+	if o.progress == nil {
+		return
+	}
+	o.progress.warnings++
+	o.progress.emit(progressEvent{Event: eventWarning, Category: category, Message: strings.TrimSpace(fmt.Sprintf(format, a...))})
+}
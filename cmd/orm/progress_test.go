@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+// Example_progressConsumer shows a consumer of --progress-socket, such as an
+// IDE plugin, following a run until its run-finished event.
+func Example_progressConsumer() {
+	dir, err := os.MkdirTemp("", "orm-progress")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The consumer listens before the run and reads a JSON event per line
+	socket := filepath.Join(dir, "orm.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		panic(err)
+	}
+	defer ln.Close()
+	events := make(chan []string)
+	go func() {
+		var lines []string
+		defer func() { events <- lines }()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for scanner := bufio.NewScanner(conn); scanner.Scan(); {
+			var ev struct {
+				Event, Table string
+				Files        []string
+				Summary      *struct{ OK bool }
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+				return
+			}
+			switch ev.Event {
+			case "table-finished":
+				for _, file := range ev.Files {
+					lines = append(lines, fmt.Sprintf("%s %s %s", ev.Event, ev.Table, path.Base(file)))
+				}
+			case "run-finished":
+				lines = append(lines, fmt.Sprintf("%s ok=%v", ev.Event, ev.Summary.OK))
+				return
+			default:
+				lines = append(lines, strings.TrimSpace(ev.Event+" "+ev.Table))
+			}
+		}
+	}()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, name text)").Error; err != nil {
+		panic(err)
+	}
+	// gen resolves the model package in the module of the working directory
+	out, err := os.MkdirTemp(testdata, "progress-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(out)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(out); err != nil {
+		panic(err)
+	}
+
+	// The run writes its console output as usual, left out here
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, _ = os.Open(os.DevNull)
+	color.Output = os.Stdout
+	c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})).Command()
+	c.SetArgs([]string{"-t", "users", "--progress-socket", socket, "--config", "", "--lockfile", ""})
+	err = c.Execute()
+	os.Stdout, color.Output = stdout, output
+	if err != nil {
+		panic(err)
+	}
+
+	for _, line := range <-events {
+		fmt.Println(line)
+	}
+	// Output:
+	// run-started
+	// table-started users
+	// table-finished users users.gen.go
+	// run-finished ok=true
+}
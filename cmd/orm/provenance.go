@@ -0,0 +1,174 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"gorm.io/gen"
+)
+
+// provenanceMarker starts every provenance comment, so the comments can be
+// recognized and stripped when comparing generated files.
+const provenanceMarker = "// col: "
+
+// provenanceOpt records, for every field of the table, the source column and
+// the rules that touched it. It must be the last option of the table so the
+// recorded field names are final.
+func (o *Orm) provenanceOpt(table string, columns []columnMeta) gen.ModelOpt {
+	types := make(map[string]string, len(columns))
+	for _, col := range columns {
+		types[col.Name] = col.DataType
+	}
+
+	fields := make(map[string]string)
+	o.provenance[table] = fields
+	return gen.FieldModify(func(f gen.Field) gen.Field {
+		typ := types[f.ColumnName]
+		fields[fieldName(o.meta, f)] = fmt.Sprintf(
+			"%s %s; rules: ignore=%s retag=%s regorm=%s type=%s",
+			f.ColumnName, typ,
+			cmp.Or(o.ignoreRule(table, f.ColumnName), "no"),
+			matchRule(o.opt.retags, table, f.ColumnName),
+			matchRule(o.opt.reGromTags, table, f.ColumnName),
+			o.typeRule(table, typ),
+		)
+		return f
+	})
+}
+
+// provenancePass appends the recorded provenance comment to each struct field.
+func (o *Orm) provenancePass(table string, src []byte) ([]byte, error) {
+	fields, ok := o.provenance[table]
+	if !ok {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the end of line offset of every annotated field
+	comments := make(map[int]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		st, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, f := range st.Fields.List {
+			if len(f.Names) != 1 {
+				continue
+			}
+			comment, ok := fields[f.Names[0].Name]
+			if !ok {
+				continue
+			}
+			end := fset.Position(f.End()).Offset
+			if i := bytes.IndexByte(src[end:], '\n'); i >= 0 {
+				comments[end+i] = comment
+			}
+		}
+		return true
+	})
+
+	var buf bytes.Buffer
+	for i, b := range src {
+		if comment, ok := comments[i]; ok {
+			buf.WriteString(" " + provenanceMarker)
+			buf.WriteString(comment)
+		}
+		buf.WriteByte(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// stripProvenance returns src without its provenance comments.
+func stripProvenance(src []byte) []byte {
+	marker := []byte(" " + provenanceMarker)
+	if !bytes.Contains(src, marker) {
+		return src
+	}
+	var buf bytes.Buffer
+	for line := range bytes.Lines(src) {
+		i := bytes.Index(line, marker)
+		if i < 0 {
+			buf.Write(line)
+			continue
+		}
+		// With the alignment gofmt added before the comment
+		buf.Write(bytes.TrimRight(line[:i], " \t"))
+		if bytes.HasSuffix(line, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// sameGenerated reports whether two generated files have the same content
+// but for their provenance comments, so that a run with --provenance leaves
+// the files of a run without it unchanged and the other way around.
+func sameGenerated(a, b []byte) bool {
+	return bytes.Equal(stripProvenance(a), stripProvenance(b))
+}
+
+// matchRule returns the `table->column->value` rule applied to a column,
+// preferring table-specific rules over global ones, or "-" when none applies.
+func matchRule(rules []string, table, column string) string {
+	matched := "-"
+	for _, rule := range rules {
+		parts := strings.Split(rule, "->")
+		if len(parts) != 3 || parts[1] != column {
+			continue
+		}
+		switch parts[0] {
+		case table:
+			return parts[0] + "->" + parts[1]
+		case "*":
+			matched = parts[0] + "->" + parts[1]
+		}
+	}
+	return matched
+}
+
+// typeRule returns the key of the WithDataType or WithTableDataType mapping
+// applied to a column type, preferring table-specific mappings, or "-".
+func (o *Orm) typeRule(table, typ string) string {
+	plain, tabled := matchTypeRule(o.opt.dataType, table, typ), matchTypeRule(o.opt.tableDataType, table, typ)
+	if plain == "-" || tabled == table+"->"+typ {
+		return tabled
+	}
+	return plain
+}
+
+// matchTypeRule returns the data type mapping key applied to a column type,
+// preferring table-specific mappings over global ones, or "-" when none applies.
+func matchTypeRule[F any](types map[string]F, table, typ string) string {
+	if _, ok := types[table+"->"+typ]; ok {
+		return table + "->" + typ
+	}
+	if _, ok := types["*->"+typ]; ok {
+		return "*->" + typ
+	}
+	return "-"
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestProvenanceComparedUnchanged(t *testing.T) {
+	db := openFixture(t, "users")
+	plain := generate(t, db, []string{"-t", "users"})
+	annotated := generate(t, db, []string{"-t", "users", "--provenance"})
+
+	src := annotated["model/users.gen.go"]
+	if line := fieldLine(src, "Email"); !strings.Contains(line, provenanceMarker+"email ") || !strings.Contains(line, "ignore=no ") {
+		t.Fatalf("no provenance comment: %q", line)
+	}
+	if got := string(stripProvenance([]byte(src))); got != plain["model/users.gen.go"] {
+		t.Errorf("stripped provenance run differs from the plain run:\n%s", got)
+	}
+
+	// Either run leaves the files of the other untouched
+	for _, tt := range []struct {
+		name          string
+		first, second []string
+	}{
+		{name: "provenance over plain", first: nil, second: []string{"--provenance"}},
+		{name: "plain over provenance", first: []string{"--provenance"}, second: nil},
+	} {
+		before := generate(t, db, append([]string{"-t", "users"}, tt.first...))
+		after := generateHere(t, db, append([]string{"-t", "users"}, tt.second...))
+		if !maps.Equal(before, after) {
+			t.Errorf("%s: files rewritten", tt.name)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/pflag"
+)
+
+// prune removes the generated files of the query and model directories that
+// the run no longer generates, printing each of them, or only lists them with
+// dryRun. Removed files are counted as deleted by the dry run result.
+func (o *Orm) prune(ctx context.Context, args *pflag.FlagSet, dryRun bool) error {
+	tables, err := args.GetStringArray("tables")
+	if err != nil {
+		return err
+	}
+	every := !slices.ContainsFunc(tables, func(t string) bool {
+		return !strings.HasPrefix(t, "!")
+	})
+	stale, err := o.pruneTargets(every)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		for _, path := range stale {
+			color.Yellow("would remove  %s\n", relPath(cwd, path))
+		}
+		if !o.planning.diff {
+			o.planning.deleted += len(stale)
+		}
+		return nil
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	wait, err := args.GetDuration("lock-wait")
+	if err != nil {
+		return err
+	}
+	release, err := o.lock(ctx, wait)
+	if err != nil {
+		return err
+	}
+	defer release()
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		color.Yellow("removed  %s\n", relPath(cwd, path))
+	}
+	return nil
+}
+
+// pruneTargets returns the files of the query and model directories carrying
+// the generated banner that no struct of the run generates: the files of the
+// tables dropped from the database, and the files of any other table when
+// every table is selected. Files protected by .ormkeep are never returned.
+func (o *Orm) pruneTargets(every bool) ([]string, error) {
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return nil, err
+	}
+	model, err := o.modelOutPath()
+	if err != nil {
+		return nil, err
+	}
+	outFile := o.opt.gconf.OutFile
+	if outFile == "" {
+		outFile = "gen.go"
+	}
+
+	// The query and model files of a table share their name
+	generated := map[string]bool{outFile: true}
+	for _, meta := range o.structs {
+		generated[metaString(meta, "FileName")+".gen.go"] = true
+		generated[metaString(meta, "FileName")+factoryFileSuffix] = o.withFactories
+	}
+	owners := make(map[string]string)
+	if _, err := os.Stat(model); err == nil {
+		models, err := parseModels(model)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range models {
+			owners[filepath.Base(m.File)] = m.Name
+		}
+	}
+	live, err := userTables(o.meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, dir := range []string{out, model} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.gen.go"))
+		if err != nil {
+			return nil, err
+		}
+		keep, err := loadKeep(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			name := filepath.Base(path)
+			if generated[name] || keep.Kept(name) {
+				continue
+			}
+			// Without every table, only the files of dropped tables go
+			table, ok := owners[name]
+			if !every && (!ok || slices.Contains(live, table)) {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if isGenerated(data) {
+				stale = append(stale, path)
+			}
+		}
+	}
+	slices.Sort(stale)
+	return slices.Compact(stale), nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+)
+
+// schemaTablesSQL lists the tables of a schema other than the current one.
+const schemaTablesSQL = "SELECT table_name AS table_name FROM information_schema.tables " +
+	"WHERE table_schema = ? AND table_type = 'BASE TABLE' ORDER BY table_name"
+
+// splitTable splits a schema-qualified table name such as core.users, the
+// schema is empty for bare names.
+func splitTable(name string) (schema, table string) {
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		return schema, table
+	}
+	return "", name
+}
+
+// qualifiedTables returns the qualified names of the tables of every schema
+// referenced by a schema-qualified selector.
+func (o *Orm) qualifiedTables(selectors []string) ([]string, error) {
+	var tables []string
+	seen := make(map[string]bool)
+	for _, val := range selectors {
+		name, _, _ := strings.Cut(strings.TrimPrefix(val, "!"), "@")
+		if strings.HasPrefix(name, "/") {
+			continue
+		}
+		schema, _ := splitTable(name)
+		if schema == "" || seen[schema] {
+			continue
+		}
+		seen[schema] = true
+		if snap, ok := snapshotOf(o.meta); ok {
+			tables = append(tables, snap.schemaTables(schema)...)
+			continue
+		}
+
+		var names []string
+		if err := o.meta.Raw(schemaTablesSQL, schema).Scan(&names).Error; err != nil {
+			return nil, err
+		}
+		for _, t := range names {
+			tables = append(tables, schema+"."+t)
+		}
+	}
+	return tables, nil
+}
+
+// qualifiedModelName returns the model name of a table, prefixed with the
+// schema of a qualified table when prefix is set: core.users is CoreUser.
+func (o *Orm) qualifiedModelName(name string, prefix bool) string {
+	schema, table := splitTable(trimTablePrefix(name, o.tablePrefix))
+	if schema != "" && prefix {
+		return o.modelName(schema + "_" + table)
+	}
+	return o.modelName(table)
+}
+
+// qualifiedFileName returns the file name of a table without rename rule,
+// prefixed with the schema of a qualified table when prefix is set.
+func qualifiedFileName(name string, abbreviations map[string]string, prefix bool) string {
+	schema, table := splitTable(name)
+	file := abbreviate(strings.ToLower(table), abbreviations)
+	if schema != "" && prefix {
+		return strings.ToLower(schema) + "_" + file
+	}
+	return file
+}
+
+// trimTablePrefix returns the table name without prefix, keeping its schema.
+// A table named after the prefix alone keeps it.
+func trimTablePrefix(name, prefix string) string {
+	schema, table := splitTable(name)
+	trimmed, ok := strings.CutPrefix(table, prefix)
+	if !ok || trimmed == "" {
+		return name
+	}
+	if schema != "" {
+		return schema + "." + trimmed
+	}
+	return trimmed
+}
+
+// aliasTableRules makes the rules of the bare table name apply to a
+// schema-qualified one, unless the qualified name has rules of its own.
+func (rs *ruleSet) aliasTableRules(qualified string) {
+	_, bare := splitTable(qualified)
+	if bare == qualified {
+		return
+	}
+	alias(rs.retags, qualified, bare)
+	alias(rs.regormtags, qualified, bare)
+	alias(rs.ignores, qualified, bare)
+	alias(rs.ignoreRules, qualified, bare)
+	alias(rs.types, qualified, bare)
+	alias(rs.redact, qualified, bare)
+	alias(rs.fieldRenames, qualified, bare)
+	alias(rs.serializers, qualified, bare)
+	alias(rs.autoTimes, qualified, bare)
+	alias(rs.gormTagged, qualified, bare)
+	alias(rs.retagged, qualified, bare)
+	alias(rs.jsonOmit, qualified, bare)
+}
+
+// alias copies the entry of bare to qualified when qualified has none.
+func alias[V any](m map[string]V, qualified, bare string) {
+	if _, ok := m[qualified]; ok {
+		return
+	}
+	if v, ok := m[bare]; ok {
+		m[qualified] = v
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaPrefixNames(t *testing.T) {
+	files := generate(t, openFixture(t, "schemas"), []string{"-t", "core.*", "-t", "audit.*", "--style", "dao", "--schema-prefix-names"},
+		WithDaoTables([]string{"*"}),
+		// The rule of the qualified name wins over the one of the bare name
+		WithIgnore([]string{"users->name", "audit.users->action"}),
+	)
+	for _, tt := range []struct {
+		schema, model string
+		fields        []string
+		dropped       string
+	}{
+		{schema: "core", model: "CoreUser", fields: []string{"ID", "Email"}, dropped: "Name"},
+		{schema: "audit", model: "AuditUser", fields: []string{"ID", "UserID", "Name"}, dropped: "Action"},
+	} {
+		model, ok := files["model/"+tt.schema+"_users.gen.go"]
+		if !ok {
+			t.Fatalf("no model of %s.users in %v", tt.schema, keys(files))
+		}
+		for _, want := range []string{
+			"type " + tt.model + " struct",
+			"func (*" + tt.model + ") TableName() string",
+			`return TableName` + tt.model,
+			`const TableName` + tt.model + ` = "` + tt.schema + `.users"`,
+		} {
+			if !strings.Contains(model, want) {
+				t.Errorf("%s model lacks %q:\n%s", tt.schema, want, model)
+			}
+		}
+		for _, field := range tt.fields {
+			if fieldLine(model, field) == "" {
+				t.Errorf("%s model lacks %s", tt.model, field)
+			}
+		}
+		if line := fieldLine(model, tt.dropped); line != "" {
+			t.Errorf("%s model keeps %s: %s", tt.model, tt.dropped, line)
+		}
+		if dao := files["dao/"+tt.schema+"_users.gen.go"]; !strings.Contains(dao, "model."+tt.model) {
+			t.Errorf("%s dao does not query model.%s", tt.schema, tt.model)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"database/sql"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// readOnlyCallback is the name of the callbacks refusing writes with --read-only.
+const readOnlyCallback = "czx:read_only"
+
+// readStatements are the leading keywords of the statements that never write.
+var readStatements = []string{"SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH", "VALUES", "PRAGMA"}
+
+// readOnlyTx begins the read-only transaction the reads of a connection the
+// tool opened run in, so a run against a primary cannot change it. It returns
+// the transaction and the func ending it, which must be called on every path.
+// Drivers without a read-only mode keep db.
+func readOnlyTx(ctx context.Context, db *gorm.DB) (*gorm.DB, func(), error) {
+	name := db.Dialector.Name()
+	switch name {
+	case "mysql", "postgres":
+		tx := db.WithContext(ctx).Begin(&sql.TxOptions{ReadOnly: true})
+		if tx.Error != nil {
+			return nil, nil, tx.Error
+		}
+		return tx, func() { tx.Rollback() }, nil
+	case "sqlite":
+		// The driver ignores TxOptions.ReadOnly, query_only holds for the
+		// connection of the transaction until it is reset
+		tx := db.WithContext(ctx).Begin()
+		if tx.Error != nil {
+			return nil, nil, tx.Error
+		}
+		end := func() {
+			tx.Set(readOnlyCallback, true).Exec("PRAGMA query_only = OFF")
+			tx.Rollback()
+		}
+		if err := tx.Exec("PRAGMA query_only = ON").Error; err != nil {
+			end()
+			return nil, nil, err
+		}
+		return tx, end, nil
+	}
+	cmd.Debugf("The %s driver has no read-only transactions, reads run outside a transaction", name)
+	return db, func() {}, nil
+}
+
+// guardWrites makes every statement of db that would write fail with a
+// ReadOnlyError. Creates, updates and deletes always write, raw statements
+// write unless they start with a read keyword. The callbacks stay registered
+// on a connection passed with WithDB.
+func guardWrites(db *gorm.DB) error {
+	cb := db.Callback()
+	if cb.Raw().Get(readOnlyCallback) != nil {
+		return nil
+	}
+	always := func(db *gorm.DB) { refuseWrite(db, true) }
+	byText := func(db *gorm.DB) { refuseWrite(db, false) }
+	if err := cb.Create().Before("gorm:create").Register(readOnlyCallback, always); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(readOnlyCallback, always); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register(readOnlyCallback, always); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register(readOnlyCallback, byText); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register(readOnlyCallback, byText); err != nil {
+		return err
+	}
+	return cb.Raw().Before("gorm:raw").Register(readOnlyCallback, byText)
+}
+
+// refuseWrite adds a ReadOnlyError to db when its statement writes. The
+// statements of the read-only transaction itself are set to pass.
+func refuseWrite(db *gorm.DB, write bool) {
+	if _, internal := db.Get(readOnlyCallback); internal {
+		return
+	}
+	stmt := strings.TrimSpace(db.Statement.SQL.String())
+	if !write && !writes(stmt) {
+		return
+	}
+	if stmt == "" && db.Statement.Table != "" {
+		stmt = db.Statement.Table
+	}
+	_ = db.AddError(&ReadOnlyError{Statement: stmt})
+}
+
+// writes reports whether a raw statement may write, by its leading keyword.
+// An empty statement is built by gorm from the model and is a read. PRAGMA
+// statements assigning a value write.
+func writes(stmt string) bool {
+	if stmt == "" {
+		return false
+	}
+	keyword, _, _ := strings.Cut(strings.TrimLeft(stmt, "( \t\n"), " ")
+	keyword = strings.ToUpper(strings.TrimRight(keyword, ";"))
+	if keyword == "PRAGMA" {
+		return strings.Contains(stmt, "=")
+	}
+	for _, read := range readStatements {
+		if keyword == read {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// statementLog is a driver hook recording the statements a connection ran.
+type statementLog struct {
+	mu    sync.Mutex
+	stmts []string
+}
+
+// hook registers the recording callbacks of every statement type on db,
+// after the statement ran.
+func (l *statementLog) hook(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	cb := db.Callback()
+	record := func(kind string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			if db.Error != nil {
+				return
+			}
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.stmts = append(l.stmts, kind+" "+strings.TrimSpace(db.Statement.SQL.String()))
+		}
+	}
+	for _, err := range []error{
+		cb.Create().After("gorm:create").Register("test:record", record("create")),
+		cb.Update().After("gorm:update").Register("test:record", record("update")),
+		cb.Delete().After("gorm:delete").Register("test:record", record("delete")),
+		cb.Query().After("gorm:query").Register("test:record", record("query")),
+		cb.Row().After("gorm:row").Register("test:record", record("row")),
+		cb.Raw().After("gorm:raw").Register("test:record", record("raw")),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// statements returns the recorded statements.
+func (l *statementLog) statements() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.stmts)
+}
+
+// openUsers returns a sqlite database in a file holding a users table, with
+// the statements it runs from now on recorded.
+func openUsers(t *testing.T) (*gorm.DB, *statementLog) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	log := &statementLog{}
+	log.hook(t, db)
+	return db, log
+}
+
+func TestReadOnlyRunReads(t *testing.T) {
+	db, log := openUsers(t)
+	if err := run(t, db, []string{"-t", "users", "--read-only"}); err != nil {
+		t.Fatal(err)
+	}
+
+	stmts := log.statements()
+	if len(stmts) == 0 {
+		t.Fatal("no statement recorded")
+	}
+	for _, stmt := range stmts {
+		kind, sql, _ := strings.Cut(stmt, " ")
+		if kind == "create" || kind == "update" || kind == "delete" || writes(sql) {
+			t.Errorf("--read-only run wrote: %s", stmt)
+		}
+	}
+
+	// The guard stays on the injected connection, writes are refused
+	// before they reach the database
+	err := db.Exec("INSERT INTO users (name) VALUES ('ada')").Error
+	var ro *ReadOnlyError
+	if !errors.As(err, &ro) {
+		t.Fatalf("insert = %v, want a ReadOnlyError", err)
+	}
+	if err := db.Create(&struct {
+		ID   int
+		Name string
+	}{Name: "ada"}).Error; !errors.As(err, &ro) {
+		t.Errorf("create = %v, want a ReadOnlyError", err)
+	}
+	var n int64
+	if err := db.Table("users").Count(&n).Error; err != nil || n != 0 {
+		t.Errorf("users count = %d, %v, want 0", n, err)
+	}
+}
+
+func TestReadOnlyTx(t *testing.T) {
+	db, log := openUsers(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, end, err := readOnlyTx(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Exec("INSERT INTO users (name) VALUES ('ada')").Error; err == nil {
+		t.Error("insert succeeded in the read-only transaction")
+	}
+	var n int64
+	if err := tx.Table("users").Count(&n).Error; err != nil {
+		t.Errorf("read in the read-only transaction: %v", err)
+	}
+	end()
+
+	// Ending the transaction releases its connection in a writable state
+	if inUse := sqlDB.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections in use after the end", inUse)
+	}
+	if err := db.Exec("INSERT INTO users (name) VALUES ('ada')").Error; err != nil {
+		t.Errorf("insert after the end: %v", err)
+	}
+	if !slices.Contains(log.statements(), "raw PRAGMA query_only = OFF") {
+		t.Errorf("query_only not reset, statements: %v", log.statements())
+	}
+}
+
+func TestReadOnlyTxCancelled(t *testing.T) {
+	db, _ := openUsers(t)
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed begin leaks no connection
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := readOnlyTx(ctx, db); err == nil {
+		t.Fatal("begin succeeded with a cancelled context")
+	}
+	if inUse := sqlDB.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connections in use after the failed begin", inUse)
+	}
+}
+
+func TestWrites(t *testing.T) {
+	for stmt, want := range map[string]bool{
+		"":                                     false,
+		"SELECT * FROM users":                  false,
+		"  (select 1)":                         false,
+		"WITH t AS (SELECT 1) SELECT * FROM t": false,
+		"PRAGMA table_info(users)":             false,
+		"PRAGMA query_only = ON":               true,
+		"SHOW TABLES;":                         false,
+		"insert into users values (1)":         true,
+		"UPDATE users SET name = 'x'":          true,
+		"DELETE FROM users":                    true,
+		"CREATE TABLE t (id int)":              true,
+	} {
+		if got := writes(stmt); got != want {
+			t.Errorf("writes(%q) = %v, want %v", stmt, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+)
+
+// Relationship types of WithRelation.
+const (
+	BelongsTo = "belongs_to"
+	HasOne    = "has_one"
+	HasMany   = "has_many"
+	Many2Many = "many2many"
+)
+
+// relationTypes are the gen relationships by relationship type.
+var relationTypes = map[string]field.RelationshipType{
+	BelongsTo: field.BelongsTo,
+	HasOne:    field.HasOne,
+	HasMany:   field.HasMany,
+	Many2Many: field.Many2Many,
+}
+
+// Relation declares an association field of the model of a table.
+type Relation struct {
+	// Table is the table of the model holding the field
+	Table string `json:"table"`
+	// Type is belongs_to, has_one, has_many or many2many
+	Type string `json:"type"`
+	// Field is the name of the struct field
+	Field string `json:"field"`
+	// Target is the table of the associated model, generated by the same run
+	Target string `json:"target"`
+	// GormTag are the gorm settings of the field, such as "foreignKey:UserID",
+	// many2many naming its join table with "many2many:user_roles"
+	GormTag string `json:"gormTag,omitempty"`
+}
+
+// validate checks the type, field name and tag of the relation.
+func (r Relation) validate() error {
+	rule := r.Table + "->" + r.Type + "->" + r.Field + "->" + r.Target
+	if _, ok := relationTypes[r.Type]; !ok {
+		return &RuleSyntaxError{Rule: rule, Reason: "relationship must be belongs_to, has_one, has_many or many2many"}
+	}
+	if r.Table == "" || r.Target == "" {
+		return &RuleSyntaxError{Rule: rule, Reason: "relation without table or target table"}
+	}
+	if !token.IsIdentifier(r.Field) || !token.IsExported(r.Field) {
+		return &RuleSyntaxError{Rule: rule, Reason: "field " + r.Field + " is not an exported identifier"}
+	}
+	if join := r.gormTag()["many2many"]; r.Type == Many2Many && (len(join) == 0 || join[0] == "") {
+		return &RuleSyntaxError{Rule: rule, Reason: `many2many relation without a "many2many:<join table>" gorm setting`}
+	}
+	return nil
+}
+
+// gormTag parses the gorm settings of the relation.
+func (r Relation) gormTag() field.GormTag {
+	tag := field.GormTag{}
+	for _, s := range strings.Split(r.GormTag, ";") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(s, ":")
+		tag.Append(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	return tag
+}
+
+// relate regenerates the models of the tables with relations, adding their
+// association fields. The associated models are the models of the run
+// without their own associations, a model associated with itself being
+// referenced by pointer. generated are the models of the run and their
+// options by table.
+func (o *Orm) relate(relations []Relation, generated map[string]any, opts map[string][]gen.ModelOpt) error {
+	byTable := make(map[string][]Relation)
+	for _, r := range relations {
+		if err := r.validate(); err != nil {
+			return err
+		}
+		// Relations of tables left out of the run
+		if _, ok := generated[r.Table]; !ok {
+			continue
+		}
+		if _, ok := generated[r.Target]; !ok {
+			return &RuleSyntaxError{Rule: r.Table + "->" + r.Field, Reason: "target table " + r.Target + " is not generated by the run"}
+		}
+		if slices.ContainsFunc(byTable[r.Table], func(o Relation) bool { return o.Field == r.Field }) {
+			return &RuleSyntaxError{Rule: r.Table + "->" + r.Field, Reason: "field declared by several relations"}
+		}
+		byTable[r.Table] = append(byTable[r.Table], r)
+	}
+
+	for table, relations := range byTable {
+		topts := slices.Clip(opts[table])
+		for _, r := range relations {
+			config := &field.RelateConfig{GORMTag: r.gormTag()}
+			if r.Target == r.Table && (r.Type == BelongsTo || r.Type == HasOne) {
+				config.RelatePointer = true
+			}
+			topts = append(topts, fieldRelate(relationTypes[r.Type], r.Field, generated[r.Target], config))
+		}
+		old := generated[table]
+		model := o.generator.GenerateModelAs(table, metaString(old, "ModelStructName"), topts...)
+		o.setTableComment(model, table)
+		for i, s := range o.structs {
+			if s == old {
+				o.structs[i] = model
+			}
+		}
+	}
+	return nil
+}
+
+// fieldRelate returns the gen.FieldRelate option of a model of the run, whose
+// type is internal to gen.
+func fieldRelate(relationship field.RelationshipType, name string, target any, config *field.RelateConfig) gen.ModelOpt {
+	out := reflect.ValueOf(gen.FieldRelate).Call([]reflect.Value{
+		reflect.ValueOf(relationship), reflect.ValueOf(name), reflect.ValueOf(target), reflect.ValueOf(config),
+	})
+	return out[0].Interface().(gen.ModelOpt)
+}
@@ -0,0 +1,280 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"archive/zip"
+	"cmp"
+	"command/cmd"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+// replayDropped are the generation flags of a bundle a replay ignores, as
+// they write outside the replay directory or need files of the capture.
+var replayDropped = []string{"graph", "docs-site", "progress-socket", "progress-file", "metrics", "policy", "policy-report"}
+
+// bundle is a capture bundle read back.
+type bundle struct {
+	snap     schemaSnapshot
+	settings bundleSettingsFile
+	report   bundleReportFile
+}
+
+// replayCommand returns the orm replay subcommand.
+func (o *Orm) replayCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "replay <bundle>",
+		Short: "Regenerate a run captured with --capture, without the database",
+		Long: `Regenerate a run captured with --capture into the directory of --out from
+the schema snapshot and settings of the bundle, without connecting to any
+database, then compare the files with the checksums of the captured run.
+
+A go.mod declaring the module of the captured run is written to --out when
+absent. The annotae interfaces of the dao cannot be captured, so their
+methods are missing from a replay, and a bundle written with
+--redact-comments replays without the comments.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Reproduce a run reported with a bundle
+command orm replay bundle.zip -o ./repro`,
+		Args: cobra.ExactArgs(1),
+		RunE: o.invoke((*Orm).replay),
+	}
+	o.generationFlags(c.Flags())
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Hidden = true
+	})
+	c.Flags().StringP("out", "o", "./repro", cmd.T("orm.replay.flag.out"))
+	return c
+}
+
+// replay is the execution logic for the orm replay command.
+func (o *Orm) replay(c *cobra.Command, args []string) error {
+	out, err := c.Flags().GetString("out")
+	if err != nil {
+		return err
+	}
+	b, err := readBundle(args[0])
+	if err != nil {
+		return usage("reading the bundle", fmt.Errorf("%s: %w", args[0], err))
+	}
+	flags := b.settings.Flags
+	for _, name := range replayDropped {
+		delete(flags, name)
+	}
+	if err := setGenerationFlags(c.Flags(), flags); err != nil {
+		return usage("reading the bundle", fmt.Errorf("%s: %w", args[0], err))
+	}
+
+	root, err := filepath.Abs(out)
+	if err != nil {
+		return usage("replaying", err)
+	}
+	if err := replayModule(root, b.settings.Options.Module); err != nil {
+		return fail("replaying", err)
+	}
+	db, err := openSnapshot(&b.snap)
+	if err != nil {
+		return fail("replaying", err)
+	}
+	o.opt = b.settings.Options.option(root, &b.snap)
+	o.opt.db, o.meta = db, db
+
+	if err := o.setup(); err != nil {
+		return fail(cmd.T("orm.formatting_rules"), err)
+	}
+	if err := o.exec(c.Context(), c.Flags()); err != nil {
+		return fail("replaying", err)
+	}
+
+	style, err := c.Flags().GetString("style")
+	if err != nil {
+		return err
+	}
+	sums, err := o.fileSums(root, style != "model")
+	if err != nil {
+		return fail("replaying", err)
+	}
+	return b.report.compare(root, sums)
+}
+
+// compare prints how the files of a replay in root compare with the report.
+func (r *bundleReportFile) compare(root string, sums []bundleFileSum) error {
+	replayed := make(map[string]string, len(sums))
+	for _, s := range sums {
+		replayed[s.Path] = s.SHA256
+	}
+	var identical int
+	var differ, missing []string
+	for _, f := range r.Files {
+		switch sum, ok := replayed[f.Path]; {
+		case !ok:
+			missing = append(missing, f.Path)
+		case sum != f.SHA256:
+			differ = append(differ, f.Path)
+		default:
+			identical++
+		}
+	}
+
+	for _, path := range differ {
+		color.Yellow("differs: %s\n", path)
+	}
+	for _, path := range missing {
+		color.Yellow("missing: %s\n", path)
+	}
+	if len(r.DaoApi) > 0 {
+		color.Yellow("The annotae methods of the captured run are not replayed: %v\n", r.DaoApi)
+	}
+	color.Green("\nReplayed %d tables into %s: %d identical, %d differ, %d missing.\n\n", len(r.Tables), root, identical, len(differ), len(missing))
+	return nil
+}
+
+// readBundle reads a capture bundle.
+func readBundle(name string) (*bundle, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	b := &bundle{}
+	for _, e := range []struct {
+		name  string
+		value any
+	}{{bundleSchema, &b.snap}, {bundleSettings, &b.settings}, {bundleReport, &b.report}} {
+		if err := readBundleEntry(zr, e.name, e.value); err != nil {
+			return nil, err
+		}
+	}
+	if b.settings.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.settings.Version)
+	}
+	return b, nil
+}
+
+// readBundleEntry decodes the JSON entry name of a bundle into v.
+func readBundleEntry(zr *zip.ReadCloser, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// replayModule creates the replay directory with a go.mod for module, so the
+// import paths of the generated packages are those of the captured run.
+func replayModule(root, module string) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(root, "go.mod")
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(path, fmt.Appendf(nil, "module %s\n", cmp.Or(module, "repro")), 0644)
+}
+
+// option returns the options of the captured run, generating under root.
+func (c captureOptions) option(root string, snap *schemaSnapshot) OrmOption {
+	opt := OrmOption{
+		gconf: gen.Config{
+			OutPath:           filepath.Join(root, filepath.FromSlash(c.OutPath)),
+			OutFile:           c.OutFile,
+			ModelPkgPath:      filepath.Join(root, filepath.FromSlash(c.ModelPkgPath)),
+			WithUnitTest:      c.WithUnitTest,
+			FieldNullable:     c.FieldNullable,
+			FieldCoverable:    c.FieldCoverable,
+			FieldSignable:     c.FieldSignable,
+			FieldWithIndexTag: c.FieldWithIndexTag,
+			FieldWithTypeTag:  c.FieldWithTypeTag,
+		},
+		rename:         c.Rename,
+		ignore:         c.Ignore,
+		onlyFields:     c.OnlyFields,
+		retags:         c.Retags,
+		reGromTags:     c.ReGormTags,
+		daoTables:      c.DaoTables,
+		redact:         c.Redact,
+		fieldRename:    c.FieldRename,
+		abbreviations:  c.Abbreviations,
+		acronyms:       c.Acronyms,
+		serializer:     c.Serializer,
+		serializerType: c.SerializerType,
+		jsonType:       c.JSONType,
+		autoTime:       c.AutoTime,
+		jsonOmit:       c.JSONOmit,
+		softDelete:     c.SoftDelete,
+		nullable:       c.Nullable,
+		notNullable:    c.NotNullable,
+		relations:      c.Relations,
+		excludeTables:  c.ExcludeTables,
+		enums:          c.Enums,
+	}
+	for _, name := range c.Mode {
+		opt.gconf.Mode |= genModeNames[name]
+	}
+	if c.JSONTags != nil {
+		opt.jsonTagStrategy = func(column string) string {
+			return c.JSONTags[column]
+		}
+	}
+	if c.ModelNames != nil {
+		opt.modelNameStrategy = func(table string) string {
+			return c.ModelNames[table]
+		}
+	}
+
+	// The recorded types are mapped by the database types of their columns
+	for table, columns := range c.DataTypes {
+		t, err := snap.table(table)
+		if err != nil {
+			continue
+		}
+		for _, col := range t.Columns {
+			if _, ok := columns[col.NameValue]; !ok {
+				continue
+			}
+			if opt.dataType == nil {
+				opt.dataType = make(map[string]DataTypeFn)
+			}
+			opt.dataType[table+"->"+col.DataTypeValue] = func(ct gorm.ColumnType) string {
+				return columns[ct.Name()]
+			}
+		}
+	}
+	return opt
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type (
+	// ProgressReporter receives the progress of the generation of the models
+	// table by table, see WithProgressReporter.
+	ProgressReporter interface {
+		// TableStarted is called before the model of the table is generated,
+		// index counting the tables of the run from 1 to total.
+		TableStarted(index, total int, table string)
+		// TableFinished is called once the model of the table is generated,
+		// or with the error it failed with.
+		TableFinished(table string, elapsed time.Duration, err error)
+	}
+	// terminalReporter writes a line per table, "[37/512] users … done (120ms)".
+	terminalReporter struct {
+		w            io.Writer
+		index, total int
+	}
+	// tableProgress is the progress of the models of a run.
+	tableProgress struct {
+		reporter ProgressReporter
+		// total is the number of selected entries, done the number started
+		total, done int
+	}
+)
+
+// TableStarted implements ProgressReporter. The line is written once the
+// table is finished, so warnings never break it.
+func (r *terminalReporter) TableStarted(index, total int, _ string) {
+	r.index, r.total = index, total
+}
+
+// TableFinished implements ProgressReporter.
+func (r *terminalReporter) TableFinished(table string, elapsed time.Duration, err error) {
+	status := "done"
+	if err != nil {
+		status = color.RedString("failed")
+	}
+	fmt.Fprintf(r.w, "%s %s … %s (%s)\n", color.HiBlackString("[%d/%d]", r.index, r.total), table, status, elapsed.Round(time.Millisecond))
+}
+
+// start reports the start of the next table and returns the function
+// reporting its end.
+func (p *tableProgress) start(table string) func(error) {
+	if p == nil || p.reporter == nil {
+		return func(error) {}
+	}
+	p.done++
+	p.reporter.TableStarted(p.done, p.total, table)
+	began := time.Now()
+	return func(err error) {
+		p.reporter.TableFinished(table, time.Since(began), err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingReporter records the progress events of a run.
+type recordingReporter struct {
+	events []string
+	err    error
+}
+
+func (r *recordingReporter) TableStarted(index, total int, table string) {
+	r.events = append(r.events, fmt.Sprintf("started %d/%d %s", index, total, table))
+}
+
+func (r *recordingReporter) TableFinished(table string, _ time.Duration, err error) {
+	status := "done"
+	if err != nil {
+		status, r.err = "failed", err
+	}
+	r.events = append(r.events, "finished "+table+" "+status)
+}
+
+// failingOrders makes the orders table of the shop fixture fail: its
+// primary key is ignored with --strict-rules.
+var failingOrders = []string{"-t", "customers", "-t", "orders", "--strict-rules"}
+
+func TestProgressReporter(t *testing.T) {
+	r := &recordingReporter{}
+	err := run(t, openFixture(t, "shop"), failingOrders, WithIgnore([]string{"orders->id"}), WithProgressReporter(r))
+	var rule *RuleSyntaxError
+	if !errors.As(err, &rule) || !strings.Contains(err.Error(), "table orders") {
+		t.Fatalf("run = %v, want a rule error naming orders", err)
+	}
+
+	want := []string{"started 1/2 customers", "finished customers done", "started 2/2 orders", "finished orders failed"}
+	if !slices.Equal(r.events, want) {
+		t.Errorf("events %v, want %v", r.events, want)
+	}
+	if !errors.Is(err, r.err) {
+		t.Errorf("orders finished with %v, the run failed with %v", r.err, err)
+	}
+}
+
+func TestTerminalProgress(t *testing.T) {
+	// The progress lines go to the standard error
+	output := func(args []string, opts ...IOrmOption) (string, error) {
+		var err error
+		out := captureOutput(t, func() {
+			stderr := os.Stderr
+			os.Stderr = os.Stdout
+			defer func() { os.Stderr = stderr }()
+			err = run(t, openFixture(t, "shop"), args, opts...)
+		})
+		return out, err
+	}
+
+	out, err := output(failingOrders, WithIgnore([]string{"orders->id"}))
+	if err == nil {
+		t.Fatal("the run with a failing table succeeded")
+	}
+	for _, want := range []string{"[1/2] customers … done (", "[2/2] orders … failed ("} {
+		if !strings.Contains(out, want) {
+			t.Errorf("progress lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "completed successfully") {
+		t.Errorf("a failed run reported success:\n%s", out)
+	}
+
+	out, err = output([]string{"-t", "customers", "-t", "orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "[2/2] orders … done (") || !strings.Contains(out, "Took ") {
+		t.Errorf("progress or elapsed time missing:\n%s", out)
+	}
+
+	// --quiet keeps the progress lines out
+	out, err = output([]string{"-t", "customers", "-t", "orders", "--quiet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "[1/2]") {
+		t.Errorf("--quiet printed the progress:\n%s", out)
+	}
+}
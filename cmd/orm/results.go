@@ -0,0 +1,70 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"go/token"
+	"reflect"
+)
+
+// checkResultTypes checks the row structs returned by the methods of an
+// annotae interface, such as []annotae.UserOrderSummary for a join, which gen
+// scans the rows of the query into. The generated dao refers to them by their
+// package, so they must be exported structs of an importable package with
+// exported fields. gorm matches the columns of the rows to the fields by the
+// column of their gorm tag, or else by the snake_case of the field name:
+// "order_count" fills OrderCount, and columns without a field are dropped.
+func checkResultTypes(annotae any) error {
+	iface, err := annotaeInterface(annotae)
+	if err != nil {
+		return err
+	}
+	for i := range iface.NumMethod() {
+		m := iface.Method(i)
+		for j := range m.Type.NumOut() {
+			t := m.Type.Out(j)
+			for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+				t = t.Elem()
+			}
+			if t.Kind() != reflect.Struct || t.Name() == "" || t.PkgPath() == "time" {
+				continue
+			}
+			err := &ResultTypeError{Method: iface.Name() + "." + m.Name, Type: t.String()}
+			switch {
+			case !token.IsExported(t.Name()):
+				err.Reason = "the struct is not exported"
+			case t.PkgPath() == "main":
+				err.Reason = "the struct is in package main, which the dao cannot import"
+			case !exportedFields(t):
+				err.Reason = "the struct has no exported field to scan the columns into"
+			default:
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// exportedFields reports whether a struct has an exported field.
+func exportedFields(t reflect.Type) bool {
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gen"
+)
+
+func TestRegenerateUnchanged(t *testing.T) {
+	db := openFixture(t, "shop")
+	args := []string{"-t", "customers", "-t", "orders", "--style", "dao", "--with-stringer"}
+	opts := []IOrmOption{WithDaoTables([]string{"customers", "orders"})}
+	first := generate(t, db, args, opts...)
+	if len(first) == 0 {
+		t.Fatal("nothing generated")
+	}
+
+	// Back date the files, a rewrite would give them the current time
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for name := range first {
+		if err := os.Chtimes(filepath.FromSlash(name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtimes := func() map[string]time.Time {
+		times := make(map[string]time.Time)
+		for name := range first {
+			info, err := os.Stat(filepath.FromSlash(name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			times[name] = info.ModTime()
+		}
+		return times
+	}
+
+	generateHere(t, db, args, opts...)
+	for name, mtime := range mtimes() {
+		if !mtime.Equal(old) {
+			t.Errorf("%s rewritten by the second run", name)
+		}
+	}
+
+	generateHere(t, db, append(args, "--force-write"), opts...)
+	for name, mtime := range mtimes() {
+		if mtime.Equal(old) {
+			t.Errorf("%s not rewritten with --force-write", name)
+		}
+	}
+}
+
+// countingFS is a MapFS counting the files written to it.
+type countingFS struct {
+	MapFS
+	writes int
+}
+
+func (c *countingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	c.writes++
+	return c.MapFS.WriteFile(name, data, perm)
+}
+
+func TestRegenerateUnchangedWriteFS(t *testing.T) {
+	t.Chdir(t.TempDir())
+	db := openFixture(t, "shop")
+	fsys := &countingFS{MapFS: MapFS{}}
+	run := func(extra ...string) {
+		c := NewOrmCommand(
+			WithDB(db),
+			WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}),
+			WithDaoTables([]string{"customers", "orders"}),
+			WithFS(fsys),
+		).Command()
+		c.SetArgs(append([]string{"-t", "customers", "-t", "orders", "--style", "dao", "--config", ""}, extra...))
+		c.SilenceUsage, c.SilenceErrors = true, true
+		if err := c.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run()
+	files := fsys.writes
+	if files == 0 || files != len(fsys.MapFS) {
+		t.Fatalf("first run wrote %d files: %v", files, keys(toStrings(fsys.MapFS)))
+	}
+	run()
+	if fsys.writes != files {
+		t.Errorf("second run rewrote %d files", fsys.writes-files)
+	}
+	run("--force-write")
+	if fsys.writes != 2*files {
+		t.Errorf("--force-write rewrote %d of %d files", fsys.writes-files, files)
+	}
+}
+
+// toStrings returns the files of m as strings.
+func toStrings(m MapFS) map[string]string {
+	files := make(map[string]string, len(m))
+	for name, data := range m {
+		files[name] = string(data)
+	}
+	return files
+}
@@ -0,0 +1,366 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/imports"
+)
+
+// rlsScoped ends the doc comment of the dao methods scoped by rlsFacades,
+// which are left alone when the file is processed again.
+const rlsScoped = "// Scoped by row-level security to the rows of the leading parameter."
+
+// rlsParam is the leading parameter of the dao methods of a table under
+// row-level security, the value of its row-level security column.
+type rlsParam struct {
+	Name, Type string
+	// Import is the package of Type, empty for a predeclared type
+	Import string
+}
+
+// rlsMarker names, in the comment of a table, the column every query of the
+// table must filter by under row-level security, e.g. "[rls:tenant_id]".
+var rlsMarker = regexp.MustCompile(`\[rls:\s*(\w+)\s*\]`)
+
+// recordRLS records the row-level security column of the table of a
+// generated struct from the marker of its comment. The marker must name a
+// column of the table.
+func (o *Orm) recordRLS(meta any, table string) error {
+	m := rlsMarker.FindStringSubmatch(metaString(meta, "TableComment"))
+	if m == nil {
+		return nil
+	}
+	if !slices.ContainsFunc(o.columns[table], func(c columnMeta) bool { return c.Name == m[1] }) {
+		return fmt.Errorf("table %s: the row-level security marker %s names no column of the table", table, m[0])
+	}
+	o.rls[table] = m[1]
+	return nil
+}
+
+// missingRLS returns the generated tables without a row-level security marker.
+func (o *Orm) missingRLS() []string {
+	var missing []string
+	for _, meta := range o.structs {
+		if table := metaString(meta, "TableName"); table != "" && o.rls[table] == "" {
+			missing = append(missing, table)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// rlsPass appends the <Model>RLSColumn constant of a table under row-level
+// security to its model.
+func (o *Orm) rlsPass(table string, src []byte) ([]byte, error) {
+	column, ok := o.rls[table]
+	if !ok {
+		return src, nil
+	}
+	for _, meta := range o.structs {
+		if metaString(meta, "TableName") != table {
+			continue
+		}
+		model := metaString(meta, "ModelStructName")
+		src = fmt.Appendf(src, "\n// %sRLSColumn is the row-level security column every query of %s must filter by.\n", model, table)
+		src = fmt.Appendf(src, "const %sRLSColumn = %q\n", model, column)
+	}
+	return src, nil
+}
+
+// rlsFacades makes the value of the row-level security column the leading
+// parameter of the annotae methods of the dao of every table under row-level
+// security, and scopes their statements to its rows: each one runs against
+// a common table expression of the same name as the table, holding the rows
+// of the value only. Only query methods of tables without schema can be
+// scoped, the others are an error.
+func (o *Orm) rlsFacades() error {
+	if len(o.rls) == 0 {
+		return nil
+	}
+	out, err := filepath.Abs(o.opt.gconf.OutPath)
+	if err != nil {
+		return err
+	}
+	modelDir, err := o.modelOutPath()
+	if err != nil {
+		return err
+	}
+	for _, meta := range o.structs {
+		table, file := metaString(meta, "TableName"), metaString(meta, "FileName")
+		column, ok := o.rls[table]
+		if !ok || !selected(o.opt.daoTables, table) {
+			continue
+		}
+		methods, err := o.annotaeMethods(table)
+		if err != nil || len(methods) == 0 {
+			return err
+		}
+		path := filepath.Join(out, file+".gen.go")
+		src, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			// Models only
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		model, err := os.ReadFile(filepath.Join(modelDir, file+".gen.go"))
+		if err != nil {
+			return err
+		}
+		param, err := o.rlsParamOf(model, table, column)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+		if src, err = o.rlsScope(src, table, column, param, metaString(meta, "QueryStructName")+"Do", methods); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if src, err = imports.Process(path, src, nil); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := writeFileAtomic(path, src, 0640); err != nil {
+			return err
+		}
+		o.rlsParams[table] = param
+	}
+	return nil
+}
+
+// annotaeMethods returns the names of the methods of the annotae interfaces
+// applied to the dao of a table.
+func (o *Orm) annotaeMethods(table string) ([]string, error) {
+	var names []string
+	for _, key := range []string{"*", table} {
+		api, ok := o.opt.daoApi[key]
+		if !ok {
+			continue
+		}
+		iface, err := annotaeInterface(api)
+		if err != nil {
+			return nil, err
+		}
+		for i := range iface.NumMethod() {
+			names = append(names, iface.Method(i).Name)
+		}
+	}
+	return names, nil
+}
+
+// rlsParamOf returns the leading parameter of the row-level security column
+// of a table, typed as the field of the column in the model source.
+func (o *Orm) rlsParamOf(model []byte, table, column string) (rlsParam, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", model, 0)
+	if err != nil {
+		return rlsParam{}, err
+	}
+	var field string
+	for name, col := range o.fields[table] {
+		if col == column {
+			field = name
+		}
+	}
+	param := rlsParam{Name: acronymize(lowerCamelCase(column), o.acronyms)}
+	ast.Inspect(file, func(n ast.Node) bool {
+		f, ok := n.(*ast.Field)
+		if !ok || param.Type != "" || len(f.Names) != 1 || f.Names[0].Name != field {
+			return true
+		}
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, f.Type)
+		param.Type = buf.String()
+		if sel, ok := f.Type.(*ast.SelectorExpr); ok {
+			param.Import = packageImport(file, sel)
+		}
+		return false
+	})
+	if param.Type == "" {
+		return rlsParam{}, fmt.Errorf("no field of the row-level security column %s", column)
+	}
+	return param, nil
+}
+
+// packageImport returns the import path of the package of a qualified type.
+func packageImport(file *ast.File, sel *ast.SelectorExpr) string {
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil && imp.Name.Name == pkg.Name || imp.Name == nil && filepath.Base(path) == pkg.Name {
+			return path
+		}
+	}
+	return ""
+}
+
+// rlsScope rewrites the methods of the do type of a generated query file,
+// and their declarations in its interfaces, to take param first and run
+// their statement on the rows of its value only.
+func (o *Orm) rlsScope(src []byte, table, column string, param rlsParam, do string, methods []string) ([]byte, error) {
+	if strings.Contains(table, ".") {
+		return nil, fmt.Errorf("the dao methods of schema-qualified table %s cannot be scoped by row-level security", table)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	// Inserted texts by offset, and replaced ranges by start offset
+	inserts := make(map[int]string)
+	type edit struct {
+		end  int
+		text string
+	}
+	edits := make(map[int]edit)
+	addParam := func(ft *ast.FuncType, name string) error {
+		for _, f := range ft.Params.List {
+			for _, n := range f.Names {
+				if n.Name == param.Name {
+					return fmt.Errorf("method %s already has a parameter %s", name, param.Name)
+				}
+			}
+		}
+		text := param.Name + " " + param.Type
+		if len(ft.Params.List) > 0 {
+			text += ", "
+		}
+		inserts[offset(ft.Params.Opening)+1] = text
+		return nil
+	}
+
+	cte := rlsCTE(o.meta.Dialector.Name(), table, column)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) != 1 || !slices.Contains(methods, d.Name.Name) {
+				continue
+			}
+			recv := d.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if id, ok := recv.(*ast.Ident); !ok || id.Name != do {
+				continue
+			}
+			if d.Doc != nil && strings.Contains(d.Doc.Text(), strings.TrimPrefix(rlsScoped, "// ")) {
+				// Scoped by an earlier run
+				return src, nil
+			}
+			body := string(src[offset(d.Body.Pos()):offset(d.Body.End())])
+			const raw = "Raw(generateSQL.String(), params...)"
+			if strings.Count(body, raw) != 1 || strings.Contains(body, ".Exec(") {
+				return nil, fmt.Errorf("method %s of table %s is not a query and cannot be scoped by row-level security", d.Name.Name, table)
+			}
+			if err := addParam(d.Type, d.Name.Name); err != nil {
+				return nil, err
+			}
+			start := offset(d.Body.Pos()) + strings.Index(body, raw)
+			edits[start] = edit{start + len(raw), fmt.Sprintf("Raw(%q+generateSQL.String(), append([]interface{}{%s}, params...)...)", cte, param.Name)}
+			doc := rlsScoped + "\n"
+			if d.Doc != nil {
+				doc = "//\n" + doc
+			}
+			inserts[offset(d.Type.Func)] = doc
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				it, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					continue
+				}
+				for _, m := range it.Methods.List {
+					ft, ok := m.Type.(*ast.FuncType)
+					if !ok || len(m.Names) != 1 || !slices.Contains(methods, m.Names[0].Name) {
+						continue
+					}
+					if err := addParam(ft, m.Names[0].Name); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	offsets := make([]int, 0, len(inserts)+len(edits))
+	for i := range inserts {
+		offsets = append(offsets, i)
+	}
+	for i := range edits {
+		offsets = append(offsets, i)
+	}
+	sort.Ints(offsets)
+	var buf bytes.Buffer
+	last := 0
+	for _, i := range offsets {
+		buf.Write(src[last:i])
+		last = i
+		if text, ok := inserts[i]; ok {
+			buf.WriteString(text)
+		}
+		if e, ok := edits[i]; ok {
+			buf.WriteString(e.text)
+			last = e.end
+		}
+	}
+	buf.Write(src[last:])
+	if param.Import != "" && !bytes.Contains(buf.Bytes(), []byte(strconv.Quote(param.Import))) {
+		return addImport(buf.Bytes(), file.Name.End(), fset, param.Import), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// rlsCTE returns the common table expression shadowing a table with its rows
+// of one value of column, prepended to the statements of the scoped
+// methods. The table inside the expression is qualified where the driver
+// would otherwise take it for a recursive reference.
+func rlsCTE(driver, table, column string) string {
+	source := table
+	switch driver {
+	case "sqlite":
+		source = "main." + table
+	case "sqlserver":
+		source = "dbo." + table
+	}
+	return fmt.Sprintf("WITH %s AS (SELECT * FROM %s WHERE %s = ?) ", table, source, column)
+}
+
+// addImport adds an import declaration of path after the package clause
+// ending at pos.
+func addImport(src []byte, pos token.Pos, fset *token.FileSet, path string) []byte {
+	i := fset.Position(pos).Offset
+	return slices.Concat(src[:i], []byte("\n\nimport "+strconv.Quote(path)), src[i:])
+}
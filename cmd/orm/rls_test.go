@@ -0,0 +1,79 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/annotae"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRLSFacade(t *testing.T) {
+	files := generateBuilt(t, openFixture(t, "rls"), []string{"-t", "documents", "-t", "notes", "--style", "dao", "--with-mocks"},
+		WithDaoTables([]string{"documents", "notes"}),
+		WithDaoApi(map[string]any{"*": func(annotae.Querier) {}}),
+	)
+
+	if !strings.Contains(files["model/documents.gen.go"], `const DocumentRLSColumn = "tenant_id"`) {
+		t.Error("no RLS column constant")
+	}
+	dao := files["dao/documents.gen.go"]
+	for _, want := range []string{
+		"GetByID(tenantID int64, id int) (result model.Document, err error)",
+		rlsScoped,
+		`Raw("WITH documents AS (SELECT * FROM documents WHERE tenant_id = ?) "+generateSQL.String(), append([]interface{}{tenantID}, params...)...)`,
+	} {
+		if !strings.Contains(dao, want) {
+			t.Errorf("documents dao lacks %q", want)
+		}
+	}
+	if !strings.Contains(files["dao/mocks/documents.gen.go"], "GetByID(tenantID int64, id int) (model.Document, error)") {
+		t.Error("mock of documents without the RLS parameter")
+	}
+
+	// Tables without the marker are unaffected
+	if dao := files["dao/notes.gen.go"]; !strings.Contains(dao, "GetByID(id int)") || strings.Contains(dao, "WITH ") {
+		t.Error("notes dao scoped without RLS marker")
+	}
+}
+
+func TestRLSCTE(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE documents (id integer PRIMARY KEY, tenant_id integer, title text)",
+		"INSERT INTO documents VALUES (1, 1, 'mine'), (2, 2, 'theirs'), (3, 1, 'also mine')",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The statement of the method runs on the rows of the tenant only
+	var titles []string
+	query := rlsCTE("sqlite", "documents", "tenant_id") + "SELECT title FROM documents WHERE id > ? ORDER BY id"
+	if err := db.Raw(query, 1, 0).Scan(&titles).Error; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(titles, ",") != "mine,also mine" {
+		t.Errorf("tenant 1 reads %v", titles)
+	}
+}
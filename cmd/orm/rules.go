@@ -0,0 +1,578 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"slices"
+	"strings"
+
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+)
+
+// ruleSet is the parsed form of the rule options.
+// It is built by parseRules without touching the generator, so the rules can
+// be parsed any number of times before being applied with applyRules.
+type ruleSet struct {
+	// global model options, applied to every table
+	global []gen.ModelOpt
+	// table-specific JSON tag renames, column -> tag
+	retags map[string][][2]string
+	// table-specific gorm column renames, column -> column
+	regormtags map[string][][2]string
+	// table-specific ignored columns
+	ignores map[string][]string
+	// ignore rule of each ignored column by table, "*" for all tables
+	ignoreRules map[string]map[string]string
+	// only columns generated by table, "*" for the tables without a rule,
+	// and their rule
+	onlyFields map[string]map[string]bool
+	onlyRules  map[string]string
+	// table-specific data type mapping
+	types map[string]map[string]DataTypeFn
+	// global data type mapping
+	globalTypes map[string]DataTypeFn
+	// global data type mapping receiving the table, bound per table
+	globalTableTypes map[string]TableDataTypeFn
+	// file name by table name
+	rename map[string]string
+	// redacted columns by table, "*" for all tables
+	redact map[string][]string
+	// explicit field names by table and column, "*" for all tables
+	fieldRenames map[string]map[string]string
+	// file name abbreviations by word
+	abbreviations map[string]string
+	// table-specific serializer fields, column -> serializer, Go type
+	serializers map[string][][3]string
+	// import paths of the types of WithJSONType, sorted
+	jsonImports []string
+	// auto time columns by table, column -> mode, "*" for all tables
+	autoTimes map[string][][2]string
+	// soft delete columns by table, "*" for all tables
+	softDeletes map[string][]string
+	// pointer (true) or value (false) fields by table and column, "*" for
+	// all tables
+	nullable map[string]map[string]bool
+	// columns with reGromTags rules by table, "*" for all tables
+	gormTagged map[string]map[string]bool
+	// columns with retag rules by table, "*" for all tables
+	retagged map[string]map[string]bool
+	// columns left out of JSON by table, "*" for all tables
+	jsonOmit map[string][]string
+	// qualified is set when schema-qualified tables are selected
+	qualified bool
+	// prefix is the table prefix left out of the file names
+	prefix string
+}
+
+// serializerTypes are the Go types of serializer fields without an explicit
+// WithSerializerType entry. Other serializers require one.
+var serializerTypes = map[string]string{
+	"json":     "map[string]any",
+	"unixtime": "time.Time",
+}
+
+// parseRules parses the rule options into a ruleSet.
+func (o *Orm) parseRules() (ruleSet, error) {
+	rs := ruleSet{
+		retags:           make(map[string][][2]string),
+		regormtags:       make(map[string][][2]string),
+		ignores:          make(map[string][]string),
+		ignoreRules:      make(map[string]map[string]string),
+		onlyFields:       make(map[string]map[string]bool),
+		onlyRules:        make(map[string]string),
+		types:            make(map[string]map[string]DataTypeFn),
+		globalTypes:      make(map[string]DataTypeFn),
+		globalTableTypes: make(map[string]TableDataTypeFn),
+		rename:           o.opt.rename,
+		abbreviations:    o.opt.abbreviations,
+		redact:           make(map[string][]string),
+		fieldRenames:     make(map[string]map[string]string),
+		serializers:      make(map[string][][3]string),
+		autoTimes:        make(map[string][][2]string),
+		softDeletes:      make(map[string][]string),
+		nullable:         make(map[string]map[string]bool),
+		gormTagged:       make(map[string]map[string]bool),
+		retagged:         make(map[string]map[string]bool),
+		jsonOmit:         make(map[string][]string),
+	}
+
+	// Process retag options
+	for _, retag := range o.opt.retags {
+		parts := strings.Split(retag, "->")
+		if len(parts) != 3 {
+			return ruleSet{}, &RuleSyntaxError{Rule: retag, Reason: "expected table->column->tag"}
+		}
+		if _, ok := rs.retagged[parts[0]]; !ok {
+			rs.retagged[parts[0]] = make(map[string]bool)
+		}
+		rs.retagged[parts[0]][parts[1]] = true
+		// Global retag
+		if parts[0] == "*" {
+			rs.global = append(rs.global, o.retagOpt(parts[1], parts[2]))
+			continue
+		}
+		rs.retags[parts[0]] = append(rs.retags[parts[0]], [2]string{parts[1], parts[2]})
+	}
+
+	// Process reGromTag options
+	for _, retag := range o.opt.reGromTags {
+		parts := strings.Split(retag, "->")
+		if len(parts) != 3 {
+			return ruleSet{}, &RuleSyntaxError{Rule: retag, Reason: "expected table->column->tag"}
+		}
+		if strings.TrimSpace(parts[2]) == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: retag, Reason: "empty gorm column name, use - to drop the column from gorm"}
+		}
+		if _, ok := rs.gormTagged[parts[0]]; !ok {
+			rs.gormTagged[parts[0]] = make(map[string]bool)
+		}
+		rs.gormTagged[parts[0]][parts[1]] = true
+		// Global reGromTag
+		if parts[0] == "*" {
+			rs.global = append(rs.global, gen.FieldGORMTag(parts[1], func(tag field.GormTag) field.GormTag {
+				return tag.Set(field.TagKeyGormColumn, parts[2])
+			}))
+			continue
+		}
+		rs.regormtags[parts[0]] = append(rs.regormtags[parts[0]], [2]string{parts[1], parts[2]})
+	}
+
+	// Process ignore options
+	for _, ignore := range o.opt.ignore {
+		parts := strings.Split(ignore, "->")
+		if len(parts) != 2 {
+			return ruleSet{}, &RuleSyntaxError{Rule: ignore, Reason: "expected table->column[,column]"}
+		}
+		fields := strings.Split(parts[1], ",")
+		if _, ok := rs.ignoreRules[parts[0]]; !ok {
+			rs.ignoreRules[parts[0]] = make(map[string]string)
+		}
+		for _, f := range fields {
+			rs.ignoreRules[parts[0]][f] = ignore
+		}
+		if parts[0] == "*" {
+			rs.global = append(rs.global, gen.FieldIgnore(fields...))
+			continue
+		}
+		rs.ignores[parts[0]] = append(rs.ignores[parts[0]], fields...)
+	}
+
+	// Process only fields options, one rule per table
+	for _, only := range o.opt.onlyFields {
+		parts := strings.Split(only, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: only, Reason: "expected table->column[,column]"}
+		}
+		if r, ok := rs.onlyRules[parts[0]]; ok {
+			return ruleSet{}, &RuleSyntaxError{Rule: only, Reason: "conflicts with " + r + ", list the columns of a table in one rule"}
+		}
+		rs.onlyRules[parts[0]] = only
+		rs.onlyFields[parts[0]] = make(map[string]bool)
+		for _, f := range strings.Split(parts[1], ",") {
+			rs.onlyFields[parts[0]][f] = true
+		}
+	}
+
+	// Process JSON omit options
+	for _, omit := range o.opt.jsonOmit {
+		parts := strings.Split(omit, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: omit, Reason: "expected table->column[,column]"}
+		}
+		rs.jsonOmit[parts[0]] = append(rs.jsonOmit[parts[0]], strings.Split(parts[1], ",")...)
+	}
+
+	// Process nullable options, a column cannot be both for the same tables
+	for _, rules := range []struct {
+		list    []string
+		pointer bool
+	}{{o.opt.nullable, true}, {o.opt.notNullable, false}} {
+		for _, rule := range rules.list {
+			parts := strings.Split(rule, "->")
+			if len(parts) != 2 || parts[1] == "" {
+				return ruleSet{}, &RuleSyntaxError{Rule: rule, Reason: "expected table->column[,column]"}
+			}
+			if _, ok := rs.nullable[parts[0]]; !ok {
+				rs.nullable[parts[0]] = make(map[string]bool)
+			}
+			for _, column := range strings.Split(parts[1], ",") {
+				if pointer, ok := rs.nullable[parts[0]][column]; ok && pointer != rules.pointer {
+					return ruleSet{}, &RuleSyntaxError{Rule: rule, Reason: "column " + column + " is listed by both WithNullable and WithNotNullable"}
+				}
+				rs.nullable[parts[0]][column] = rules.pointer
+			}
+		}
+	}
+
+	// Process redact options
+	for _, redact := range o.opt.redact {
+		parts := strings.Split(redact, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: redact, Reason: "expected table->column[,column]"}
+		}
+		rs.redact[parts[0]] = append(rs.redact[parts[0]], strings.Split(parts[1], ",")...)
+	}
+
+	// Process field rename options
+	for _, key := range sortedKeys(o.opt.fieldRename) {
+		name := o.opt.fieldRename[key]
+		parts := strings.Split(key, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected table->column"}
+		}
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "field name " + name + " is not an exported identifier"}
+		}
+		if _, ok := rs.fieldRenames[parts[0]]; !ok {
+			rs.fieldRenames[parts[0]] = make(map[string]string)
+		}
+		rs.fieldRenames[parts[0]][parts[1]] = name
+	}
+
+	// Process serializer options
+	for _, key := range sortedKeys(o.opt.serializer) {
+		serializer := o.opt.serializer[key]
+		parts := strings.Split(key, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected table->column"}
+		}
+		typ, ok := o.opt.serializerType[key]
+		if !ok {
+			typ, ok = serializerTypes[serializer]
+		}
+		if serializer == "" || !ok {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "serializer " + serializer + " needs a WithSerializerType entry"}
+		}
+		if parts[0] == "*" {
+			rs.global = append(rs.global, serializerOpts(parts[1], serializer, typ)...)
+			continue
+		}
+		rs.serializers[parts[0]] = append(rs.serializers[parts[0]], [3]string{parts[1], serializer, typ})
+	}
+
+	// Process JSON type options, serializer json fields of a qualified type
+	for _, key := range sortedKeys(o.opt.jsonType) {
+		value := o.opt.jsonType[key]
+		parts := strings.Split(key, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected table->column"}
+		}
+		if _, ok := o.opt.serializer[key]; ok {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "column has both a serializer and a JSON type"}
+		}
+		typ, path, err := parseJSONType(value)
+		if err != nil {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: err.Error()}
+		}
+		if path != "" && !slices.Contains(rs.jsonImports, path) {
+			rs.jsonImports = append(rs.jsonImports, path)
+		}
+		if parts[0] == "*" {
+			rs.global = append(rs.global, serializerOpts(parts[1], "json", typ)...)
+			continue
+		}
+		rs.serializers[parts[0]] = append(rs.serializers[parts[0]], [3]string{parts[1], "json", typ})
+	}
+	slices.Sort(rs.jsonImports)
+
+	// Process auto time options
+	for _, key := range sortedKeys(o.opt.autoTime) {
+		mode := o.opt.autoTime[key]
+		parts := strings.Split(key, "->")
+		if len(parts) != 2 || parts[1] == "" {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected table->column"}
+		}
+		if _, _, ok := parseAutoTime(mode); !ok {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "auto time " + mode + " must be create or update, optionally with :milli or :nano"}
+		}
+		rs.autoTimes[parts[0]] = append(rs.autoTimes[parts[0]], [2]string{parts[1], mode})
+	}
+
+	// Process soft delete options, a bare column applying to all tables
+	softDelete := o.opt.softDelete
+	if len(softDelete) == 0 {
+		softDelete = defaultSoftDelete
+	}
+	for _, key := range softDelete {
+		table, column, ok := strings.Cut(key, "->")
+		if !ok {
+			table, column = "*", key
+		}
+		if table == "" || column == "" || strings.Contains(column, "->") {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected column or table->column"}
+		}
+		rs.softDeletes[table] = append(rs.softDeletes[table], column)
+	}
+
+	// Process data type mapping options
+	for _, key := range sortedKeys(o.opt.dataType) {
+		typ := o.opt.dataType[key]
+		parts := strings.Split(key, "->")
+		if len(parts) != 2 {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected table->type"}
+		}
+		// Global data type mapping
+		if parts[0] == "*" {
+			rs.globalTypes[parts[1]] = typ
+			continue
+		}
+
+		// Table-specific data type mapping
+		if _, ok := rs.types[parts[0]]; !ok {
+			rs.types[parts[0]] = make(map[string]DataTypeFn)
+		}
+		rs.types[parts[0]][parts[1]] = typ
+	}
+	for _, key := range sortedKeys(o.opt.tableDataType) {
+		typ := o.opt.tableDataType[key]
+		parts := strings.Split(key, "->")
+		if len(parts) != 2 {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "expected table->type"}
+		}
+		if _, ok := o.opt.dataType[key]; ok {
+			return ruleSet{}, &RuleSyntaxError{Rule: key, Reason: "mapped by both WithDataType and WithTableDataType"}
+		}
+		if parts[0] == "*" {
+			rs.globalTableTypes[parts[1]] = typ
+			continue
+		}
+		if _, ok := rs.types[parts[0]]; !ok {
+			rs.types[parts[0]] = make(map[string]DataTypeFn)
+		}
+		rs.types[parts[0]][parts[1]] = bindTable(typ, parts[0])
+	}
+
+	return rs, nil
+}
+
+// bindTable returns the DataTypeFn of fn for the columns of table.
+func bindTable(fn TableDataTypeFn, table string) DataTypeFn {
+	return func(column gorm.ColumnType) string {
+		return fn(table, column)
+	}
+}
+
+// serializerOpts returns the options mapping a column to a Go type through
+// a gorm serializer, such as a JSON column to a []Item field.
+func serializerOpts(column, serializer, typ string) []gen.ModelOpt {
+	return []gen.ModelOpt{
+		gen.FieldType(column, typ),
+		gen.FieldGORMTag(column, func(tag field.GormTag) field.GormTag {
+			return tag.Set("serializer", serializer)
+		}),
+	}
+}
+
+// parseJSONType splits the type of a WithJSONType entry, a qualified
+// identifier optionally behind * and [] and optionally qualified by the
+// import path of its package, into the Go type of the field and the import
+// path, empty when the package is given by name only:
+//
+//	*example.com/app/types.Address -> *types.Address, example.com/app/types
+//	[]types.Tag                    -> []types.Tag
+func parseJSONType(value string) (typ, path string, err error) {
+	rest, prefix := strings.TrimSpace(value), ""
+	for {
+		if strings.HasPrefix(rest, "*") {
+			prefix, rest = prefix+"*", rest[1:]
+		} else if strings.HasPrefix(rest, "[]") {
+			prefix, rest = prefix+"[]", rest[2:]
+		} else {
+			break
+		}
+	}
+
+	dot, slash := strings.LastIndex(rest, "."), strings.LastIndex(rest, "/")
+	if dot < slash+1 {
+		return "", "", fmt.Errorf("type %q is not a qualified identifier such as *types.Address", value)
+	}
+	if slash >= 0 {
+		path = rest[:dot]
+		if strings.ContainsAny(path, " \t\"\\") || slices.Contains(strings.Split(path, "/"), "") {
+			return "", "", fmt.Errorf("type %q has an invalid import path %q", value, path)
+		}
+	}
+	typ = prefix + rest[slash+1:]
+
+	expr, err := parser.ParseExpr(typ)
+	for err == nil {
+		if star, ok := expr.(*ast.StarExpr); ok {
+			expr = star.X
+		} else if array, ok := expr.(*ast.ArrayType); ok && array.Len == nil {
+			expr = array.Elt
+		} else {
+			break
+		}
+	}
+	if sel, ok := expr.(*ast.SelectorExpr); err != nil || !ok || !token.IsExported(sel.Sel.Name) {
+		return "", "", fmt.Errorf("type %q is not a qualified identifier such as *types.Address", value)
+	} else if _, ok := sel.X.(*ast.Ident); !ok {
+		return "", "", fmt.Errorf("the package of type %q is not an identifier", value)
+	}
+	return typ, path, nil
+}
+
+// jsonOmitOpts returns the options dropping the omitted columns of a table
+// from JSON. They come after the retag rules, which lose with a warning.
+func (o *Orm) jsonOmitOpts(table string) []gen.ModelOpt {
+	var opts []gen.ModelOpt
+	for _, key := range []string{"*", table} {
+		for _, column := range o.rules.jsonOmit[key] {
+			if o.rules.retagged[table][column] || o.rules.retagged["*"][column] {
+				o.warn(warnRules, "Warning: retag of %s.%s ignored, the column is omitted from JSON\n", table, column)
+			}
+			opts = append(opts, gen.FieldJSONTag(column, "-"))
+		}
+	}
+	return opts
+}
+
+// nullableOpts returns the options making the fields of the WithNullable
+// columns of a table pointers and those of the WithNotNullable columns
+// values. The rules of the table win over the "*" rules.
+func (o *Orm) nullableOpts(table string) []gen.ModelOpt {
+	pointers := make(map[string]bool)
+	maps.Copy(pointers, o.rules.nullable["*"])
+	maps.Copy(pointers, o.rules.nullable[table])
+	if len(pointers) == 0 {
+		return nil
+	}
+	return []gen.ModelOpt{gen.FieldModify(func(f gen.Field) gen.Field {
+		pointer, ok := pointers[f.ColumnName]
+		switch {
+		case !ok:
+		case pointer && !strings.HasPrefix(f.Type, "*"):
+			f.Type = "*" + f.Type
+		case !pointer:
+			f.Type = strings.TrimPrefix(f.Type, "*")
+		}
+		return f
+	})}
+}
+
+// applyRules applies the generator-wide parts of a ruleSet.
+// Applying the same ruleSet again leaves the generator unchanged.
+// With schemaPrefix the files of schema-qualified tables are prefixed with
+// their schema, else they are named after the bare table.
+func applyRules(g *gen.Generator, rs ruleSet, schemaPrefix bool) {
+	// Process rename and abbreviation options
+	if len(rs.rename) == 0 && len(rs.abbreviations) == 0 && !rs.qualified && rs.prefix == "" {
+		return
+	}
+	g.WithFileNameStrategy(func(tableName string) (fileName string) {
+		if name, ok := rs.rename[tableName]; ok {
+			return name
+		}
+		if schema, table := splitTable(tableName); schema != "" {
+			if name, ok := rs.rename[table]; ok && schemaPrefix {
+				return strings.ToLower(schema) + "_" + name
+			} else if ok {
+				return name
+			}
+		}
+		return qualifiedFileName(trimTablePrefix(tableName, rs.prefix), rs.abbreviations, schemaPrefix)
+	})
+}
+
+// ignoreRule returns the ignore rule dropping a column of table, preferring
+// table-specific rules, or "" when the column is kept.
+func (o *Orm) ignoreRule(table, column string) string {
+	if r, ok := o.rules.ignoreRules[table][column]; ok {
+		return r
+	}
+	return o.rules.ignoreRules["*"][column]
+}
+
+// onlyRule returns the only fields rule of table, preferring the rule of the
+// table over the "*" one, empty when there is none.
+func (o *Orm) onlyRule(table string) string {
+	if r, ok := o.rules.onlyRules[table]; ok {
+		return r
+	}
+	return o.rules.onlyRules["*"]
+}
+
+// leftOut returns the columns of table its only fields rule leaves out.
+func (o *Orm) leftOut(table string) []string {
+	only, ok := o.rules.onlyFields[table]
+	if !ok {
+		only, ok = o.rules.onlyFields["*"]
+	}
+	if !ok {
+		return nil
+	}
+	var left []string
+	for _, col := range o.columns[table] {
+		if !only[col.Name] {
+			left = append(left, col.Name)
+		}
+	}
+	return left
+}
+
+// dropRule returns the rule dropping a column of table, an ignore rule or
+// an only fields rule leaving it out, empty when the column is generated.
+func (o *Orm) dropRule(table, column string) string {
+	if r := o.ignoreRule(table, column); r != "" {
+		return r
+	}
+	if slices.Contains(o.leftOut(table), column) {
+		return o.onlyRule(table)
+	}
+	return ""
+}
+
+// checkIgnores warns when the ignore and only fields rules drop a column of
+// the primary key, composite or not, or every column of a table, naming the
+// responsible rule. With strict set the warning is returned as an error
+// instead.
+func (o *Orm) checkIgnores(table string, strict bool) error {
+	columns := o.columns[table]
+	if len(columns) == 0 {
+		return nil
+	}
+	var problems []*RuleSyntaxError
+	dropped := 0
+	for _, col := range columns {
+		r := o.dropRule(table, col.Name)
+		if r == "" {
+			continue
+		}
+		dropped++
+		if col.primaryKey() {
+			problems = append(problems, &RuleSyntaxError{Rule: r, Reason: fmt.Sprintf("drops primary key column %s of table %s", col.Name, table)})
+		}
+	}
+	if dropped == len(columns) {
+		problems = append(problems, &RuleSyntaxError{Rule: o.dropRule(table, columns[0].Name), Reason: fmt.Sprintf("with the other rules drops every column of table %s", table)})
+	}
+
+	for _, p := range problems {
+		if strict {
+			return p
+		}
+		o.warn(warnRules, "%s\n", cmd.T("orm.ignore_rule_warning", p.Rule, p.Reason))
+	}
+	return nil
+}
@@ -0,0 +1,434 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestParseRulesErrors(t *testing.T) {
+	text := func(gorm.ColumnType) string { return "string" }
+	tableText := func(string, gorm.ColumnType) string { return "string" }
+
+	tests := []struct {
+		name   string
+		opt    OrmOption
+		rule   string
+		reason string
+	}{
+		{
+			name:   "retag without tag",
+			opt:    OrmOption{retags: []string{"users->name"}},
+			rule:   "users->name",
+			reason: "expected table->column->tag",
+		},
+		{
+			name:   "regormtag without tag",
+			opt:    OrmOption{reGromTags: []string{"users->name"}},
+			rule:   "users->name",
+			reason: "expected table->column->tag",
+		},
+		{
+			name:   "regormtag with a blank tag",
+			opt:    OrmOption{reGromTags: []string{"users->name-> "}},
+			rule:   "users->name-> ",
+			reason: "empty gorm column name, use - to drop the column from gorm",
+		},
+		{
+			name:   "ignore without column",
+			opt:    OrmOption{ignore: []string{"users"}},
+			rule:   "users",
+			reason: "expected table->column[,column]",
+		},
+		{
+			name:   "json omit with an empty column",
+			opt:    OrmOption{jsonOmit: []string{"users->"}},
+			rule:   "users->",
+			reason: "expected table->column[,column]",
+		},
+		{
+			name:   "nullable without column",
+			opt:    OrmOption{nullable: []string{"users"}},
+			rule:   "users",
+			reason: "expected table->column[,column]",
+		},
+		{
+			name:   "not nullable with an empty column",
+			opt:    OrmOption{notNullable: []string{"users->"}},
+			rule:   "users->",
+			reason: "expected table->column[,column]",
+		},
+		{
+			name:   "nullable and not nullable",
+			opt:    OrmOption{nullable: []string{"users->nickname"}, notNullable: []string{"users->age,nickname"}},
+			rule:   "users->age,nickname",
+			reason: "column nickname is listed by both WithNullable and WithNotNullable",
+		},
+		{
+			name:   "redact without column",
+			opt:    OrmOption{redact: []string{"users"}},
+			rule:   "users",
+			reason: "expected table->column[,column]",
+		},
+		{
+			name:   "field rename without column",
+			opt:    OrmOption{fieldRename: map[string]string{"users->": "Name"}},
+			rule:   "users->",
+			reason: "expected table->column",
+		},
+		{
+			name:   "field rename to an unexported name",
+			opt:    OrmOption{fieldRename: map[string]string{"users->type": "kind"}},
+			rule:   "users->type",
+			reason: "field name kind is not an exported identifier",
+		},
+		{
+			name:   "field rename to an invalid identifier",
+			opt:    OrmOption{fieldRename: map[string]string{"users->2fa": "2FA"}},
+			rule:   "users->2fa",
+			reason: "field name 2FA is not an exported identifier",
+		},
+		{
+			name:   "serializer without column",
+			opt:    OrmOption{serializer: map[string]string{"orders": "json"}},
+			rule:   "orders",
+			reason: "expected table->column",
+		},
+		{
+			name:   "serializer without type",
+			opt:    OrmOption{serializer: map[string]string{"orders->items": "gob"}},
+			rule:   "orders->items",
+			reason: "serializer gob needs a WithSerializerType entry",
+		},
+		{
+			name:   "JSON type without column",
+			opt:    OrmOption{jsonType: map[string]string{"users": "types.Address"}},
+			rule:   "users",
+			reason: "expected table->column",
+		},
+		{
+			name:   "JSON type and serializer",
+			opt:    OrmOption{serializer: map[string]string{"users->address": "json"}, jsonType: map[string]string{"users->address": "types.Address"}},
+			rule:   "users->address",
+			reason: "column has both a serializer and a JSON type",
+		},
+		{
+			name:   "JSON type not qualified",
+			opt:    OrmOption{jsonType: map[string]string{"users->address": "Address"}},
+			rule:   "users->address",
+			reason: `type "Address" is not a qualified identifier such as *types.Address`,
+		},
+		{
+			name:   "JSON type unexported",
+			opt:    OrmOption{jsonType: map[string]string{"users->address": "types.address"}},
+			rule:   "users->address",
+			reason: `type "types.address" is not a qualified identifier such as *types.Address`,
+		},
+		{
+			name:   "JSON type with an invalid import path",
+			opt:    OrmOption{jsonType: map[string]string{"users->address": "example.com//types.Address"}},
+			rule:   "users->address",
+			reason: `type "example.com//types.Address" has an invalid import path "example.com//types"`,
+		},
+		{
+			name:   "JSON type of a nested package",
+			opt:    OrmOption{jsonType: map[string]string{"users->address": "types.geo.Address"}},
+			rule:   "users->address",
+			reason: `the package of type "types.geo.Address" is not an identifier`,
+		},
+		{
+			name:   "auto time without column",
+			opt:    OrmOption{autoTime: map[string]string{"users": "create"}},
+			rule:   "users",
+			reason: "expected table->column",
+		},
+		{
+			name:   "auto time of an unknown mode",
+			opt:    OrmOption{autoTime: map[string]string{"users->deleted_ts": "delete"}},
+			rule:   "users->deleted_ts",
+			reason: "auto time delete must be create or update, optionally with :milli or :nano",
+		},
+		{
+			name:   "soft delete without table",
+			opt:    OrmOption{softDelete: []string{"->deleted_at"}},
+			rule:   "->deleted_at",
+			reason: "expected column or table->column",
+		},
+		{
+			name:   "soft delete with three parts",
+			opt:    OrmOption{softDelete: []string{"users->deleted_at->x"}},
+			rule:   "users->deleted_at->x",
+			reason: "expected column or table->column",
+		},
+		{
+			name:   "data type without type",
+			opt:    OrmOption{dataType: map[string]DataTypeFn{"users": text}},
+			rule:   "users",
+			reason: "expected table->type",
+		},
+		{
+			name:   "table data type without type",
+			opt:    OrmOption{tableDataType: map[string]TableDataTypeFn{"users": tableText}},
+			rule:   "users",
+			reason: "expected table->type",
+		},
+		{
+			name:   "data type and table data type",
+			opt:    OrmOption{dataType: map[string]DataTypeFn{"users->json": text}, tableDataType: map[string]TableDataTypeFn{"users->json": tableText}},
+			rule:   "users->json",
+			reason: "mapped by both WithDataType and WithTableDataType",
+		},
+		{
+			name:   "first of several bad renames",
+			opt:    OrmOption{fieldRename: map[string]string{"users->name": "name", "orders->id": "id", "items->sku": "sku"}},
+			rule:   "items->sku",
+			reason: "field name sku is not an exported identifier",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newOrm(tt.opt).parseRules()
+			var syntax *RuleSyntaxError
+			if !errors.As(err, &syntax) {
+				t.Fatalf("parseRules error = %v, want a RuleSyntaxError", err)
+			}
+			if syntax.Rule != tt.rule || syntax.Reason != tt.reason {
+				t.Errorf("parseRules error = %q %q, want %q %q", syntax.Rule, syntax.Reason, tt.rule, tt.reason)
+			}
+		})
+	}
+}
+
+func TestParseRulesValid(t *testing.T) {
+	o := newOrm(OrmOption{
+		retags:      []string{"*->created_at->createdAt", "users->name->userName"},
+		reGromTags:  []string{"users->name->user_name", "*->legacy->-"},
+		ignore:      []string{"*->tmp", "users->salt,otp"},
+		jsonOmit:    []string{"*->password_hash"},
+		nullable:    []string{"users->nickname"},
+		notNullable: []string{"orders->coupon_id"},
+		redact:      []string{"users->email,phone"},
+		fieldRename: map[string]string{"*->2fa_enabled": "TwoFactorEnabled"},
+		serializer:  map[string]string{"orders->items": "json"},
+		jsonType:    map[string]string{"users->address": "*example.com/app/types.Address"},
+		autoTime:    map[string]string{"*->updated_ts": "update:milli"},
+		softDelete:  []string{"removed_at", "users->deleted_at"},
+	})
+	first, err := o.parseRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Parsing again gives the same rules, never accumulating global options
+	second, err := o.parseRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.global) != len(second.global) || len(first.global) != 3 {
+		t.Errorf("global options = %d then %d, want 3", len(first.global), len(second.global))
+	}
+	if got := first.ignores["users"]; len(got) != 2 {
+		t.Errorf("ignores of users = %v", got)
+	}
+	if got := first.softDeletes["*"]; len(got) != 1 || got[0] != "removed_at" {
+		t.Errorf("soft deletes of * = %v", got)
+	}
+	if got := first.jsonImports; len(got) != 1 || got[0] != "example.com/app/types" {
+		t.Errorf("JSON imports = %v", got)
+	}
+}
+
+func TestCheckIgnores(t *testing.T) {
+	keyed := []columnMeta{{Name: "id", Key: "PRI"}, {Name: "name"}}
+	keyless := []columnMeta{{Name: "event"}, {Name: "payload"}}
+	composite := []columnMeta{{Name: "user_id", Key: "PRI"}, {Name: "role_id", Key: "PRI"}, {Name: "granted_at"}}
+	tests := []struct {
+		name    string
+		columns []columnMeta
+		ignore  []string
+		only    []string
+		rule    string
+		reason  string
+	}{
+		{
+			name:    "primary key",
+			columns: keyed,
+			ignore:  []string{"users->id"},
+			rule:    "users->id",
+			reason:  "drops primary key column id of table users",
+		},
+		{
+			name:    "every column",
+			columns: keyless,
+			ignore:  []string{"*->event", "users->payload"},
+			rule:    "*->event",
+			reason:  "with the other rules drops every column of table users",
+		},
+		{
+			name:    "some columns",
+			columns: keyed,
+			ignore:  []string{"*->name"},
+		},
+		{
+			name:    "composite key",
+			columns: composite,
+			ignore:  []string{"*->role_id"},
+			rule:    "*->role_id",
+			reason:  "drops primary key column role_id of table users",
+		},
+		{
+			name:    "only fields without the key",
+			columns: keyed,
+			only:    []string{"users->name"},
+			rule:    "users->name",
+			reason:  "drops primary key column id of table users",
+		},
+		{
+			name:    "only fields without part of a composite key",
+			columns: composite,
+			only:    []string{"*->user_id,granted_at"},
+			rule:    "*->user_id,granted_at",
+			reason:  "drops primary key column role_id of table users",
+		},
+		{
+			name:    "only fields with the ignore rules",
+			columns: keyless,
+			ignore:  []string{"users->event"},
+			only:    []string{"users->event"},
+			rule:    "users->event",
+			reason:  "with the other rules drops every column of table users",
+		},
+		{
+			name:    "only fields of the table over the global ones",
+			columns: composite,
+			only:    []string{"*->granted_at", "users->user_id,role_id"},
+		},
+		{
+			name:    "only fields of another table",
+			columns: keyed,
+			only:    []string{"orders->id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOrm(OrmOption{ignore: tt.ignore, onlyFields: tt.only})
+			o.columns = map[string][]columnMeta{"users": tt.columns}
+			rules, err := o.parseRules()
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.rules = rules
+
+			err = o.checkIgnores("users", true)
+			if tt.rule == "" {
+				if err != nil {
+					t.Errorf("checkIgnores = %v", err)
+				}
+				return
+			}
+			var syntax *RuleSyntaxError
+			if !errors.As(err, &syntax) || syntax.Rule != tt.rule || syntax.Reason != tt.reason {
+				t.Errorf("checkIgnores = %v, want %q %q", err, tt.rule, tt.reason)
+			}
+		})
+	}
+}
+
+func TestOnlyFields(t *testing.T) {
+	files := generate(t, openFixture(t, "users"), []string{"-t", "users"}, WithOnlyFields([]string{"users->id,name,email"}))
+	model := files["model/users.gen.go"]
+	for _, field := range []string{"ID", "Name", "Email"} {
+		if fieldLine(model, field) == "" {
+			t.Errorf("field %s missing:\n%s", field, model)
+		}
+	}
+	for _, field := range []string{"Phone", "PasswordHash", "CreatedAt"} {
+		if line := fieldLine(model, field); line != "" {
+			t.Errorf("field left out generated: %s", line)
+		}
+	}
+}
+
+func TestOnlyFieldsConflict(t *testing.T) {
+	o := newOrm(OrmOption{onlyFields: []string{"users->id", "users->name"}})
+	var syntax *RuleSyntaxError
+	if _, err := o.parseRules(); !errors.As(err, &syntax) || syntax.Rule != "users->name" {
+		t.Errorf("parseRules = %v, want a conflict of users->name", err)
+	}
+}
+
+func TestRegormTags(t *testing.T) {
+	rs, err := (&Orm{opt: OrmOption{
+		retags:     []string{"customers->name->full_name"},
+		reGromTags: []string{"customers->email->mail", "orders->total->amount", "*->status->state"},
+	}}).parseRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rs.regormtags["customers"]; len(got) != 1 || got[0] != [2]string{"email", "mail"} {
+		t.Errorf("customers regormtags = %v", got)
+	}
+	if got := rs.retags["customers"]; len(got) != 1 || got[0] != [2]string{"name", "full_name"} {
+		t.Errorf("customers retags = %v", got)
+	}
+
+	tests := []struct {
+		name  string
+		rules []string
+		// tags are the expected gorm tags by table and field
+		tags map[string]map[string]string
+	}{
+		{
+			name:  "global",
+			rules: []string{"*->status->state"},
+			tags: map[string]map[string]string{
+				"customers": {"Status": "column:state;not null", "Email": "column:email;not null"},
+				"orders":    {"Status": "column:state;not null"},
+			},
+		},
+		{
+			name:  "table",
+			rules: []string{"customers->email->mail"},
+			tags: map[string]map[string]string{
+				"customers": {"Email": "column:mail;not null", "Status": "column:status;not null"},
+				"orders":    {"Status": "column:status;not null"},
+			},
+		},
+		{
+			name:  "global and table",
+			rules: []string{"*->status->state", "orders->status->order_state", "customers->email->mail"},
+			tags: map[string]map[string]string{
+				"customers": {"Email": "column:mail;not null", "Status": "column:state;not null"},
+				"orders":    {"Status": "column:order_state;not null", "Total": "column:total;not null"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generate(t, openFixture(t, "shop"), []string{"-t", "customers", "-t", "orders"}, WithReGromTags(tt.rules))
+			for table, fields := range tt.tags {
+				for name, tag := range fields {
+					if line := fieldLine(files["model/"+table+".gen.go"], name); !strings.Contains(line, `gorm:"`+tag+`"`) {
+						t.Errorf("%s.%s = %q, want gorm:%q", table, name, line, tag)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bufio"
+	"bytes"
+	"command/cmd"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// auditShare is the share of the tables above which an audit column is
+// suggested to be ignored everywhere.
+const auditShare = 0.8
+
+var (
+	// trackingColumns are the audit columns recording who changed a row and
+	// from where.
+	trackingColumns = []string{"created_by", "updated_by", "deleted_by", "modified_by", "last_modified_by", "changed_by", "created_ip", "updated_ip"}
+	// softDeleteNames are the usual names of soft delete columns.
+	softDeleteNames = []string{"deleted_at", "deleted_on", "deleted_time", "delete_time", "removed_at"}
+	// suggestionKeys are the list keys of the config file a suggestion adds
+	// entries to, in the order they are printed.
+	suggestionKeys = []string{"ignore", "retags", "soft_delete"}
+)
+
+// ruleSuggestion is a rule proposed from the schema with the columns that
+// triggered it.
+type ruleSuggestion struct {
+	Reason string
+	// Triggers are the table.column the suggestion is made for
+	Triggers []string
+	// Rules are the entries added to the list keys of the config file
+	Rules map[string][]string
+	// Settings are the flag values added to the settings section
+	Settings map[string]string
+	// Note is printed for suggestions without a rule to add yet
+	Note string
+}
+
+// rulesCommand returns the orm rules subcommand.
+func (o *Orm) rulesCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "rules",
+		Short: "Work with the generation rules",
+		Args:  cobra.NoArgs,
+	}
+	c.AddCommand(o.rulesSuggestCommand())
+	return c
+}
+
+// rulesSuggestCommand returns the orm rules suggest subcommand.
+func (o *Orm) rulesSuggestCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "suggest",
+		Short: "Propose ignore, retag and soft delete rules from the schema",
+		Long: `Introspect the listed tables, or all tables, and propose rules for them:
+audit columns found in more than 80% of the tables are ignored, nullable
+timestamps named like deleted_at delete softly, the JSON tags of the columns
+not following the naming of most columns are retagged, columns marked
+[deprecated] are excluded and enum columns are listed. Each suggestion shows
+the columns it was made for and is accepted or vetoed on the terminal, or
+accepted as a whole with --auto. The accepted rules are printed in config
+file syntax, and merged into the YAML config file of --config with --write.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Review the suggestions for every table
+command orm rules suggest
+
+# Accept every suggestion for the order tables and merge them into ./czx.yaml
+command orm rules suggest -t 'order*' --auto --write`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).rulesSuggest),
+	}
+	c.Flags().StringArrayP("tables", "t", nil, cmd.T("orm.rules.suggest.flag.tables"))
+	c.Flags().Bool("auto", false, cmd.T("orm.rules.suggest.flag.auto"))
+	c.Flags().Bool("write", false, cmd.T("orm.rules.suggest.flag.write"))
+	// The deprecated setting of an accepted suggestion is read back
+	c.Flags().String("deprecated", deprecatedComment, cmd.T("orm.flag.deprecated"))
+	return c
+}
+
+// rulesSuggest is the execution logic for the orm rules suggest command.
+func (o *Orm) rulesSuggest(c *cobra.Command, _ []string) error {
+	tables, err := c.Flags().GetStringArray("tables")
+	if err != nil {
+		return err
+	}
+	auto, err := c.Flags().GetBool("auto")
+	if err != nil {
+		return err
+	}
+	write, err := c.Flags().GetBool("write")
+	if err != nil {
+		return err
+	}
+	path, err := c.Flags().GetString("config")
+	if err != nil {
+		return err
+	}
+	if write && (path == "" || isTOML(path)) {
+		return usage("suggesting rules", errors.New("--write merges into a YAML config file, set --config to one"))
+	}
+	if !auto && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return usage("suggesting rules", errors.New("reviewing the suggestions needs a terminal, accept them all with --auto instead"))
+	}
+
+	closeConn, err := o.prepare(c)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	if o.deprecatedMode, err = c.Flags().GetString("deprecated"); err != nil {
+		return err
+	}
+	o.introspectBatch = 50
+	_, selected, err := o.selectTables(tables)
+	if err != nil {
+		return fail("suggesting rules", err)
+	}
+	var names []string
+	for _, entry := range selected {
+		name, _, _ := strings.Cut(entry, "@")
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	suggestions := o.suggestRules(names)
+	if len(suggestions) == 0 {
+		color.Green("The rules already fit the %d tables, nothing to suggest\n", len(names))
+		return nil
+	}
+	accepted := suggestions
+	if !auto {
+		if accepted, err = reviewSuggestions(suggestions); err != nil {
+			return fail("suggesting rules", err)
+		}
+	}
+	if len(accepted) == 0 {
+		color.Yellow("No suggestion accepted\n")
+		return nil
+	}
+
+	fmt.Print(suggestionBlock(accepted, isTOML(path)))
+	if !write {
+		return nil
+	}
+	if err := mergeSuggestions(path, accepted); err != nil {
+		return fail("writing the config", err)
+	}
+	color.Green("Merged %d suggestions into %s\n", len(accepted), path)
+	return nil
+}
+
+// suggestRules returns the rules suggested for the columns of tables, leaving
+// out what the current rules already cover.
+func (o *Orm) suggestRules(tables []string) []ruleSuggestion {
+	var suggestions []ruleSuggestion
+	ignored := func(table, column string) bool {
+		return o.rules.ignoreRules["*"][column] != "" || o.rules.ignoreRules[table][column] != ""
+	}
+
+	// Audit columns of most tables
+	for _, column := range trackingColumns {
+		var triggers []string
+		for _, table := range tables {
+			if slices.ContainsFunc(o.columns[table], func(c columnMeta) bool { return c.Name == column }) && !ignored(table, column) {
+				triggers = append(triggers, table+"."+column)
+			}
+		}
+		if len(triggers) > 0 && float64(len(triggers)) > auditShare*float64(len(tables)) {
+			suggestions = append(suggestions, ruleSuggestion{
+				Reason:   fmt.Sprintf("audit column %s is in %d of %d tables, ignore it", column, len(triggers), len(tables)),
+				Triggers: triggers,
+				Rules:    map[string][]string{"ignore": {"*->" + column}},
+			})
+		}
+	}
+
+	// Soft delete columns other than the default deleted_at
+	var softTriggers, softRules []string
+	for _, table := range tables {
+		for _, col := range o.columns[table] {
+			if !slices.Contains(softDeleteNames, col.Name) || !softDeleteType(col.DataType) || col.Nullable != "YES" {
+				continue
+			}
+			if o.rules.softDeleted(table, col.Name) || ignored(table, col.Name) {
+				continue
+			}
+			softTriggers = append(softTriggers, table+"."+col.Name)
+			softRules = append(softRules, table+"->"+col.Name)
+		}
+	}
+	if len(softRules) > 0 {
+		// The rules replace the default, which is kept when in use
+		if len(o.opt.softDelete) == 0 {
+			softRules = append([]string{defaultSoftDelete[0]}, softRules...)
+		}
+		suggestions = append(suggestions, ruleSuggestion{
+			Reason:   "nullable timestamps named like deleted_at, map them to gorm.DeletedAt",
+			Triggers: softTriggers,
+			Rules:    map[string][]string{"soft_delete": softRules},
+		})
+	}
+
+	// JSON tags following the naming of most columns
+	var snake, camel int
+	for _, table := range tables {
+		for _, col := range o.columns[table] {
+			switch columnNaming(col.Name) {
+			case "snake":
+				snake++
+			case "camel":
+				camel++
+			}
+		}
+	}
+	if snake > 0 && camel > 0 {
+		minority, convert, style := "camel", snakeCase, "snake_case"
+		if camel > snake {
+			minority, convert, style = "snake", lowerCamelCase, "lowerCamelCase"
+		}
+		var triggers, retags []string
+		for _, table := range tables {
+			for _, col := range o.columns[table] {
+				if columnNaming(col.Name) != minority || o.rules.retagged[table][col.Name] || o.rules.retagged["*"][col.Name] || ignored(table, col.Name) {
+					continue
+				}
+				triggers = append(triggers, table+"."+col.Name)
+				retags = append(retags, table+"->"+col.Name+"->"+convert(col.Name))
+			}
+		}
+		if len(retags) > 0 {
+			suggestions = append(suggestions, ruleSuggestion{
+				Reason:   fmt.Sprintf("most columns are %s (%d against %d), retag the JSON of the others alike", style, max(snake, camel), min(snake, camel)),
+				Triggers: triggers,
+				Rules:    map[string][]string{"retags": retags},
+			})
+		}
+	}
+
+	// Columns marked deprecated in their comment
+	if o.deprecatedMode != deprecatedExclude {
+		var triggers []string
+		for _, table := range tables {
+			for _, col := range o.columns[table] {
+				if strings.Contains(strings.ToLower(col.Comment), deprecatedMarker) && !ignored(table, col.Name) {
+					triggers = append(triggers, table+"."+col.Name)
+				}
+			}
+		}
+		if len(triggers) > 0 {
+			suggestions = append(suggestions, ruleSuggestion{
+				Reason:   "columns are marked " + deprecatedMarker + " in their comment, leave them out of the models",
+				Triggers: triggers,
+				Settings: map[string]string{"deprecated": deprecatedExclude},
+			})
+		}
+	}
+
+	// Enum columns, generated as strings
+	var enums []string
+	for _, table := range tables {
+		for _, col := range o.columns[table] {
+			if enumColumn(col) && !ignored(table, col.Name) {
+				enums = append(enums, table+"."+col.Name)
+			}
+		}
+	}
+	if len(enums) > 0 {
+		suggestions = append(suggestions, ruleSuggestion{
+			Reason:   "enum columns are generated as plain strings",
+			Triggers: enums,
+			Note:     "enum candidates, generated as Go enum types with orm.WithEnums(true)",
+		})
+	}
+	return suggestions
+}
+
+// columnNaming returns the naming of a column name: snake for lower case
+// words joined by underscores, camel for words joined by capitals, empty
+// for a single lower case word or anything else.
+func columnNaming(name string) string {
+	hasUpper := strings.ContainsFunc(name, unicode.IsUpper)
+	hasUnderscore := strings.Contains(name, "_")
+	switch {
+	case hasUnderscore && !hasUpper:
+		return "snake"
+	case hasUpper && !hasUnderscore:
+		return "camel"
+	default:
+		return ""
+	}
+}
+
+// snakeCase converts a camel case name to snake case: userID to user_id.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// lowerCamelCase converts a snake case name to lower camel case: user_id to
+// userId.
+func lowerCamelCase(name string) string {
+	var b strings.Builder
+	for i, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		if i > 0 && b.Len() > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// enumColumn reports whether a column holds one of a fixed set of values.
+func enumColumn(col columnMeta) bool {
+	dataType := strings.ToLower(col.DataType)
+	return dataType == "enum" || dataType == "set" || strings.HasPrefix(strings.ToLower(col.Type), "enum(")
+}
+
+// reviewSuggestions asks on the terminal which suggestions to accept, each
+// shown with the columns it was made for.
+func reviewSuggestions(suggestions []ruleSuggestion) ([]ruleSuggestion, error) {
+	in := bufio.NewReader(os.Stdin)
+	var accepted []ruleSuggestion
+	for i, s := range suggestions {
+		fmt.Printf("\n%s %s\n", color.HiBlackString("[%d/%d]", i+1, len(suggestions)), s.Reason)
+		fmt.Printf("  %s %s\n", color.HiBlackString("from"), strings.Join(s.Triggers, ", "))
+		if s.Note != "" {
+			fmt.Printf("  %s\n", color.HiBlackString(s.Note))
+		}
+		for _, line := range strings.Split(strings.TrimSpace(suggestionBlock([]ruleSuggestion{s}, false)), "\n") {
+			if !strings.HasPrefix(line, "#") {
+				fmt.Printf("  %s\n", color.CyanString(line))
+			}
+		}
+		fmt.Print("Accept? [Y/n] ")
+		answer, err := in.ReadString('\n')
+		if err != nil {
+			return nil, errors.New("rule review cancelled")
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "", "y", "yes":
+			accepted = append(accepted, s)
+		}
+	}
+	fmt.Println()
+	return accepted, nil
+}
+
+// suggestionBlock returns the rules of the suggestions in the syntax of a
+// YAML or TOML config file, the notes and triggers as comments.
+func suggestionBlock(suggestions []ruleSuggestion, toml bool) string {
+	var b strings.Builder
+	for _, key := range suggestionKeys {
+		var values []string
+		for _, s := range suggestions {
+			if len(s.Rules[key]) > 0 {
+				fmt.Fprintf(&b, "# %s\n", s.Reason)
+				values = append(values, s.Rules[key]...)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if toml {
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = strconv.Quote(v)
+			}
+			fmt.Fprintf(&b, "%s = [%s]\n\n", key, strings.Join(quoted, ", "))
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n", key)
+		for _, v := range values {
+			fmt.Fprintf(&b, "  - %s\n", strconv.Quote(v))
+		}
+		b.WriteString("\n")
+	}
+	for _, s := range suggestions {
+		if s.Note != "" {
+			fmt.Fprintf(&b, "# %s: %s\n\n", s.Note, strings.Join(s.Triggers, ", "))
+		}
+	}
+
+	// The settings table comes last in TOML
+	format := "  %s: %s\n"
+	if toml {
+		format = "%s = %s\n"
+	}
+	var settings []string
+	for _, s := range suggestions {
+		for _, key := range sortedKeys(s.Settings) {
+			settings = append(settings, "# "+s.Reason+"\n", fmt.Sprintf(format, key, strconv.Quote(s.Settings[key])))
+		}
+	}
+	if len(settings) > 0 {
+		if toml {
+			b.WriteString("[settings]\n")
+		} else {
+			b.WriteString("settings:\n")
+		}
+		b.WriteString(strings.Join(settings, ""))
+	}
+	return b.String()
+}
+
+// mergeSuggestions merges the rules of the suggestions into the YAML config
+// file at path, created when missing. Entries already in the file are kept
+// once, the comments of the file stay in place.
+func mergeSuggestions(path string, suggestions []ruleSuggestion) error {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case len(bytes.TrimSpace(data)) > 0:
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: the document is not a mapping", path)
+	}
+
+	for _, s := range suggestions {
+		for _, key := range suggestionKeys {
+			list := mappingValue(root, key, yaml.SequenceNode)
+			for _, rule := range s.Rules[key] {
+				if !slices.ContainsFunc(list.Content, func(n *yaml.Node) bool { return n.Value == rule }) {
+					list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: rule, Style: yaml.DoubleQuotedStyle})
+				}
+			}
+		}
+		if len(s.Settings) > 0 {
+			settings := mappingValue(root, "settings", yaml.MappingNode)
+			for _, key := range sortedKeys(s.Settings) {
+				value := mappingValue(settings, key, yaml.ScalarNode)
+				value.Tag, value.Value, value.Style = "!!str", s.Settings[key], 0
+			}
+		}
+	}
+	// Keys added without an entry are left out
+	for i := 0; i < len(root.Content); i += 2 {
+		if v := root.Content[i+1]; v.Kind == yaml.SequenceNode && len(v.Content) == 0 && v.Line == 0 {
+			root.Content = slices.Delete(root.Content, i, i+2)
+			i -= 2
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out.Bytes(), 0644)
+}
+
+// mappingValue returns the value of key in a mapping node, added with the
+// kind when missing.
+func mappingValue(m *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: kind}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
@@ -0,0 +1,121 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+// suggestSchema adds to the shop fixture the columns each suggestion is
+// made for: an audit column in every table, a soft delete column, a camel
+// case column among snake case ones and a deprecated column.
+func suggestSchema(snap *schemaSnapshot) {
+	column := func(name, typ string, nullable bool, comment string) snapshotColumn {
+		dataType, _, _ := strings.Cut(typ, "(")
+		return snapshotColumn{NameValue: name, DataTypeValue: dataType, ColumnTypeValue: &typ, NullableValue: &nullable, CommentValue: &comment}
+	}
+	for i := range snap.Tables {
+		t := &snap.Tables[i]
+		t.Columns = append(t.Columns, column("created_by", "varchar(64)", false, ""))
+		switch t.Name {
+		case "customers":
+			t.Columns = append(t.Columns,
+				column("removed_at", "datetime", true, ""),
+				column("fax", "varchar(32)", true, "[deprecated] use email"))
+		case "orders":
+			t.Columns = append(t.Columns, column("shipDate", "datetime", true, ""))
+		}
+	}
+}
+
+// ormRun runs the orm command with args and the config file in the working
+// directory, and returns its output.
+func ormRun(t *testing.T, db *gorm.DB, args ...string) (string, error) {
+	t.Helper()
+	c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}), WithDaoTables([]string{"*"})).Command()
+	c.SetArgs(append(args, "--config", "orm.yaml"))
+	c.SilenceUsage, c.SilenceErrors = true, true
+	var err error
+	out := captureOutput(t, func() { err = c.Execute() })
+	return out, err
+}
+
+func TestRulesSuggestCleanRun(t *testing.T) {
+	t.Chdir(t.TempDir())
+	db := openFixtureWith(t, "shop", suggestSchema)
+	if err := os.WriteFile("orm.yaml", []byte("# Rules of the shop\nignore: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ormRun(t, db, "rules", "suggest", "--auto", "--write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"audit column created_by is in 2 of 2 tables",
+		"customers->removed_at",
+		"orders->shipDate->ship_date",
+		"columns are marked [deprecated] in their comment",
+		"Merged 4 suggestions into orm.yaml",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("suggestions lack %q:\n%s", want, out)
+		}
+	}
+	config, err := os.ReadFile("orm.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Generating with the accepted rules warns about nothing
+	out, err = ormRun(t, db, "-t", "*", "--style", "dao", "--strict-rules")
+	if err != nil {
+		t.Fatalf("run with the suggested rules:\n%s\n%v", config, err)
+	}
+	if strings.Contains(out, "Warning") {
+		t.Errorf("run with the suggested rules warned:\n%s", out)
+	}
+	files := readTree(t, ".")
+	customers, orders := files["model/customers.gen.go"], files["model/orders.gen.go"]
+	for _, tt := range []struct {
+		src, field, want string
+	}{
+		{customers, "CreatedBy", ""},
+		{orders, "CreatedBy", ""},
+		{customers, "Fax", ""},
+		{customers, "RemovedAt", "gorm.DeletedAt"},
+		{orders, "ShipDate", `json:"ship_date`},
+	} {
+		line := fieldLine(tt.src, tt.field)
+		if tt.want == "" && line != "" || !strings.Contains(line, tt.want) {
+			t.Errorf("field %s = %q, want %q", tt.field, line, tt.want)
+		}
+	}
+
+	// The rules fit the schema now
+	out, err = ormRun(t, db, "rules", "suggest", "--auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "nothing to suggest") {
+		t.Errorf("suggestions after accepting them all:\n%s\nconfig:\n%s", out, config)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/pflag"
+	"gorm.io/gorm/clause"
+)
+
+// sampleWidth is the number of runes an example value is cut to.
+const sampleWidth = 40
+
+// sampleRun samples the tables of the models about to be generated with
+// --sample, before the generation as the factories use the samples.
+func (o *Orm) sampleRun(ctx context.Context, args *pflag.FlagSet) error {
+	n, err := args.GetInt("sample")
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return fmt.Errorf("invalid --sample %d, must be 0 or more rows", n)
+	}
+	if n == 0 {
+		return nil
+	}
+	redact, err := args.GetStringSlice("sample-redact")
+	if err != nil {
+		return err
+	}
+	return o.sample(ctx, n, redact)
+}
+
+// sample reads up to n rows of every generated table ordered by primary key,
+// and records their values as the examples of the columns, shown by the docs
+// and picked by the factories. The columns matching a redact pattern, as
+// column or table.column, get a fake value of their type instead. Runs
+// without a connection skip sampling.
+func (o *Orm) sample(ctx context.Context, n int, redact []string) error {
+	if _, ok := snapshotOf(o.meta); ok {
+		o.warn(warnDatabase, "Warning: --sample skipped, the run has no database connection\n")
+		return nil
+	}
+	if o.samples == nil {
+		o.samples = make(map[string]map[string][]string)
+	}
+	for _, meta := range o.structs {
+		table := metaString(meta, "TableName")
+		columns := o.columns[table]
+		if table == "" || len(columns) == 0 {
+			continue
+		}
+
+		var order []clause.OrderByColumn
+		for _, col := range columns {
+			if col.primaryKey() {
+				order = append(order, clause.OrderByColumn{Column: clause.Column{Name: col.Name}})
+			}
+		}
+		tx := o.meta.WithContext(ctx).Table(table).Limit(n)
+		if len(order) > 0 {
+			tx = tx.Order(clause.OrderBy{Columns: order})
+		}
+		var rows []map[string]any
+		if err := tx.Find(&rows).Error; err != nil {
+			return fmt.Errorf("sampling %s: %w", table, err)
+		}
+
+		examples := make(map[string][]string, len(columns))
+		for _, col := range columns {
+			fake := sampleRedacted(redact, table, col.Name)
+			for _, row := range rows {
+				if fake {
+					examples[col.Name] = append(examples[col.Name], fakeValue(col.DataType))
+					continue
+				}
+				examples[col.Name] = append(examples[col.Name], sampleValue(row[col.Name]))
+			}
+		}
+		o.samples[table] = examples
+	}
+	return nil
+}
+
+// sampleRedacted reports whether a column matches one of the redact patterns.
+func sampleRedacted(patterns []string, table, column string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, column); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, table+"."+column); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleValue formats a sampled value on one line, cut to sampleWidth runes.
+func sampleValue(v any) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(v) {
+			s = "0x" + hex.EncodeToString(v)
+			break
+		}
+		s = string(v)
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	default:
+		s = fmt.Sprint(v)
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) > sampleWidth {
+		s = string([]rune(s)[:sampleWidth-1]) + "…"
+	}
+	return s
+}
+
+// fakeValue returns the value standing for a redacted value of a column of
+// the database type.
+func fakeValue(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	switch {
+	case strings.Contains(dataType, "int"), strings.Contains(dataType, "dec"),
+		strings.Contains(dataType, "num"), strings.Contains(dataType, "float"),
+		strings.Contains(dataType, "double"), strings.Contains(dataType, "real"):
+		return "0"
+	case strings.Contains(dataType, "bool"), dataType == "bit":
+		return "false"
+	case strings.Contains(dataType, "time"), strings.Contains(dataType, "date"):
+		return "1970-01-01T00:00:00Z"
+	case strings.Contains(dataType, "json"):
+		return "{}"
+	case strings.Contains(dataType, "uuid"):
+		return "00000000-0000-0000-0000-000000000000"
+	}
+	return "<redacted>"
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// compareHosts warns about tables present on only one of the target and the
+// introspection hosts, when metadata is read from a separate connection.
+func (o *Orm) compareHosts(introspected []string) error {
+	if o.meta == o.opt.db {
+		return nil
+	}
+
+	target, err := userTables(o.opt.db)
+	if err != nil {
+		return err
+	}
+	var missing, extra []string
+	for _, t := range introspected {
+		if !slices.Contains(target, t) {
+			missing = append(missing, t)
+		}
+	}
+	for _, t := range target {
+		if !slices.Contains(introspected, t) {
+			extra = append(extra, t)
+		}
+	}
+	if len(missing) > 0 {
+		o.warn(warnDatabase, "Tables missing on the target host: %s\n", strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		o.warn(warnDatabase, "Tables missing on the introspection host: %s\n", strings.Join(extra, ", "))
+	}
+	return nil
+}
+
+// schemaPass qualifies the source table recorded in the model comment with
+// the logical schema name.
+func (o *Orm) schemaPass(table string, src []byte) ([]byte, error) {
+	return bytes.Replace(src,
+		[]byte("mapped from table <"+table+">"),
+		[]byte("mapped from table <"+o.schemaName+"."+table+">"), 1), nil
+}
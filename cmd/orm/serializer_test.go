@@ -0,0 +1,127 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// serializerItem is the Item type of the model package, written next to the
+// generated models.
+const serializerItem = `package model
+
+type Item struct {
+	SKU string ` + "`json:\"sku\"`" + `
+	Qty int    ` + "`json:\"qty\"`" + `
+}
+`
+
+// serializerProgram stores an order of items through the generated model,
+// reads it back and prints the stored column and the items read.
+const serializerProgram = `package main
+
+import (
+	"fmt"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"MODEL"
+)
+
+func main() {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err := db.Exec("CREATE TABLE orders (id integer PRIMARY KEY, items text)").Error; err != nil {
+		panic(err)
+	}
+	in := model.Order{Items: []model.Item{{SKU: "tea", Qty: 2}, {SKU: "cup", Qty: 1}}}
+	if err := db.Create(&in).Error; err != nil {
+		panic(err)
+	}
+	var column string
+	if err := db.Raw("SELECT items FROM orders WHERE id = ?", in.ID).Scan(&column).Error; err != nil {
+		panic(err)
+	}
+	var out model.Order
+	if err := db.First(&out, in.ID).Error; err != nil {
+		panic(err)
+	}
+	fmt.Println(column)
+	fmt.Printf("%+v\n", out.Items)
+}
+`
+
+func TestSerializerRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE orders (id integer PRIMARY KEY, items text)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp(testdata, "build-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Chdir(dir)
+	if err := os.MkdirAll("model", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("model", "item.go"), []byte(serializerItem), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := generateHere(t, db, []string{"-t", "orders"},
+		WithSerializer(map[string]string{"orders->items": "json"}),
+		WithSerializerType(map[string]string{"orders->items": "[]Item"}),
+	)
+	line := fieldLine(files["model/orders.gen.go"], "Items")
+	if !strings.Contains(line, "[]Item") || !strings.Contains(line, "serializer:json") {
+		t.Fatalf("Items = %q", line)
+	}
+
+	program := strings.Replace(serializerProgram, "MODEL", "command/cmd/orm/testdata/"+filepath.Base(dir)+"/model", 1)
+	if err := os.MkdirAll("roundtrip", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("roundtrip", "main.go"), []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("go", "run", "./roundtrip").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+
+	column, items, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if column != `[{"sku":"tea","qty":2},{"sku":"cup","qty":1}]` {
+		t.Errorf("items stored as %s", column)
+	}
+	if items != "[{SKU:tea Qty:2} {SKU:cup Qty:1}]" {
+		t.Errorf("items read back as %s", items)
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+// serveShutdown is how long the requests in flight get to finish on shutdown.
+const serveShutdown = 10 * time.Second
+
+type (
+	// server is the state of orm serve: the warm connections and config of
+	// the command, from which every request derives a fresh run.
+	server struct {
+		base  *Orm
+		token string
+		// txDB and txMeta are set when the generation and the metadata
+		// connections were opened by the server, each request reading them
+		// in read-only transactions of its own
+		txDB, txMeta bool
+		// mu serializes the runs, as gen keeps global state
+		mu sync.Mutex
+	}
+	// serveRequest is the body of the /describe, /plan and /generate requests.
+	serveRequest struct {
+		// Flags are the generation flags of the run by name, as in a plan file
+		Flags map[string][]string `json:"flags"`
+	}
+	// serveError is the body of a failed request.
+	serveError struct {
+		Error    string `json:"error"`
+		ExitCode int    `json:"exitCode"`
+	}
+	// serveResult is the body of a /generate request.
+	serveResult struct {
+		Rewritten int `json:"rewritten"`
+		Unchanged int `json:"unchanged"`
+	}
+)
+
+// serveCommand returns the orm serve subcommand.
+func (o *Orm) serveCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve describe, plan and generate runs over a JSON HTTP API",
+		Long: `Connect to the database once and serve runs to editors and other tools over
+HTTP, without a process and a connection per run:
+
+  GET  /health    the database answers
+  POST /describe  like orm describe --output json
+  POST /plan      like orm plan --output json
+  POST /generate  like orm, answering the counts of written and unchanged files
+
+The bodies of the POST requests hold the generation flags of the run by name,
+{"flags": {"tables": ["users"], "style": ["dao"]}}, and failures answer
+{"error": "...", "exitCode": 2} with the exit code of the command. Every
+request runs on a fresh state from the options and the config file read at
+startup, one at a time.
+
+Requests authenticate with the bearer token printed at startup. The server
+listens on loopback addresses only unless --allow-remote is set, and stops on
+Ctrl-C or SIGTERM once the request in flight is done.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Serve on a random loopback port
+command orm serve --dsn "root:root@tcp(127.0.0.1:3306)/amg"
+
+# Plan the dao run of the users table
+curl -H "Authorization: Bearer $TOKEN" -d '{"flags":{"tables":["users"],"style":["dao"]}}' http://127.0.0.1:PORT/plan`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).serve),
+	}
+	c.Flags().String("listen", "127.0.0.1:0", cmd.T("orm.serve.flag.listen"))
+	c.Flags().Bool("allow-remote", false, cmd.T("orm.serve.flag.allow-remote"))
+	return c
+}
+
+// serve is the execution logic for the orm serve command.
+func (o *Orm) serve(c *cobra.Command, _ []string) error {
+	listen, err := c.Flags().GetString("listen")
+	if err != nil {
+		return err
+	}
+	remote, err := c.Flags().GetBool("allow-remote")
+	if err != nil {
+		return err
+	}
+	if err := checkListen(listen, remote); err != nil {
+		return usage("serving", err)
+	}
+	s, closeConn, err := o.newServer(c.Context(), c.Flags())
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fail("serving", err)
+	}
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	color.Green("Serving on http://%s\n", ln.Addr())
+	fmt.Printf("Token: %s\n", s.token)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ln)
+	}()
+	select {
+	case err := <-done:
+		return fail("serving", err)
+	case <-c.Context().Done():
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serveShutdown)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return fail("stopping the server", err)
+	}
+	color.Green("\nServer stopped.\n\n")
+	return nil
+}
+
+// newServer connects to the database and returns the server of its
+// connections with a random token. The returned func closes the connections.
+func (o *Orm) newServer(ctx context.Context, args *pflag.FlagSet) (*server, func(), error) {
+	if err := o.loadConfigFile(args); err != nil {
+		return nil, nil, usage(cmd.T("orm.loading_config"), err)
+	}
+	o.txPerRequest = true
+	injected := o.opt.db
+	closeConn, err := o.open(ctx, args)
+	if err != nil {
+		return nil, nil, fail(cmd.T("orm.connecting"), err)
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		closeConn()
+		return nil, nil, fail("serving", err)
+	}
+	return &server{
+		base:   o,
+		token:  hex.EncodeToString(token),
+		txDB:   injected == nil,
+		txMeta: o.meta != o.opt.db,
+	}, closeConn, nil
+}
+
+// checkListen rejects the addresses of other hosts than the loopback unless
+// remote is set.
+func checkListen(listen string, remote bool) error {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return err
+	}
+	if remote || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%s is not a loopback address, set --allow-remote to listen on it", listen)
+	}
+	return nil
+}
+
+// handler returns the authenticated routes of the server.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.health)
+	mux.HandleFunc("POST /describe", s.run((*Orm).serveDescribe))
+	mux.HandleFunc("POST /plan", s.run((*Orm).servePlan))
+	mux.HandleFunc("POST /generate", s.run((*Orm).serveGenerate))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, serveError{Error: "missing or invalid bearer token", ExitCode: cmd.ExitUsage})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// health answers whether the database answers.
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	db, err := s.base.meta.DB()
+	if err == nil {
+		err = db.PingContext(r.Context())
+	}
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, serveError{Error: err.Error(), ExitCode: cmd.ExitExternal})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// run returns the handler executing fn on a fresh run with the generation
+// flags of the request.
+func (s *server) run(fn func(*Orm, context.Context, *pflag.FlagSet) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req serveRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeServeError(w, usage("reading the request", err))
+			return
+		}
+		run := s.base.invocation()
+		run.meta = s.base.meta
+		args := pflag.NewFlagSet("serve", pflag.ContinueOnError)
+		run.generationFlags(args)
+		if err := setGenerationFlags(args, req.Flags); err != nil {
+			writeServeError(w, usage("reading the request", err))
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		end, err := s.beginRead(r.Context(), run)
+		if err != nil {
+			writeServeError(w, fail(cmd.T("orm.connecting"), err))
+			return
+		}
+		defer end()
+		result, err := run.serveRun(r.Context(), args, fn)
+		if err != nil {
+			writeServeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// beginRead points the connections of run opened by the server to read-only
+// transactions, ended by the returned func once the request is answered.
+func (s *server) beginRead(ctx context.Context, run *Orm) (func(), error) {
+	var ends []func()
+	end := func() {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i]()
+		}
+	}
+	begin := func(db *gorm.DB) (*gorm.DB, error) {
+		tx, endTx, err := readOnlyTx(ctx, db)
+		if err != nil {
+			end()
+			return nil, fmt.Errorf("begin read-only transaction: %w", err)
+		}
+		ends = append(ends, endTx)
+		return tx, nil
+	}
+	var err error
+	if s.txDB {
+		if run.opt.db, err = begin(s.base.opt.db); err != nil {
+			return nil, err
+		}
+		if !s.txMeta {
+			run.meta = run.opt.db
+		}
+	}
+	if s.txMeta {
+		if run.meta, err = begin(s.base.meta); err != nil {
+			return nil, err
+		}
+	}
+	return end, nil
+}
+
+// serveRun sets up the generator of a request and executes fn.
+func (o *Orm) serveRun(ctx context.Context, args *pflag.FlagSet, fn func(*Orm, context.Context, *pflag.FlagSet) (any, error)) (result any, err error) {
+	defer func() {
+		// gen panics on errors
+		if r := recover(); r != nil {
+			err = fail("generating", fmt.Errorf("%v", r))
+		}
+	}()
+	if err := o.setup(); err != nil {
+		return nil, fail(cmd.T("orm.formatting_rules"), err)
+	}
+	return fn(o, ctx, args)
+}
+
+// serveDescribe describes the tables of the request.
+func (o *Orm) serveDescribe(_ context.Context, args *pflag.FlagSet) (any, error) {
+	tables, err := args.GetStringArray("tables")
+	if err != nil {
+		return nil, err
+	}
+	described, err := o.execDescribe(tables)
+	if err != nil {
+		return nil, fail("describing tables", err)
+	}
+	return described, nil
+}
+
+// servePlan plans the run of the request.
+func (o *Orm) servePlan(ctx context.Context, args *pflag.FlagSet) (any, error) {
+	o.planning = &planning{}
+	if err := o.exec(ctx, args); err != nil {
+		return nil, fail("planning", err)
+	}
+	return o.planning.result, nil
+}
+
+// serveGenerate executes the run of the request.
+func (o *Orm) serveGenerate(ctx context.Context, args *pflag.FlagSet) (any, error) {
+	if err := o.exec(ctx, args); err != nil {
+		return nil, fail(cmd.T("orm.generating"), err)
+	}
+	return serveResult{Rewritten: o.rewritten, Unchanged: o.unchanged}, nil
+}
+
+// writeServeError answers err with the HTTP status of its exit code.
+func writeServeError(w http.ResponseWriter, err error) {
+	code := cmd.ExitCode(err, true)
+	status := http.StatusInternalServerError
+	switch code {
+	case cmd.ExitUsage:
+		status = http.StatusBadRequest
+	case cmd.ExitExternal:
+		status = http.StatusBadGateway
+	case cmd.ExitRefused:
+		status = http.StatusConflict
+	}
+	if errors.Is(err, context.Canceled) {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, serveError{Error: err.Error(), ExitCode: code})
+}
+
+// writeJSON answers v as JSON with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
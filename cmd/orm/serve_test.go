@@ -0,0 +1,111 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+// startServer serves the sqlite database at path on a random port and
+// returns the server and its URL.
+func startServer(t *testing.T, path string) (*server, string) {
+	t.Helper()
+	o := NewOrmCommand(WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}))
+	serve, _, err := o.Command().Find([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := serve.ParseFlags([]string{"--driver", "sqlite", "--dsn", path, "--config", ""}); err != nil {
+		t.Fatal(err)
+	}
+	s, closeConn, err := o.invocation().newServer(context.Background(), serve.Flags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(closeConn)
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return s, ts.URL
+}
+
+// describeColumns returns the columns of table described by the server.
+func describeColumns(t *testing.T, s *server, url, table string) []string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/describe", strings.NewReader(`{"flags":{"tables":["`+table+`"]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var described []describeTable
+	if err := json.NewDecoder(resp.Body).Decode(&described); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("describe answered %d: %v", resp.StatusCode, err)
+	}
+	var cols []string
+	for _, dt := range described {
+		for _, col := range dt.Columns {
+			cols = append(cols, col.Name)
+		}
+	}
+	return cols
+}
+
+func TestServeTransactionPerRequest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	path := filepath.Join(t.TempDir(), "serve.db")
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, name text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	s, url := startServer(t, path)
+
+	if got := describeColumns(t, s, url, "users"); strings.Join(got, ",") != "id,name" {
+		t.Fatalf("columns = %v", got)
+	}
+	// No transaction of the request outlives it
+	pool, err := s.base.opt.db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inUse := pool.Stats().InUse; inUse != 0 {
+		t.Errorf("%d connection(s) still in use after the request", inUse)
+	}
+
+	// A change between two requests is neither blocked nor missed
+	if err := db.Exec("ALTER TABLE users ADD COLUMN email text").Error; err != nil {
+		t.Fatalf("schema change between requests: %v", err)
+	}
+	if got := describeColumns(t, s, url, "users"); strings.Join(got, ",") != "id,name,email" {
+		t.Errorf("columns after the change = %v", got)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"command/cmd"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix prefixes the environment variables setting orm flags:
+// --connect-retries is read from CZX_ORM_CONNECT_RETRIES.
+const envPrefix = "CZX_ORM_"
+
+// settingChoices lists the accepted values of the enumerated settings.
+var settingChoices = map[string][]string{
+	"style":      {"model", "dao"},
+	"deprecated": {deprecatedIgnore, deprecatedComment, deprecatedExclude},
+}
+
+// envName returns the environment variable of a flag.
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// applyEnv sets the flags not given on the command line from the environment.
+// The variables of deprecated flags set their replacement.
+func applyEnv(args *pflag.FlagSet) error {
+	var err error
+	args.VisitAll(func(f *pflag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		// The variable of the replacement wins
+		if r, ok := cmd.Replacement(f); ok {
+			if _, set := os.LookupEnv(envName(r)); set {
+				return
+			}
+		}
+		name := envName(f.Name)
+		if f, err = cmd.ResolveFlag(args, f.Name, name); err != nil || f.Changed {
+			return
+		}
+		if setErr := args.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%s: %w", name, setErr)
+		}
+	})
+	return err
+}
+
+// applySettings sets the flags given by neither the command line nor the
+// environment from the settings of the config file.
+func applySettings(args *pflag.FlagSet, settings map[string]any) error {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := "$.settings." + name
+		f, err := cmd.ResolveFlag(args, name, path)
+		if err != nil {
+			return err
+		}
+		if f == nil || slices.Contains([]string{"config", "stdin-config", "profile"}, name) {
+			return fmt.Errorf("%s: unknown setting", path)
+		}
+		values, isList := settings[name].([]any)
+		if isList && !strings.HasSuffix(f.Value.Type(), "Array") && !strings.HasSuffix(f.Value.Type(), "Slice") {
+			return fmt.Errorf("%s: expected a single %s value, got a list", path, f.Value.Type())
+		}
+		if !isList {
+			values = []any{settings[name]}
+		} else {
+			path += "[%d]"
+		}
+
+		// Values are validated even when the flag or environment wins
+		changed := f.Changed
+		for i, v := range values {
+			at := path
+			if isList {
+				at = fmt.Sprintf(path, i)
+			}
+			switch v.(type) {
+			case string, bool, int, float64:
+			default:
+				return fmt.Errorf("%s: expected a %s value, got %v", at, f.Value.Type(), v)
+			}
+			value := fmt.Sprint(v)
+			if choices, ok := settingChoices[f.Name]; ok && !slices.Contains(choices, value) {
+				return fmt.Errorf("%s: invalid value %q, must be one of %s", at, value, strings.Join(choices, ", "))
+			}
+			if changed {
+				continue
+			}
+			if err := args.Set(f.Name, value); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeConfig decodes a config file, as TOML when source names a .toml file
+// and as YAML otherwise. Unknown keys are rejected, and errors name the line
+// or key of the offending value.
+func decodeConfig(source string, data []byte) (fileConfig, error) {
+	var conf fileConfig
+	if isTOML(source) {
+		md, err := toml.Decode(string(data), &conf)
+		if err != nil {
+			return fileConfig{}, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fileConfig{}, fmt.Errorf("$.%s: unknown key", undecoded[0])
+		}
+		for name, v := range conf.Settings {
+			conf.Settings[name] = tomlValue(v)
+		}
+		return conf, nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	// An empty document is an empty config
+	if err := dec.Decode(&conf); err != nil && !errors.Is(err, io.EOF) {
+		return fileConfig{}, err
+	}
+	return conf, nil
+}
+
+// isTOML reports whether a config file or URL names a TOML document.
+func isTOML(source string) bool {
+	if isRemote(source) {
+		if u, err := url.Parse(source); err == nil {
+			source = u.Path
+		}
+	}
+	return strings.EqualFold(filepath.Ext(source), ".toml")
+}
+
+// tomlValue converts the int64 values of a decoded TOML setting.
+func tomlValue(v any) any {
+	switch v := v.(type) {
+	case int64:
+		return int(v)
+	case []any:
+		for i := range v {
+			v[i] = tomlValue(v[i])
+		}
+	}
+	return v
+}
+
+// decodeJSONConfig decodes a JSON config document, reporting errors with the
+// JSON path of the offending value.
+func decodeJSONConfig(data []byte) (fileConfig, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return fileConfig{}, fmt.Errorf("$: %w at offset %d", err, syntaxErr.Offset)
+		}
+		return fileConfig{}, fmt.Errorf("$: expected an object: %w", err)
+	}
+
+	known := make(map[string]bool)
+	t := reflect.TypeFor[fileConfig]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+	for key := range raw {
+		if !known[key] {
+			return fileConfig{}, fmt.Errorf("$.%s: unknown key", key)
+		}
+	}
+
+	var conf fileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&conf); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fileConfig{}, fmt.Errorf("%s: expected %s, got %s", jsonPath(typeErr.Field), typeErr.Type, typeErr.Value)
+		}
+		return fileConfig{}, fmt.Errorf("$: %w", err)
+	}
+	for name, v := range conf.Settings {
+		conf.Settings[name] = jsonValue(v)
+	}
+	return conf, nil
+}
+
+// jsonPath formats the dotted field path of a decoding error, "ignore.0"
+// becomes "$.ignore[0]".
+func jsonPath(field string) string {
+	path := "$"
+	for _, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			path += "[" + part + "]"
+		} else {
+			path += "." + part
+		}
+	}
+	return path
+}
+
+// jsonValue converts the json.Number values of a decoded setting.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+	}
+	return v
+}
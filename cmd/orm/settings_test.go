@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestDeprecatedFlagSupply(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		settings map[string]any
+		want     []string
+	}{
+		{
+			name: "command line",
+			args: []string{"--bench-tables", "users"},
+			want: []string{"users"},
+		},
+		{
+			name: "environment",
+			env:  map[string]string{"CZX_ORM_BENCH_TABLES": "users"},
+			want: []string{"users"},
+		},
+		{
+			name: "environment of the replacement wins",
+			env:  map[string]string{"CZX_ORM_BENCH_TABLES": "users", "CZX_ORM_BENCHMARK_TABLES": "orders"},
+			want: []string{"orders"},
+		},
+		{
+			name: "command line wins over the environment",
+			args: []string{"--benchmark-tables", "orders"},
+			env:  map[string]string{"CZX_ORM_BENCH_TABLES": "users"},
+			want: []string{"orders"},
+		},
+		{
+			name:     "config file",
+			settings: map[string]any{"bench-tables": []any{"users", "orders"}},
+			want:     []string{"users", "orders"},
+		},
+		{
+			name:     "environment wins over the config file",
+			env:      map[string]string{"CZX_ORM_BENCH_TABLES": "users"},
+			settings: map[string]any{"bench-tables": "orders"},
+			want:     []string{"users"},
+		},
+		{
+			name: "default",
+			want: []string{"*"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			fs := pflag.NewFlagSet("orm", pflag.ContinueOnError)
+			newOrm(OrmOption{}).generationFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := applyEnv(fs); err != nil {
+				t.Fatal(err)
+			}
+			if err := applySettings(fs, tt.settings); err != nil {
+				t.Fatal(err)
+			}
+			got, err := fs.GetStringArray("benchmark-tables")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("--benchmark-tables = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
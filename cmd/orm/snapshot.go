@@ -0,0 +1,454 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// errOffline is returned by the queries of a snapshot connection.
+var errOffline = errors.New("the schema snapshot has no database to query")
+
+type (
+	// schemaSnapshot is the schema read by a run, enough for gen and the
+	// introspection to generate the same code without the database.
+	schemaSnapshot struct {
+		Driver   string `json:"driver"`
+		Database string `json:"database"`
+		// Tables are the tables of the database, the generated ones with
+		// their metadata
+		Tables []snapshotTable `json:"tables"`
+		// ScanTypes are the Go types of the database types for the drivers
+		// gen maps through the scan type of the columns
+		ScanTypes map[string]string `json:"scanTypes,omitempty"`
+	}
+	// snapshotTable is a table of a schemaSnapshot.
+	snapshotTable struct {
+		Name string `json:"name"`
+		// Comment is nil when the table comment could not be read
+		Comment *string          `json:"comment,omitempty"`
+		Columns []snapshotColumn `json:"columns,omitempty"`
+		Indexes []snapshotIndex  `json:"indexes,omitempty"`
+		// Meta is the column metadata of the introspection
+		Meta []columnMeta `json:"meta,omitempty"`
+	}
+	// snapshotColumn is a gorm.ColumnType read back from a snapshot. Nil
+	// values are the ones the driver did not report.
+	snapshotColumn struct {
+		NameValue          string    `json:"name"`
+		DataTypeValue      string    `json:"dataType"`
+		ColumnTypeValue    *string   `json:"columnType,omitempty"`
+		PrimaryKeyValue    *bool     `json:"primaryKey,omitempty"`
+		AutoIncrementValue *bool     `json:"autoIncrement,omitempty"`
+		LengthValue        *int64    `json:"length,omitempty"`
+		DecimalSizeValue   *[2]int64 `json:"decimalSize,omitempty"`
+		NullableValue      *bool     `json:"nullable,omitempty"`
+		UniqueValue        *bool     `json:"unique,omitempty"`
+		CommentValue       *string   `json:"comment,omitempty"`
+		DefaultValueValue  *string   `json:"default,omitempty"`
+	}
+	// snapshotIndex is a gorm.Index read back from a snapshot.
+	snapshotIndex struct {
+		TableValue      string   `json:"table"`
+		NameValue       string   `json:"name"`
+		ColumnList      []string `json:"columns"`
+		PrimaryKeyValue *bool    `json:"primaryKey,omitempty"`
+		UniqueValue     *bool    `json:"unique,omitempty"`
+		OptionValue     string   `json:"option,omitempty"`
+	}
+)
+
+// snapshot reads the schema of the tables introspected by the run. With
+// redact the table and column comments are blanked.
+func (o *Orm) snapshot(redact bool) (*schemaSnapshot, error) {
+	all, err := userTables(o.meta)
+	if err != nil {
+		return nil, err
+	}
+	for table := range o.columns {
+		if !slices.Contains(all, table) {
+			all = append(all, table)
+		}
+	}
+	slices.Sort(all)
+
+	name := o.meta.Dialector.Name()
+	snap := &schemaSnapshot{Driver: name, Database: o.meta.Migrator().CurrentDatabase()}
+	for _, table := range all {
+		t := snapshotTable{Name: table}
+		meta, ok := o.columns[table]
+		if !ok {
+			snap.Tables = append(snap.Tables, t)
+			continue
+		}
+		t.Meta = slices.Clone(meta)
+
+		types, err := o.meta.Migrator().ColumnTypes(table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		for _, ct := range types {
+			t.Columns = append(t.Columns, newSnapshotColumn(ct))
+			// gen maps the columns of the other drivers by scan type
+			if name != "mysql" && name != "sqlite" && ct.ScanType() != nil {
+				if snap.ScanTypes == nil {
+					snap.ScanTypes = make(map[string]string)
+				}
+				snap.ScanTypes[ct.DatabaseTypeName()] = ct.ScanType().String()
+			}
+		}
+		// gen ignores the tables whose indexes or comment cannot be read
+		if indexes, err := o.meta.Migrator().GetIndexes(table); err == nil {
+			for _, idx := range indexes {
+				t.Indexes = append(t.Indexes, newSnapshotIndex(idx))
+			}
+		}
+		if tt, err := o.meta.Migrator().TableType(table); err == nil {
+			if comment, ok := tt.Comment(); ok {
+				t.Comment = &comment
+			}
+		}
+		if redact || o.isDegraded(degradedComments) {
+			t.redactComments()
+		}
+		snap.Tables = append(snap.Tables, t)
+	}
+	return snap, nil
+}
+
+// redactComments blanks the comments of the table and its columns.
+func (t *snapshotTable) redactComments() {
+	blank := func(comment *string) *string {
+		if comment == nil {
+			return nil
+		}
+		return new(string)
+	}
+	t.Comment = blank(t.Comment)
+	for i := range t.Columns {
+		t.Columns[i].CommentValue = blank(t.Columns[i].CommentValue)
+	}
+	for i := range t.Meta {
+		t.Meta[i].Comment = ""
+	}
+}
+
+// table returns the snapshot of a table.
+func (s *schemaSnapshot) table(name string) (*snapshotTable, error) {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i], nil
+		}
+	}
+	return nil, fmt.Errorf("table %s is not in the schema snapshot", name)
+}
+
+// schemaTables returns the qualified tables of schema in the snapshot.
+func (s *schemaSnapshot) schemaTables(schema string) []string {
+	var tables []string
+	for _, t := range s.Tables {
+		if strings.HasPrefix(t.Name, schema+".") {
+			tables = append(tables, t.Name)
+		}
+	}
+	return tables
+}
+
+// snapshotOf returns the snapshot read by a connection opened on one.
+func snapshotOf(db *gorm.DB) (*schemaSnapshot, bool) {
+	if db == nil {
+		return nil, false
+	}
+	d, ok := db.Dialector.(snapshotDialector)
+	return d.snap, ok
+}
+
+// newSnapshotColumn records a column type.
+func newSnapshotColumn(ct gorm.ColumnType) snapshotColumn {
+	col := snapshotColumn{NameValue: ct.Name(), DataTypeValue: ct.DatabaseTypeName()}
+	if v, ok := ct.ColumnType(); ok {
+		col.ColumnTypeValue = &v
+	}
+	if v, ok := ct.PrimaryKey(); ok {
+		col.PrimaryKeyValue = &v
+	}
+	if v, ok := ct.AutoIncrement(); ok {
+		col.AutoIncrementValue = &v
+	}
+	if v, ok := ct.Length(); ok {
+		col.LengthValue = &v
+	}
+	if precision, scale, ok := ct.DecimalSize(); ok {
+		col.DecimalSizeValue = &[2]int64{precision, scale}
+	}
+	if v, ok := ct.Nullable(); ok {
+		col.NullableValue = &v
+	}
+	if v, ok := ct.Unique(); ok {
+		col.UniqueValue = &v
+	}
+	if v, ok := ct.Comment(); ok {
+		col.CommentValue = &v
+	}
+	if v, ok := ct.DefaultValue(); ok {
+		col.DefaultValueValue = &v
+	}
+	return col
+}
+
+// newSnapshotIndex records an index.
+func newSnapshotIndex(idx gorm.Index) snapshotIndex {
+	si := snapshotIndex{TableValue: idx.Table(), NameValue: idx.Name(), ColumnList: idx.Columns(), OptionValue: idx.Option()}
+	if v, ok := idx.PrimaryKey(); ok {
+		si.PrimaryKeyValue = &v
+	}
+	if v, ok := idx.Unique(); ok {
+		si.UniqueValue = &v
+	}
+	return si
+}
+
+// value returns the value of a recorded pointer and whether it was recorded.
+func value[T any](v *T) (T, bool) {
+	if v == nil {
+		var zero T
+		return zero, false
+	}
+	return *v, true
+}
+
+// Name implements gorm.ColumnType.
+func (c snapshotColumn) Name() string { return c.NameValue }
+
+// DatabaseTypeName implements gorm.ColumnType.
+func (c snapshotColumn) DatabaseTypeName() string { return c.DataTypeValue }
+
+// ColumnType implements gorm.ColumnType.
+func (c snapshotColumn) ColumnType() (string, bool) { return value(c.ColumnTypeValue) }
+
+// PrimaryKey implements gorm.ColumnType.
+func (c snapshotColumn) PrimaryKey() (bool, bool) { return value(c.PrimaryKeyValue) }
+
+// AutoIncrement implements gorm.ColumnType.
+func (c snapshotColumn) AutoIncrement() (bool, bool) { return value(c.AutoIncrementValue) }
+
+// Length implements gorm.ColumnType.
+func (c snapshotColumn) Length() (int64, bool) { return value(c.LengthValue) }
+
+// DecimalSize implements gorm.ColumnType.
+func (c snapshotColumn) DecimalSize() (int64, int64, bool) {
+	size, ok := value(c.DecimalSizeValue)
+	return size[0], size[1], ok
+}
+
+// Nullable implements gorm.ColumnType.
+func (c snapshotColumn) Nullable() (bool, bool) { return value(c.NullableValue) }
+
+// Unique implements gorm.ColumnType.
+func (c snapshotColumn) Unique() (bool, bool) { return value(c.UniqueValue) }
+
+// ScanType implements gorm.ColumnType. The scan types gen reads are replayed
+// through the data type map, see dataTypes.
+func (c snapshotColumn) ScanType() reflect.Type { return reflect.TypeFor[any]() }
+
+// Comment implements gorm.ColumnType.
+func (c snapshotColumn) Comment() (string, bool) { return value(c.CommentValue) }
+
+// DefaultValue implements gorm.ColumnType.
+func (c snapshotColumn) DefaultValue() (string, bool) { return value(c.DefaultValueValue) }
+
+// Table implements gorm.Index.
+func (i snapshotIndex) Table() string { return i.TableValue }
+
+// Name implements gorm.Index.
+func (i snapshotIndex) Name() string { return i.NameValue }
+
+// Columns implements gorm.Index.
+func (i snapshotIndex) Columns() []string { return i.ColumnList }
+
+// PrimaryKey implements gorm.Index.
+func (i snapshotIndex) PrimaryKey() (bool, bool) { return value(i.PrimaryKeyValue) }
+
+// Unique implements gorm.Index.
+func (i snapshotIndex) Unique() (bool, bool) { return value(i.UniqueValue) }
+
+// Option implements gorm.Index.
+func (i snapshotIndex) Option() string { return i.OptionValue }
+
+type (
+	// snapshotDialector opens a connection answering the schema queries of
+	// gen from a snapshot. It is named after the snapshot driver, so the
+	// driver type mappings apply, and every other query fails.
+	snapshotDialector struct {
+		snap *schemaSnapshot
+	}
+	// snapshotMigrator reads the schema of a snapshotDialector.
+	snapshotMigrator struct {
+		migrator.Migrator
+		snap *schemaSnapshot
+	}
+	// offlinePool is the connection pool of a snapshotDialector.
+	offlinePool struct{}
+)
+
+// openSnapshot opens a connection on a snapshot.
+func openSnapshot(snap *schemaSnapshot) (*gorm.DB, error) {
+	return gorm.Open(snapshotDialector{snap: snap}, &gorm.Config{Logger: logger.Discard})
+}
+
+// Name implements gorm.Dialector.
+func (d snapshotDialector) Name() string { return d.snap.Driver }
+
+// Initialize implements gorm.Dialector.
+func (d snapshotDialector) Initialize(db *gorm.DB) error {
+	db.ConnPool = offlinePool{}
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	return nil
+}
+
+// Migrator implements gorm.Dialector.
+func (d snapshotDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return snapshotMigrator{Migrator: migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}}, snap: d.snap}
+}
+
+// DataTypeOf implements gorm.Dialector.
+func (d snapshotDialector) DataTypeOf(*schema.Field) string { return "" }
+
+// DefaultValueOf implements gorm.Dialector.
+func (d snapshotDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+// BindVarTo implements gorm.Dialector.
+func (d snapshotDialector) BindVarTo(writer clause.Writer, _ *gorm.Statement, _ any) {
+	writer.WriteByte('?')
+}
+
+// QuoteTo implements gorm.Dialector.
+func (d snapshotDialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteString(str)
+}
+
+// Explain implements gorm.Dialector.
+func (d snapshotDialector) Explain(sql string, vars ...any) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
+
+// CurrentDatabase implements gorm.Migrator.
+func (m snapshotMigrator) CurrentDatabase() string { return m.snap.Database }
+
+// GetTables implements gorm.Migrator, the tables of other schemas are read
+// with schemaTables.
+func (m snapshotMigrator) GetTables() ([]string, error) {
+	var tables []string
+	for _, t := range m.snap.Tables {
+		if !strings.Contains(t.Name, ".") {
+			tables = append(tables, t.Name)
+		}
+	}
+	return tables, nil
+}
+
+// HasTable implements gorm.Migrator.
+func (m snapshotMigrator) HasTable(value any) bool {
+	name, ok := value.(string)
+	if !ok {
+		return false
+	}
+	_, err := m.snap.table(name)
+	return err == nil
+}
+
+// ColumnTypes implements gorm.Migrator.
+func (m snapshotMigrator) ColumnTypes(value any) ([]gorm.ColumnType, error) {
+	t, err := m.lookup(value)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]gorm.ColumnType, len(t.Columns))
+	for i, c := range t.Columns {
+		types[i] = c
+	}
+	return types, nil
+}
+
+// GetIndexes implements gorm.Migrator.
+func (m snapshotMigrator) GetIndexes(value any) ([]gorm.Index, error) {
+	t, err := m.lookup(value)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]gorm.Index, len(t.Indexes))
+	for i, idx := range t.Indexes {
+		indexes[i] = idx
+	}
+	return indexes, nil
+}
+
+// TableType implements gorm.Migrator.
+func (m snapshotMigrator) TableType(value any) (gorm.TableType, error) {
+	t, err := m.lookup(value)
+	if err != nil {
+		return nil, err
+	}
+	if t.Comment == nil {
+		return nil, fmt.Errorf("table %s has no comment in the schema snapshot", t.Name)
+	}
+	return migrator.TableType{NameValue: t.Name, TypeValue: "BASE TABLE", CommentValue: sql.NullString{String: *t.Comment, Valid: true}}, nil
+}
+
+// lookup returns the snapshot of a table given by name.
+func (m snapshotMigrator) lookup(value any) (*snapshotTable, error) {
+	name, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a table name: %w", value, errOffline)
+	}
+	return m.snap.table(name)
+}
+
+// PrepareContext implements gorm.ConnPool.
+func (offlinePool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errOffline
+}
+
+// ExecContext implements gorm.ConnPool.
+func (offlinePool) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, errOffline
+}
+
+// QueryContext implements gorm.ConnPool.
+func (offlinePool) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errOffline
+}
+
+// QueryRowContext implements gorm.ConnPool. A *sql.Row cannot carry an error
+// outside database/sql, the snapshot is never read by row.
+func (offlinePool) QueryRowContext(context.Context, string, ...any) *sql.Row {
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"slices"
+	"strings"
+
+	"gorm.io/gen"
+)
+
+// defaultSoftDelete are the soft delete columns without WithSoftDelete.
+var defaultSoftDelete = []string{"*->deleted_at"}
+
+// softDeleteType reports whether a column of the database type can hold the
+// deletion time of gorm.DeletedAt.
+func softDeleteType(dataType string) bool {
+	dataType = strings.ToLower(dataType)
+	return strings.HasPrefix(dataType, "timestamp") || strings.HasPrefix(dataType, "datetime") || dataType == "smalldatetime"
+}
+
+// softDeleteOpts returns the options mapping the soft delete columns of a
+// table to gorm.DeletedAt. Ignored columns stay ignored, a data type rule
+// keyed by the column wins, and non-nullable or non-timestamp columns keep
+// their type with a warning.
+func (o *Orm) softDeleteOpts(table string, columns []columnMeta) []gen.ModelOpt {
+	var opts []gen.ModelOpt
+	for _, col := range columns {
+		if !o.rules.softDeleted(table, col.Name) {
+			continue
+		}
+		if o.rules.ignoreRules[table][col.Name] != "" || o.rules.ignoreRules["*"][col.Name] != "" {
+			continue
+		}
+		if matchTypeRule(o.opt.dataType, table, col.Name) != "-" || matchTypeRule(o.opt.tableDataType, table, col.Name) != "-" {
+			continue
+		}
+		if !softDeleteType(col.DataType) || col.Nullable != "YES" {
+			o.warn(warnRules, "Warning: soft delete column %s.%s is not a nullable timestamp (%s), its type is kept\n", table, col.Name, col.DataType)
+			continue
+		}
+		opts = append(opts, gen.FieldType(col.Name, "gorm.DeletedAt"))
+	}
+	return opts
+}
+
+// softDeleted reports whether a column of the table is a soft delete column.
+func (rs ruleSet) softDeleted(table, column string) bool {
+	return slices.Contains(rs.softDeletes["*"], column) || slices.Contains(rs.softDeletes[table], column)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import "gorm.io/gorm"
+
+// spatialPkg is the import path of the spatial column types.
+const spatialPkg = "command/types"
+
+// spatialTypes maps the MySQL spatial column types with --spatial.
+// Explicit data type rules win.
+var spatialTypes = map[string]DataTypeFn{
+	"point":              fixedType("types.Point"),
+	"geometry":           fixedType("types.Geometry"),
+	"linestring":         fixedType("types.Geometry"),
+	"polygon":            fixedType("types.Geometry"),
+	"multipoint":         fixedType("types.Geometry"),
+	"multilinestring":    fixedType("types.Geometry"),
+	"multipolygon":       fixedType("types.Geometry"),
+	"geometrycollection": fixedType("types.Geometry"),
+	"geomcollection":     fixedType("types.Geometry"),
+}
+
+// fixedType returns a DataTypeFn mapping every column to typ.
+func fixedType(typ string) DataTypeFn {
+	return func(gorm.ColumnType) string {
+		return typ
+	}
+}
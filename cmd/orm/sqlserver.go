@@ -0,0 +1,160 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"database/sql"
+	"strings"
+
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+const (
+	// sqlserverTablesSQL lists the base tables of the current database.
+	sqlserverTablesSQL = "SELECT TABLE_SCHEMA AS table_schema, TABLE_NAME AS table_name FROM INFORMATION_SCHEMA.TABLES " +
+		"WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_CATALOG = DB_NAME() ORDER BY TABLE_SCHEMA, TABLE_NAME"
+	// sqlserverColumnsSQL reads the columns of a table of a schema.
+	sqlserverColumnsSQL = `SELECT c.COLUMN_NAME AS name, c.DATA_TYPE AS data_type, c.COLUMN_DEFAULT AS column_default,
+	c.IS_NULLABLE AS is_nullable, c.CHARACTER_MAXIMUM_LENGTH AS length, c.NUMERIC_PRECISION AS numeric_precision,
+	c.NUMERIC_SCALE AS numeric_scale,
+	COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsIdentity') AS is_identity,
+	(SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS t JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE k
+		ON k.CONSTRAINT_SCHEMA = t.CONSTRAINT_SCHEMA AND k.CONSTRAINT_NAME = t.CONSTRAINT_NAME
+		WHERE t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME AND k.COLUMN_NAME = c.COLUMN_NAME
+		AND t.CONSTRAINT_TYPE = 'PRIMARY KEY') AS is_primary,
+	(SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS t JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE k
+		ON k.CONSTRAINT_SCHEMA = t.CONSTRAINT_SCHEMA AND k.CONSTRAINT_NAME = t.CONSTRAINT_NAME
+		WHERE t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME AND k.COLUMN_NAME = c.COLUMN_NAME
+		AND t.CONSTRAINT_TYPE = 'UNIQUE') AS is_unique
+FROM INFORMATION_SCHEMA.COLUMNS c WHERE c.TABLE_SCHEMA = ? AND c.TABLE_NAME = ? ORDER BY c.ORDINAL_POSITION`
+)
+
+// sqlserverDialector is the SQL Server dialector with a migrator that knows
+// schema-qualified table names such as sales.Orders.
+type sqlserverDialector struct {
+	sqlserver.Dialector
+}
+
+// sqlserverOpen returns the dialector of the sqlserver --driver.
+func sqlserverOpen(dsn string) gorm.Dialector {
+	return sqlserverDialector{sqlserver.Dialector{Config: &sqlserver.Config{DSN: dsn}}}
+}
+
+// Migrator implements gorm.Dialector.
+func (d sqlserverDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqlserverMigrator{d.Dialector.Migrator(db).(sqlserver.Migrator)}
+}
+
+// sqlserverMigrator lists the tables of every schema and reads the columns
+// of schema-qualified tables, which the sqlserver migrator looks up by bare
+// name in any schema.
+type sqlserverMigrator struct {
+	sqlserver.Migrator
+}
+
+// GetTables returns the base tables of the current database, qualified with
+// their schema when it is not the default schema of the user.
+func (m sqlserverMigrator) GetTables() ([]string, error) {
+	var rows []struct {
+		TableSchema string
+		TableName   string
+	}
+	if err := m.DB.Raw(sqlserverTablesSQL).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	current := m.defaultSchema()
+	tables := make([]string, 0, len(rows))
+	for _, r := range rows {
+		if strings.EqualFold(r.TableSchema, current) {
+			tables = append(tables, r.TableName)
+			continue
+		}
+		tables = append(tables, r.TableSchema+"."+r.TableName)
+	}
+	return tables, nil
+}
+
+// ColumnTypes returns the columns of a table. Tables of the default schema
+// are read by the sqlserver migrator, the others with their schema.
+func (m sqlserverMigrator) ColumnTypes(value any) ([]gorm.ColumnType, error) {
+	name, ok := value.(string)
+	schema, table := splitTable(name)
+	if !ok || schema == "" {
+		return m.Migrator.ColumnTypes(value)
+	}
+	if strings.EqualFold(schema, m.defaultSchema()) {
+		return m.Migrator.ColumnTypes(table)
+	}
+
+	// The driver types of the columns, as the sqlserver migrator reports them
+	rows, err := m.DB.Session(&gorm.Session{}).Table(name).Limit(1).Rows()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rows.ColumnTypes()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []struct {
+		Name             string
+		DataType         string
+		ColumnDefault    sql.NullString
+		IsNullable       string
+		Length           sql.NullInt64
+		NumericPrecision sql.NullInt64
+		NumericScale     sql.NullInt64
+		IsIdentity       sql.NullInt64
+		IsPrimary        int
+		IsUnique         int
+	}
+	if err := m.DB.Raw(sqlserverColumnsSQL, schema, table).Scan(&columns).Error; err != nil {
+		return nil, err
+	}
+	types := make([]gorm.ColumnType, 0, len(columns))
+	for _, c := range columns {
+		ct := migrator.ColumnType{
+			NameValue:          sql.NullString{String: c.Name, Valid: true},
+			ColumnTypeValue:    sql.NullString{String: c.DataType, Valid: true},
+			DataTypeValue:      sql.NullString{String: strings.ToUpper(c.DataType), Valid: true},
+			DefaultValueValue:  c.ColumnDefault,
+			NullableValue:      sql.NullBool{Bool: strings.EqualFold(c.IsNullable, "YES"), Valid: true},
+			LengthValue:        c.Length,
+			DecimalSizeValue:   c.NumericPrecision,
+			ScaleValue:         c.NumericScale,
+			AutoIncrementValue: sql.NullBool{Bool: c.IsIdentity.Int64 == 1, Valid: true},
+			PrimaryKeyValue:    sql.NullBool{Bool: c.IsPrimary > 0, Valid: true},
+			UniqueValue:        sql.NullBool{Bool: c.IsUnique > 0, Valid: true},
+		}
+		for _, r := range raw {
+			if r.Name() == c.Name {
+				ct.SQLColumnType = r
+				break
+			}
+		}
+		types = append(types, ct)
+	}
+	return types, nil
+}
+
+// defaultSchema returns the default schema of the user, usually dbo.
+func (m sqlserverMigrator) defaultSchema() (name string) {
+	m.DB.Raw("SELECT SCHEMA_NAME()").Row().Scan(&name)
+	return name
+}
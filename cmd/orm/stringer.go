@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// redacted replaces the value of redacted fields in textual output.
+const redacted = "<redacted>"
+
+// stringerPass appends String and LogValue methods to the model of a table.
+// Redacted fields print as "<redacted>", their JSON and DB behavior is kept.
+func (o *Orm) stringerPass(table string, src []byte) ([]byte, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := o.fields[table]
+	hidden := append(slices.Clone(o.rules.redact["*"]), o.rules.redact[table]...)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			var names []string
+			var isRedacted []bool
+			for _, f := range st.Fields.List {
+				for _, name := range f.Names {
+					names = append(names, name.Name)
+					isRedacted = append(isRedacted, slices.Contains(hidden, columns[name.Name]))
+				}
+			}
+			src = append(src, stringerMethods(ts.Name.Name, names, isRedacted)...)
+		}
+	}
+	return src, nil
+}
+
+// stringerMethods renders the String and LogValue methods of a model.
+func stringerMethods(model string, names []string, isRedacted []bool) []byte {
+	var format, args, attrs []string
+	for i, name := range names {
+		if isRedacted[i] {
+			format = append(format, name+":"+redacted)
+			attrs = append(attrs, fmt.Sprintf("slog.String(%q, %q)", name, redacted))
+			continue
+		}
+		format = append(format, name+":%v")
+		args = append(args, "m."+name)
+		attrs = append(attrs, fmt.Sprintf("slog.Any(%q, m.%s)", name, name))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// String implements fmt.Stringer, redacted fields print as %q.\n", redacted)
+	fmt.Fprintf(&buf, "func (m %s) String() string {\n", model)
+	fmt.Fprintf(&buf, "\treturn fmt.Sprintf(%q", model+"{"+strings.Join(format, " ")+"}")
+	for _, arg := range args {
+		buf.WriteString(", " + arg)
+	}
+	buf.WriteString(")\n}\n")
+
+	fmt.Fprintf(&buf, "\n// LogValue implements slog.LogValuer, redacted fields log as %q.\n", redacted)
+	fmt.Fprintf(&buf, "func (m %s) LogValue() slog.Value {\n", model)
+	buf.WriteString("\treturn slog.GroupValue(\n")
+	for _, attr := range attrs {
+		buf.WriteString("\t\t" + attr + ",\n")
+	}
+	buf.WriteString("\t)\n}\n")
+	return buf.Bytes()
+}
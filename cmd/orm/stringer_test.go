@@ -0,0 +1,39 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import "testing"
+
+func TestStringer(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		opts   []IOrmOption
+	}{
+		{name: "plain", golden: "stringer_plain.golden"},
+		{
+			name:   "redacted",
+			golden: "stringer_redacted.golden",
+			opts:   []IOrmOption{WithRedact([]string{"*->password_hash", "users->email,phone"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := generate(t, openFixture(t, "users"), []string{"-t", "users", "--with-stringer"}, tt.opts...)
+			golden(t, tt.golden, files["model/users.gen.go"])
+		})
+	}
+}
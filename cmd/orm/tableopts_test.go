@@ -0,0 +1,48 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableOptionsStayScoped(t *testing.T) {
+	opts := []IOrmOption{
+		WithIgnore([]string{"customers->created_at"}),
+		WithRetags([]string{"customers->status->state"}),
+		WithReGromTags([]string{"customers->email->mail"}),
+	}
+	for _, order := range [][]string{{"customers", "orders"}, {"orders", "customers"}} {
+		files := generate(t, openFixture(t, "shop"), []string{"-t", order[0], "-t", order[1]}, opts...)
+
+		customers := files["model/customers.gen.go"]
+		if fieldLine(customers, "CreatedAt") != "" || !strings.Contains(fieldLine(customers, "Email"), "column:mail;") || !strings.Contains(fieldLine(customers, "Status"), `json:"state,omitempty"`) {
+			t.Errorf("%v: customer rules not applied:\n%s", order, customers)
+		}
+
+		orders := files["model/orders.gen.go"]
+		if !strings.Contains(fieldLine(orders, "CreatedAt"), `json:"created_at,omitempty"`) {
+			t.Errorf("%v: orders lost created_at:\n%s", order, orders)
+		}
+		if !strings.Contains(fieldLine(orders, "Status"), `json:"status,omitempty"`) {
+			t.Errorf("%v: orders status retagged:\n%s", order, orders)
+		}
+		if strings.Contains(orders, "column:mail") || strings.Contains(orders, "state") {
+			t.Errorf("%v: customer rules reached orders:\n%s", order, orders)
+		}
+	}
+}
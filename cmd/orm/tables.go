@@ -0,0 +1,247 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bufio"
+	"command/cmd"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Table selectors of the -t flag, resolved by resolveTables:
+//
+//   - users, users@Member: the table, optionally with its model name
+//   - user_*: the tables matching a glob
+//   - /^audit_\d+$/: the tables matching a regular expression
+//   - log_*@Log, /^log_\d+$/@Log: the first matching table, in name order,
+//     generated as the model, for sharded tables of the same columns
+//   - @tables.txt: the selectors listed in a file, one per line
+//   - !audit_log, !tmp_*, !/_bak$/, !@skip.txt: the tables subtracted from
+//     the selection
+//
+// The tables of the included selectors are unioned, all tables when there
+// is none, then the tables of the negative selectors and of --exclude are
+// subtracted.
+
+// expandTableFiles replaces the @file selectors with the selectors listed in
+// the files, one per line, negated for !@file selectors. Blank lines and #
+// comments are skipped.
+func expandTableFiles(selectors []string) ([]string, error) {
+	var expanded []string
+	for _, sel := range selectors {
+		negative, name := "", sel
+		if rest, ok := strings.CutPrefix(sel, "!"); ok {
+			negative, name = "!", rest
+		}
+		name, ok := strings.CutPrefix(name, "@")
+		if !ok {
+			expanded = append(expanded, sel)
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading table selectors: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			if strings.HasPrefix(text, "@") {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: table files cannot include %s", name, line, text)
+			}
+			if negative != "" && strings.HasPrefix(text, "!") {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: negative table files cannot list negative selectors", name, line)
+			}
+			expanded = append(expanded, negative+text)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading table selectors: %w", err)
+		}
+	}
+	return expanded, nil
+}
+
+// tableSelector is a parsed selector of the -t flag.
+type tableSelector struct {
+	raw      string
+	negative bool
+	// name is the table of an exact selector, model name suffix included
+	name string
+	glob string
+	re   *regexp.Regexp
+	// model is the model name of a pattern selecting its first match
+	model string
+}
+
+// parseTableSelector parses a selector without @file expansion.
+func parseTableSelector(raw string) (tableSelector, error) {
+	s := tableSelector{raw: raw}
+	sel, negative := strings.CutPrefix(raw, "!")
+	s.negative = negative
+	switch {
+	case sel == "":
+		return s, fmt.Errorf("empty table selector %q", raw)
+	case !strings.HasSuffix(sel, "/") && strings.Contains(sel, "@"):
+		// Patterns name their model after the last @
+		i := strings.LastIndex(sel, "@")
+		if pattern, err := parseTableSelector(sel[:i]); err == nil && pattern.pattern() {
+			if negative {
+				return s, fmt.Errorf("table selector %q: a negative selector cannot name a model", raw)
+			}
+			if sel[i+1:] == "" {
+				return s, fmt.Errorf("table selector %q: empty model name", raw)
+			}
+			pattern.raw, pattern.model = raw, sel[i+1:]
+			return pattern, nil
+		}
+		s.name = sel
+	case len(sel) > 1 && strings.HasPrefix(sel, "/") && strings.HasSuffix(sel, "/"):
+		re, err := regexp.Compile(sel[1 : len(sel)-1])
+		if err != nil {
+			return s, fmt.Errorf("table selector %q: %w", raw, err)
+		}
+		s.re = re
+	case strings.ContainsAny(sel, "*?["):
+		if _, err := path.Match(sel, ""); err != nil {
+			return s, fmt.Errorf("table selector %q: %w", raw, err)
+		}
+		s.glob = sel
+	default:
+		s.name = sel
+	}
+	return s, nil
+}
+
+// pattern reports whether the selector is a glob or a regular expression.
+func (s tableSelector) pattern() bool {
+	return s.glob != "" || s.re != nil
+}
+
+// match reports whether the selector matches the table.
+func (s tableSelector) match(table string) bool {
+	switch {
+	case s.re != nil:
+		return s.re.MatchString(table)
+	case s.glob != "":
+		ok, _ := path.Match(s.glob, table)
+		return ok
+	}
+	name, _, _ := strings.Cut(s.name, "@")
+	return name == table
+}
+
+// resolveTables resolves the expanded selectors against the tables of the
+// database, in selector order. Exact selectors are kept as given, so unknown
+// tables are still reported by the generation, and patterns expand to the
+// tables they match.
+func (o *Orm) resolveTables(selectors, all []string) ([]string, error) {
+	var includes, excludes []tableSelector
+	for _, raw := range selectors {
+		s, err := parseTableSelector(raw)
+		if err != nil {
+			return nil, err
+		}
+		if s.negative {
+			excludes = append(excludes, s)
+		} else {
+			includes = append(includes, s)
+		}
+	}
+
+	var tables []string
+	add := func(table string) {
+		if !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	// Without includes every table of the current schema is selected
+	if len(includes) == 0 {
+		for _, table := range all {
+			if schema, _ := splitTable(table); schema == "" {
+				add(table)
+			}
+		}
+	}
+	for _, s := range includes {
+		if !s.pattern() {
+			add(s.name)
+			continue
+		}
+		var matches []string
+		for _, table := range all {
+			if s.match(table) {
+				matches = append(matches, table)
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			o.warn(warnTables, "Warning: table selector %s matches no table\n", s.raw)
+		case s.model != "":
+			first := slices.Min(matches)
+			cmd.Debugf("Table selector %s generates %s from %s of %d tables\n", s.raw, s.model, first, len(matches))
+			add(first + "@" + s.model)
+		default:
+			for _, table := range matches {
+				add(table)
+			}
+		}
+	}
+
+	// Tables of --exclude are counted apart from the negative selectors
+	var excluded []tableSelector
+	for _, raw := range o.exclude {
+		if strings.HasPrefix(raw, "!") || strings.HasPrefix(raw, "@") {
+			return nil, fmt.Errorf("exclude selector %q: only names and patterns can be excluded", raw)
+		}
+		s, err := parseTableSelector(raw)
+		if err != nil {
+			return nil, err
+		} else if s.model != "" {
+			return nil, fmt.Errorf("exclude selector %q: only names and patterns can be excluded", raw)
+		}
+		excluded = append(excluded, s)
+	}
+	var skipped []string
+	tables = slices.DeleteFunc(tables, func(table string) bool {
+		name, _, _ := strings.Cut(table, "@")
+		if slices.ContainsFunc(excluded, func(s tableSelector) bool { return s.match(name) }) {
+			skipped = append(skipped, name)
+			return true
+		}
+		return slices.ContainsFunc(excludes, func(s tableSelector) bool { return s.match(name) })
+	})
+	if len(skipped) > 0 {
+		color.HiBlack("Skipped %d tables matching --exclude: %s\n", len(skipped), strings.Join(skipped, ", "))
+	}
+	if len(tables) == 0 && len(selectors)+len(excluded) > 0 {
+		return nil, &EmptyGenerationError{Style: "model", Reason: "the table selectors select no table"}
+	}
+	cmd.Debugf("Resolved tables: %s\n", strings.Join(tables, ", "))
+	return tables, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTableSelector(t *testing.T) {
+	tests := []struct {
+		raw     string
+		name    string
+		glob    string
+		re      string
+		model   string
+		negated bool
+		err     string
+	}{
+		{raw: "", err: "empty table selector"},
+		{raw: "!", err: "empty table selector"},
+		{raw: "users", name: "users"},
+		{raw: "users@Member", name: "users@Member"},
+		{raw: "!users", name: "users", negated: true},
+		{raw: "user_*", glob: "user_*"},
+		{raw: "!tmp_?", glob: "tmp_?", negated: true},
+		{raw: "user_[", err: "syntax error in pattern"},
+		{raw: `/^audit_\d+$/`, re: `^audit_\d+$`},
+		{raw: "/(/", err: "missing closing )"},
+		{raw: "/", name: "/"},
+		{raw: "log_*@Log", glob: "log_*", model: "Log"},
+		{raw: `/^log_\d+$/@Log`, re: `^log_\d+$`, model: "Log"},
+		{raw: "log_*@", err: "empty model name"},
+		{raw: "!log_*@Log", err: "a negative selector cannot name a model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			s, err := parseTableSelector(tt.raw)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("parseTableSelector = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var re string
+			if s.re != nil {
+				re = s.re.String()
+			}
+			if s.name != tt.name || s.glob != tt.glob || re != tt.re || s.model != tt.model || s.negative != tt.negated {
+				t.Errorf("parseTableSelector = %+v", s)
+			}
+		})
+	}
+}
+
+func TestResolveTables(t *testing.T) {
+	all := []string{"audit_1", "audit_2", "log_2024", "log_2025", "orders", "tmp_a", "users", "core.users"}
+	tests := []struct {
+		name      string
+		selectors []string
+		exclude   []string
+		want      []string
+		err       string
+	}{
+		{name: "all tables of the current schema", want: []string{"audit_1", "audit_2", "log_2024", "log_2025", "orders", "tmp_a", "users"}},
+		{name: "duplicate names", selectors: []string{"users", "orders", "users"}, want: []string{"users", "orders"}},
+		{name: "name and glob overlap", selectors: []string{"users", "u*", "audit_*"}, want: []string{"users", "audit_1", "audit_2"}},
+		{name: "glob and regexp overlap", selectors: []string{"audit_*", `/^audit_\d$/`}, want: []string{"audit_1", "audit_2"}},
+		{name: "unknown names are kept", selectors: []string{"nope"}, want: []string{"nope"}},
+		{name: "glob matching nothing", selectors: []string{"users", "zz_*"}, want: []string{"users"}},
+		{name: "negated name", selectors: []string{"!tmp_a", "!log_*"}, want: []string{"audit_1", "audit_2", "orders", "users"}},
+		{name: "include and exclude overlap", selectors: []string{"users", "!users"}, err: "select no table"},
+		{name: "negation wins over order", selectors: []string{"!audit_1", "audit_*"}, want: []string{"audit_2"}},
+		{name: "negated model name", selectors: []string{"users@Member", "!users"}, err: "select no table"},
+		{name: "sharded model", selectors: []string{"log_*@Log", "log_*@Log"}, want: []string{"log_2024@Log"}},
+		{name: "qualified glob", selectors: []string{"core.*"}, want: []string{"core.users"}},
+		{name: "exclude overlapping the includes", selectors: []string{"audit_*", "users"}, exclude: []string{"audit_?"}, want: []string{"users"}},
+		{name: "exclude of everything", exclude: []string{"*", "core.*"}, err: "select no table"},
+		{name: "negative exclude", exclude: []string{"!users"}, err: "only names and patterns"},
+		{name: "exclude of a file", exclude: []string{"@skip.txt"}, err: "only names and patterns"},
+		{name: "exclude naming a model", exclude: []string{"log_*@Log"}, err: "only names and patterns"},
+		{name: "empty selector", selectors: []string{"users", ""}, err: "empty table selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOrm(OrmOption{})
+			o.exclude = tt.exclude
+			got, err := o.resolveTables(tt.selectors, all)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("resolveTables = %v, %v, want an error containing %q", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("resolveTables = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTableFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for name, data := range map[string]string{
+		"tables.txt":  "# billing\nusers\n\norders\n!tmp_*\n",
+		"skip.txt":    "tmp_*\nlog_*\n",
+		"nested.txt":  "users\n@tables.txt\n",
+		"negated.txt": "!users\n",
+	} {
+		if err := os.WriteFile(filepath.Join(".", name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := expandTableFiles([]string{"audit_*", "@tables.txt", "!@skip.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"audit_*", "users", "orders", "!tmp_*", "!tmp_*", "!log_*"}; !slices.Equal(got, want) {
+		t.Errorf("expandTableFiles = %v, want %v", got, want)
+	}
+
+	for selector, want := range map[string]string{
+		"@nested.txt":   "nested.txt:2: table files cannot include @tables.txt",
+		"!@negated.txt": "negated.txt:1: negative table files cannot list negative selectors",
+	} {
+		if _, err := expandTableFiles([]string{selector}); err == nil || err.Error() != want {
+			t.Errorf("expandTableFiles(%s) = %v, want %s", selector, err, want)
+		}
+	}
+	if _, err := expandTableFiles([]string{"@missing.txt"}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expandTableFiles(@missing.txt) = %v, want a missing file", err)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/spf13/pflag"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// tunnelNet prefixes the mysql network names dialing through the SSH tunnel.
+const tunnelNet = "czx-ssh"
+
+// tunnel opens an SSH tunnel when --ssh-host is set. The returned rewrite
+// func rewrites a DSN so the database, reached over tcp or a unix socket, is
+// dialed through the tunnel, and the close func tears the tunnel down.
+func tunnel(args *pflag.FlagSet, driver string) (func(string) (string, error), func(), error) {
+	host, err := args.GetString("ssh-host")
+	if err != nil || host == "" {
+		return func(dsn string) (string, error) { return dsn, nil }, func() {}, err
+	}
+	if driver != "mysql" {
+		return nil, nil, fmt.Errorf("--ssh-host only supports the mysql driver, not %s", driver)
+	}
+	username, err := args.GetString("ssh-user")
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := args.GetString("ssh-key")
+	if err != nil {
+		return nil, nil, err
+	}
+	knownHosts, err := args.GetString("ssh-known-hosts")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := dialSSH(host, username, key, knownHosts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rewrite := func(dsn string) (string, error) {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return "", fmt.Errorf("invalid dsn: %w", err)
+		}
+		// Each network gets its own dialer through the tunnel
+		network := cfg.Net
+		cfg.Net = tunnelNet + "+" + network
+		mysql.RegisterDialContext(cfg.Net, func(_ context.Context, addr string) (net.Conn, error) {
+			return client.Dial(network, addr)
+		})
+		return cfg.FormatDSN(), nil
+	}
+	return rewrite, func() { client.Close() }, nil
+}
+
+// dialSSH connects to the SSH host, authenticating with the key file and the
+// running ssh-agent, if any.
+func dialSSH(host, username, key, knownHosts string) (*ssh.Client, error) {
+	if !strings.Contains(host, ":") {
+		host = net.JoinHostPort(host, "22")
+	}
+	if username == "" {
+		u, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("ssh: unknown user: %w", err)
+		}
+		username = u.Username
+	}
+
+	var auths []ssh.AuthMethod
+	if key != "" {
+		pem, err := os.ReadFile(key)
+		if err != nil {
+			return nil, fmt.Errorf("ssh: read key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("ssh: parse key %s: %w", key, err)
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			defer conn.Close()
+			auths = append(auths, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	if len(auths) == 0 {
+		return nil, errors.New("ssh: no authentication method, set --ssh-key or start an ssh-agent")
+	}
+
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("ssh: %w", err)
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKey, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("ssh: load known hosts: %w", err)
+	}
+
+	client, err := ssh.Dial("tcp", host, &ssh.ClientConfig{
+		User:            username,
+		Auth:            auths,
+		HostKeyCallback: hostKey,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return nil, fmt.Errorf("ssh: authentication failed for %s@%s: %w", username, host, err)
+		}
+		return nil, fmt.Errorf("ssh: connect to %s: %w", host, err)
+	}
+	return client, nil
+}
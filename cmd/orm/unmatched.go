@@ -0,0 +1,139 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// columnRule is a rule naming the columns it applies to by table.
+type columnRule struct {
+	// Kind is the option of the rule, such as retag
+	Kind  string
+	Rule  string
+	Table string
+	// Columns are the column names, or the column names and database types
+	// for the data type rules
+	Columns []string
+	Types   bool
+}
+
+// columnRules returns the rules of the options naming columns.
+func (o *Orm) columnRules() []columnRule {
+	var rules []columnRule
+	arrows := func(kind string, list []string) {
+		for _, rule := range list {
+			parts := strings.Split(rule, "->")
+			if len(parts) >= 2 {
+				rules = append(rules, columnRule{Kind: kind, Rule: rule, Table: parts[0], Columns: strings.Split(parts[1], ",")})
+			}
+		}
+	}
+	arrows("retag", o.opt.retags)
+	arrows("reGromTag", o.opt.reGromTags)
+	arrows("ignore", o.opt.ignore)
+	arrows("only fields", o.opt.onlyFields)
+	arrows("nullable", o.opt.nullable)
+	arrows("not nullable", o.opt.notNullable)
+	for _, key := range sortedKeys(o.opt.dataType) {
+		table, typ, _ := strings.Cut(key, "->")
+		rules = append(rules, columnRule{Kind: "data type", Rule: key, Table: table, Columns: []string{typ}, Types: true})
+	}
+	for _, key := range sortedKeys(o.opt.tableDataType) {
+		table, typ, _ := strings.Cut(key, "->")
+		rules = append(rules, columnRule{Kind: "data type", Rule: key, Table: table, Columns: []string{typ}, Types: true})
+	}
+	return rules
+}
+
+// checkUnmatchedRules warns about the rules matching nothing in the schema,
+// typos most of the time: the column rules naming no column of the selected
+// tables, or a table missing from the database, and the rename and dao
+// table entries naming a missing table. all are the tables of the database
+// and tables the selected ones, whose columns are introspected. With strict
+// set the rules are returned as an error instead.
+func (o *Orm) checkUnmatchedRules(all, tables []string, strict bool) error {
+	exists := func(table string) bool {
+		_, bare := splitTable(table)
+		return slices.Contains(all, table) || slices.ContainsFunc(all, func(t string) bool {
+			_, b := splitTable(t)
+			return b == bare
+		})
+	}
+	matches := func(col columnMeta, name string, types bool) bool {
+		if col.Name == name {
+			return true
+		}
+		return types && (strings.EqualFold(col.DataType, name) || strings.EqualFold(col.Type, name))
+	}
+
+	var unmatched []string
+	for _, r := range o.columnRules() {
+		if r.Table != "*" && !exists(r.Table) {
+			unmatched = append(unmatched, fmt.Sprintf("%s %q: no table %s in the database", r.Kind, r.Rule, r.Table))
+			continue
+		}
+		var scope []string
+		for _, table := range tables {
+			if _, bare := splitTable(table); r.Table == "*" || r.Table == table || r.Table == bare {
+				scope = append(scope, table)
+			}
+		}
+		// Tables left out of the run are not checked
+		if len(scope) == 0 {
+			continue
+		}
+		for _, name := range r.Columns {
+			found := slices.ContainsFunc(scope, func(table string) bool {
+				return slices.ContainsFunc(o.columns[table], func(col columnMeta) bool { return matches(col, name, r.Types) })
+			})
+			if found {
+				continue
+			}
+			what := "column"
+			if r.Types {
+				what = "column or type"
+			}
+			where := "the selected tables"
+			if r.Table != "*" {
+				where = "table " + r.Table
+			}
+			unmatched = append(unmatched, fmt.Sprintf("%s %q: no %s %s in %s", r.Kind, r.Rule, what, name, where))
+		}
+	}
+	for _, table := range sortedKeys(o.opt.rename) {
+		if !exists(table) {
+			unmatched = append(unmatched, fmt.Sprintf("rename %q: no table %s in the database", table, table))
+		}
+	}
+	for _, table := range o.opt.daoTables {
+		if table != "*" && !exists(table) {
+			unmatched = append(unmatched, fmt.Sprintf("dao table %q: no table %s in the database", table, table))
+		}
+	}
+
+	if len(unmatched) == 0 {
+		return nil
+	}
+	if strict {
+		return &UnmatchedRulesError{Rules: unmatched}
+	}
+	o.warn(warnRules, "%s\n  %s\n", cmd.T("orm.unmatched_rules_warning", len(unmatched)), strings.Join(unmatched, "\n  "))
+	return nil
+}
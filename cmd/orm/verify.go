@@ -0,0 +1,227 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// staleFile is a generated file not matching the lockfile, and why.
+type staleFile struct {
+	Path   string
+	Reason string
+	// Cause is the schema or the tool after an upgrade of the command
+	Cause string
+}
+
+// verifyCommand returns the orm verify subcommand.
+func (o *Orm) verifyCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the generated files against the lockfile",
+		Long: `Check that the generated code still matches the lockfile written by the
+last generating run, listing every stale file with the reason.
+
+The fast mode, the default, checks that the gen.Config is the recorded one,
+that every recorded file is on disk with its recorded content and, when a
+database is configured, that the columns of the recorded tables are
+unchanged. --full generates again with the recorded generation flags into a
+staging directory and compares every file with the one on disk. Provenance
+comments are ignored by both.
+
+When the lockfile was written by another version of the command, --full
+attributes every file changed since to the schema, when the columns of one of
+its tables changed, or to the tool, when only the generated output did, and
+counts both apart.
+
+` + cmd.ExitCodesHelp,
+		Example: `# Check the generated code in CI, fast
+command orm verify
+
+# Check it by generating it again
+command orm verify --full`,
+		Args: cobra.NoArgs,
+		RunE: o.invoke((*Orm).verify),
+	}
+	o.generationFlags(c.Flags())
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Hidden = true
+	})
+	c.Flags().Bool("full", false, cmd.T("orm.verify.flag.full"))
+	return c
+}
+
+// verify is the execution logic for the orm verify command.
+func (o *Orm) verify(c *cobra.Command, _ []string) error {
+	full, err := c.Flags().GetBool("full")
+	if err != nil {
+		return err
+	}
+	path, err := c.Flags().GetString("lockfile")
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return usage("verifying", errors.New("--lockfile is empty"))
+	}
+	l, err := readLockfile(path)
+	if err != nil {
+		return usage("reading the lockfile", err)
+	}
+	if full {
+		if err := setGenerationFlags(c.Flags(), l.Flags); err != nil {
+			return usage("reading the lockfile", fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	if err := o.loadConfigFile(c.Flags()); err != nil {
+		return usage(cmd.T("orm.loading_config"), err)
+	}
+
+	closeConn, err := o.open(c.Context(), c.Flags())
+	switch {
+	case errors.Is(err, ErrNoDB) && !full:
+		color.Yellow("No database is configured, the columns of the tables are not checked.\n")
+	case err != nil:
+		return fail(cmd.T("orm.connecting"), err)
+	default:
+		defer closeConn()
+	}
+
+	var stale []staleFile
+	if full {
+		stale, err = o.verifyFull(c.Context(), c.Flags(), l)
+	} else {
+		stale, err = o.verifyFast(c.Context(), c.Flags(), l)
+	}
+	if err != nil {
+		return fail("verifying", err)
+	}
+	staleErr := &StaleLockError{Lockfile: path, Stale: len(stale)}
+	for _, s := range stale {
+		switch s.Cause {
+		case "schema":
+			staleErr.Schema++
+		case "tool":
+			staleErr.Tool++
+		default:
+			color.Yellow("stale: %s (%s)\n", s.Path, s.Reason)
+			continue
+		}
+		color.Yellow("stale: %s (%s, changed with the %s)\n", s.Path, s.Reason, s.Cause)
+	}
+	if len(stale) > 0 {
+		return staleErr
+	}
+	color.Green("\nThe %d generated files match %s.\n\n", len(l.Files), path)
+	return nil
+}
+
+// verifyFast checks the recorded files on disk against their checksums and,
+// when connected, the recorded tables against their metadata checksums.
+func (o *Orm) verifyFast(ctx context.Context, args *pflag.FlagSet, l *lockfile) ([]staleFile, error) {
+	if err := o.normalizeConfig(); err != nil {
+		return nil, err
+	}
+	stale := o.verifyConfig(l)
+
+	changed := make(map[string]bool)
+	if o.meta != nil {
+		batch, err := args.GetInt("introspect-batch")
+		if err != nil {
+			return nil, err
+		}
+		o.meta = o.meta.WithContext(ctx)
+		if err := o.introspect(sortedKeys(l.Tables), batch); err != nil {
+			return nil, err
+		}
+		hashes := o.tableHashes()
+		for table, sum := range l.Tables {
+			changed[table] = hashes[table] != sum
+		}
+	}
+
+	for _, f := range l.Files {
+		data, err := os.ReadFile(f.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			stale = append(stale, staleFile{Path: f.Path, Reason: "missing"})
+			continue
+		case err != nil:
+			return nil, err
+		case contentSum(data) != f.SHA256:
+			stale = append(stale, staleFile{Path: f.Path, Reason: "edited since it was generated"})
+			continue
+		}
+		if i := slices.IndexFunc(f.Tables, func(t string) bool { return changed[t] }); i >= 0 {
+			stale = append(stale, staleFile{Path: f.Path, Reason: "the columns of " + f.Tables[i] + " changed"})
+		}
+	}
+	return stale, nil
+}
+
+// verifyFull generates into a staging directory with the options of the
+// invocation and compares the staged files with the files on disk. The
+// stale files are attributed to the schema or the tool when l was written
+// by another version of the command.
+func (o *Orm) verifyFull(ctx context.Context, args *pflag.FlagSet, l *lockfile) ([]staleFile, error) {
+	if err := o.setup(); err != nil {
+		return nil, err
+	}
+	stale := o.verifyConfig(l)
+
+	o.planning = &planning{}
+	if err := o.exec(ctx, args); err != nil {
+		return nil, err
+	}
+	// After an upgrade the files differing from the lockfile are attributed
+	u := o.newUpgrade(l)
+	planned := make(map[string]bool)
+	for _, c := range o.planning.result.Changes {
+		planned[c.Path] = true
+		if c.Action != planSkip {
+			stale = append(stale, staleFile{Path: c.Path, Reason: c.Reason, Cause: u.attribute(c.Path, c.sum, c.Table)})
+		}
+	}
+	for _, f := range l.Files {
+		if !planned[f.Path] {
+			stale = append(stale, staleFile{Path: f.Path, Reason: "no longer generated", Cause: u.attribute(f.Path, "", "")})
+		}
+	}
+	slices.SortFunc(stale, func(a, b staleFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return stale, nil
+}
+
+// verifyConfig compares the effective gen.Config with the recorded one.
+func (o *Orm) verifyConfig(l *lockfile) []staleFile {
+	if reflect.DeepEqual(o.lockedConfig(), l.Config) {
+		return nil
+	}
+	return []staleFile{{Path: "gen.Config", Reason: "differs from the recorded config"}}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"command/cmd"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+// verify runs orm verify on db with args and opts in the working directory.
+func verify(db *gorm.DB, args []string, opts ...IOrmOption) error {
+	opts = append([]IOrmOption{WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})}, opts...)
+	c := NewOrmCommand(opts...).Command()
+	c.SetArgs(append(append([]string{"verify"}, args...), "--config", ""))
+	c.SilenceUsage, c.SilenceErrors = true, true
+	return c.ExecuteContext(context.Background())
+}
+
+// renamePhone is a schema change of the users fixture.
+func renamePhone(snap *schemaSnapshot) {
+	snap.Tables[0].Columns[3].NameValue = "mobile"
+}
+
+func TestVerifyFresh(t *testing.T) {
+	db := openFixture(t, "users")
+	dao := WithDaoTables([]string{"users"})
+	generate(t, db, []string{"-t", "users", "--style", "dao", "--provenance"}, dao)
+	for _, args := range [][]string{nil, {"--full"}} {
+		if err := verify(db, args, dao); err != nil {
+			t.Errorf("verify %v: %v", args, err)
+		}
+	}
+}
+
+func TestVerifyStale(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		full   bool
+		change func(t *testing.T) *gorm.DB
+	}{
+		{name: "edited", change: editModel},
+		{name: "edited full", full: true, change: editModel},
+		{name: "missing", change: func(t *testing.T) *gorm.DB {
+			if err := os.Remove("model/users.gen.go"); err != nil {
+				t.Fatal(err)
+			}
+			return openFixture(t, "users")
+		}},
+		{name: "schema", change: func(t *testing.T) *gorm.DB {
+			return openFixtureWith(t, "users", renamePhone)
+		}},
+		{name: "schema full", full: true, change: func(t *testing.T) *gorm.DB {
+			return openFixtureWith(t, "users", renamePhone)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			generate(t, openFixture(t, "users"), []string{"-t", "users"})
+			args := []string{}
+			if tt.full {
+				args = append(args, "--full")
+			}
+			err := verify(tt.change(t), args)
+			var staleErr *StaleLockError
+			if !errors.As(err, &staleErr) || staleErr.Stale != 1 {
+				t.Fatalf("err = %v, want 1 stale file", err)
+			}
+			if code := cmd.ExitCode(err, true); code != cmd.ExitStale {
+				t.Errorf("exit code %d, want %d", code, cmd.ExitStale)
+			}
+		})
+	}
+}
+
+// editModel edits the generated model of users.
+func editModel(t *testing.T) *gorm.DB {
+	f, err := os.OpenFile("model/users.gen.go", os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("\n// edited by hand\n"); err != nil {
+		t.Fatal(err)
+	}
+	return openFixture(t, "users")
+}
+
+func TestVerifyNoLockfile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	err := verify(openFixture(t, "users"), nil)
+	if code := cmd.ExitCode(err, true); code != cmd.ExitUsage || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("exit code %d, err = %v, want %d for a missing lockfile", code, err, cmd.ExitUsage)
+	}
+}
+
+func TestDoctorFreshness(t *testing.T) {
+	db := openFixture(t, "users")
+	generate(t, db, []string{"-t", "users"})
+	o := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}))
+	if r := o.checkFreshness(context.Background()); r.Status != cmd.CheckPass {
+		t.Errorf("fresh code: %+v, want a pass", r)
+	}
+	editModel(t)
+	if r := o.checkFreshness(context.Background()); r.Status != cmd.CheckWarn {
+		t.Errorf("edited code: %+v, want a warning", r)
+	}
+}
+
+func TestVerifyUpgradeAttribution(t *testing.T) {
+	generate(t, openFixture(t, "shop"), []string{"-t", "customers", "-t", "orders"})
+
+	// The previous version generated orders another way, and the columns of
+	// customers changed since
+	data, err := os.ReadFile("model/orders.gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, "\n// generated by the previous version\n"...)
+	if err := os.WriteFile("model/orders.gen.go", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := readLockfile(defaultLockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.ToolVersion = "v0.9.0"
+	for i, f := range lock.Files {
+		if f.Path == "model/orders.gen.go" {
+			lock.Files[i].SHA256 = contentSum(data)
+		}
+	}
+	data, err = json.Marshal(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(defaultLockfile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := openFixtureWith(t, "shop", func(snap *schemaSnapshot) {
+		snap.Tables[0].Columns[3].NameValue = "state"
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		var err error
+		out := captureOutput(t, func() { err = verify(db, []string{"--full"}) })
+		var staleErr *StaleLockError
+		if !errors.As(err, &staleErr) || staleErr.Schema != 1 || staleErr.Tool != 1 {
+			t.Fatalf("err = %v, want a file changed with the schema and one with the tool", err)
+		}
+		for _, line := range []string{
+			"stale: model/customers.gen.go (generated content changed, changed with the schema)",
+			"stale: model/orders.gen.go (generated content changed, changed with the tool)",
+		} {
+			if !strings.Contains(out, line) {
+				t.Errorf("output lacks %q:\n%s", line, out)
+			}
+		}
+	})
+	t.Run("diff", func(t *testing.T) {
+		c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"})).Command()
+		c.SetArgs([]string{"-t", "customers", "-t", "orders", "--diff", "--config", ""})
+		c.SilenceUsage, c.SilenceErrors = true, true
+		out := captureOutput(t, func() { err = c.ExecuteContext(context.Background()) })
+		var staleErr *StaleOutputError
+		if !errors.As(err, &staleErr) || staleErr.Updated != 2 {
+			t.Fatalf("err = %v, want 2 changed files", err)
+		}
+		for _, line := range []string{
+			"model/customers.gen.go changed with the schema",
+			"model/orders.gen.go changed with the tool",
+			"1 files changed with the schema, 1 with the tool",
+		} {
+			if !strings.Contains(out, line) {
+				t.Errorf("output lacks %q:\n%s", line, out)
+			}
+		}
+	})
+}
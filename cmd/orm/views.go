@@ -0,0 +1,151 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// structTag matches the key:"value" pairs of a struct tag.
+var structTag = regexp.MustCompile(`(\w+):"((?:[^"\\]|\\.)*)"`)
+
+// viewField is a field of the value variant of a model.
+type viewField struct {
+	Name, Type, Tag string
+}
+
+// viewsPass appends to the model of a table its value variant <Model>View,
+// holding the columns of the model without the associations, a ToView
+// converter and a Scan<Model>Views helper for raw queries. The fields of the
+// variant are read-only for gorm, so saving one writes no column.
+func (o *Orm) viewsPass(_ string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var views []byte
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			var fields []viewField
+			for _, f := range st.Fields.List {
+				if len(f.Names) != 1 || f.Tag == nil {
+					continue
+				}
+				tag, err := strconv.Unquote(f.Tag.Value)
+				if err != nil {
+					return nil, err
+				}
+				// Associations have no column
+				viewTag, ok := readOnlyTag(tag)
+				if !ok {
+					continue
+				}
+				typ := string(src[fset.Position(f.Type.Pos()).Offset:fset.Position(f.Type.End()).Offset])
+				fields = append(fields, viewField{f.Names[0].Name, typ, viewTag})
+			}
+			views = append(views, viewCode(ts.Name.Name, fields)...)
+		}
+	}
+	if len(views) == 0 {
+		return src, nil
+	}
+
+	// The scan helpers take a *gorm.DB, which gen leaves out unused
+	astutil.AddImport(fset, file, "gorm.io/gorm")
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	return append(buf.Bytes(), views...), nil
+}
+
+// readOnlyTag returns the tag of a model field for its value variant: the
+// gorm column and serializer marked read-only with "->", and the other tags
+// as is. ok is false for fields without a column.
+func readOnlyTag(tag string) (string, bool) {
+	var column bool
+	var tags []string
+	for _, m := range structTag.FindAllStringSubmatch(tag, -1) {
+		if m[1] != "gorm" {
+			tags = append(tags, m[0])
+			continue
+		}
+		var settings []string
+		for _, s := range strings.Split(reflect.StructTag(tag).Get("gorm"), ";") {
+			key, _, _ := strings.Cut(s, ":")
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "column":
+				column = true
+				settings = append(settings, s)
+			case "serializer":
+				settings = append(settings, s)
+			}
+		}
+		settings = append(settings, "->")
+		tags = append([]string{fmt.Sprintf("gorm:%q", strings.Join(settings, ";"))}, tags...)
+	}
+	return strings.Join(tags, " "), column
+}
+
+// viewCode renders the value variant of a model with its converter and scan
+// helper.
+func viewCode(model string, fields []viewField) []byte {
+	view := model + "View"
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n// %s is the read-only value variant of %s for the read paths, without\n", view, model)
+	fmt.Fprintf(&buf, "// its associations. Its fields are read-only for gorm, saving it writes no column.\n")
+	fmt.Fprintf(&buf, "type %s struct {\n", view)
+	for _, f := range fields {
+		fmt.Fprintf(&buf, "\t%s %s `%s`\n", f.Name, f.Type, f.Tag)
+	}
+	buf.WriteString("}\n")
+
+	fmt.Fprintf(&buf, "\n// ToView returns the value variant of m.\n")
+	fmt.Fprintf(&buf, "func (m *%s) ToView() %s {\n\treturn %s{\n", model, view, view)
+	for _, f := range fields {
+		fmt.Fprintf(&buf, "\t\t%s: m.%s,\n", f.Name, f.Name)
+	}
+	buf.WriteString("\t}\n}\n")
+
+	fmt.Fprintf(&buf, "\n// Scan%ss scans the rows of a raw query into value variants of %s,\n", view, model)
+	fmt.Fprintf(&buf, "// e.g. Scan%ss(db.Raw(\"SELECT ...\")).\n", view)
+	fmt.Fprintf(&buf, "func Scan%ss(tx *gorm.DB) ([]%s, error) {\n", view, view)
+	fmt.Fprintf(&buf, "\tvar views []%s\n\terr := tx.Scan(&views).Error\n\treturn views, err\n}\n", view)
+	return buf.Bytes()
+}
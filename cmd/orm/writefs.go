@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type (
+	// WriteFS receives the generated files instead of the output directories,
+	// see WithFS. Names are slash-separated paths relative to the working
+	// directory, such as dao/query/user.gen.go. A WriteFS also reading its
+	// files back, like MapFS, only receives the files whose content changed
+	// unless --force-write is set.
+	WriteFS interface {
+		WriteFile(name string, data []byte, perm fs.FileMode) error
+	}
+	// readFS is a WriteFS reading its files back.
+	readFS interface {
+		ReadFile(name string) ([]byte, error)
+	}
+	// MapFS is an in-memory WriteFS holding the written files by name.
+	MapFS map[string][]byte
+)
+
+// WriteFile implements WriteFS.
+func (m MapFS) WriteFile(name string, data []byte, _ fs.FileMode) error {
+	m[name] = bytes.Clone(data)
+	return nil
+}
+
+// ReadFile returns the content of a file written to m.
+func (m MapFS) ReadFile(name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(data), nil
+}
+
+// fsName returns the WriteFS name of a target path.
+func fsName(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return relPath(cwd, path)
+}
+
+// writeFile writes a generated file to the WriteFS of the options when set,
+// else atomically to disk.
+func (o *Orm) writeFile(path string, data []byte, perm fs.FileMode) error {
+	if o.opt.fs == nil {
+		return writeFileAtomic(path, data, perm)
+	}
+	if err := o.opt.fs.WriteFile(fsName(path), data, perm); err != nil {
+		return err
+	}
+	o.markWritten(path)
+	return nil
+}
+
+// fsHolds reports whether the WriteFS of the options can read back the file
+// at path with the given content.
+func (o *Orm) fsHolds(path string, data []byte) bool {
+	r, ok := o.opt.fs.(readFS)
+	if !ok {
+		return false
+	}
+	old, err := r.ReadFile(fsName(path))
+	return err == nil && sameGenerated(old, data)
+}
+
+// markWritten records a file of the WriteFS as generated by the run.
+func (o *Orm) markWritten(path string) {
+	if o.written == nil {
+		o.written = make(map[string]bool)
+	}
+	o.written[path] = true
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package orm
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"command/cmd"
+
+	"gorm.io/gen"
+)
+
+func TestMapFS(t *testing.T) {
+	m := MapFS{}
+	data := []byte("package model\n")
+	if err := m.WriteFile("model/users.gen.go", data, 0640); err != nil {
+		t.Fatal(err)
+	}
+	// The written and read contents are copies
+	data[0] = 'P'
+	got, err := m.ReadFile("model/users.gen.go")
+	if err != nil || string(got) != "package model\n" {
+		t.Fatalf("ReadFile = %q, %v", got, err)
+	}
+	got[0] = 'P'
+	if string(m["model/users.gen.go"]) != "package model\n" {
+		t.Errorf("ReadFile shares the content of the file")
+	}
+
+	delete(m, "model/users.gen.go")
+	if _, err := m.ReadFile("model/users.gen.go"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile of a deleted file = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestWriteFileFS(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	m := MapFS{}
+	o := newOrm(OrmOption{fs: m})
+	path := filepath.Join(dir, "model", "users.gen.go")
+	data := []byte("// Code generated by gorm.io/gen. DO NOT EDIT.\n\npackage model\n")
+
+	// Written files are named relative to the working directory
+	if err := o.writeFile(path, data, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if string(m["model/users.gen.go"]) != string(data) || !o.written[path] {
+		t.Fatalf("writeFile wrote %v, marked %v", keys(toStrings(m)), o.written)
+	}
+
+	// The file is unchanged for the same content only
+	if !o.fsHolds(path, data) {
+		t.Error("fsHolds = false for the written content")
+	}
+	if o.fsHolds(path, []byte("// Code generated by gorm.io/gen. DO NOT EDIT.\n\npackage dao\n")) {
+		t.Error("fsHolds = true for another content")
+	}
+	delete(m, "model/users.gen.go")
+	if o.fsHolds(path, data) {
+		t.Error("fsHolds = true for a deleted file")
+	}
+
+	// A WriteFS that cannot read its files back never holds them
+	o = newOrm(OrmOption{fs: &countingFS{MapFS: MapFS{}}})
+	if o.fsHolds(path, data) {
+		t.Error("fsHolds = true without ReadFile")
+	}
+}
+
+func TestWriteFSRuns(t *testing.T) {
+	t.Chdir(t.TempDir())
+	db := openFixture(t, "users")
+	m := MapFS{}
+	run := func(args ...string) error {
+		c := NewOrmCommand(WithDB(db), WithConfig(gen.Config{OutPath: "dao", ModelPkgPath: "model"}), WithFS(m)).Command()
+		c.SetArgs(append([]string{"-t", "users", "--config", ""}, args...))
+		c.SilenceUsage, c.SilenceErrors = true, true
+		return c.Execute()
+	}
+
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	model, ok := m["model/users.gen.go"]
+	if !ok || len(readTree(t, ".")) != 0 {
+		t.Fatalf("files %v in memory, %v on disk", keys(toStrings(m)), keys(readTree(t, ".")))
+	}
+
+	// A file deleted from the FS is written again, the others are unchanged
+	m["model/stale.gen.go"] = []byte("package model\n")
+	delete(m, "model/users.gen.go")
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if string(m["model/users.gen.go"]) != string(model) {
+		t.Error("deleted file not written again")
+	}
+	if _, ok := m["model/stale.gen.go"]; !ok {
+		t.Error("file of the FS not generated by the run removed")
+	}
+
+	// Deleting files no longer generated is left to the owner of the FS
+	err := run("--prune")
+	if err == nil || cmd.ExitCode(err, true) != cmd.ExitUsage {
+		t.Errorf("--prune with WithFS = %v, want a usage error", err)
+	}
+}
@@ -16,10 +16,15 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"sort"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type ICommand interface {
@@ -27,6 +32,12 @@ type ICommand interface {
 	Command() *cobra.Command
 }
 
+// verbose enables debug output for all commands.
+var verbose bool
+
+// groups are the titles of the command groups registered with RegisterGroup.
+var groups = make(map[string]string)
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "command",
@@ -44,17 +55,94 @@ to quickly create a Cobra application.`,
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute(cmd ...ICommand) {
-	for _, c := range cmd {
+// The error of a failed command is printed and returned with its exit code,
+// see ExitCodesHelp, for the caller to exit with.
+func Execute(cmds ...ICommand) error {
+	return ExecuteWith(cmds)
+}
+
+// ExecuteWith is Execute with options, such as WithDefaults.
+func ExecuteWith(cmds []ICommand, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// Localize the commands before they are built
+	if loc, ok := argLocale(os.Args[1:]); ok {
+		locale = loc
+	}
+	strictFlags = argStrictFlags(os.Args[1:])
+	// The root flags were described before --locale was read
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		f.Usage = T("root.flag." + f.Name)
+	})
+	for _, c := range cmds {
 		rootCmd.AddCommand(c.Command())
 	}
-	if err := rootCmd.Execute(); err != nil {
-		color.Red("%s", err.Error())
-		os.Exit(1)
+	addGroups(rootCmd)
+	if err := applyDefaults(rootCmd, o.defaults); err != nil {
+		color.Red("\n%s\n\n", T("error", err))
+		return Exit(ExitUsage, err)
+	}
+	// Cancel the command context on Ctrl-C or SIGTERM so long waits can be
+	// aborted and the deferred cleanups, such as the lock release, run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var ran bool
+	trackRun(rootCmd, &ran)
+	rootCmd.SilenceErrors = true
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		color.Red("\n%s\n\n", T("error", err))
+		return Exit(ExitCode(err, ran), err)
+	}
+	return nil
+}
+
+// RegisterGroup registers a command group for the GroupID of third-party
+// commands, listed after the built-in groups in the help output. The groups
+// of commands without one are created by Execute, titled by their ID.
+func RegisterGroup(id, title string) {
+	groups[id] = title
+}
+
+// addGroups adds the registered groups and the groups used by the commands
+// of root that are missing, sorted by ID after the existing ones.
+func addGroups(root *cobra.Command) {
+	titles := make(map[string]string)
+	for id, title := range groups {
+		titles[id] = title
+	}
+	for _, c := range root.Commands() {
+		if _, ok := titles[c.GroupID]; c.GroupID != "" && !ok {
+			titles[c.GroupID] = c.GroupID
+		}
+	}
+
+	ids := make([]string, 0, len(titles))
+	for id := range titles {
+		if !root.ContainsGroup(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		root.AddGroup(&cobra.Group{ID: id, Title: titles[id]})
+	}
+}
+
+// Debugf prints a debug message when the verbose flag is set.
+func Debugf(format string, a ...any) {
+	if !verbose {
+		return
 	}
+	color.HiBlack(format, a...)
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, T("root.flag.verbose"))
+	rootCmd.PersistentFlags().StringVar(&locale, "locale", locale, T("root.flag.locale"))
+	rootCmd.PersistentFlags().BoolVar(&strictFlags, "strict-flags", false, T("root.flag.strict-flags"))
 	rootCmd.AddGroup(
 		&cobra.Group{ID: "db", Title: "database commands"},
 		&cobra.Group{ID: "encrypt", Title: "Encryption commands"},
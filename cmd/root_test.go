@@ -0,0 +1,125 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"command/cmd"
+	"command/cmd/encrypt"
+	"command/cmd/orm"
+
+	"github.com/spf13/cobra"
+)
+
+// groupCommand is a third-party command of a group not registered with
+// cmd.RegisterGroup.
+type groupCommand struct {
+	name, group string
+}
+
+func (g groupCommand) Command() *cobra.Command {
+	return &cobra.Command{Use: g.name, Short: "Third-party " + g.name, GroupID: g.group, RunE: func(*cobra.Command, []string) error { return nil }}
+}
+
+// failingCommand is a command whose run fails with err.
+type failingCommand struct {
+	name string
+	err  error
+}
+
+func (f failingCommand) Command() *cobra.Command {
+	return &cobra.Command{Use: f.name, Short: "Fails", RunE: func(*cobra.Command, []string) error { return f.err }}
+}
+
+func TestExecuteCustomGroups(t *testing.T) {
+	args, stdout := os.Args, os.Stdout
+	defer func() { os.Args, os.Stdout = args, stdout }()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Args, os.Stdout = []string{"command", "--help"}, w
+
+	err = cmd.Execute(
+		orm.NewOrmCommand(),
+		encrypt.NewRSA(),
+		groupCommand{name: "zap", group: "zeta"},
+		groupCommand{name: "ops", group: "ops"},
+		groupCommand{name: "backup", group: "db"},
+	)
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Built-in groups first, then the custom ones alphabetically
+	help, last := string(out), -1
+	for _, section := range []string{"database commands\n", "Encryption commands\n", "\nops\n", "\nzeta\n"} {
+		i := strings.Index(help, section)
+		if i < 0 || i < last {
+			t.Fatalf("section %q missing or out of order:\n%s", section, help)
+		}
+		last = i
+	}
+	for _, name := range []string{"orm", "rsa", "backup", "ops", "zap"} {
+		if !strings.Contains(help, "  "+name+" ") {
+			t.Errorf("command %s not listed:\n%s", name, help)
+		}
+	}
+}
+
+func TestExecuteExitCode(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"refused", []string{"refuse"}, cmd.ExitRefused},
+		{"internal", []string{"crash"}, cmd.ExitFailure},
+		{"usage", []string{"refuse", "--no-such-flag"}, cmd.ExitUsage},
+	}
+	cmds := []cmd.ICommand{
+		failingCommand{name: "refuse", err: cmd.Exit(cmd.ExitRefused, errors.New("overwrite protection"))},
+		failingCommand{name: "crash", err: errors.New("disk full")},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Args = append([]string{"command"}, tt.args...)
+			// The root command is global, add the commands once
+			var err error
+			if i == 0 {
+				err = cmd.Execute(cmds...)
+			} else {
+				err = cmd.Execute()
+			}
+			if err == nil {
+				t.Fatal("the failing command succeeded")
+			}
+			if got := cmd.ExitCode(err, true); got != tt.want {
+				t.Errorf("exit code %d, want %d: %v", got, tt.want, err)
+			}
+		})
+	}
+}
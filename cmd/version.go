@@ -0,0 +1,92 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type Version struct {
+	verify bool
+}
+
+// NewVersion returns the version command.
+func NewVersion() *Version {
+	return &Version{}
+}
+
+// Command implements ICommand.
+func (v *Version) Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of the command",
+		Long: `Print the version, commit and Go version the command was built with.
+
+With --verify, every embedded asset of the binary, such as the templates and
+message catalogs, is checked against the SHA-256 manifest generated for the
+build. A mismatch means the binary is corrupted, for example by a truncated
+download, and the command must be reinstalled.
+
+` + ExitCodesHelp,
+		Example: `# Print the version
+command version
+
+# Check the binary for corruption
+command version --verify`,
+		Args: cobra.NoArgs,
+		RunE: v.run,
+	}
+	cmd.Flags().BoolVar(&v.verify, "verify", false, T("version.flag.verify"))
+	return cmd
+}
+
+// run executes the version command logic.
+func (v *Version) run(*cobra.Command, []string) error {
+	version, commit := "(devel)", "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				commit = s.Value
+			}
+		}
+	}
+	fmt.Printf("version %s, commit %s, %s %s/%s\n", version, commit, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if !v.verify {
+		return nil
+	}
+	n, err := VerifyAssets()
+	if err != nil {
+		return err
+	}
+	color.Green("%d embedded assets verified.\n", n)
+	return nil
+}
+
+// assetsCheck fails when an embedded asset does not match the build manifest.
+func assetsCheck(context.Context) CheckResult {
+	n, err := VerifyAssets()
+	if err != nil {
+		return CheckResult{Status: CheckFail, Message: err.Error(), Hint: "reinstall the command"}
+	}
+	return CheckResult{Status: CheckPass, Message: fmt.Sprintf("%d embedded assets verified", n)}
+}
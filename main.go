@@ -20,18 +20,13 @@ import (
 	"command/cmd"
 	"command/cmd/encrypt"
 	"command/cmd/orm"
+	"os"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gen"
 	"gorm.io/gorm"
 )
 
 func main() {
-	gormdb, err := gorm.Open(mysql.Open("root:root@tcp(127.0.0.1:3306)/amg?charset=utf8mb4&loc=Local&parseTime=True"))
-	if err != nil {
-		panic(err)
-	}
-
 	timeFunc := func(detailType gorm.ColumnType) (dataType string) {
 		switch detailType.Name() {
 		case "created_at":
@@ -52,7 +47,6 @@ func main() {
 				FieldWithIndexTag: false,
 				FieldWithTypeTag:  true,
 			}),
-			orm.WithDB(gormdb),
 			orm.WithDataType(map[string]orm.DataTypeFn{
 				"*->timestamp": timeFunc,
 			}),
@@ -62,10 +56,19 @@ func main() {
 			orm.WithReGromTags([]string{"*->created_at->-", "*->updated_at->-"}),
 			orm.WithDaoTables([]string{"user", "game"}),
 			orm.WithDaoApi(map[string]any{
-				"*": func(annotae.Querier) {},
+				"*":    func(annotae.Querier) {},
+				"user": func(annotae.Reporter) {},
 			}),
 		),
 		encrypt.NewRSA(),
+		encrypt.NewBundle(),
+		encrypt.NewInspect(),
+		encrypt.NewAudit(),
+		encrypt.NewEncrypt(),
+		cmd.NewVersion(),
+	}
+	cmds = append(cmds, cmd.NewDoctor(cmds))
+	if err := cmd.Execute(cmds...); err != nil {
+		os.Exit(cmd.ExitCode(err, true))
 	}
-	cmd.Execute(cmds...)
 }
@@ -0,0 +1,205 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy evaluates allow/deny rules over the items a command is
+// about to generate, so CI can restrict what product teams may produce.
+//
+// A policy file is YAML:
+//
+//	default: allow
+//	rules:
+//	  - name: no-raw-sql-on-payments
+//	    effect: deny
+//	    styles: [dao]
+//	    tables: ["payment*"]
+//	    methods: ["*"]
+//	  - effect: allow
+//	    paths: [db/, model/]
+//	  - effect: deny
+//	    paths: ["*"]
+//
+// Rules are evaluated in order and the first matching rule decides, items
+// matching no rule get the default effect. A rule matches an item when every
+// criterion it sets matches; a criterion only matches items carrying that
+// attribute, so a paths rule never applies to a method item.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Effect is the decision of a rule.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+type (
+	// Rule allows or denies the items matching all of its criteria.
+	Rule struct {
+		Name   string `yaml:"name"`
+		Effect Effect `yaml:"effect"`
+		// Tables are glob patterns of table names
+		Tables []string `yaml:"tables"`
+		// Styles are generation styles, such as model or dao
+		Styles []string `yaml:"styles"`
+		// Paths are output path prefixes, relative to the working directory
+		Paths []string `yaml:"paths"`
+		// Methods are glob patterns of interface method names
+		Methods []string `yaml:"methods"`
+	}
+	// Policy is an ordered list of rules with a default effect.
+	Policy struct {
+		Default Effect `yaml:"default"`
+		Rules   []Rule `yaml:"rules"`
+	}
+	// Item is something a command is about to generate.
+	Item struct {
+		Table  string
+		Style  string
+		Path   string
+		Method string
+	}
+	// Violation is an item denied by a rule.
+	Violation struct {
+		Rule string
+		Item Item
+	}
+)
+
+// Load reads and validates the policy file at name.
+func Load(name string) (*Policy, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return p, nil
+}
+
+// Parse decodes and validates a policy.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if p.Default == "" {
+		p.Default = Allow
+	}
+	if p.Default != Allow && p.Default != Deny {
+		return nil, fmt.Errorf("default: invalid effect %q, must be allow or deny", p.Default)
+	}
+
+	for i, r := range p.Rules {
+		if r.Effect != Allow && r.Effect != Deny {
+			return nil, fmt.Errorf("%s: invalid effect %q, must be allow or deny", r.label(i), r.Effect)
+		}
+		if len(r.Tables)+len(r.Styles)+len(r.Paths)+len(r.Methods) == 0 {
+			return nil, fmt.Errorf("%s: no criteria, set tables, styles, paths or methods", r.label(i))
+		}
+		for _, pattern := range slices.Concat(r.Tables, r.Methods) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern %q: %w", r.label(i), pattern, err)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// Evaluate returns the violations of items, in the order of items.
+func (p *Policy) Evaluate(items []Item) []Violation {
+	var violations []Violation
+	for _, item := range items {
+		effect, rule := p.Default, "default"
+		for i, r := range p.Rules {
+			if r.matches(item) {
+				effect, rule = r.Effect, r.label(i)
+				break
+			}
+		}
+		if effect == Deny {
+			violations = append(violations, Violation{Rule: rule, Item: item})
+		}
+	}
+	return violations
+}
+
+// label names a rule in violations and errors.
+func (r Rule) label(i int) string {
+	if r.Name != "" {
+		return fmt.Sprintf("rules[%d] (%s)", i, r.Name)
+	}
+	return fmt.Sprintf("rules[%d]", i)
+}
+
+// matches reports whether every criterion of the rule matches the item.
+func (r Rule) matches(item Item) bool {
+	return criterion(r.Tables, item.Table, globMatch) &&
+		criterion(r.Styles, item.Style, func(style, s string) bool { return style == "*" || style == s }) &&
+		criterion(r.Paths, item.Path, prefixMatch) &&
+		criterion(r.Methods, item.Method, globMatch)
+}
+
+// criterion reports whether value matches one of patterns. An unset
+// criterion matches anything, a set one never matches a missing value.
+func criterion(patterns []string, value string, match func(pattern, value string) bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if value == "" {
+		return false
+	}
+	return slices.ContainsFunc(patterns, func(p string) bool { return match(p, value) })
+}
+
+func globMatch(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// prefixMatch matches a path against a directory prefix, "*" matches all.
+func prefixMatch(prefix, value string) bool {
+	if prefix == "*" {
+		return true
+	}
+	prefix, value = path.Clean(prefix), path.Clean(value)
+	return value == prefix || strings.HasPrefix(value, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+func (i Item) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"table", i.Table}, {"style", i.Style}, {"path", i.Path}, {"method", i.Method}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+kv[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s denied by %s", v.Item, v.Rule)
+}
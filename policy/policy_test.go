@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{"empty", "", ""},
+		{"deny by default", "default: deny\n", ""},
+		{"invalid default", "default: maybe\n", `default: invalid effect "maybe"`},
+		{"invalid effect", "rules:\n  - effect: block\n    tables: [users]\n", `rules[0]: invalid effect "block"`},
+		{"no criteria", "rules:\n  - name: all\n    effect: deny\n", "rules[0] (all): no criteria"},
+		{"invalid pattern", "rules:\n  - effect: deny\n    methods: [\"[\"]\n", `rules[0]: invalid pattern "["`},
+		{"invalid yaml", "rules: [\n", "yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse([]byte(tt.policy))
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p.Default != Allow && p.Default != Deny {
+					t.Errorf("default %q", p.Default)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(`default: allow
+rules:
+  - name: no-raw-sql-on-payments
+    effect: deny
+    styles: [dao]
+    tables: ["payment*"]
+    methods: ["*"]
+  - effect: allow
+    paths: [db/, model/]
+  - effect: deny
+    paths: ["*"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []Item{
+		{Table: "payments", Style: "dao", Method: "GetByID"},
+		{Table: "payments", Style: "dao"},
+		{Table: "users", Style: "dao", Method: "GetByID"},
+		{Table: "users", Style: "model", Path: "model/users.gen.go"},
+		{Table: "users", Style: "model", Path: "db/query/users.gen.go"},
+		{Table: "users", Style: "model", Path: "models/users.gen.go"},
+		{Table: "payments", Style: "dao", Path: "dao/payments.gen.go"},
+	}
+	want := []Violation{
+		{Rule: "rules[0] (no-raw-sql-on-payments)", Item: items[0]},
+		{Rule: "rules[2]", Item: items[5]},
+		{Rule: "rules[2]", Item: items[6]},
+	}
+	if got := p.Evaluate(items); !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate = %v, want %v", got, want)
+	}
+
+	// Items matching no rule get the default effect
+	p.Default = Deny
+	got := p.Evaluate([]Item{{Table: "users", Style: "dao"}})
+	if len(got) != 1 || got[0].Rule != "default" {
+		t.Errorf("Evaluate = %v, want a violation of the default", got)
+	}
+}
+
+func TestPrefixMatch(t *testing.T) {
+	tests := []struct {
+		prefix, value string
+		want          bool
+	}{
+		{"model/", "model/users.gen.go", true},
+		{"model", "model/users.gen.go", true},
+		{"model/", "model", true},
+		{"model/", "models/users.gen.go", false},
+		{"./db/", "db/query/users.gen.go", true},
+		{"*", "anything.go", true},
+	}
+	for _, tt := range tests {
+		if got := prefixMatch(tt.prefix, tt.value); got != tt.want {
+			t.Errorf("prefixMatch(%q, %q) = %v, want %v", tt.prefix, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(name, []byte("default: never\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(name); err == nil || !strings.HasPrefix(err.Error(), name+": ") {
+		t.Errorf("Load = %v, want an error prefixed with the file", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Load = %v, want a missing file", err)
+	}
+}
+
+func TestViolationError(t *testing.T) {
+	v := Violation{Rule: "rules[1]", Item: Item{Table: "users", Style: "dao", Method: "GetByID"}}
+	if got := v.Error(); got != "table=users style=dao method=GetByID denied by rules[1]" {
+		t.Errorf("Error = %q", got)
+	}
+}
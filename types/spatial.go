@@ -0,0 +1,146 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package types
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// wkbPoint is the WKB geometry type of a point.
+const wkbPoint = 1
+
+// Point is a POINT column, X is the longitude and Y the latitude.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Value encodes the point as little endian WKB.
+func (p Point) Value() (driver.Value, error) {
+	buf := make([]byte, 21)
+	buf[0] = 1
+	binary.LittleEndian.PutUint32(buf[1:], wkbPoint)
+	binary.LittleEndian.PutUint64(buf[5:], math.Float64bits(p.Lng))
+	binary.LittleEndian.PutUint64(buf[13:], math.Float64bits(p.Lat))
+	return buf, nil
+}
+
+// Scan decodes a point from WKB, MySQL's SRID prefixed WKB or WKT.
+func (p *Point) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*p = Point{}
+		return nil
+	case string:
+		return p.parseWKT(value)
+	case []byte:
+		if bytes.HasPrefix(bytes.ToUpper(bytes.TrimSpace(value)), []byte("POINT")) {
+			return p.parseWKT(string(value))
+		}
+		if err := p.parseWKB(value); err == nil {
+			return nil
+		}
+		// MySQL stores a 4 byte SRID before the WKB
+		if len(value) > 4 {
+			return p.parseWKB(value[4:])
+		}
+		return fmt.Errorf("can not convert %v to point", v)
+	}
+	return fmt.Errorf("can not convert %v to point", v)
+}
+
+// parseWKB decodes a WKB point of either byte order.
+func (p *Point) parseWKB(data []byte) error {
+	if len(data) != 21 {
+		return fmt.Errorf("invalid WKB point of %d bytes", len(data))
+	}
+	var order binary.ByteOrder
+	switch data[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return fmt.Errorf("invalid WKB byte order %d", data[0])
+	}
+	if typ := order.Uint32(data[1:]); typ != wkbPoint {
+		return fmt.Errorf("WKB geometry type %d is not a point", typ)
+	}
+	*p = Point{
+		Lng: math.Float64frombits(order.Uint64(data[5:])),
+		Lat: math.Float64frombits(order.Uint64(data[13:])),
+	}
+	return nil
+}
+
+// parseWKT decodes a WKT point such as POINT(13.4 52.5).
+func (p *Point) parseWKT(s string) error {
+	s = strings.TrimSpace(s)
+	body, ok := strings.CutPrefix(strings.ToUpper(s), "POINT")
+	if !ok {
+		return fmt.Errorf("can not convert %q to point", s)
+	}
+	body = strings.TrimSpace(body)
+	body, ok = strings.CutPrefix(body, "(")
+	if ok {
+		body, ok = strings.CutSuffix(body, ")")
+	}
+	coords := strings.Fields(body)
+	if !ok || len(coords) != 2 {
+		return fmt.Errorf("can not convert %q to point", s)
+	}
+	lng, err := strconv.ParseFloat(coords[0], 64)
+	if err != nil {
+		return fmt.Errorf("can not convert %q to point: %w", s, err)
+	}
+	lat, err := strconv.ParseFloat(coords[1], 64)
+	if err != nil {
+		return fmt.Errorf("can not convert %q to point: %w", s, err)
+	}
+	*p = Point{Lat: lat, Lng: lng}
+	return nil
+}
+
+// Geometry is a geometry column other than a point, kept as raw bytes.
+type Geometry []byte
+
+func (g Geometry) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	return []byte(g), nil
+}
+
+func (g *Geometry) Scan(v any) error {
+	switch value := v.(type) {
+	case nil:
+		*g = nil
+		return nil
+	case []byte:
+		*g = bytes.Clone(value)
+		return nil
+	case string:
+		*g = Geometry(value)
+		return nil
+	}
+	return fmt.Errorf("can not convert %v to geometry", v)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2025 czx-lab www.aiweimeng.top
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// wkb encodes a point as WKB in the given byte order.
+func wkb(order binary.ByteOrder, lng, lat float64) []byte {
+	buf := make([]byte, 21)
+	if order == binary.LittleEndian {
+		buf[0] = 1
+	}
+	order.PutUint32(buf[1:], wkbPoint)
+	order.PutUint64(buf[5:], math.Float64bits(lng))
+	order.PutUint64(buf[13:], math.Float64bits(lat))
+	return buf
+}
+
+func TestPointScan(t *testing.T) {
+	want := Point{Lat: 52.52, Lng: 13.405}
+	srid := append([]byte{0xe6, 0x10, 0, 0}, wkb(binary.LittleEndian, want.Lng, want.Lat)...)
+	for name, v := range map[string]any{
+		"little endian": wkb(binary.LittleEndian, want.Lng, want.Lat),
+		"big endian":    wkb(binary.BigEndian, want.Lng, want.Lat),
+		"mysql srid":    srid,
+		"wkt":           "POINT(13.405 52.52)",
+		"wkt bytes":     []byte(" point ( 13.405 52.52 ) "),
+	} {
+		var p Point
+		if err := p.Scan(v); err != nil || p != want {
+			t.Errorf("%s: %+v, %v", name, p, err)
+		}
+	}
+
+	line := wkb(binary.LittleEndian, 1, 2)
+	line[1] = 2
+	for name, v := range map[string]any{
+		"line string": line,
+		"byte order":  append([]byte{7}, wkb(binary.BigEndian, 1, 2)[1:]...),
+		"truncated":   wkb(binary.LittleEndian, 1, 2)[:20],
+		"wkt":         "POINT(1)",
+		"polygon":     "POLYGON((0 0,1 1,1 0,0 0))",
+		"int":         42,
+	} {
+		var p Point
+		if err := p.Scan(v); err == nil {
+			t.Errorf("%s: scanned as %+v", name, p)
+		}
+	}
+
+	p := want
+	if err := p.Scan(nil); err != nil || p != (Point{}) {
+		t.Errorf("nil: %+v, %v", p, err)
+	}
+}
+
+func TestPointValue(t *testing.T) {
+	v, err := Point{Lat: 52.52, Lng: 13.405}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v.([]byte), wkb(binary.LittleEndian, 13.405, 52.52)) {
+		t.Errorf("value = %x", v)
+	}
+}
+
+func TestPointJSON(t *testing.T) {
+	data, err := json.Marshal(Point{Lat: 52.52, Lng: 13.405})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"lat":52.52,"lng":13.405}` {
+		t.Errorf("marshaled %s", data)
+	}
+}
+
+func TestSpatialRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE places (id integer PRIMARY KEY, location blob, area blob)").Error; err != nil {
+		t.Fatal(err)
+	}
+	type place struct {
+		ID       int64
+		Location Point    `gorm:"column:location"`
+		Area     Geometry `gorm:"column:area"`
+	}
+
+	polygon := Geometry{0x01, 0x03, 0, 0, 0, 0xff}
+	in := place{Location: Point{Lat: -33.8688, Lng: 151.2093}, Area: polygon}
+	if err := db.Table("places").Create(&in).Error; err != nil {
+		t.Fatal(err)
+	}
+	// A big endian point written by another client
+	if err := db.Exec("INSERT INTO places (id, location) VALUES (?, ?)", 2, wkb(binary.BigEndian, 2.35, 48.85)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var out []place
+	if err := db.Table("places").Order("id").Find(&out).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("read %+v", out)
+	}
+	if out[0].Location != in.Location || !bytes.Equal(out[0].Area, polygon) {
+		t.Errorf("round trip = %+v, want %+v", out[0], in)
+	}
+	if out[1].Location != (Point{Lat: 48.85, Lng: 2.35}) || out[1].Area != nil {
+		t.Errorf("big endian row = %+v", out[1])
+	}
+}